@@ -4,6 +4,12 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -20,9 +26,31 @@ Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		todo, err := service.CreateTodo(cmd.Context(), viper.GetString("title"), viper.GetString("description"), viper.GetStringSlice("labels"))
+		exprs, err := cmd.Flags().GetStringArray("field")
+		cobra.CheckErr(err)
+		fields, err := parseFieldFlags(exprs)
 		cobra.CheckErr(err)
-		logger.Info("created todo", "todo", todo)
+
+		opts := []todo.CreateOption{todo.WithFields(fields)}
+		switch {
+		case viper.GetBool("strict"):
+			opts = append(opts, todo.WithDuplicateCheck(todo.RefuseDuplicates))
+		case viper.GetBool("check-duplicates"):
+			opts = append(opts, todo.WithDuplicateCheck(warnDuplicates))
+		}
+
+		created, err := service.CreateTodo(cmd.Context(), viper.GetString("title"), viper.GetString("description"), viper.GetStringSlice("labels"), opts...)
+		var dupErr *todo.DuplicateError
+		if errors.As(err, &dupErr) {
+			printDuplicateCandidates(dupErr.Candidates)
+			logger.Error("refusing to create todo with likely duplicates", "error", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			logger.Error("failed to create todo", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		logger.Info("created todo", "todo", created)
 	},
 }
 
@@ -33,4 +61,23 @@ func init() {
 	cobra.CheckErr(createCmd.MarkFlagRequired("title"))
 	createCmd.Flags().StringP("description", "d", "", "The description of the todo")
 	createCmd.Flags().StringSliceP("labels", "l", []string{}, "The labels of the todo")
+	createCmd.Flags().StringArray("field", []string{}, "Custom field value as key=value (repeatable)")
+	createCmd.Flags().Bool("check-duplicates", false, "Warn about likely duplicates among open todos before creating")
+	createCmd.Flags().Bool("strict", false, "Refuse to create the todo when likely duplicates exist")
+
+	// Shell completion for labels
+	cobra.CheckErr(createCmd.RegisterFlagCompletionFunc("labels", completeLabels))
+}
+
+// warnDuplicates prints likely duplicates and lets the create proceed.
+func warnDuplicates(candidates []*todo.DuplicateCandidate) error {
+	fmt.Fprintln(os.Stderr, "Warning: this todo looks similar to existing open todos:")
+	printDuplicateCandidates(candidates)
+	return nil
+}
+
+func printDuplicateCandidates(candidates []*todo.DuplicateCandidate) {
+	for _, c := range candidates {
+		fmt.Fprintf(os.Stderr, "  [%6.2f] %s %s (%s)\n", c.Score, c.Todo.ID, c.Todo.Title, c.Todo.Status)
+	}
 }
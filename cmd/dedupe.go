@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find and merge duplicate todos",
+	Long: `Scan open todos for likely duplicates and offer to merge each cluster.
+
+Each cluster is merged into its oldest todo: labels are combined, custom fields
+missing on the oldest todo are copied over and an empty description is filled
+in. The other todos in the cluster are then deleted.
+
+Examples:
+  todoify dedupe             # review each cluster interactively
+  todoify dedupe --dry-run   # only print the clusters
+  todoify dedupe --yes       # merge every cluster without asking`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clusters, err := service.FindDuplicateClusters(cmd.Context())
+		if err != nil {
+			logger.Error("failed to find duplicates", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		if len(clusters) == 0 {
+			fmt.Println("No duplicates found.")
+			return
+		}
+
+		fmt.Printf("Found %d cluster(s) of likely duplicates:\n\n", len(clusters))
+
+		in := bufio.NewReader(os.Stdin)
+		merged := 0
+		for i, c := range clusters {
+			printDuplicateCluster(i+1, c)
+
+			if viper.GetBool("dry-run") {
+				continue
+			}
+			if !viper.GetBool("yes") && !confirm(in, "Merge into the first todo?") {
+				fmt.Println()
+				continue
+			}
+
+			ids := make([]string, 0, len(c.Duplicates))
+			for _, d := range c.Duplicates {
+				ids = append(ids, d.Todo.ID.String())
+			}
+			if _, err := service.MergeTodos(cmd.Context(), c.Primary.ID.String(), ids); err != nil {
+				logger.Error("failed to merge todos", "primary", c.Primary.ID, "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+			merged++
+			fmt.Printf("Merged %d todo(s) into %s\n\n", len(ids), c.Primary.ID)
+		}
+
+		if !viper.GetBool("dry-run") {
+			fmt.Printf("Merged %d of %d cluster(s).\n", merged, len(clusters))
+		}
+	},
+}
+
+func printDuplicateCluster(n int, c *todo.DuplicateCluster) {
+	fmt.Printf("%d. %s %s (%s)\n", n, c.Primary.ID, c.Primary.Title, c.Primary.Status)
+	for _, d := range c.Duplicates {
+		fmt.Printf("   [%6.2f] %s %s (%s)\n", d.Score, d.Todo.ID, d.Todo.Title, d.Todo.Status)
+	}
+}
+
+// confirm asks a yes/no question on stdout and reads the answer from in.
+// Anything other than y or yes counts as no.
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().BoolP("yes", "y", false, "Merge every cluster without asking")
+	dedupeCmd.Flags().Bool("dry-run", false, "Only print the clusters, do not merge")
+}
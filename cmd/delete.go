@@ -6,6 +6,8 @@ package cmd
 import (
 	"os"
 
+	"github.com/MattDevy/es-todoify/internal/sdk"
+
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
@@ -26,16 +28,19 @@ to quickly create a Cobra application.`,
 		id, err := uuid.Parse(args[0])
 		if err != nil {
 			logger.Error("failed to parse todo id", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 		err = service.DeleteTodo(cmd.Context(), id.String())
 		if err != nil {
 			logger.Error("failed to delete todo", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+
+	// Shell completion for the todo ID
+	deleteCmd.ValidArgsFunction = completeTodoIDs
 }
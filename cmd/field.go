@@ -0,0 +1,152 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// fieldCmd represents the field command
+var fieldCmd = &cobra.Command{
+	Use:   "field",
+	Short: "Manage custom fields that can be attached to todos",
+	Long: `Manage the registry of custom, user-defined fields.
+
+Custom fields let teams attach extra metadata (story points, customer, sprint,
+severity) to todos. Each field has a type (string, number, date, enum, bool),
+can be marked as required, and enum fields declare their allowed values.
+
+Field values are set with --field key=value on create and update, and can be
+filtered with --where key<op>value on list.`,
+}
+
+// fieldAddCmd represents the field add command
+var fieldAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Define a new custom field (or replace an existing definition)",
+	Long: `Define a new custom field or replace an existing definition with the same name.
+
+Field names must start with a lowercase letter and contain only lowercase
+letters, digits and underscores.
+
+Examples:
+  # Add a numeric field
+  todoify field add points --type number
+
+  # Add a required enum field
+  todoify field add severity --type enum --allowed low,medium,high --required
+
+  # Add a date field
+  todoify field add due --type date`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		def := todo.FieldDefinition{
+			Name:          args[0],
+			Type:          todo.FieldType(viper.GetString("type")),
+			Required:      viper.GetBool("required"),
+			AllowedValues: viper.GetStringSlice("allowed"),
+		}
+
+		created, err := service.DefineField(cmd.Context(), def)
+		if err != nil {
+			if validationErr := todo.TranslateError(err); validationErr != nil {
+				logger.Error("validation failed", "error", validationErr)
+			} else {
+				logger.Error("failed to define field", "error", err)
+			}
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		logger.Info("successfully defined field", "name", created.Name, "type", created.Type)
+	},
+}
+
+// fieldListCmd represents the field list command
+var fieldListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"l"},
+	Short:   "List custom field definitions",
+	Run: func(cmd *cobra.Command, args []string) {
+		defs, err := service.ListFields(cmd.Context())
+		if err != nil {
+			logger.Error("failed to list fields", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		if len(defs) == 0 {
+			fmt.Println("No custom fields defined.")
+			return
+		}
+
+		for _, d := range defs {
+			line := fmt.Sprintf("%-20s %-8s", d.Name, d.Type)
+			if d.Required {
+				line += " required"
+			}
+			if len(d.AllowedValues) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(d.AllowedValues, ", "))
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+// fieldDeleteCmd represents the field delete command
+var fieldDeleteCmd = &cobra.Command{
+	Use:     "delete [name]",
+	Aliases: []string{"d"},
+	Short:   "Delete a custom field definition",
+	Long: `Delete a custom field definition.
+
+Values already stored on existing todos are not removed, but the field can no
+longer be set or filtered on until it is defined again.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.DeleteField(cmd.Context(), args[0]); err != nil {
+			logger.Error("failed to delete field", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		logger.Info("successfully deleted field", "name", args[0])
+	},
+}
+
+// parseFieldFlags converts repeated --field key=value flags into a map of raw values.
+func parseFieldFlags(exprs []string) (map[string]any, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]any, len(exprs))
+	for _, expr := range exprs {
+		key, value, err := todo.ParseFieldAssignment(expr)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+
+	return fields, nil
+}
+
+func init() {
+	rootCmd.AddCommand(fieldCmd)
+	fieldCmd.AddCommand(fieldAddCmd, fieldListCmd, fieldDeleteCmd)
+
+	fieldAddCmd.Flags().String("type", "", "Field type (string, number, date, enum, bool)")
+	cobra.CheckErr(fieldAddCmd.MarkFlagRequired("type"))
+	fieldAddCmd.Flags().Bool("required", false, "Require a value for this field on every todo")
+	fieldAddCmd.Flags().StringSlice("allowed", []string{}, "Allowed values for enum fields (comma-separated)")
+
+	viper.BindPFlag("type", fieldAddCmd.Flags().Lookup("type"))
+	viper.BindPFlag("required", fieldAddCmd.Flags().Lookup("required"))
+	viper.BindPFlag("allowed", fieldAddCmd.Flags().Lookup("allowed"))
+}
@@ -4,11 +4,15 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/MattDevy/es-todoify/internal/sdk"
 	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -16,19 +20,36 @@ import (
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:     "list",
+	Use:     "list [query]",
 	Aliases: []string{"l"},
 	Short:   "List todos with optional filtering, sorting, and pagination",
 	Long: `List todos from Elasticsearch with powerful filtering and search capabilities.
 
-You can filter by status, labels, search text, and date ranges. Results can be
-sorted by different fields and paginated for large result sets. Use --count to
-get the total number of matching todos instead of listing them.
+You can filter by status, labels, search text, and date ranges. Completed and
+cancelled todos are hidden unless --all, --status or --not-status is given, or
+the query has a status term. Results can be
+sorted by different fields and paginated for large result sets; offsets stop at
+10,000 results, use --cursor or --all-pages to go further. Use --count to
+get the total number of matching todos instead of listing them, or --facets to
+also show counts per status, label and created month for the whole filter.
+
+An optional query can be given using the query language. Terms are AND-ed
+together, can be negated with "-" or NOT, combined with OR and grouped with
+parentheses:
+
+  status:<status>            label:<label>          title:<text>    desc:<text>
+  created<op><date|age>      updated<op><date|age>  sort:[-]<field> free text
+
+Dates are YYYY-MM-DD or RFC3339, ages are 12h, 7d or 2w (updated<7d means
+"updated less than 7 days ago"). Quote values to match phrases.
 
 Examples:
-  # List all todos (default: 50 most recent)
+  # List open todos (default: 50 most recent, completed/cancelled hidden)
   todoify list
 
+  # Include completed and cancelled todos
+  todoify list --all
+
   # Count all todos
   todoify list --count
 
@@ -38,26 +59,64 @@ Examples:
   # Count pending todos
   todoify list --status pending -c
 
+  # Show counts per status, label and created month alongside the todos
+  todoify list --labels backend --facets
+
+  # Filter by any of several statuses
+  todoify list --status pending,in_progress
+
+  # Exclude statuses
+  todoify list --not-status completed,cancelled,blocked
+
   # Filter by multiple labels
   todoify list --labels bug,urgent
 
+  # Filter by any of several labels, excluding others
+  todoify list --any-labels bug,incident --not-labels wontfix
+
   # Full-text search
   todoify list --search "authentication"
 
   # Pagination
   todoify list --limit 10 --offset 20
 
+  # Cursor pagination (consistent while data changes, no depth limit)
+  todoify list --limit 100 --cursor <cursor from the previous page>
+
+  # Every matching todo, following cursors
+  todoify list --all-pages
+
   # Custom sorting
   todoify list --sort-by title --sort-order asc
 
+  # Filter on custom fields
+  todoify list --where points>=3 --where severity=high
+
   # Combined filters
-  todoify list --status in_progress --labels backend --limit 25`,
+  todoify list --status in_progress --labels backend --limit 25
+
+  # Query language
+  todoify list 'status:pending label:bug -label:wontfix (title:"login" OR desc:oauth) updated<7d sort:title'`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Build filter from flags
-		filter, err := buildFilterFromFlags()
+		filter, err := buildFilterFromFlags(cmd)
 		if err != nil {
 			logger.Error("invalid filter parameters", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		// Parse the query language expression
+		if len(args) > 0 {
+			filter.Query, err = parseQueryArgs(args)
+			if err != nil {
+				os.Exit(1)
+			}
+		}
+
+		// Hide terminal statuses unless the flags or the query choose
+		// statuses, or --all is set
+		if !viper.GetBool("all") {
+			filter.HideTerminal()
 		}
 
 		// Check if count flag is set
@@ -66,7 +125,7 @@ Examples:
 			count, err := service.CountTodos(cmd.Context(), filter)
 			if err != nil {
 				logger.Error("failed to count todos", "error", err)
-				os.Exit(1)
+				os.Exit(sdk.ExitCode(err))
 			}
 
 			// Print count result
@@ -74,11 +133,30 @@ Examples:
 			return
 		}
 
+		// List todos together with facet counts
+		if viper.GetBool("facets") {
+			todos, facets, err := service.ListTodosWithFacets(cmd.Context(), filter)
+			if err != nil {
+				logger.Error("failed to list todos", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+
+			printTodos(todos)
+			printFacets(facets)
+			return
+		}
+
+		// Follow cursors page by page
+		if viper.IsSet("cursor") || viper.GetBool("all-pages") {
+			listPages(cmd, filter)
+			return
+		}
+
 		// Call service to list todos
 		todos, err := service.ListTodos(cmd.Context(), filter)
 		if err != nil {
 			logger.Error("failed to list todos", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 
 		// Print results
@@ -87,23 +165,35 @@ Examples:
 }
 
 // buildFilterFromFlags constructs a ListFilter from command flags
-func buildFilterFromFlags() (todo.ListFilter, error) {
+func buildFilterFromFlags(cmd *cobra.Command) (todo.ListFilter, error) {
 	filter := todo.DefaultListFilter()
 
-	// Status filter
+	// Status filters
 	if viper.IsSet("status") {
-		statusStr := viper.GetString("status")
-		status := todo.Status(statusStr)
-		if !status.IsValid() {
-			return filter, fmt.Errorf("invalid status: %s (valid: pending, in_progress, completed, cancelled, blocked)", statusStr)
+		statuses, err := parseStatuses(viper.GetStringSlice("status"))
+		if err != nil {
+			return filter, err
 		}
-		filter.Status = status
+		filter.Statuses = statuses
+	}
+	if viper.IsSet("not-status") {
+		statuses, err := parseStatuses(viper.GetStringSlice("not-status"))
+		if err != nil {
+			return filter, err
+		}
+		filter.ExcludeStatuses = statuses
 	}
 
-	// Labels filter
+	// Labels filters
 	if viper.IsSet("labels") {
 		filter.Labels = viper.GetStringSlice("labels")
 	}
+	if viper.IsSet("any-labels") {
+		filter.AnyLabels = viper.GetStringSlice("any-labels")
+	}
+	if viper.IsSet("not-labels") {
+		filter.ExcludeLabels = viper.GetStringSlice("not-labels")
+	}
 
 	// Search query
 	if viper.IsSet("search") {
@@ -130,6 +220,19 @@ func buildFilterFromFlags() (todo.ListFilter, error) {
 		filter.ToDate = &toDate
 	}
 
+	// Custom field conditions
+	conditions, err := cmd.Flags().GetStringArray("where")
+	if err != nil {
+		return filter, err
+	}
+	for _, expr := range conditions {
+		cond, err := todo.ParseFieldCondition(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = append(filter.Where, cond)
+	}
+
 	// Pagination
 	if viper.IsSet("limit") {
 		filter.Limit = viper.GetInt("limit")
@@ -137,13 +240,16 @@ func buildFilterFromFlags() (todo.ListFilter, error) {
 	if viper.IsSet("offset") {
 		filter.Offset = viper.GetInt("offset")
 	}
+	if viper.IsSet("cursor") {
+		filter.Cursor = viper.GetString("cursor")
+	}
 
 	// Sorting
 	if viper.IsSet("sort-by") {
 		sortByStr := viper.GetString("sort-by")
 		sortBy := todo.SortField(sortByStr)
 		if !sortBy.IsValid() {
-			return filter, fmt.Errorf("invalid sort-by: %s (valid: createTime, updateTime, title, status)", sortByStr)
+			return filter, fmt.Errorf("invalid sort-by: %s (valid: createTime, updateTime, title, status, _score)", sortByStr)
 		}
 		filter.SortBy = sortBy
 	}
@@ -160,6 +266,62 @@ func buildFilterFromFlags() (todo.ListFilter, error) {
 	return filter, nil
 }
 
+// listPages prints the page at the filter's cursor, or every page when --all-pages is set.
+// Without --all-pages the cursor for the next page is printed to stderr.
+func listPages(cmd *cobra.Command, filter todo.ListFilter) {
+	var todos []*todo.Todo
+	for {
+		page, err := service.ListTodosPage(cmd.Context(), filter)
+		if err != nil {
+			logger.Error("failed to list todos", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		todos = append(todos, page.Todos...)
+
+		if !viper.GetBool("all-pages") {
+			printTodos(todos)
+			if page.NextCursor != "" {
+				fmt.Fprintf(os.Stderr, "\nNext page: --cursor %s\n", page.NextCursor)
+			}
+			return
+		}
+
+		if page.NextCursor == "" {
+			printTodos(todos)
+			return
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// parseStatuses converts status flag values to statuses, validating each one.
+func parseStatuses(values []string) ([]todo.Status, error) {
+	statuses := make([]todo.Status, 0, len(values))
+	for _, v := range values {
+		status := todo.Status(v)
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid status: %s (valid: pending, in_progress, completed, cancelled, blocked)", v)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// parseQueryArgs parses the query language from positional arguments.
+// Parse errors are reported with a caret pointing at the offending column.
+func parseQueryArgs(args []string) (*todo.Query, error) {
+	q, err := todo.ParseQuery(strings.Join(args, " "))
+	if err != nil {
+		var qe *todo.QueryError
+		if errors.As(err, &qe) {
+			fmt.Fprintln(os.Stderr, qe.Pointer())
+		}
+		logger.Error("invalid query", "error", err)
+		return nil, err
+	}
+	return q, nil
+}
+
 // printTodos prints todos in a simple, readable format
 func printTodos(todos []*todo.Todo) {
 	if len(todos) == 0 {
@@ -179,6 +341,9 @@ func printTodos(todos []*todo.Todo) {
 		if len(t.Labels) > 0 {
 			fmt.Printf("Labels:      [%s]\n", strings.Join(t.Labels, ", "))
 		}
+		for _, name := range slices.Sorted(maps.Keys(t.Fields)) {
+			fmt.Printf("%-13s%v\n", name+":", t.Fields[name])
+		}
 		fmt.Printf("Created:     %s\n", t.CreateTime.Format(time.RFC3339))
 		fmt.Printf("Updated:     %s\n", t.UpdateTime.Format(time.RFC3339))
 
@@ -190,36 +355,80 @@ func printTodos(todos []*todo.Todo) {
 	}
 }
 
+// printFacets prints facet counts as "key (count)" lists
+func printFacets(facets *todo.Facets) {
+	fmt.Println()
+	fmt.Println("Facets:")
+	for _, f := range []struct {
+		name    string
+		buckets []todo.FacetBucket
+	}{
+		{"Status", facets.Statuses},
+		{"Labels", facets.Labels},
+		{"Created", facets.CreatedMonths},
+	} {
+		parts := make([]string, 0, len(f.buckets))
+		for _, b := range f.buckets {
+			parts = append(parts, fmt.Sprintf("%s (%d)", b.Key, b.Count))
+		}
+		fmt.Printf("  %-9s%s\n", f.name+":", strings.Join(parts, ", "))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 
 	// Count flag
 	listCmd.Flags().BoolP("count", "c", false, "Return count of todos matching filter instead of listing them")
+	listCmd.Flags().Bool("facets", false, "Also show counts per status, label and created month for the filter")
 
 	// Filter flags
-	listCmd.Flags().StringP("status", "s", "", "Filter by status (pending, in_progress, completed, cancelled, blocked)")
+	listCmd.Flags().StringSliceP("status", "s", []string{}, "Filter by status (comma-separated, any of: pending, in_progress, completed, cancelled, blocked)")
+	listCmd.Flags().StringSlice("not-status", []string{}, "Exclude todos with these statuses (comma-separated)")
+	listCmd.Flags().BoolP("all", "a", false, "Include completed and cancelled todos (hidden by default)")
 	listCmd.Flags().StringSliceP("labels", "l", []string{}, "Filter by labels (comma-separated, must have all)")
+	listCmd.Flags().StringSlice("any-labels", []string{}, "Filter by labels (comma-separated, must have at least one)")
+	listCmd.Flags().StringSlice("not-labels", []string{}, "Exclude todos with any of these labels (comma-separated)")
 	listCmd.Flags().StringP("search", "q", "", "Search query for title and description")
 	listCmd.Flags().String("from-date", "", "Filter todos created on or after this date (RFC3339 format)")
 	listCmd.Flags().String("to-date", "", "Filter todos created on or before this date (RFC3339 format)")
+	listCmd.Flags().StringArray("where", []string{}, "Filter on a custom field as key<op>value, ops: = != > >= < <= (repeatable)")
 
 	// Pagination flags
 	listCmd.Flags().Int("limit", 50, "Maximum number of results to return")
 	listCmd.Flags().Int("offset", 0, "Number of results to skip (for pagination)")
+	listCmd.Flags().String("cursor", "", "Continue from the cursor printed after the previous page")
+	listCmd.Flags().Bool("all-pages", false, "Follow cursors and list every matching todo (--limit is the page size)")
+	listCmd.MarkFlagsMutuallyExclusive("cursor", "offset")
+	listCmd.MarkFlagsMutuallyExclusive("all-pages", "offset")
+	listCmd.MarkFlagsMutuallyExclusive("facets", "cursor")
+	listCmd.MarkFlagsMutuallyExclusive("facets", "all-pages")
 
 	// Sorting flags
-	listCmd.Flags().String("sort-by", "createTime", "Field to sort by (createTime, updateTime, title, status)")
+	listCmd.Flags().String("sort-by", "createTime", "Field to sort by (createTime, updateTime, title, status, _score)")
 	listCmd.Flags().String("sort-order", "desc", "Sort order (asc, desc)")
 
 	// Bind flags to viper
 	viper.BindPFlag("count", listCmd.Flags().Lookup("count"))
+	viper.BindPFlag("facets", listCmd.Flags().Lookup("facets"))
 	viper.BindPFlag("status", listCmd.Flags().Lookup("status"))
+	viper.BindPFlag("not-status", listCmd.Flags().Lookup("not-status"))
+	viper.BindPFlag("all", listCmd.Flags().Lookup("all"))
 	viper.BindPFlag("labels", listCmd.Flags().Lookup("labels"))
+	viper.BindPFlag("any-labels", listCmd.Flags().Lookup("any-labels"))
+	viper.BindPFlag("not-labels", listCmd.Flags().Lookup("not-labels"))
 	viper.BindPFlag("search", listCmd.Flags().Lookup("search"))
 	viper.BindPFlag("from-date", listCmd.Flags().Lookup("from-date"))
 	viper.BindPFlag("to-date", listCmd.Flags().Lookup("to-date"))
 	viper.BindPFlag("limit", listCmd.Flags().Lookup("limit"))
 	viper.BindPFlag("offset", listCmd.Flags().Lookup("offset"))
+	viper.BindPFlag("cursor", listCmd.Flags().Lookup("cursor"))
+	viper.BindPFlag("all-pages", listCmd.Flags().Lookup("all-pages"))
 	viper.BindPFlag("sort-by", listCmd.Flags().Lookup("sort-by"))
 	viper.BindPFlag("sort-order", listCmd.Flags().Lookup("sort-order"))
+
+	// Shell completion for label flags
+	for _, flag := range []string{"labels", "any-labels", "not-labels"} {
+		cobra.CheckErr(listCmd.RegisterFlagCompletionFunc(flag, completeLabels))
+	}
 }
@@ -6,6 +6,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/MattDevy/es-todoify/internal/sdk"
 	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -44,7 +45,7 @@ Examples:
 		id, err := uuid.Parse(args[0])
 		if err != nil {
 			logger.Error("failed to parse todo id", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 
 		// Get and validate status
@@ -64,7 +65,7 @@ Examples:
 		updatedTodo, err := service.ChangeStatus(cmd.Context(), id.String(), status)
 		if err != nil {
 			logger.Error("failed to change status", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 
 		// Log success
@@ -81,4 +82,7 @@ func init() {
 
 	// Bind flag to viper
 	viper.BindPFlag("status", markCmd.Flags().Lookup("status"))
+
+	// Shell completion for the todo ID
+	markCmd.ValidArgsFunction = completeTodoIDs
 }
@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MattDevy/es-todoify/internal/mcpapi"
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mcpCmd represents the mcp command
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve todos to assistants over the Model Context Protocol",
+	Long: `Run a Model Context Protocol (MCP) server on stdin and stdout, so assistants
+and editor integrations can manage todos in the configured backend. Logs go to
+stderr.
+
+Tools:
+  create_todo     create a todo
+  list_todos      list todos matching a filter, with cursor pagination
+  get_todo        get a todo by ID
+  update_todo     update title, description, labels or custom fields
+  change_status   change the status of a todo
+  delete_todo     delete a todo
+  stats           count matching todos per status, label and creation month
+
+list_todos and stats take the list filter parameters of the REST API (status,
+notStatus, labels, anyLabels, notLabels, search, from, to, where and q).
+Failed calls return an error result with a code such as NOT_FOUND or
+BAD_USER_INPUT, and per-field messages for invalid input.
+
+The views section of the config file defines saved views, each a query language
+expression. They are served as todoify://views/NAME resources listing the todos
+they match; todos are also readable as todoify://todos/ID.
+
+  views:
+    blocked: "status:blocked"
+    this-week: "-status:completed updated<7d sort:-updated"
+
+Calls run as the OS user, with its rbac role and the --tenant, like other commands.
+
+Examples:
+  # Register with an MCP client that starts servers as commands
+  todoify mcp --backend file`,
+	// stdout carries the protocol, so everything set up logs to stderr
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logOutput = os.Stderr
+		return setup(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		views, err := loadViews()
+		if err != nil {
+			logger.Error("invalid views", "error", err)
+			os.Exit(1)
+		}
+
+		server := mcpapi.NewServer(service,
+			mcpapi.WithLogger(logger),
+			mcpapi.WithViews(views),
+		)
+		if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			logger.Error("MCP server failed", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+	},
+}
+
+// loadViews reads the saved views of the config file and checks their queries.
+func loadViews() (map[string]string, error) {
+	views := viper.GetStringMapString("views")
+	for name, expr := range views {
+		if _, err := todo.ParseQuery(expr); err != nil {
+			return nil, fmt.Errorf("view %s: %w", name, err)
+		}
+	}
+	return views, nil
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package operations
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/transfer"
+	"github.com/spf13/cobra"
+)
+
+// NewBackupCmd creates the backup command with injected dependencies.
+// This command writes every todo of the configured backend to an archive.
+func NewBackupCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Write all todos to a backup archive",
+		Long: `Write all todos to a versioned, gzip-compressed tar archive.
+
+The archive holds:
+- manifest.json: archive format version, creation time and counts
+- todos.ndjson: every todo, one JSON document per line, with IDs and timestamps
+- fields.json: custom field definitions
+- mappings.json and settings.json: the live index mapping and settings
+  (Elasticsearch only)
+
+Backups work with any backend and can be restored into any other with
+"ops restore", e.g. to move todos from Elasticsearch to a local file.
+
+Examples:
+  # Back up the Elasticsearch index
+  todoify ops backup --out backup.tar.gz
+
+  # Back up a local file store
+  todoify ops backup --backend file --out backup.tar.gz`,
+		Run: func(cmd *cobra.Command, args []string) {
+			requireAdmin(cmd)
+
+			repo := sdk.GetRepo(cmd.Context())
+			logger := sdk.GetLogger(cmd.Context())
+
+			f, err := os.Create(out)
+			if err != nil {
+				logger.Error("failed to create backup file", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+
+			manifest, err := transfer.Backup(cmd.Context(), repo, f, func(done, total int) {
+				if total > 0 {
+					fmt.Fprintf(os.Stderr, "\rBacked up %d/%d todos", done, total)
+				}
+			})
+			if manifest != nil && manifest.Todos > 0 {
+				fmt.Fprintln(os.Stderr)
+			}
+			if err == nil {
+				err = f.Close()
+			} else {
+				f.Close()
+				os.Remove(out)
+			}
+			if err != nil {
+				logger.Error("failed to back up todos", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+
+			logger.Info("backup written", "file", out, "todos", manifest.Todos, "fields", manifest.Fields, "schema", manifest.Schema)
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Archive to write (e.g. backup.tar.gz)")
+	cobra.CheckErr(cmd.MarkFlagRequired("out"))
+
+	return cmd
+}
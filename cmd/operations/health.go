@@ -26,6 +26,12 @@ This command performs a health check on the Elasticsearch cluster and returns in
 - Node count
 - Elasticsearch version
 - Detailed cluster statistics (shards, pending tasks, etc.)
+- Statistics for the todo index only (shard health, document count, size)
+- Mapping drift: fields missing from the index, fields with a different type,
+  unexpected fields added by dynamic mapping and differing index settings
+
+The status follows the todo index rather than the whole cluster, and is
+degraded when the mapping drifted or a migration is pending.
 
 Examples:
   # Check backend health
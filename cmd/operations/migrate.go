@@ -4,6 +4,7 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package operations
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/MattDevy/es-todoify/internal/sdk"
@@ -12,20 +13,45 @@ import (
 )
 
 // NewMigrateCmd creates the migrate command with injected dependencies.
-// This command creates or updates Elasticsearch indices based on the defined mappings.
+// This command creates or upgrades Elasticsearch indices based on the defined mappings.
 func NewMigrateCmd() *cobra.Command {
+	var dryRun bool
+
 	cmd := &cobra.Command{
 		Use:   "migrate",
-		Short: "Create or update Elasticsearch indices",
-		Long: `Create or update Elasticsearch indices based on the defined mappings.
+		Short: "Create or upgrade Elasticsearch indices",
+		Long: `Create or upgrade Elasticsearch indices based on the defined mappings.
+
+Todos are stored in versioned indices (todos-v1, todos-v2, ...) behind an alias
+named after the configured index, which the application reads and writes through.
+
+This command detects the mapping version behind the alias and, when the embedded
+mapping is newer, creates the new versioned index, reindexes all todos into it
+and atomically moves the alias. The application keeps working during the
+migration. The previous index is kept so it can be inspected or deleted later.
 
-This command will create the necessary indices in Elasticsearch for storing todos.
-If the indices already exist, this command will fail. Use with caution in production.
+Before that, ILM policies and composable index templates are installed: one for
+the versioned todo indices, rolled-over data streams for history and audit
+entries, and trash indices deleted after --es-trash-retention-days. Shards,
+replicas, refresh interval and analyzer come from the --es-* settings flags or
+the config file. Replicas and refresh interval are applied in place to an
+up-to-date index; changing shards or analyzer requires a new mapping version.
+
+An index created by an older version of todoify (a concrete index named like the
+alias) is migrated the same way and replaced by the alias.
 
 Examples:
-  # Create indices
-  todoify operations migrate`,
+  # Create or upgrade indices
+  todoify operations migrate
+
+  # Show what would be done without changing anything
+  todoify ops migrate --dry-run
+
+  # Single-node development cluster (stays green instead of yellow)
+  todoify ops migrate --es-replicas 0`,
 		Run: func(cmd *cobra.Command, args []string) {
+			requireAdmin(cmd)
+
 			repo := sdk.GetRepo(cmd.Context())
 			logger := sdk.GetLogger(cmd.Context())
 			// Type assert to Elasticsearch repository
@@ -35,16 +61,51 @@ Examples:
 				os.Exit(1)
 			}
 
-			// Create indices
-			err := esRepository.CreateIndices(cmd.Context())
+			plan, err := esRepository.PlanMigration(cmd.Context())
 			if err != nil {
-				logger.Error("failed to create indices", "error", err)
-				os.Exit(1)
+				logger.Error("failed to plan migration", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+			printPlan(plan)
+
+			if dryRun || plan.UpToDate() {
+				return
+			}
+
+			err = esRepository.Migrate(cmd.Context(), plan, func(p esrepo.MigrationProgress) {
+				if p.Total > 0 {
+					fmt.Printf("\rReindexed %d/%d todos (%.0f%%)", p.Created+p.Updated, p.Total, float64(p.Created+p.Updated)/float64(p.Total)*100)
+				}
+			})
+			if plan.Docs > 0 {
+				fmt.Println()
+			}
+			if err != nil {
+				logger.Error("failed to migrate indices", "error", err)
+				os.Exit(sdk.ExitCode(err))
 			}
 
-			logger.Info("indices created successfully")
+			logger.Info("indices migrated successfully", "alias", plan.Alias, "index", plan.TargetIndex)
 		},
 	}
 
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the migration plan without changing anything")
+
 	return cmd
 }
+
+// printPlan describes a migration plan in plain words
+func printPlan(plan *esrepo.MigrationPlan) {
+	switch {
+	case plan.UpToDate():
+		fmt.Printf("%s is up to date (%s, mapping version %d)\n", plan.Alias, plan.CurrentIndex, plan.CurrentVersion)
+	case plan.CurrentIndex == "":
+		fmt.Printf("Will create %s (mapping version %d) behind alias %s\n", plan.TargetIndex, plan.TargetVersion, plan.Alias)
+	case plan.Legacy():
+		fmt.Printf("Will reindex %d todos from unversioned index %s into %s (mapping version %d) and replace it with an alias\n",
+			plan.Docs, plan.CurrentIndex, plan.TargetIndex, plan.TargetVersion)
+	default:
+		fmt.Printf("Will reindex %d todos from %s (mapping version %d) into %s (mapping version %d) and move alias %s\n",
+			plan.Docs, plan.CurrentIndex, plan.CurrentVersion, plan.TargetIndex, plan.TargetVersion, plan.Alias)
+	}
+}
@@ -4,6 +4,10 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package operations
 
 import (
+	"os"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +20,9 @@ func Register(parentCmd *cobra.Command) {
 	// Add subcommands with dependencies
 	opsCmd.AddCommand(NewMigrateCmd())
 	opsCmd.AddCommand(NewHealthCmd())
+	opsCmd.AddCommand(NewBackupCmd())
+	opsCmd.AddCommand(NewRestoreCmd())
+	opsCmd.AddCommand(NewCopyCmd())
 
 	// Register operations command to parent
 	parentCmd.AddCommand(opsCmd)
@@ -31,8 +38,21 @@ func NewOperationsCmd() *cobra.Command {
 		Long: `Administrative and maintenance operations for managing the Elasticsearch backend.
 
 This includes operations like creating indices, running migrations, and other
-administrative tasks that are separate from day-to-day todo management.`,
+administrative tasks that are separate from day-to-day todo management.
+
+When role-based access control is configured, only admins may run migrate,
+backup, restore and copy.`,
 	}
 
 	return cmd
 }
+
+// requireAdmin exits unless the user may run backend operations. These work
+// on the repository directly, so the service cannot check them itself.
+func requireAdmin(cmd *cobra.Command) {
+	ctx := cmd.Context()
+	if err := sdk.GetService(ctx).Authorize(ctx, todo.ActionAdmin); err != nil {
+		sdk.GetLogger(ctx).Error("operation not allowed", "error", err)
+		os.Exit(sdk.ExitCode(err))
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package operations
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/transfer"
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreCmd creates the restore command with injected dependencies.
+// This command loads a backup archive into the configured backend.
+func NewRestoreCmd() *cobra.Command {
+	var (
+		in         string
+		onConflict string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Load todos from a backup archive",
+		Long: `Load todos from an archive written by "ops backup" into the configured backend.
+
+The backend's storage is created first (for Elasticsearch, the same as
+"ops migrate"), then field definitions and todos are loaded in bulk with their
+original IDs and timestamps. Indices are created from the mapping of this
+version of todoify; the mapping and settings stored in the archive are kept
+for reference only.
+
+--on-conflict decides what happens to todos and fields that already exist:
+  skip       keep the existing one (default)
+  overwrite  replace it with the one from the archive
+  fail       stop at the first conflict; batches loaded before it are kept
+
+Examples:
+  # Restore into Elasticsearch
+  todoify ops restore --in backup.tar.gz
+
+  # Move todos from Elasticsearch into a local file
+  todoify ops backup --out backup.tar.gz
+  todoify ops restore --backend file --in backup.tar.gz
+
+  # Replace todos that already exist
+  todoify ops restore --in backup.tar.gz --on-conflict overwrite`,
+		Run: func(cmd *cobra.Command, args []string) {
+			requireAdmin(cmd)
+
+			repo := sdk.GetRepo(cmd.Context())
+			logger := sdk.GetLogger(cmd.Context())
+
+			policy := transfer.ConflictPolicy(onConflict)
+			if !policy.IsValid() {
+				logger.Error("invalid --on-conflict, must be one of skip, overwrite, fail", "value", onConflict)
+				os.Exit(1)
+			}
+
+			f, err := os.Open(in)
+			if err != nil {
+				logger.Error("failed to open backup file", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+			defer f.Close()
+
+			manifest, result, err := transfer.Restore(cmd.Context(), repo, f, policy, func(done, total int) {
+				if total > 0 {
+					fmt.Fprintf(os.Stderr, "\rRestored %d/%d todos", done, total)
+				}
+			})
+			if manifest != nil && manifest.Todos > 0 {
+				fmt.Fprintln(os.Stderr)
+			}
+			if err != nil {
+				logger.Error("failed to restore todos", "error", err)
+				os.Exit(sdk.ExitCode(err))
+			}
+
+			logger.Info("backup restored", "file", in, "created", manifest.CreatedAt,
+				"written", result.Written, "skipped", result.Skipped, "fields", result.Fields)
+		},
+	}
+
+	cmd.Flags().StringVarP(&in, "in", "i", "", "Archive to read (written by ops backup)")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(transfer.ConflictSkip), "What to do with todos that already exist (skip, overwrite, fail)")
+	cobra.CheckErr(cmd.MarkFlagRequired("in"))
+
+	return cmd
+}
@@ -7,15 +7,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/MattDevy/es-todoify/cmd/operations"
+	"github.com/MattDevy/es-todoify/internal/backend"
+	"github.com/MattDevy/es-todoify/internal/rbac"
 	"github.com/MattDevy/es-todoify/internal/sdk"
 	"github.com/MattDevy/es-todoify/internal/todo"
 	esrepo "github.com/MattDevy/es-todoify/internal/todo/repositories/elasticsearch/v9"
-	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/MattDevy/es-todoify/internal/webhook"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -25,6 +30,15 @@ var (
 	logger  *slog.Logger = slog.Default()
 	repo    todo.Repository
 	service *todo.Service
+
+	// logOutput is where initLogger writes, stderr for commands that own stdout
+	logOutput io.Writer = os.Stdout
+
+	// webhooks queues the service's changes for the configured webhooks
+	webhooks *webhook.Dispatcher
+
+	// authorizer enforces the rbac policy of the config file, if any
+	authorizer *rbac.Authorizer
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -42,27 +56,64 @@ It provides a simple and powerful way to manage your todos with features like:
 All data is stored in Elasticsearch, giving you the power of full-text search,
 aggregations, and scalability for your todo management.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Initialize Viper configuration
-		if err := initConfig(cmd); err != nil {
-			return err
+		// Shell completion sets up lazily so scripts can be generated without a backend
+		if isCompletionCmd(cmd) {
+			return nil
 		}
+		return setup(cmd)
+	},
+}
+
+// setup initializes configuration, logging, the repository and the service,
+// and stores them in the command context for sub-commands.
+func setup(cmd *cobra.Command) error {
+	// Initialize Viper configuration
+	if err := initConfig(cmd); err != nil {
+		return err
+	}
+
+	initLogger()
+
+	// Initialize repository
+	if err := initRepository(); err != nil {
+		return err
+	}
 
-		initLogger()
+	initWebhooks()
+	if err := initAuthorizer(); err != nil {
+		return err
+	}
+	service = newService(todo.WithPublisher(webhooks))
 
-		// Initialize repository
-		if err := initRepository(); err != nil {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if authorizer != nil {
+		// Local commands run with the role of the OS user
+		ctx = todo.WithPrincipal(ctx, localPrincipal())
+	}
+	if tenant := viper.GetString("tenant"); tenant != "" {
+		if err := todo.ValidateTenant(tenant); err != nil {
 			return err
 		}
+		ctx = todo.WithTenant(ctx, tenant)
+	}
+	ctx = sdk.WithService(ctx, service)
+	ctx = sdk.WithRepo(ctx, repo)
+	ctx = sdk.WithLogger(ctx, logger)
+	cmd.SetContext(ctx)
 
-		service = todo.NewService(repo)
-
-		ctx := sdk.WithService(cmd.Context(), service)
-		ctx = sdk.WithRepo(ctx, repo)
-		ctx = sdk.WithLogger(ctx, logger)
-		cmd.SetContext(ctx)
+	return nil
+}
 
-		return nil
-	},
+// isCompletionCmd reports whether cmd generates or serves shell completions.
+func isCompletionCmd(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return cmd.HasParent() && cmd.Parent().Name() == "completion"
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -70,19 +121,34 @@ aggregations, and scalability for your todo management.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(sdk.ExitCode(err))
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.todoify.yaml)")
+	rootCmd.PersistentFlags().String("tenant", "", "Tenant to act for when the backend isolates tenants, also given to created access tokens")
+
+	// Backend selection
+	rootCmd.PersistentFlags().String("backend", backend.Elasticsearch, "Storage backend (elasticsearch, file)")
+	rootCmd.PersistentFlags().String("file-path", "", "Todo store for the file backend (default is $HOME/.todoify/todos.json)")
+	rootCmd.PersistentFlags().String("webhooks-path", "", "Webhook store and delivery queue (default is $HOME/.todoify/webhooks.json)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSlice("es-addrs", []string{"http://localhost:9200"}, "Elasticsearch addresses (comma-separated)")
 	rootCmd.PersistentFlags().String("es-username", "", "Elasticsearch username")
 	rootCmd.PersistentFlags().String("es-password", "", "Elasticsearch password")
 	rootCmd.PersistentFlags().String("es-api-key", "", "Elasticsearch API key")
-	rootCmd.PersistentFlags().String("es-index", "todos", "Elasticsearch index name")
+	rootCmd.PersistentFlags().String("es-index", backend.DefaultIndex, "Elasticsearch index name")
+
+	// Elasticsearch index settings, applied by "ops migrate"
+	defaults := esrepo.DefaultIndexSettings()
+	rootCmd.PersistentFlags().Int("es-shards", defaults.Shards, "Number of primary shards for new indices")
+	rootCmd.PersistentFlags().Int("es-replicas", defaults.Replicas, "Number of replica shards (use 0 on a single-node cluster)")
+	rootCmd.PersistentFlags().String("es-refresh-interval", defaults.RefreshInterval, "How often new writes become searchable")
+	rootCmd.PersistentFlags().String("es-analyzer", defaults.Analyzer, "Built-in analyzer for full-text fields (e.g. standard, english)")
+	rootCmd.PersistentFlags().Int("es-trash-retention-days", defaults.TrashRetentionDays, "Days to keep trashed todos before deletion")
+	rootCmd.PersistentFlags().String("es-tenancy", backend.TenancyNone, "Tenant isolation: none, filter (tenant field) or routing (tenant field and shard routing)")
 
 	// Register operations parent command (subcommands added lazily when deps are available)
 	operations.Register(rootCmd)
@@ -123,72 +189,82 @@ func initConfig(cmd *cobra.Command) error {
 	}
 
 	// Set defaults (in case not provided anywhere)
-	viper.SetDefault("es-addrs", []string{"http://localhost:9200"})
-	viper.SetDefault("es-index", "todos")
+	home, _ := os.UserHomeDir()
+	backend.SetDefaults(viper.GetViper(), home)
+	if home != "" {
+		viper.SetDefault("webhooks-path", filepath.Join(home, ".todoify", "webhooks.json"))
+	}
 
 	return nil
 }
 
 func initLogger() {
 	// TODO: Support different log levels and output formats
-	logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	logger = slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 }
 
-// initElasticsearchClient initializes the Elasticsearch typed client
+// initRepository opens the repository for the configured backend
 func initRepository() error {
-	// Retrieve configuration values from Viper
-	esAddrs := viper.GetStringSlice("es-addrs")
-	esUsername := viper.GetString("es-username")
-	esPassword := viper.GetString("es-password")
-	esAPIKey := viper.GetString("es-api-key")
+	cfg := backend.FromViper(viper.GetViper())
 
-	// Validate required configuration
-	if len(esAddrs) == 0 {
-		return errors.New("at least one Elasticsearch address is required (use --es-addrs, TODOIFY_ES_ADDRS, or config file)")
+	r, err := backend.Open(context.Background(), cfg)
+	if err != nil {
+		return err
 	}
+	repo = r
 
-	// Validate that both username and password are provided together
-	if (esUsername != "" && esPassword == "") || (esUsername == "" && esPassword != "") {
-		return errors.New("both es-username and es-password must be provided together")
-	}
+	logger.Debug("Opened repository", "backend", cfg.String())
 
-	// Validate that API key and username/password are mutually exclusive
-	if esAPIKey != "" && (esUsername != "" || esPassword != "") {
-		return errors.New("cannot use both API key and username/password authentication; choose one method")
-	}
+	return nil
+}
 
-	// Build Elasticsearch client config
-	cfg := elasticsearch.Config{
-		Addresses: esAddrs,
+// initAuthorizer loads the rbac policy from the config file, e.g.
+//
+//	rbac:
+//	  default: viewer
+//	  bindings:
+//	    - subject: alice
+//	      role: admin
+func initAuthorizer() error {
+	if !viper.IsSet("rbac") {
+		return nil
 	}
 
-	// Add authentication if provided
-	if esAPIKey != "" {
-		cfg.APIKey = esAPIKey
-	} else if esUsername != "" && esPassword != "" {
-		cfg.Username = esUsername
-		cfg.Password = esPassword
+	var policy rbac.Policy
+	if err := viper.UnmarshalKey("rbac", &policy); err != nil {
+		return fmt.Errorf("failed to read rbac policy: %w", err)
 	}
-
-	// Create typed client
-	client, err := elasticsearch.NewTypedClient(cfg)
+	a, err := rbac.NewAuthorizer(&policy)
 	if err != nil {
-		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+		return err
 	}
+	authorizer = a
+	return nil
+}
 
-	// Verify connection by pinging the cluster
-	ctx := context.Background()
-	info, err := client.Info().Do(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Elasticsearch at %v: %w\nPlease check:\n  - Elasticsearch is running\n  - Addresses are correct\n  - Credentials are valid", esAddrs, err)
+// newService creates the service for the repository, enforcing the rbac
+// policy if one is configured.
+func newService(opts ...todo.ServiceOption) *todo.Service {
+	if authorizer != nil {
+		opts = append(opts, todo.WithAuthorizer(authorizer))
 	}
+	return todo.NewService(repo, opts...)
+}
 
-	repo = esrepo.NewRepository(client, viper.GetString("es-index"))
-
-	// Log successful connection
-	logger.Debug("Connected to Elasticsearch cluster", "clusterName", info.ClusterName)
+// localPrincipal is the OS user running a command.
+func localPrincipal() *todo.Principal {
+	p := &todo.Principal{Scopes: todo.AllScopes(), Method: "local"}
+	if u, err := user.Current(); err == nil {
+		p.Subject = u.Username
+	}
+	return p
+}
 
-	return nil
+// initWebhooks creates the webhook dispatcher. Commands only queue
+// deliveries; "todoify serve" and "todoify webhook flush" send them.
+func initWebhooks() {
+	store := webhook.NewStore(viper.GetString("webhooks-path"))
+	webhooks = webhook.NewDispatcher(store, webhook.WithLogger(logger))
 }
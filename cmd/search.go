@@ -0,0 +1,126 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ansiHighlight and ansiReset render highlighted fragments in bold yellow on terminals.
+	ansiHighlight = "\x1b[1;33m"
+	ansiReset     = "\x1b[0m"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:     "search [query]",
+	Aliases: []string{"s"},
+	Short:   "Search todos and show why they matched",
+	Long: `Search todos using the query language and show relevance scores and
+highlighted fragments from the title and description.
+
+Results are sorted by relevance unless the query contains a sort term. See
+'todoify list --help' for the query language. Completed and cancelled todos are
+hidden unless --all is given or the query has a status term.
+
+Examples:
+  # Free-text search
+  todoify search oauth login
+
+  # Phrase search limited to bugs
+  todoify search '"refresh token" label:bug'
+
+  # Search including completed todos, sorted by title
+  todoify search --all 'login sort:title'`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query, err := parseQueryArgs(args)
+		if err != nil {
+			os.Exit(1)
+		}
+
+		filter := todo.ListFilter{
+			Query: query,
+			Limit: viper.GetInt("limit"),
+		}
+		if !viper.GetBool("all") {
+			filter.HideTerminal()
+		}
+
+		results, err := service.SearchTodos(cmd.Context(), filter)
+		if err != nil {
+			logger.Error("failed to search todos", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		printSearchResults(results, useColor())
+	},
+}
+
+// printSearchResults prints search results with scores and highlighted fragments.
+func printSearchResults(results []*todo.SearchResult, color bool) {
+	if len(results) == 0 {
+		fmt.Println("No todos found.")
+		return
+	}
+
+	fmt.Printf("Found %d todo(s):\n\n", len(results))
+
+	for i, r := range results {
+		title := r.Todo.Title
+		if fragments := r.Highlights["title"]; len(fragments) > 0 {
+			title = fragments[0]
+		}
+
+		fmt.Printf("[%6.2f] %s (%s)\n", r.Score, renderHighlight(title, color), r.Todo.Status)
+		fmt.Printf("         %s\n", r.Todo.ID)
+		for _, fragment := range r.Highlights["description"] {
+			fmt.Printf("         …%s…\n", renderHighlight(fragment, color))
+		}
+
+		if i < len(results)-1 {
+			fmt.Println()
+		}
+	}
+}
+
+// renderHighlight replaces highlight tags with terminal escape codes, or with
+// asterisks when color output is disabled.
+func renderHighlight(s string, color bool) string {
+	pre, post := "*", "*"
+	if color {
+		pre, post = ansiHighlight, ansiReset
+	}
+	return strings.NewReplacer(todo.HighlightPreTag, pre, todo.HighlightPostTag, post).Replace(s)
+}
+
+// useColor reports whether stdout is a terminal and NO_COLOR is not set.
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().Int("limit", 20, "Maximum number of results to return")
+	searchCmd.Flags().BoolP("all", "a", false, "Include completed and cancelled todos (hidden by default)")
+
+	viper.BindPFlag("limit", searchCmd.Flags().Lookup("limit"))
+	viper.BindPFlag("all", searchCmd.Flags().Lookup("all"))
+}
@@ -0,0 +1,389 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/api"
+	"github.com/MattDevy/es-todoify/internal/auth"
+	"github.com/MattDevy/es-todoify/internal/backend"
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/graphqlapi"
+	"github.com/MattDevy/es-todoify/internal/grpcapi"
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/sdk"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/MattDevy/es-todoify/internal/webui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the todo API over HTTP or gRPC",
+	Long: `Serve todos as a JSON REST and GraphQL API, or with --grpc as a gRPC API, on top of the
+configured backend.
+
+Endpoints (under /api/v1):
+  POST   /todos               create a todo
+  GET    /todos               list todos (filter parameters below)
+  GET    /todos/count         count todos matching the filter
+  GET    /todos/{id}          get a todo
+  PATCH  /todos/{id}          update title, description, labels or fields
+  PUT    /todos/{id}/status   change the status
+  DELETE /todos/{id}          delete a todo
+  GET    /events              stream changes as server-sent events
+  GET    /events/ws           stream changes over a WebSocket
+  GET    /openapi.yaml        the OpenAPI 3.1 document
+
+GET /healthz reports the backend health (503 when unhealthy).
+
+GET / serves a web UI listing the todos grouped by status, as a list or a
+kanban board. Its filters are named after the flags of "todoify list", which
+it also follows in hiding completed and cancelled todos unless statuses are
+chosen or "all" is set. Todos can be created, edited and moved between
+statuses in place, and open pages update live as todos change. The UI has no
+login: it is for a single user on their own machine, and anyone who can reach
+it can read and change every todo. Listen on a local address then, e.g.
+--addr localhost:8080. The UI is not served with --auth, since browsers cannot
+send bearer credentials, or with --ui=false.
+
+POST /graphql serves the GraphQL API (schema in internal/graphqlapi/schema.graphql)
+with queries, mutations and the todoChanged subscription, which streams
+server-sent events when requested with "Accept: text/event-stream". Requests
+must be sent as application/json.
+
+List and count accept status, notStatus, labels, anyLabels, notLabels
+(comma-separated or repeated), search, from, to, where (repeatable), q (query
+language), limit, offset, sortBy and sortOrder. List also accepts cursor (empty
+to start cursor pagination) and facets=true.
+
+The event streams accept the same filter parameters as count, plus types
+(created, updated, status_changed, deleted). They carry changes made through
+this server only, not changes made directly against the backend.
+
+Errors are application/problem+json: 400 for invalid input (with per-field
+messages), 404 for unknown todos, 409 for conflicts and 422 for disallowed
+status transitions.
+
+With --grpc the todoify.v1.TodoService from proto/todoify/v1/todo.proto is
+served together with the standard grpc.health.v1 health service and server
+reflection. Invalid arguments carry google.rpc.BadRequest field violations.
+
+With --auth every endpoint except /healthz and /openapi.yaml, and every
+TodoService method, requires a bearer credential: a personal access token from
+"todoify token create" or, with --jwt-jwks, a JWT signed by one of the keys in
+the JWKS file. JWTs must have sub and exp claims and grant scopes with a scope
+or scp claim. Reading needs the read scope and changing todos the write scope.
+Missing or invalid credentials get 401 (gRPC UNAUTHENTICATED) and missing
+scopes 403 (PERMISSION_DENIED).
+
+The rbac policy of the config file, if any, then gives each authenticated
+subject a role: viewers may only read, editors may also change todos, and
+admins may also define fields and manage access tokens. Roles bound to a
+project only apply to the todos with the project's label, and other todos are
+404. Calls the role does not allow get 403 (PERMISSION_DENIED). The policy
+requires --auth, since callers without a subject are refused.
+
+With --es-tenancy filter or routing, each caller only sees the todos of its
+tenant: the tenant of its access token ("todoify --tenant NAME token create")
+or the tenant claim of its JWT. Todos of other tenants are 404, and live
+change events only go to subscribers of the same tenant.
+
+With --rate-limit each HTTP or gRPC client may make that many requests per
+second, in bursts of up to --rate-burst. Clients are access tokens or JWT
+subjects with --auth, and IP addresses otherwise (the last X-Forwarded-For
+address with --trust-forwarded-for). Counts and lists that search or request
+facets are expensive and have their own, usually lower, --expensive-rate-limit
+and --expensive-rate-burst; each count and search in a GraphQL request takes
+an expensive request. With --auth every request also counts against the
+--address-rate-limit and --address-rate-burst of its IP address before the
+credential is checked, so bad credentials are throttled too. Throttled
+requests get 429 with a Retry-After header, or RESOURCE_EXHAUSTED over gRPC,
+and GET /metrics reports allowed and throttled requests in the Prometheus text
+format. /healthz, /metrics and the gRPC health service are never throttled.
+
+Webhooks added with "todoify webhook add" are delivered while the server runs,
+both for changes made through it and for changes queued by other commands.
+
+On SIGINT or SIGTERM the server stops accepting connections and waits up to
+--shutdown-timeout for in-flight requests to finish.
+
+Examples:
+  # Serve on port 8080
+  todoify serve --addr :8080
+
+  # Serve a local file store
+  todoify serve --backend file
+
+  # Serve gRPC on port 9090
+  todoify serve --grpc
+
+  # Require access tokens or JWTs from an identity provider
+  todoify serve --auth --jwt-jwks jwks.json --jwt-issuer https://idp.example.com --jwt-audience todoify
+
+  # Allow each client 10 requests per second, and 1 search or count
+  todoify serve --auth --rate-limit 10 --rate-burst 20 --expensive-rate-limit 1 --expensive-rate-burst 5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Publish the changes made through the server to live subscribers
+		changes = events.NewBroker()
+		service = newService(todo.WithPublisher(changes), todo.WithPublisher(webhooks))
+
+		// Deliver webhooks, including those queued by other commands
+		go webhooks.Run(ctx)
+
+		var err error
+		if authenticator, err = newAuthenticator(); err != nil {
+			logger.Error("invalid authentication settings", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		if authorizer != nil && authenticator == nil {
+			err := errors.New("the rbac policy requires --auth, since requests without a principal are refused")
+			logger.Error("invalid server settings", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		if cfg := backend.FromViper(viper.GetViper()); cfg.Backend == backend.Elasticsearch && cfg.Tenancy != backend.TenancyNone && authenticator == nil {
+			logger.Warn("API requests have no tenant without --auth, so an isolating backend refuses them")
+		}
+
+		if limiter, err = newRateLimiter(); err != nil {
+			logger.Error("invalid rate limit settings", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		if viper.GetBool("grpc") {
+			addr := viper.GetString("addr")
+			if !cmd.Flags().Changed("addr") {
+				addr = defaultGRPCAddr
+			}
+			err = serveGRPC(ctx, addr)
+		} else {
+			err = serveHTTP(ctx, viper.GetString("addr"))
+		}
+		if err != nil {
+			logger.Error("server failed", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		logger.Info("server stopped")
+	},
+}
+
+// changes carries the change events of the served service.
+var changes *events.Broker
+
+// authenticator verifies API credentials; nil serves without authentication.
+var authenticator *auth.Authenticator
+
+// newAuthenticator builds the authenticator from the --auth and --jwt-* flags.
+func newAuthenticator() (*auth.Authenticator, error) {
+	if !viper.GetBool("auth") {
+		if viper.GetString("jwt-jwks") != "" {
+			return nil, errors.New("--jwt-jwks requires --auth")
+		}
+		return nil, nil
+	}
+
+	var opts []auth.Option
+	if path := viper.GetString("jwt-jwks"); path != "" {
+		set, err := auth.LoadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		v, err := auth.NewJWTVerifier(set,
+			auth.WithIssuer(viper.GetString("jwt-issuer")),
+			auth.WithAudience(viper.GetString("jwt-audience")),
+		)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, auth.WithJWT(v))
+	}
+
+	return auth.New(service, opts...), nil
+}
+
+// limiter throttles API clients; nil serves without rate limits.
+var limiter *ratelimit.Limiter
+
+// newRateLimiter builds the rate limiter from the --rate-limit,
+// --expensive-rate-* and --address-rate-* flags. Expensive requests and
+// addresses refill at the standard rate unless their own limit is set.
+func newRateLimiter() (*ratelimit.Limiter, error) {
+	standard := ratelimit.Limit{Rate: viper.GetFloat64("rate-limit"), Burst: viper.GetInt("rate-burst")}
+	expensive := ratelimit.Limit{Rate: viper.GetFloat64("expensive-rate-limit"), Burst: viper.GetInt("expensive-rate-burst")}
+	address := ratelimit.Limit{Rate: viper.GetFloat64("address-rate-limit"), Burst: viper.GetInt("address-rate-burst")}
+	if standard.Rate == 0 && expensive.Rate == 0 && address.Rate == 0 {
+		return nil, nil
+	}
+	if expensive.Rate == 0 {
+		expensive.Rate = standard.Rate
+	}
+	if address.Rate == 0 {
+		address.Rate = standard.Rate
+	}
+	return ratelimit.New(
+		ratelimit.WithLimit(ratelimit.Standard, standard),
+		ratelimit.WithLimit(ratelimit.Expensive, expensive),
+		ratelimit.WithLimit(ratelimit.Address, address),
+	)
+}
+
+// defaultGRPCAddr is the listen address in --grpc mode when --addr is not given.
+const defaultGRPCAddr = ":9090"
+
+// serveHTTP serves the REST API until ctx is done, then drains in-flight requests.
+func serveHTTP(ctx context.Context, addr string) error {
+	gql := graphqlapi.NewServer(service,
+		graphqlapi.WithLogger(logger),
+		graphqlapi.WithEvents(changes),
+	)
+	opts := []api.Option{
+		api.WithLogger(logger),
+		api.WithEvents(changes),
+		api.WithHandler("/graphql", gql.Handler()),
+	}
+	if authenticator != nil {
+		opts = append(opts, api.WithAuthenticator(authenticator))
+	}
+	if viper.GetBool("ui") {
+		if authenticator != nil {
+			// Browsers cannot send bearer credentials with page loads and forms
+			logger.Info("the web UI is not served with --auth")
+		} else {
+			ui := webui.NewServer(service,
+				webui.WithLogger(logger),
+				webui.WithEventsURL(api.BasePath+"/events"),
+			).Handler()
+			for _, pattern := range webui.Patterns {
+				opts = append(opts, api.WithHandler(pattern, ui))
+			}
+		}
+	}
+	if limiter != nil {
+		opts = append(opts, api.WithRateLimiter(limiter))
+	}
+	if viper.GetBool("trust-forwarded-for") {
+		opts = append(opts, api.WithForwardedFor())
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api.NewServer(service, opts...).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// End live event streams so shutdown does not wait for them
+	server.RegisterOnShutdown(changes.Close)
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("serving todo API", "addr", server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("shutdown-timeout"))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveGRPC serves the gRPC API until ctx is done, then drains in-flight
+// requests. The health service follows the backend health while serving.
+func serveGRPC(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	opts := []grpcapi.Option{grpcapi.WithLogger(logger)}
+	if authenticator != nil {
+		opts = append(opts, grpcapi.WithAuthenticator(authenticator))
+	}
+	if limiter != nil {
+		opts = append(opts, grpcapi.WithRateLimiter(limiter))
+	}
+	srv := grpcapi.NewServer(service, opts...)
+	server := grpc.NewServer(srv.ServerOptions()...)
+	srv.Register(server)
+
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	go srv.WatchHealth(healthCtx, viper.GetDuration("health-interval"))
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("serving todo gRPC API", "addr", lis.Addr().String())
+		errCh <- server.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, waiting for in-flight requests")
+	srv.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(viper.GetDuration("shutdown-timeout")):
+		server.Stop()
+		return errors.New("graceful shutdown timed out")
+	}
+	return <-errCh
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", ":8080", "Address to listen on")
+	serveCmd.Flags().Duration("shutdown-timeout", 10*time.Second, "How long to wait for in-flight requests on shutdown")
+	serveCmd.Flags().Bool("grpc", false, "Serve the gRPC API instead of the REST API (default address :9090)")
+	serveCmd.Flags().Duration("health-interval", 10*time.Second, "How often the gRPC health service checks the backend")
+	serveCmd.Flags().Bool("ui", true, "Serve the web UI at /, for single-user local use without login (not with --auth)")
+
+	// Authentication flags
+	serveCmd.Flags().Bool("auth", false, "Require a bearer access token or JWT on API requests")
+	serveCmd.Flags().String("jwt-jwks", "", "JWKS file with the keys JWTs may be signed with")
+	serveCmd.Flags().String("jwt-issuer", "", "Required iss claim of JWTs")
+	serveCmd.Flags().String("jwt-audience", "", "Audience JWTs must be issued for")
+
+	// Rate limiting flags
+	serveCmd.Flags().Float64("rate-limit", 0, "Requests per second allowed per API client (0 for no limit)")
+	serveCmd.Flags().Int("rate-burst", 20, "Requests an API client may make at once")
+	serveCmd.Flags().Float64("expensive-rate-limit", 0, "Searches, facets and counts per second allowed per API client (0 for --rate-limit)")
+	serveCmd.Flags().Int("expensive-rate-burst", 5, "Searches, facets and counts an API client may make at once")
+	serveCmd.Flags().Float64("address-rate-limit", 0, "Requests per second allowed per IP address before authentication with --auth (0 for --rate-limit)")
+	serveCmd.Flags().Int("address-rate-burst", 20, "Requests an IP address may make at once before authentication with --auth")
+	serveCmd.Flags().Bool("trust-forwarded-for", false, "Identify HTTP client addresses by the X-Forwarded-For address set by a reverse proxy")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/sdk"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// suggestCmd represents the suggest command
+var suggestCmd = &cobra.Command{
+	Use:   "suggest [prefix]",
+	Short: "Suggest todo titles and labels starting with a prefix",
+	Long: `Suggest completions for a prefix: todos whose title (or ID) starts with it
+and existing labels starting with it.
+
+The same suggestions power shell completion for todo IDs and label flags
+(see 'todoify completion --help').
+
+Examples:
+  # Complete a title
+  todoify suggest logi
+
+  # Show the most recently updated todos and all labels
+  todoify suggest`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		suggestions, err := service.Suggest(cmd.Context(), prefix, viper.GetInt("limit"))
+		if err != nil {
+			logger.Error("failed to suggest", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
+		if len(suggestions.Todos) == 0 && len(suggestions.Labels) == 0 {
+			fmt.Println("No suggestions.")
+			return
+		}
+
+		if len(suggestions.Todos) > 0 {
+			fmt.Println("Todos:")
+			for _, s := range suggestions.Todos {
+				fmt.Printf("  %s  %s\n", s.ID, s.Title)
+			}
+		}
+
+		if len(suggestions.Labels) > 0 {
+			fmt.Println("Labels:")
+			for _, label := range suggestions.Labels {
+				fmt.Printf("  %s\n", label)
+			}
+		}
+	},
+}
+
+// completeTodoIDs completes todo ID arguments with suggestions, showing titles as descriptions.
+func completeTodoIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || setupCompletion(cmd) != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	suggestions, err := service.Suggest(cmd.Context(), toComplete, 20)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := make([]string, 0, len(suggestions.Todos))
+	for _, s := range suggestions.Todos {
+		completions = append(completions, s.ID.String()+"\t"+s.Title)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLabels completes comma-separated label flags with existing labels.
+func completeLabels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if setupCompletion(cmd) != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Only complete the label after the last comma
+	done, current := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done, current = toComplete[:i+1], toComplete[i+1:]
+	}
+
+	suggestions, err := service.Suggest(cmd.Context(), current, 50)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := make([]string, 0, len(suggestions.Labels))
+	for _, label := range suggestions.Labels {
+		completions = append(completions, done+label)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// setupCompletion initializes the service for shell completion, which runs
+// without the root command's PersistentPreRunE.
+func setupCompletion(cmd *cobra.Command) error {
+	if service != nil {
+		return nil
+	}
+	return setup(cmd)
+}
+
+func init() {
+	rootCmd.AddCommand(suggestCmd)
+
+	suggestCmd.Flags().Int("limit", 10, "Maximum number of suggestions of each kind")
+	viper.BindPFlag("limit", suggestCmd.Flags().Lookup("limit"))
+}
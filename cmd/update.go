@@ -6,6 +6,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/MattDevy/es-todoify/internal/sdk"
 	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -16,11 +17,11 @@ import (
 var updateCmd = &cobra.Command{
 	Use:     "update [todo-id]",
 	Aliases: []string{"u"},
-	Short:   "Update a todo's title, description, or labels",
+	Short:   "Update a todo's title, description, labels, or custom fields",
 	Long: `Update one or more fields of an existing todo item.
 
-You can update the title, description, and/or labels of a todo by providing
-its UUID and one or more update flags. At least one field must be provided.
+You can update the title, description, labels, and/or custom fields of a todo by
+providing its UUID and one or more update flags. At least one field must be provided.
 
 Examples:
   # Update just the title
@@ -30,22 +31,37 @@ Examples:
   todoify update abc123-... -t "New title" -d "Updated description"
 
   # Update only labels
-  todoify update abc123-... --labels bug,urgent,backend`,
+  todoify update abc123-... --labels bug,urgent,backend
+
+  # Set custom fields (see 'todoify field --help')
+  todoify update abc123-... --field points=5 --field severity=high`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// Parse and validate UUID
 		id, err := uuid.Parse(args[0])
 		if err != nil {
 			logger.Error("failed to parse todo id", "error", err)
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 
 		// Build UpdateTodo struct from provided flags
 		update := buildUpdateFromFlags()
 
+		// Custom field values
+		exprs, err := cmd.Flags().GetStringArray("field")
+		if err != nil {
+			logger.Error("failed to read field flags", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+		update.Fields, err = parseFieldFlags(exprs)
+		if err != nil {
+			logger.Error("invalid field", "error", err)
+			os.Exit(sdk.ExitCode(err))
+		}
+
 		// Check if at least one field is provided
-		if update.Title == nil && update.Description == nil && update.Labels == nil {
-			logger.Error("at least one field must be provided to update (--title, --description, or --labels)")
+		if update.Title == nil && update.Description == nil && update.Labels == nil && update.Fields == nil {
+			logger.Error("at least one field must be provided to update (--title, --description, --labels, or --field)")
 			os.Exit(1)
 		}
 
@@ -58,7 +74,7 @@ Examples:
 			} else {
 				logger.Error("failed to update todo", "error", err)
 			}
-			os.Exit(1)
+			os.Exit(sdk.ExitCode(err))
 		}
 
 		// Log success
@@ -99,9 +115,14 @@ func init() {
 	updateCmd.Flags().StringP("title", "t", "", "New title for the todo")
 	updateCmd.Flags().StringP("description", "d", "", "New description for the todo")
 	updateCmd.Flags().StringSliceP("labels", "l", []string{}, "New labels for the todo (comma-separated)")
+	updateCmd.Flags().StringArray("field", []string{}, "Custom field value as key=value (repeatable)")
 
 	// Bind flags to viper so we can check if they were set
 	viper.BindPFlag("title", updateCmd.Flags().Lookup("title"))
 	viper.BindPFlag("description", updateCmd.Flags().Lookup("description"))
 	viper.BindPFlag("labels", updateCmd.Flags().Lookup("labels"))
+
+	// Shell completion for the todo ID and labels
+	updateCmd.ValidArgsFunction = completeTodoIDs
+	cobra.CheckErr(updateCmd.RegisterFlagCompletionFunc("labels", completeLabels))
 }
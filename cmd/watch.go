@@ -0,0 +1,212 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/MattDevy/es-todoify/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [query]",
+	Short: "Stream todo changes from a running server",
+	Long: `Watch a server started with "todoify serve" and print every change made
+through it as it happens: todos being created, updated, changing status and
+being deleted.
+
+The filter flags and the optional query select which todos to watch, as for
+list; deletions match the todo as it was. --types limits the kinds of change.
+Unlike list, completed and cancelled todos are not hidden.
+
+When the connection drops, or the server ends the stream because this client
+fell behind, watch reconnects after --retry. Changes made while disconnected
+are not replayed.
+
+Examples:
+  # Watch everything on the local server
+  todoify watch
+
+  # Watch blocked bugs on another server
+  todoify watch --server http://todo.internal:8080 --status blocked --labels bug
+
+  # Only status changes, with the query language
+  todoify watch --types status_changed 'label:backend -label:wontfix'
+
+  # One JSON event per line, e.g. for a chat bot
+  todoify watch --json`,
+	// watch only talks to the server, so it skips opening a backend
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initConfig(cmd); err != nil {
+			return err
+		}
+		initLogger()
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		filter, types, err := buildWatchFilter(cmd, args)
+		if err != nil {
+			logger.Error("invalid filter parameters", "error", err)
+			os.Exit(1)
+		}
+
+		c, err := client.New(viper.GetString("server"),
+			client.WithUserAgent("todoify-watch"),
+			client.WithToken(viper.GetString("token")),
+		)
+		if err != nil {
+			logger.Error("invalid server", "error", err)
+			os.Exit(1)
+		}
+
+		retry := viper.GetDuration("retry")
+		asJSON := viper.GetBool("json")
+		for {
+			err := watch(ctx, c, filter, types, asJSON)
+			if ctx.Err() != nil {
+				return
+			}
+			if retry <= 0 || !retryable(err) {
+				logger.Error("watch failed", "error", err)
+				os.Exit(1)
+			}
+
+			logger.Warn("watch interrupted, reconnecting", "error", err, "retry", retry)
+			select {
+			case <-time.After(retry):
+			case <-ctx.Done():
+				return
+			}
+		}
+	},
+}
+
+// watch prints events until the stream ends and returns why it ended.
+func watch(ctx context.Context, c *client.Client, filter client.ListFilter, types []client.EventType, asJSON bool) error {
+	enc := json.NewEncoder(os.Stdout)
+	for e, err := range c.Watch(ctx, filter, types...) {
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+			continue
+		}
+		printEvent(e)
+	}
+	return nil
+}
+
+// retryable reports whether reconnecting may help: the request was fine but
+// the connection or the server went away.
+func retryable(err error) bool {
+	var p *client.Problem
+	if errors.As(err, &p) {
+		return p.Status == http.StatusServiceUnavailable
+	}
+	return true
+}
+
+// printEvent prints an event as a single line.
+func printEvent(e *client.Event) {
+	t := e.Todo
+	var detail string
+	switch e.Type {
+	case client.EventStatusChanged:
+		detail = fmt.Sprintf("%s → %s", e.PreviousStatus, t.Status)
+	default:
+		detail = string(t.Status)
+	}
+	if len(t.Labels) > 0 {
+		detail += " [" + strings.Join(t.Labels, ", ") + "]"
+	}
+
+	fmt.Printf("%s  %-14s  %s  %s  %s\n", e.Time.Local().Format(time.TimeOnly), e.Type, t.ID, t.Title, detail)
+}
+
+// buildWatchFilter builds the client filter and event types from the flags
+// and the query arguments.
+func buildWatchFilter(cmd *cobra.Command, args []string) (client.ListFilter, []client.EventType, error) {
+	var filter client.ListFilter
+
+	statuses, err := parseStatuses(viper.GetStringSlice("status"))
+	if err != nil {
+		return filter, nil, err
+	}
+	for _, s := range statuses {
+		filter.Statuses = append(filter.Statuses, client.Status(s))
+	}
+	statuses, err = parseStatuses(viper.GetStringSlice("not-status"))
+	if err != nil {
+		return filter, nil, err
+	}
+	for _, s := range statuses {
+		filter.ExcludeStatuses = append(filter.ExcludeStatuses, client.Status(s))
+	}
+
+	filter.Labels = viper.GetStringSlice("labels")
+	filter.AnyLabels = viper.GetStringSlice("any-labels")
+	filter.ExcludeLabels = viper.GetStringSlice("not-labels")
+	filter.Search = viper.GetString("search")
+	if filter.Where, err = cmd.Flags().GetStringArray("where"); err != nil {
+		return filter, nil, err
+	}
+
+	// Parse locally so errors point at the offending column
+	if len(args) > 0 {
+		if _, err := parseQueryArgs(args); err != nil {
+			return filter, nil, err
+		}
+		filter.Query = strings.Join(args, " ")
+	}
+
+	var types []client.EventType
+	for _, v := range viper.GetStringSlice("types") {
+		typ := client.EventType(v)
+		switch typ {
+		case client.EventCreated, client.EventUpdated, client.EventStatusChanged, client.EventDeleted:
+			types = append(types, typ)
+		default:
+			return filter, nil, fmt.Errorf("invalid type: %s (valid: created, updated, status_changed, deleted)", v)
+		}
+	}
+
+	return filter, types, nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().String("server", "http://localhost:8080", "URL of the todoify server")
+	watchCmd.Flags().String("token", "", "Bearer token for a server with authentication (or TODOIFY_TOKEN)")
+	watchCmd.Flags().Duration("retry", 2*time.Second, "Delay before reconnecting after the stream ends (0 to exit instead)")
+	watchCmd.Flags().Bool("json", false, "Print each event as a JSON line")
+
+	// Filter flags
+	watchCmd.Flags().StringSliceP("status", "s", []string{}, "Only todos with these statuses (comma-separated)")
+	watchCmd.Flags().StringSlice("not-status", []string{}, "Exclude todos with these statuses (comma-separated)")
+	watchCmd.Flags().StringSliceP("labels", "l", []string{}, "Only todos with all of these labels (comma-separated)")
+	watchCmd.Flags().StringSlice("any-labels", []string{}, "Only todos with at least one of these labels (comma-separated)")
+	watchCmd.Flags().StringSlice("not-labels", []string{}, "Exclude todos with any of these labels (comma-separated)")
+	watchCmd.Flags().StringP("search", "q", "", "Only todos whose title or description contain every word")
+	watchCmd.Flags().StringArray("where", []string{}, "Filter on a custom field as key<op>value, ops: = != > >= < <= (repeatable)")
+	watchCmd.Flags().StringSlice("types", []string{}, "Only these kinds of change (created, updated, status_changed, deleted)")
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/MattDevy/es-todoify/internal/webhook"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// webhookCmd represents the webhook command
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Send todo changes to HTTP endpoints",
+	Long: `Manage webhooks: HTTP endpoints that receive a signed JSON POST whenever a
+todo is created, updated, changes status or is deleted.
+
+Every command that changes todos queues a delivery for each matching webhook in
+the webhook store (--webhooks-path). "todoify serve" delivers them as they are
+queued; "todoify webhook flush" delivers them once, e.g. from cron.
+
+Failed deliveries (no response or a non-2xx status) are retried with
+exponential backoff. After the last attempt they are kept as dead letters,
+which "todoify webhook retry" requeues.
+
+Each request carries these headers:
+  X-Todoify-Event          created, updated, status_changed or deleted
+  X-Todoify-Delivery       the delivery ID, the same for every retry
+  X-Todoify-Signature-256  sha256=<hex HMAC-SHA256 of the body keyed with the secret>
+
+The body is the event with the delivery and webhook IDs:
+  {"id": "...", "webhook": "...", "type": "status_changed", "todo": {...},
+   "previousStatus": "pending", "time": "..."}`,
+	// webhooks are managed in their own store, so no backend is opened
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initConfig(cmd); err != nil {
+			return err
+		}
+		initLogger()
+		initWebhooks()
+		return nil
+	},
+}
+
+// webhookAddCmd represents the webhook add command
+var webhookAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a webhook",
+	Long: `Add a webhook and print its ID and signing secret.
+
+--events limits the kinds of change delivered (all by default). --filter is a
+query language expression, as for list, that the todo must match; deletions
+match the todo as it was. Without --secret a random secret is generated.
+
+Examples:
+  # Everything
+  todoify webhook add --url https://example.com/hooks/todoify
+
+  # New and moved ops todos
+  todoify webhook add --url https://example.com/hook --events created,status_changed --filter 'label:ops'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var events []todo.EventType
+		for _, v := range viper.GetStringSlice("events") {
+			events = append(events, todo.EventType(strings.TrimSpace(v)))
+		}
+
+		w, err := webhook.New(viper.GetString("url"), events, viper.GetString("filter"), viper.GetString("secret"))
+		if err != nil {
+			logger.Error("invalid webhook", "error", err)
+			os.Exit(1)
+		}
+
+		store := webhook.NewStore(viper.GetString("webhooks-path"))
+		if err := store.AddWebhook(cmd.Context(), w); err != nil {
+			logger.Error("failed to add webhook", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("ID:     %s\n", w.ID)
+		fmt.Printf("Secret: %s\n", w.Secret)
+	},
+}
+
+// webhookListCmd represents the webhook list command
+var webhookListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"l"},
+	Short:   "List webhooks",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := webhook.NewStore(viper.GetString("webhooks-path"))
+		hooks, err := store.ListWebhooks(cmd.Context())
+		if err != nil {
+			logger.Error("failed to list webhooks", "error", err)
+			os.Exit(1)
+		}
+
+		if len(hooks) == 0 {
+			fmt.Println("No webhooks.")
+			return
+		}
+
+		for _, w := range hooks {
+			events := "all events"
+			if len(w.Events) > 0 {
+				names := make([]string, len(w.Events))
+				for i, e := range w.Events {
+					names[i] = string(e)
+				}
+				events = strings.Join(names, ",")
+			}
+			line := fmt.Sprintf("%s  %s  %s", w.ID, w.URL, events)
+			if w.Filter != "" {
+				line += fmt.Sprintf("  %q", w.Filter)
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+// webhookRemoveCmd represents the webhook remove command
+var webhookRemoveCmd = &cobra.Command{
+	Use:     "remove [id]",
+	Aliases: []string{"rm"},
+	Short:   "Remove a webhook and its deliveries",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := webhook.NewStore(viper.GetString("webhooks-path"))
+		if err := store.RemoveWebhook(cmd.Context(), args[0]); err != nil {
+			logger.Error("failed to remove webhook", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("successfully removed webhook", "id", args[0])
+	},
+}
+
+// webhookDeliveriesCmd represents the webhook deliveries command
+var webhookDeliveriesCmd = &cobra.Command{
+	Use:   "deliveries [webhook-id]",
+	Short: "Show the deliveries of a webhook and their attempts",
+	Long: `Show the deliveries of a webhook, newest first, with every attempt: its
+time, duration and the response status or error.
+
+Pending deliveries show when they are next attempted; dead deliveries can be
+requeued with "todoify webhook retry". The most recent succeeded deliveries
+are kept, pending and dead ones until the webhook is removed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := webhook.NewStore(viper.GetString("webhooks-path"))
+		deliveries, err := store.ListDeliveries(cmd.Context(), args[0])
+		if err != nil {
+			logger.Error("failed to list deliveries", "error", err)
+			os.Exit(1)
+		}
+
+		if status := viper.GetString("status"); status != "" {
+			var filtered []*webhook.Delivery
+			for _, d := range deliveries {
+				if string(d.Status) == status {
+					filtered = append(filtered, d)
+				}
+			}
+			deliveries = filtered
+		}
+
+		if viper.GetBool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(deliveries); err != nil {
+				logger.Error("failed to encode deliveries", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(deliveries) == 0 {
+			fmt.Println("No deliveries.")
+			return
+		}
+
+		for _, d := range deliveries {
+			printDelivery(d)
+		}
+	},
+}
+
+// printDelivery prints a delivery followed by one line per attempt.
+func printDelivery(d *webhook.Delivery) {
+	line := fmt.Sprintf("%s  %-9s  %-14s  %s", d.ID, d.Status, d.Event.Type, d.Event.Todo.Title)
+	if d.Status == webhook.DeliveryPending {
+		line += "  next " + d.NextAttempt.Local().Format(time.DateTime)
+	}
+	fmt.Println(line)
+
+	for _, a := range d.Attempts {
+		result := "ok"
+		if a.Error != "" {
+			result = a.Error
+		}
+		status := "---"
+		if a.StatusCode != 0 {
+			status = fmt.Sprint(a.StatusCode)
+		}
+		fmt.Printf("    %s  %s  %6s  %s\n", a.Time.Local().Format(time.DateTime), status, a.Duration.Round(time.Millisecond), result)
+	}
+}
+
+// webhookRetryCmd represents the webhook retry command
+var webhookRetryCmd = &cobra.Command{
+	Use:   "retry [delivery-id]",
+	Short: "Requeue a dead delivery",
+	Long: `Requeue a dead-lettered delivery for an immediate attempt, with a fresh set
+of retries. It is sent by a running "todoify serve" or the next flush.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := webhooks.Retry(cmd.Context(), args[0]); err != nil {
+			logger.Error("failed to retry delivery", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("successfully requeued delivery", "id", args[0])
+	},
+}
+
+// webhookFlushCmd represents the webhook flush command
+var webhookFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send the deliveries that are due",
+	Long: `Attempt every queued delivery that is due once, without waiting for retries.
+Use it to deliver webhooks when no "todoify serve" is running.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		n, err := webhooks.DeliverDue(cmd.Context())
+		if err != nil {
+			logger.Error("failed to deliver webhooks", "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("attempted deliveries", "count", n)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(webhookAddCmd, webhookListCmd, webhookRemoveCmd, webhookDeliveriesCmd, webhookRetryCmd, webhookFlushCmd)
+
+	webhookAddCmd.Flags().String("url", "", "Endpoint to POST events to")
+	cobra.CheckErr(webhookAddCmd.MarkFlagRequired("url"))
+	webhookAddCmd.Flags().StringSlice("events", []string{}, "Only these kinds of change (created, updated, status_changed, deleted)")
+	webhookAddCmd.Flags().String("filter", "", "Only todos matching this query, e.g. 'label:ops'")
+	webhookAddCmd.Flags().String("secret", "", "Signing secret (default is a random secret)")
+
+	webhookDeliveriesCmd.Flags().String("status", "", "Only deliveries with this status (pending, succeeded, dead)")
+	webhookDeliveriesCmd.Flags().Bool("json", false, "Print the deliveries as JSON")
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/coder/websocket"
+)
+
+// keepAliveInterval is how often idle event streams send a heartbeat so
+// proxies do not close them.
+const keepAliveInterval = 15 * time.Second
+
+// WithEvents serves the live change feed of the broker, which should be the
+// publisher of the server's service.
+func WithEvents(broker *events.Broker) Option {
+	return func(s *Server) {
+		s.events = broker
+	}
+}
+
+// subscribe starts a subscription to the events matching the request's
+// filter parameters (as for listing) and its types parameter.
+func (s *Server) subscribe(r *http.Request) (*events.Subscription, error) {
+	if s.events == nil {
+		return nil, fmt.Errorf("%w: live events are not enabled", todo.ErrUnsupported)
+	}
+
+	query := r.URL.Query()
+	filter, err := filterFromQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if filter, err = s.service.ResolveFilter(r.Context(), filter); err != nil {
+		return nil, err
+	}
+
+	var types []todo.EventType
+	for _, v := range listParam(query, "types") {
+		typ := todo.EventType(v)
+		if !typ.IsValid() {
+			return nil, fmt.Errorf("%w: types: invalid event type %q (valid: created, updated, status_changed, deleted)", todo.ErrInvalidInput, v)
+		}
+		types = append(types, typ)
+	}
+
+	return s.events.Subscribe(r.Context(), func(e todo.Event) bool {
+		if len(types) > 0 && !slices.Contains(types, e.Type) {
+			return false
+		}
+		return filter.Matches(e.Todo)
+	}), nil
+}
+
+// endProblem describes why a subscription ended, or returns nil when the
+// client went away.
+func endProblem(err error) *Problem {
+	switch {
+	case errors.Is(err, events.ErrLagged):
+		return httpProblem(http.StatusServiceUnavailable, "the client fell behind the event stream; reconnect and reload")
+	case errors.Is(err, events.ErrClosed):
+		return httpProblem(http.StatusServiceUnavailable, "the server is shutting down")
+	default:
+		return nil
+	}
+}
+
+// handleEvents streams the change feed as server-sent events. Each event is
+// named after its type and carries the todo.Event as JSON. A stream that
+// ends on the server side sends a final "error" event with a problem.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	sub, err := s.subscribe(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("streaming is not supported", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				if p := endProblem(sub.Err()); p != nil {
+					p.Instance = r.URL.Path
+					writeEvent(w, "error", p)
+					_ = rc.Flush()
+				}
+				return
+			}
+			if err := writeEvent(w, string(e.Type), e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// handleEventsWebSocket streams the change feed over a WebSocket as one JSON
+// todo.Event per text message. Messages from the client are ignored. A
+// stream that ends on the server side is closed with status 1013 (try again
+// later) and the reason.
+func (s *Server) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub, err := s.subscribe(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		// Accept has written the error response
+		return
+	}
+	defer conn.CloseNow()
+
+	// Reading handles pings and the client's close; the context ends with the connection
+	ctx := conn.CloseRead(r.Context())
+
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				if p := endProblem(sub.Err()); p != nil {
+					conn.Close(websocket.StatusTryAgainLater, p.Detail)
+				}
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				s.logger.Error("failed to encode event", "error", err)
+				return
+			}
+			writeCtx, cancel := context.WithTimeout(ctx, keepAliveInterval)
+			err = conn.Write(writeCtx, websocket.MessageText, data)
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// serveEvents starts an API server whose service publishes to a broker.
+func serveEvents(t *testing.T) (*httptest.Server, *events.Broker) {
+	t.Helper()
+	broker := events.NewBroker()
+	service := testutil.NewFileService(t, todo.WithPublisher(broker))
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithEvents(broker)).Handler())
+	t.Cleanup(broker.Close)
+	return srv, broker
+}
+
+// waitForSubscribers waits until the broker has n subscribers.
+func waitForSubscribers(t *testing.T, broker *events.Broker, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return broker.Subscribers() == n }, 5*time.Second, 5*time.Millisecond)
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvents reads n server-sent events, skipping comments.
+func readEvents(t *testing.T, body io.Reader, n int) []sseEvent {
+	t.Helper()
+	var got []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(body)
+	for len(got) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name != "" {
+				got = append(got, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, n)
+	return got
+}
+
+func TestServer_EventStream(t *testing.T) {
+	srv, broker := serveEvents(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+BasePath+"/events?labels=watched&types=created,status_changed,deleted", nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	waitForSubscribers(t, broker, 1)
+
+	createTodo(t, srv, CreateTodoRequest{Title: "ignored"})
+	watched := createTodo(t, srv, CreateTodoRequest{Title: "watched", Labels: []string{"watched"}})
+	title := "renamed"
+	do(t, srv, http.MethodPatch, BasePath+"/todos/"+watched.ID.String(), todo.UpdateTodo{Title: &title}, nil)
+	do(t, srv, http.MethodPut, BasePath+"/todos/"+watched.ID.String()+"/status", ChangeStatusRequest{Status: todo.StatusInProgress}, nil)
+	do(t, srv, http.MethodDelete, BasePath+"/todos/"+watched.ID.String(), nil, nil)
+
+	got := readEvents(t, resp.Body, 3)
+	var names []string
+	var decoded []todo.Event
+	for _, e := range got {
+		names = append(names, e.name)
+		var ev todo.Event
+		require.NoError(t, json.Unmarshal([]byte(e.data), &ev))
+		require.Equal(t, watched.ID, ev.Todo.ID)
+		decoded = append(decoded, ev)
+	}
+	require.Equal(t, []string{"created", "status_changed", "deleted"}, names)
+	require.Equal(t, todo.StatusPending, decoded[1].PreviousStatus)
+	require.Equal(t, "renamed", decoded[2].Todo.Title)
+}
+
+func TestServer_EventStreamEnds(t *testing.T) {
+	srv, broker := serveEvents(t)
+
+	resp, err := srv.Client().Get(srv.URL + BasePath + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	waitForSubscribers(t, broker, 1)
+
+	// Buffered events are delivered before the stream ends
+	broker.Publish(context.Background(), todo.Event{Type: todo.EventCreated, Todo: &todo.Todo{Title: "a"}})
+	broker.Close()
+
+	got := readEvents(t, resp.Body, 2)
+	require.Equal(t, "created", got[0].name)
+	require.Equal(t, "error", got[1].name)
+	var p Problem
+	require.NoError(t, json.Unmarshal([]byte(got[1].data), &p))
+	require.Equal(t, http.StatusServiceUnavailable, p.Status)
+	require.Contains(t, p.Detail, "shutting down")
+}
+
+func TestServer_EventWebSocket(t *testing.T) {
+	srv, broker := serveEvents(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+BasePath+"/events/ws?status=blocked", nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+	waitForSubscribers(t, broker, 1)
+
+	created := createTodo(t, srv, CreateTodoRequest{Title: "stuck"})
+	do(t, srv, http.MethodPut, BasePath+"/todos/"+created.ID.String()+"/status", ChangeStatusRequest{Status: todo.StatusBlocked}, nil)
+
+	typ, data, err := conn.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, websocket.MessageText, typ)
+	var ev todo.Event
+	require.NoError(t, json.Unmarshal(data, &ev))
+	require.Equal(t, todo.EventStatusChanged, ev.Type)
+	require.Equal(t, todo.StatusBlocked, ev.Todo.Status)
+
+	broker.Close()
+	_, _, err = conn.Read(ctx)
+	require.Equal(t, websocket.StatusTryAgainLater, websocket.CloseStatus(err))
+}
+
+func TestServer_EventsInvalid(t *testing.T) {
+	doc := loadSpec(t)
+	srv, _ := serveEvents(t)
+
+	for _, path := range []string{"/events", "/events/ws"} {
+		for _, query := range []string{"types=nope", "status=nope", "where=unknown=1"} {
+			resp, err := srv.Client().Get(srv.URL + BasePath + path + "?" + query)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%s?%s", path, query)
+			doc.checkResponse(t, http.MethodGet, BasePath+path, resp)
+			resp.Body.Close()
+		}
+	}
+}
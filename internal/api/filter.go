@@ -0,0 +1,133 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// filterFromQuery builds a ListFilter from URL query parameters. List
+// parameters accept comma-separated values and may be repeated:
+//
+//	status, notStatus, labels, anyLabels, notLabels  lists
+//	search                                           full-text search
+//	from, to                                         creation date range (YYYY-MM-DD or RFC3339)
+//	where                                            custom field condition key<op>value, repeatable
+//	q                                                query language expression
+//	limit, offset, cursor                            pagination
+//	sortBy, sortOrder                                sorting
+//
+// Unlike the CLI, terminal statuses are not hidden by default.
+func filterFromQuery(query url.Values) (todo.ListFilter, error) {
+	var filter todo.ListFilter
+	var err error
+
+	if filter.Statuses, err = statusesParam(query, "status"); err != nil {
+		return filter, err
+	}
+	if filter.ExcludeStatuses, err = statusesParam(query, "notStatus"); err != nil {
+		return filter, err
+	}
+
+	filter.Labels = listParam(query, "labels")
+	filter.AnyLabels = listParam(query, "anyLabels")
+	filter.ExcludeLabels = listParam(query, "notLabels")
+	filter.SearchQuery = query.Get("search")
+
+	if filter.FromDate, err = dateParam(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.ToDate, err = dateParam(query, "to"); err != nil {
+		return filter, err
+	}
+
+	for _, expr := range query["where"] {
+		cond, err := todo.ParseFieldCondition(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = append(filter.Where, cond)
+	}
+
+	if q := query.Get("q"); q != "" {
+		if filter.Query, err = todo.ParseQuery(q); err != nil {
+			return filter, err
+		}
+	}
+
+	if filter.Limit, err = intParam(query, "limit"); err != nil {
+		return filter, err
+	}
+	if filter.Offset, err = intParam(query, "offset"); err != nil {
+		return filter, err
+	}
+	filter.Cursor = query.Get("cursor")
+
+	if v := query.Get("sortBy"); v != "" {
+		filter.SortBy = todo.SortField(v)
+		if !filter.SortBy.IsValid() {
+			return filter, fmt.Errorf("%w: sortBy must be one of createTime, updateTime, title, status, _score", todo.ErrInvalidInput)
+		}
+	}
+	if v := query.Get("sortOrder"); v != "" {
+		filter.SortOrder = todo.SortOrder(v)
+		if !filter.SortOrder.IsValid() {
+			return filter, fmt.Errorf("%w: sortOrder must be asc or desc", todo.ErrInvalidInput)
+		}
+	}
+
+	return filter, nil
+}
+
+// listParam returns the comma-separated values of a repeatable parameter.
+func listParam(query url.Values, name string) []string {
+	var values []string
+	for _, v := range query[name] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+func statusesParam(query url.Values, name string) ([]todo.Status, error) {
+	var statuses []todo.Status
+	for _, v := range listParam(query, name) {
+		status := todo.Status(v)
+		if !status.IsValid() {
+			return nil, fmt.Errorf("%w: %s: invalid status %q (valid: pending, in_progress, completed, cancelled, blocked)", todo.ErrInvalidInput, name, v)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func dateParam(query url.Values, name string) (*time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := todo.ParseDate(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a date (YYYY-MM-DD or RFC3339)", todo.ErrInvalidInput, name)
+	}
+	return &t, nil
+}
+
+func intParam(query url.Values, name string) (int, error) {
+	v := query.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", todo.ErrInvalidInput, name)
+	}
+	return n, nil
+}
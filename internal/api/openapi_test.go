@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/repository"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the parsed OpenAPI document.
+type spec map[string]any
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(OpenAPI, &doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+	return spec(doc)
+}
+
+// resolve follows a local JSON pointer such as #/components/schemas/Todo.
+func (s spec) resolve(ref string) (map[string]any, error) {
+	var node any = map[string]any(s)
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$ref %s is not an object", ref)
+	}
+	return m, nil
+}
+
+// deref returns the node itself or the node its $ref points to.
+func (s spec) deref(node map[string]any) (map[string]any, error) {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node, nil
+		}
+		var err error
+		if node, err = s.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// validate checks a decoded JSON value against the subset of JSON Schema used
+// by the document: type, enum, required, properties, additionalProperties and items.
+func (s spec) validate(schema map[string]any, value any, path string) error {
+	schema, err := s.deref(schema)
+	if err != nil {
+		return err
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, value)
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, v := range obj {
+			if prop, ok := props[name].(map[string]any); ok {
+				if err := s.validate(prop, v, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case map[string]any:
+				if err := s.validate(extra, v, path+"."+name); err != nil {
+					return err
+				}
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %s", path, name)
+				}
+			default:
+				if props != nil {
+					return fmt.Errorf("%s: undocumented property %s", path, name)
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, value)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, v := range arr {
+			if err := s.validate(items, v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, value)
+		}
+	}
+	return nil
+}
+
+// operation returns the operation for a method and path template.
+func (s spec) operation(t *testing.T, method, path string) map[string]any {
+	t.Helper()
+	paths := s["paths"].(map[string]any)
+	item, ok := paths[path].(map[string]any)
+	require.True(t, ok, "path %s is not documented", path)
+	op, ok := item[strings.ToLower(method)].(map[string]any)
+	require.True(t, ok, "%s %s is not documented", method, path)
+	return op
+}
+
+// checkResponse validates a response against the documented responses of an operation.
+func (s spec) checkResponse(t *testing.T, method, path string, resp *http.Response) {
+	t.Helper()
+	op := s.operation(t, method, path)
+	responses := op["responses"].(map[string]any)
+	documented, ok := responses[fmt.Sprint(resp.StatusCode)].(map[string]any)
+	require.True(t, ok, "%s %s: status %d is not documented", method, path, resp.StatusCode)
+	documented, err := s.deref(documented)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	content, ok := documented["content"].(map[string]any)
+	if !ok {
+		require.Empty(t, body, "%s %s: status %d has an undocumented body", method, path, resp.StatusCode)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	media, ok := content[contentType].(map[string]any)
+	require.True(t, ok, "%s %s: content type %s is not documented for %d", method, path, contentType, resp.StatusCode)
+	if !strings.HasSuffix(contentType, "json") {
+		return
+	}
+
+	var value any
+	require.NoError(t, json.Unmarshal(body, &value))
+	require.NoError(t, s.validate(media["schema"].(map[string]any), value, "body"))
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	doc := loadSpec(t)
+
+	var documented []string
+	for path, item := range doc["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	var served []string
+	for _, rt := range routes {
+		served = append(served, rt.method+" "+rt.path)
+	}
+
+	sort.Strings(documented)
+	sort.Strings(served)
+	require.Equal(t, served, documented)
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	doc := loadSpec(t)
+
+	tests := []struct {
+		schema string
+		typ    any
+	}{
+		{"Todo", todo.Todo{}},
+		{"UpdateTodo", todo.UpdateTodo{}},
+		{"HealthInfo", repository.HealthInfo{}},
+		{"Facets", todo.Facets{}},
+		{"FacetBucket", todo.FacetBucket{}},
+		{"CreateTodoRequest", CreateTodoRequest{}},
+		{"ChangeStatusRequest", ChangeStatusRequest{}},
+		{"ListResponse", ListResponse{}},
+		{"CountResponse", CountResponse{}},
+		{"Event", todo.Event{}},
+		{"Problem", Problem{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, err := doc.resolve("#/components/schemas/" + tt.schema)
+			require.NoError(t, err)
+
+			var documented []string
+			for name := range schema["properties"].(map[string]any) {
+				documented = append(documented, name)
+			}
+
+			var fields []string
+			typ := reflect.TypeOf(tt.typ)
+			for i := range typ.NumField() {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				if name != "" && name != "-" {
+					fields = append(fields, name)
+				}
+			}
+
+			sort.Strings(documented)
+			sort.Strings(fields)
+			require.Equal(t, fields, documented)
+		})
+	}
+}
+
+func TestOpenAPI_ListFilter(t *testing.T) {
+	doc := loadSpec(t)
+	service := newTestService(t)
+	_, err := service.DefineField(context.Background(), todo.FieldDefinition{Name: "priority", Type: todo.FieldTypeNumber})
+	require.NoError(t, err)
+	srv := serve(t, service)
+
+	filter, err := doc.resolve("#/components/schemas/ListFilter")
+	require.NoError(t, err)
+	props := filter["properties"].(map[string]any)
+
+	// Every list parameter except facets is a filter property
+	for _, p := range doc.operation(t, http.MethodGet, BasePath+"/todos")["parameters"].([]any) {
+		param, err := doc.deref(p.(map[string]any))
+		require.NoError(t, err)
+		if name := param["name"].(string); name != "facets" {
+			require.Contains(t, props, name)
+		}
+	}
+
+	// Sample values for properties without documented examples
+	valid := map[string]string{
+		"status":    "pending,completed",
+		"notStatus": "blocked",
+		"labels":    "work",
+		"anyLabels": "work,home",
+		"notLabels": "home",
+		"search":    "docs",
+		"limit":     "10",
+		"offset":    "0",
+		"cursor":    "",
+		"sortBy":    "title",
+		"sortOrder": "asc",
+	}
+	// Values the handler must reject, proving it reads the parameter
+	invalid := map[string]string{
+		"status":    "archived",
+		"notStatus": "archived",
+		"from":      "yesterday",
+		"to":        "tomorrow",
+		"where":     "priority",
+		"q":         "status:(",
+		"limit":     "ten",
+		"offset":    "-",
+		"sortBy":    "priority",
+		"sortOrder": "up",
+	}
+
+	for name, p := range props {
+		t.Run(name, func(t *testing.T) {
+			prop := p.(map[string]any)
+			value, ok := valid[name]
+			if examples, hasExamples := prop["examples"].([]any); hasExamples {
+				value, ok = fmt.Sprint(examples[0]), true
+				if arr, isArr := examples[0].([]any); isArr {
+					value = fmt.Sprint(arr[0])
+				}
+			}
+			require.True(t, ok, "no sample value for %s", name)
+
+			resp, err := srv.Client().Get(srv.URL + BasePath + "/todos?" + url.Values{name: {value}}.Encode())
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			doc.checkResponse(t, http.MethodGet, BasePath+"/todos", resp)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			if bad, ok := invalid[name]; ok {
+				resp, err := srv.Client().Get(srv.URL + BasePath + "/todos?" + url.Values{name: {bad}}.Encode())
+				require.NoError(t, err)
+				defer resp.Body.Close()
+				require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_Responses(t *testing.T) {
+	doc := loadSpec(t)
+	srv := newTestServer(t)
+
+	created := createTodo(t, srv, CreateTodoRequest{Title: "Write docs", Labels: []string{"work"}})
+	id := created.ID.String()
+	missing := "00000000-0000-0000-0000-000000000000"
+	title := "Write better docs"
+	empty := ""
+
+	tests := []struct {
+		name     string
+		method   string
+		template string
+		path     string
+		body     any
+	}{
+		{"create", http.MethodPost, "/todos", "/todos", CreateTodoRequest{Title: "a"}},
+		{"create invalid", http.MethodPost, "/todos", "/todos", CreateTodoRequest{}},
+		{"list", http.MethodGet, "/todos", "/todos", nil},
+		{"list facets", http.MethodGet, "/todos", "/todos?facets=true", nil},
+		{"list cursor", http.MethodGet, "/todos", "/todos?cursor=&limit=1", nil},
+		{"list invalid", http.MethodGet, "/todos", "/todos?limit=x", nil},
+		{"count", http.MethodGet, "/todos/count", "/todos/count?labels=work", nil},
+		{"count invalid", http.MethodGet, "/todos/count", "/todos/count?status=x", nil},
+		{"get", http.MethodGet, "/todos/{id}", "/todos/" + id, nil},
+		{"get missing", http.MethodGet, "/todos/{id}", "/todos/" + missing, nil},
+		{"get malformed", http.MethodGet, "/todos/{id}", "/todos/x", nil},
+		{"update", http.MethodPatch, "/todos/{id}", "/todos/" + id, todo.UpdateTodo{Title: &title}},
+		{"update invalid", http.MethodPatch, "/todos/{id}", "/todos/" + id, todo.UpdateTodo{Title: &empty}},
+		{"update missing", http.MethodPatch, "/todos/{id}", "/todos/" + missing, todo.UpdateTodo{Title: &title}},
+		{"complete", http.MethodPut, "/todos/{id}/status", "/todos/" + id + "/status", ChangeStatusRequest{Status: todo.StatusCompleted}},
+		{"block completed", http.MethodPut, "/todos/{id}/status", "/todos/" + id + "/status", ChangeStatusRequest{Status: todo.StatusBlocked}},
+		{"unknown status", http.MethodPut, "/todos/{id}/status", "/todos/" + id + "/status", ChangeStatusRequest{Status: "x"}},
+		{"status missing", http.MethodPut, "/todos/{id}/status", "/todos/" + missing + "/status", ChangeStatusRequest{Status: todo.StatusCompleted}},
+		{"openapi", http.MethodGet, "/openapi.yaml", "/openapi.yaml", nil},
+		{"events disabled", http.MethodGet, "/events", "/events", nil},
+		{"websocket disabled", http.MethodGet, "/events/ws", "/events/ws", nil},
+		{"delete", http.MethodDelete, "/todos/{id}", "/todos/" + id, nil},
+		{"delete missing", http.MethodDelete, "/todos/{id}", "/todos/" + id, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != nil {
+				data, err := json.Marshal(tt.body)
+				require.NoError(t, err)
+				body = strings.NewReader(string(data))
+			}
+			req, err := http.NewRequest(tt.method, srv.URL+BasePath+tt.path, body)
+			require.NoError(t, err)
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			doc.checkResponse(t, tt.method, BasePath+tt.template, resp)
+		})
+	}
+
+	t.Run("health", func(t *testing.T) {
+		resp, err := srv.Client().Get(srv.URL + "/healthz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		doc.checkResponse(t, http.MethodGet, "/healthz", resp)
+	})
+
+	t.Run("metrics disabled", func(t *testing.T) {
+		resp, err := srv.Client().Get(srv.URL + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+		doc.checkResponse(t, http.MethodGet, "/metrics", resp)
+	})
+}
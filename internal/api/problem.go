@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// problemContentType is the media type of error responses (RFC 9457).
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details error response.
+type Problem struct {
+	// Type identifies the kind of problem. It is about:blank for plain HTTP errors.
+	Type string `json:"type"`
+
+	// Title is a short summary of the kind of problem.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the request path that caused the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Errors maps field names to validation messages, as returned by todo.TranslateError.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// problemTypeBase prefixes the type URIs of domain problems.
+const problemTypeBase = "https://github.com/MattDevy/es-todoify/problems/"
+
+// problemFor maps an error from the todo service to a problem. Unknown errors
+// are internal server errors whose details are not exposed.
+func problemFor(err error) *Problem {
+	p := &Problem{Type: "about:blank", Detail: err.Error()}
+
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		p.Status = http.StatusNotFound
+		p.Type = problemTypeBase + "not-found"
+		p.Title = "Todo not found"
+	case errors.Is(err, todo.ErrInvalidInput):
+		p.Status = http.StatusBadRequest
+		p.Type = problemTypeBase + "invalid-input"
+		p.Title = "Invalid input"
+		p.Errors = todo.FieldMessages(err)
+	case errors.Is(err, todo.ErrConflict):
+		p.Status = http.StatusConflict
+		p.Type = problemTypeBase + "conflict"
+		p.Title = "Conflict"
+	case errors.Is(err, todo.ErrInvalidStatus):
+		p.Status = http.StatusUnprocessableEntity
+		p.Type = problemTypeBase + "invalid-status"
+		p.Title = "Invalid status transition"
+	case errors.Is(err, todo.ErrUnsupported):
+		p.Status = http.StatusNotImplemented
+		p.Type = problemTypeBase + "unsupported"
+		p.Title = "Not supported by the backend"
+	case errors.Is(err, todo.ErrUnauthenticated):
+		p.Status = http.StatusUnauthorized
+		p.Type = problemTypeBase + "unauthenticated"
+		p.Title = "Authentication required"
+	case errors.Is(err, todo.ErrForbidden):
+		p.Status = http.StatusForbidden
+		p.Type = problemTypeBase + "forbidden"
+		p.Title = "Forbidden"
+	default:
+		p.Status = http.StatusInternalServerError
+		p.Title = http.StatusText(http.StatusInternalServerError)
+		p.Detail = ""
+	}
+
+	return p
+}
+
+// writeProblem writes a problem response for the request.
+func writeProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Instance = r.URL.Path
+	if p.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="todoify"`)
+	}
+	writeJSONType(w, p.Status, problemContentType, p)
+}
+
+// httpProblem returns a plain HTTP problem with the standard status text as title.
+func httpProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
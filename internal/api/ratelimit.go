@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// RateLimiter decides whether a client may make a request, as
+// ratelimit.Limiter does.
+type RateLimiter interface {
+	Allow(client string, class ratelimit.Class) (bool, time.Duration)
+}
+
+// WithRateLimiter throttles every endpoint except the health check and the
+// metrics. Clients are authenticated principals, or IP addresses when
+// authentication is disabled. Searches, facets and counts are
+// ratelimit.Expensive; everything else is ratelimit.Standard. With
+// authentication, every request is also counted per IP address as
+// ratelimit.Address before its credential is checked. Mounted handlers such
+// as GraphQL take further tokens with ratelimit.Take. Throttled requests get
+// a 429 problem with a Retry-After header. GET /metrics reports the limiter's
+// counters if it has a WriteMetrics method.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(s *Server) {
+		s.limiter = l
+	}
+}
+
+// WithForwardedFor identifies unauthenticated clients by the last address in
+// the X-Forwarded-For header instead of the connection's remote address. Use
+// it only behind a reverse proxy that appends to the header.
+func WithForwardedFor() Option {
+	return func(s *Server) {
+		s.forwardedFor = true
+	}
+}
+
+// unthrottled are the paths of endpoints that are never rate limited, so
+// load balancers and Prometheus can always reach them.
+var unthrottled = []string{"/healthz", "/metrics"}
+
+// metricsWriter is implemented by rate limiters that report metrics, such as
+// ratelimit.Limiter.
+type metricsWriter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// handleMetrics reports the rate limiter's request counters in the
+// Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.limiter.(metricsWriter)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: metrics need a rate limiter", todo.ErrUnsupported))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := m.WriteMetrics(w); err != nil {
+		s.logger.Warn("writing metrics failed", "error", err)
+	}
+}
+
+// limit refuses requests of clients over their rate limit, and adds the
+// client's ratelimit.Quota to the context of the others so handlers can
+// charge expensive work they find while serving, such as GraphQL counts. It
+// does nothing without a RateLimiter.
+func (s *Server) limit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, class := s.client(r), requestClass(r)
+		if ok, retry := s.limiter.Allow(client, class); !ok {
+			writeThrottled(w, r, &ratelimit.ExceededError{Class: class, RetryAfter: retry})
+			return
+		}
+		ctx := ratelimit.WithQuota(r.Context(), func(class ratelimit.Class) (bool, time.Duration) {
+			return s.limiter.Allow(client, class)
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// limitAddress refuses requests from IP addresses over the
+// ratelimit.Address limit before their credential is checked, so guessing
+// credentials is throttled too. It does nothing without a RateLimiter or an
+// Authenticator, since every client is then an address that limit throttles.
+func (s *Server) limitAddress(next http.Handler) http.Handler {
+	if s.limiter == nil || s.auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retry := s.limiter.Allow(s.address(r), ratelimit.Address); !ok {
+			writeThrottled(w, r, &ratelimit.ExceededError{Class: ratelimit.Address, RetryAfter: retry})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeThrottled writes the 429 problem of a refused request.
+func writeThrottled(w http.ResponseWriter, r *http.Request, err *ratelimit.ExceededError) {
+	w.Header().Set("Retry-After", ratelimit.RetryAfter(err.RetryAfter))
+	writeProblem(w, r, &Problem{
+		Type:   problemTypeBase + "rate-limited",
+		Title:  "Too many requests",
+		Status: http.StatusTooManyRequests,
+		Detail: err.Error(),
+	})
+}
+
+// client identifies the caller of a request for rate limiting.
+func (s *Server) client(r *http.Request) string {
+	if p, ok := todo.PrincipalFromContext(r.Context()); ok {
+		if p.TokenID != "" {
+			return "token:" + p.TokenID
+		}
+		return p.Method + ":" + p.Tenant + "/" + p.Subject
+	}
+	return s.address(r)
+}
+
+// address identifies the IP address a request comes from.
+func (s *Server) address(r *http.Request) string {
+	if s.forwardedFor {
+		if hops := r.Header.Values("X-Forwarded-For"); len(hops) > 0 {
+			last := hops[len(hops)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// requestClass returns the rate limit class of a request: counts, and lists
+// that search or compute facets, are expensive.
+func requestClass(r *http.Request) ratelimit.Class {
+	switch r.Pattern {
+	case http.MethodGet + " " + BasePath + "/todos/count":
+		return ratelimit.Expensive
+	case http.MethodGet + " " + BasePath + "/todos":
+		q := r.URL.Query()
+		if q.Get("search") != "" || q.Get("q") != "" || q.Get("facets") == "true" {
+			return ratelimit.Expensive
+		}
+	}
+	return ratelimit.Standard
+}
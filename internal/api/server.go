@@ -0,0 +1,257 @@
+// Package api serves todo.Service over HTTP as a JSON REST API. It only
+// depends on the service, so it works with any repository backend.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/auth"
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// BasePath prefixes every todo endpoint.
+const BasePath = "/api/v1"
+
+// maxBodyBytes limits the size of request bodies.
+const maxBodyBytes = 1 << 20
+
+// Server is the HTTP API for a todo service.
+type Server struct {
+	service *todo.Service
+	logger  *slog.Logger
+	mux     *http.ServeMux
+	mounts  map[string]http.Handler
+	events  *events.Broker
+	auth    Authenticator
+
+	limiter      RateLimiter
+	forwardedFor bool
+}
+
+// Authenticator verifies the bearer credential of a request, as
+// auth.Authenticator does.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*todo.Principal, error)
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger used for request logs and internal errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithHandler mounts another handler, such as the GraphQL endpoint, at a
+// ServeMux pattern. It is served with the same logging and panic recovery
+// as the REST endpoints.
+func WithHandler(pattern string, h http.Handler) Option {
+	return func(s *Server) {
+		if s.mounts == nil {
+			s.mounts = make(map[string]http.Handler)
+		}
+		s.mounts[pattern] = h
+	}
+}
+
+// WithAuthenticator requires every endpoint except the health check and the
+// OpenAPI document to be called with an "Authorization: Bearer" credential
+// accepted by a. The principal is added to the request context, and
+// endpoints that change todos require the write scope. Mounted handlers
+// require the read scope and check any others themselves.
+func WithAuthenticator(a Authenticator) Option {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// NewServer creates a Server for the service.
+func NewServer(service *todo.Service, opts ...Option) *Server {
+	s := &Server{
+		service: service,
+		logger:  slog.Default(),
+		mux:     http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.register()
+	return s
+}
+
+// route is an endpoint served by the API.
+type route struct {
+	method  string
+	path    string
+	handler func(*Server, http.ResponseWriter, *http.Request)
+
+	// scope is required when authentication is enabled; "" is public.
+	scope todo.Scope
+}
+
+// routes lists every endpoint. Each one is documented in openapi.yaml.
+var routes = []route{
+	{http.MethodPost, BasePath + "/todos", (*Server).handleCreate, todo.ScopeWrite},
+	{http.MethodGet, BasePath + "/todos", (*Server).handleList, todo.ScopeRead},
+	{http.MethodGet, BasePath + "/todos/count", (*Server).handleCount, todo.ScopeRead},
+	{http.MethodGet, BasePath + "/todos/{id}", (*Server).handleGet, todo.ScopeRead},
+	{http.MethodPatch, BasePath + "/todos/{id}", (*Server).handleUpdate, todo.ScopeWrite},
+	{http.MethodPut, BasePath + "/todos/{id}/status", (*Server).handleChangeStatus, todo.ScopeWrite},
+	{http.MethodDelete, BasePath + "/todos/{id}", (*Server).handleDelete, todo.ScopeWrite},
+	{http.MethodGet, BasePath + "/events", (*Server).handleEvents, todo.ScopeRead},
+	{http.MethodGet, BasePath + "/events/ws", (*Server).handleEventsWebSocket, todo.ScopeRead},
+	{http.MethodGet, BasePath + "/openapi.yaml", (*Server).handleOpenAPI, ""},
+	{http.MethodGet, "/healthz", (*Server).handleHealth, ""},
+	{http.MethodGet, "/metrics", (*Server).handleMetrics, todo.ScopeRead},
+}
+
+// register adds every route to the mux.
+func (s *Server) register() {
+	for _, rt := range routes {
+		var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt.handler(s, w, r)
+		})
+		throttled := !slices.Contains(unthrottled, rt.path)
+		if throttled {
+			h = s.limit(h)
+		}
+		if rt.scope != "" {
+			h = s.authenticate(rt.scope, h)
+		}
+		// Addresses are limited before authentication so that bad
+		// credentials are throttled too
+		if throttled {
+			h = s.limitAddress(h)
+		}
+		s.mux.Handle(rt.method+" "+rt.path, h)
+	}
+	for pattern, h := range s.mounts {
+		s.mux.Handle(pattern, s.limitAddress(s.authenticate(todo.ScopeRead, s.limit(h))))
+	}
+
+	// Anything else is a problem+json 404 rather than the mux's plain text
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, httpProblem(http.StatusNotFound, "no such endpoint"))
+	})
+}
+
+// Handler returns the HTTP handler with logging, panic recovery and
+// cross-origin protection.
+func (s *Server) Handler() http.Handler {
+	return s.logRequests(s.recoverPanics(crossOrigin(s.mux)))
+}
+
+// crossOrigin refuses requests that change state when a browser sends them
+// from another site. Without --auth nothing else stops a page from posting
+// a "simple" text/plain body to the API, while clients that are not
+// browsers send no Sec-Fetch-Site or Origin header and are unaffected.
+func crossOrigin(next http.Handler) http.Handler {
+	protection := http.NewCrossOriginProtection()
+	protection.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, httpProblem(http.StatusForbidden, "cross-origin request refused"))
+	}))
+	return protection.Handler(next)
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. to flush).
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.logger.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+	})
+}
+
+func (s *Server) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				s.logger.Error("panic serving request", "method", r.Method, "path", r.URL.Path, "panic", v)
+				writeProblem(w, r, httpProblem(http.StatusInternalServerError, ""))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate requires a bearer credential with scope and adds its
+// principal to the request context. It does nothing without an Authenticator.
+func (s *Server) authenticate(scope todo.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := s.auth.Authenticate(r.Context(), auth.BearerToken(r.Header.Get("Authorization")))
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		ctx := todo.WithPrincipal(r.Context(), p)
+		if err := auth.Require(ctx, scope); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeError writes the problem for a service error, logging unexpected ones.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := problemFor(err)
+	if p.Status == http.StatusInternalServerError {
+		s.logger.Error("request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	writeProblem(w, r, p)
+}
+
+// decodeJSON decodes a request body, rejecting unknown fields and trailing data.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) *Problem {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return httpProblem(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+	}
+	if dec.More() {
+		return httpProblem(http.StatusBadRequest, "invalid JSON body: unexpected data after the object")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	writeJSONType(w, status, "application/json", v)
+}
+
+func writeJSONType(w http.ResponseWriter, status int, contentType string, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
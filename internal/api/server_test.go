@@ -0,0 +1,542 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/auth"
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/rbac"
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) *todo.Service {
+	t.Helper()
+	return testutil.NewFileService(t)
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return serve(t, newTestService(t))
+}
+
+// serve starts an API server for the service.
+func serve(t *testing.T, service *todo.Service) *httptest.Server {
+	t.Helper()
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger())).Handler())
+	return srv
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out.
+func do(t *testing.T, srv *httptest.Server, method, path string, body any, out any) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+func createTodo(t *testing.T, srv *httptest.Server, req CreateTodoRequest) *todo.Todo {
+	t.Helper()
+	var created todo.Todo
+	resp := do(t, srv, http.MethodPost, BasePath+"/todos", req, &created)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return &created
+}
+
+func TestServer_CRUD(t *testing.T) {
+	srv := newTestServer(t)
+
+	created := createTodo(t, srv, CreateTodoRequest{Title: "Write docs", Labels: []string{"work"}})
+	require.Equal(t, "Write docs", created.Title)
+	require.Equal(t, todo.StatusPending, created.Status)
+
+	var got todo.Todo
+	resp := do(t, srv, http.MethodGet, BasePath+"/todos/"+created.ID.String(), nil, &got)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, created.ID, got.ID)
+
+	title := "Write better docs"
+	var updated todo.Todo
+	resp = do(t, srv, http.MethodPatch, BasePath+"/todos/"+created.ID.String(), todo.UpdateTodo{Title: &title}, &updated)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, title, updated.Title)
+	require.Equal(t, []string{"work"}, updated.Labels)
+
+	var changed todo.Todo
+	resp = do(t, srv, http.MethodPut, BasePath+"/todos/"+created.ID.String()+"/status", ChangeStatusRequest{Status: todo.StatusCompleted}, &changed)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, todo.StatusCompleted, changed.Status)
+
+	resp = do(t, srv, http.MethodDelete, BasePath+"/todos/"+created.ID.String(), nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	var p Problem
+	resp = do(t, srv, http.MethodGet, BasePath+"/todos/"+created.ID.String(), nil, &p)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, problemTypeBase+"not-found", p.Type)
+}
+
+func TestServer_Location(t *testing.T) {
+	srv := newTestServer(t)
+
+	var created todo.Todo
+	resp := do(t, srv, http.MethodPost, BasePath+"/todos", CreateTodoRequest{Title: "a"}, &created)
+	require.Equal(t, BasePath+"/todos/"+created.ID.String(), resp.Header.Get("Location"))
+}
+
+func TestServer_Problems(t *testing.T) {
+	srv := newTestServer(t)
+	created := createTodo(t, srv, CreateTodoRequest{Title: "done"})
+	do(t, srv, http.MethodPut, BasePath+"/todos/"+created.ID.String()+"/status", ChangeStatusRequest{Status: todo.StatusCompleted}, nil)
+
+	empty := ""
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+		wantType   string
+		wantErrors []string
+	}{
+		{
+			name:       "missing title",
+			method:     http.MethodPost,
+			path:       BasePath + "/todos",
+			body:       CreateTodoRequest{},
+			wantStatus: http.StatusBadRequest,
+			wantType:   problemTypeBase + "invalid-input",
+		},
+		{
+			name:       "invalid update",
+			method:     http.MethodPatch,
+			path:       BasePath + "/todos/" + created.ID.String(),
+			body:       todo.UpdateTodo{Title: &empty},
+			wantStatus: http.StatusBadRequest,
+			wantType:   problemTypeBase + "invalid-input",
+			wantErrors: []string{"Title"},
+		},
+		{
+			name:       "unknown body field",
+			method:     http.MethodPost,
+			path:       BasePath + "/todos",
+			body:       map[string]any{"title": "a", "priority": 1},
+			wantStatus: http.StatusBadRequest,
+			wantType:   "about:blank",
+		},
+		{
+			name:       "malformed id",
+			method:     http.MethodGet,
+			path:       BasePath + "/todos/not-a-uuid",
+			wantStatus: http.StatusBadRequest,
+			wantType:   problemTypeBase + "invalid-input",
+		},
+		{
+			name:       "unknown status",
+			method:     http.MethodPut,
+			path:       BasePath + "/todos/" + created.ID.String() + "/status",
+			body:       ChangeStatusRequest{Status: "archived"},
+			wantStatus: http.StatusBadRequest,
+			wantType:   problemTypeBase + "invalid-input",
+			wantErrors: []string{"status"},
+		},
+		{
+			name:       "disallowed transition",
+			method:     http.MethodPut,
+			path:       BasePath + "/todos/" + created.ID.String() + "/status",
+			body:       ChangeStatusRequest{Status: todo.StatusBlocked},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantType:   problemTypeBase + "invalid-status",
+		},
+		{
+			name:       "invalid filter",
+			method:     http.MethodGet,
+			path:       BasePath + "/todos?status=archived",
+			wantStatus: http.StatusBadRequest,
+			wantType:   problemTypeBase + "invalid-input",
+		},
+		{
+			name:       "unknown endpoint",
+			method:     http.MethodGet,
+			path:       "/nope",
+			wantStatus: http.StatusNotFound,
+			wantType:   "about:blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Problem
+			resp := do(t, srv, tt.method, tt.path, tt.body, &p)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			require.Equal(t, problemContentType, resp.Header.Get("Content-Type"))
+			require.Equal(t, tt.wantStatus, p.Status)
+			require.Equal(t, tt.wantType, p.Type)
+			require.NotEmpty(t, p.Instance)
+			for _, field := range tt.wantErrors {
+				require.Contains(t, p.Errors, field)
+			}
+		})
+	}
+}
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail bool
+	}{
+		{"not found", fmt.Errorf("%w: abc", todo.ErrNotFound), http.StatusNotFound, true},
+		{"invalid input", fmt.Errorf("%w: bad", todo.ErrInvalidInput), http.StatusBadRequest, true},
+		{"conflict", fmt.Errorf("%w: abc", todo.ErrConflict), http.StatusConflict, true},
+		{"invalid status", fmt.Errorf("%w: no", todo.ErrInvalidStatus), http.StatusUnprocessableEntity, true},
+		{"unsupported", fmt.Errorf("%w: search", todo.ErrUnsupported), http.StatusNotImplemented, true},
+		{"unauthenticated", fmt.Errorf("%w: missing bearer token", todo.ErrUnauthenticated), http.StatusUnauthorized, true},
+		{"missing scope", &auth.ScopeError{Scope: todo.ScopeWrite}, http.StatusForbidden, true},
+		{"forbidden", fmt.Errorf("%w: the viewer role does not allow write operations", todo.ErrForbidden), http.StatusForbidden, true},
+		{"internal", fmt.Errorf("connection refused"), http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problemFor(tt.err)
+			require.Equal(t, tt.wantStatus, p.Status)
+			require.Equal(t, tt.wantDetail, p.Detail != "")
+		})
+	}
+}
+
+func TestServer_ListAndCount(t *testing.T) {
+	srv := newTestServer(t)
+	for _, title := range []string{"alpha", "beta", "gamma"} {
+		createTodo(t, srv, CreateTodoRequest{Title: title, Labels: []string{"work"}})
+	}
+	createTodo(t, srv, CreateTodoRequest{Title: "delta", Labels: []string{"home"}})
+
+	var list ListResponse
+	resp := do(t, srv, http.MethodGet, BasePath+"/todos?labels=work&sortBy=title&sortOrder=asc", nil, &list)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var titles []string
+	for _, td := range list.Todos {
+		titles = append(titles, td.Title)
+	}
+	require.Equal(t, []string{"alpha", "beta", "gamma"}, titles)
+
+	var count CountResponse
+	resp = do(t, srv, http.MethodGet, BasePath+"/todos/count?labels=home", nil, &count)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, count.Count)
+
+	// Cursor pagination walks every todo
+	var seen []string
+	path := BasePath + "/todos?limit=3&cursor="
+	for {
+		var page ListResponse
+		resp = do(t, srv, http.MethodGet, path, nil, &page)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		for _, td := range page.Todos {
+			seen = append(seen, td.Title)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		path = BasePath + "/todos?limit=3&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	require.Len(t, seen, 4)
+
+	var empty ListResponse
+	do(t, srv, http.MethodGet, BasePath+"/todos?search=nothing", nil, &empty)
+	require.NotNil(t, empty.Todos)
+	require.Empty(t, empty.Todos)
+}
+
+func TestServer_CrossOrigin(t *testing.T) {
+	srv := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+BasePath+"/todos", strings.NewReader(`{"title":"Sneaky"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, problemContentType, resp.Header.Get("Content-Type"))
+
+	var list ListResponse
+	do(t, srv, http.MethodGet, BasePath+"/todos", nil, &list)
+	require.Empty(t, list.Todos)
+}
+
+func TestServer_Health(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := srv.Client().Get(srv.URL + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.Contains(string(body), `"status"`))
+}
+
+func TestServer_Auth(t *testing.T) {
+	doc := loadSpec(t)
+	service := newTestService(t)
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithAuthenticator(auth.New(service))).Handler())
+
+	ctx := t.Context()
+	_, reader, err := service.CreateToken(ctx, "reader", "alice", []todo.Scope{todo.ScopeRead}, 0)
+	require.NoError(t, err)
+	_, writer, err := service.CreateToken(ctx, "writer", "bob", []todo.Scope{todo.ScopeWrite}, 0)
+	require.NoError(t, err)
+	revoked, revokedToken, err := service.CreateToken(ctx, "revoked", "carol", []todo.Scope{todo.ScopeWrite}, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.RevokeToken(ctx, revoked.ID))
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		template      string
+		authorization string
+		body          any
+		wantStatus    int
+	}{
+		{"no credential", http.MethodGet, "/todos", "/todos", "", nil, http.StatusUnauthorized},
+		{"not bearer", http.MethodGet, "/todos", "/todos", "Basic " + reader, nil, http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/todos", "/todos", "Bearer todoify_0000000000000000_secret", nil, http.StatusUnauthorized},
+		{"revoked token", http.MethodGet, "/todos", "/todos", "Bearer " + revokedToken, nil, http.StatusUnauthorized},
+		{"jwt without verifier", http.MethodGet, "/todos", "/todos", "Bearer a.b.c", nil, http.StatusUnauthorized},
+		{"read", http.MethodGet, "/todos", "/todos", "Bearer " + reader, nil, http.StatusOK},
+		{"read cannot write", http.MethodPost, "/todos", "/todos", "Bearer " + reader, CreateTodoRequest{Title: "a"}, http.StatusForbidden},
+		{"write", http.MethodPost, "/todos", "/todos", "Bearer " + writer, CreateTodoRequest{Title: "a"}, http.StatusCreated},
+		{"write can read", http.MethodGet, "/todos/count", "/todos/count", "Bearer " + writer, nil, http.StatusOK},
+		{"public openapi", http.MethodGet, "/openapi.yaml", "/openapi.yaml", "", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != nil {
+				data, err := json.Marshal(tt.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+			req, err := http.NewRequest(tt.method, srv.URL+BasePath+tt.path, body)
+			require.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if resp.StatusCode == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="todoify"`, resp.Header.Get("WWW-Authenticate"))
+			}
+			doc.checkResponse(t, tt.method, BasePath+tt.template, resp)
+		})
+	}
+
+	t.Run("public health", func(t *testing.T) {
+		resp, err := srv.Client().Get(srv.URL + "/healthz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestServer_Roles(t *testing.T) {
+	repo := testutil.NewFileRepository(t)
+	authorizer, err := rbac.NewAuthorizer(&rbac.Policy{
+		Default: rbac.RoleViewer,
+		Bindings: []rbac.Binding{
+			{Subject: "bob", Role: rbac.RoleEditor},
+			{Subject: "root", Role: rbac.RoleAdmin},
+		},
+	})
+	require.NoError(t, err)
+	service := todo.NewService(repo, todo.WithAuthorizer(authorizer))
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithAuthenticator(auth.New(service))).Handler())
+
+	// Tokens are created locally, by an admin
+	ctx := todo.WithPrincipal(t.Context(), &todo.Principal{Subject: "root", Method: "local"})
+	_, viewer, err := service.CreateToken(ctx, "viewer", "alice", []todo.Scope{todo.ScopeWrite}, 0)
+	require.NoError(t, err)
+	_, editor, err := service.CreateToken(ctx, "editor", "bob", []todo.Scope{todo.ScopeWrite}, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		wantStatus int
+	}{
+		{"viewer reads", viewer, http.MethodGet, http.StatusOK},
+		{"viewer cannot create", viewer, http.MethodPost, http.StatusForbidden},
+		{"editor creates", editor, http.MethodPost, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+BasePath+"/todos", strings.NewReader(`{"title":"a"}`))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	doc := loadSpec(t)
+	limiter, err := ratelimit.New(
+		ratelimit.WithLimit(ratelimit.Standard, ratelimit.Limit{Rate: 0.01, Burst: 2}),
+		ratelimit.WithLimit(ratelimit.Expensive, ratelimit.Limit{Rate: 0.01, Burst: 1}),
+	)
+	require.NoError(t, err)
+	srv := testutil.Serve(t, NewServer(newTestService(t), WithLogger(testutil.Logger()), WithRateLimiter(limiter)).Handler())
+
+	tests := []struct {
+		name       string
+		path       string
+		template   string
+		wantStatus int
+	}{
+		{"list", "/todos", "/todos", http.StatusOK},
+		{"search", "/todos?search=docs", "/todos", http.StatusOK},
+		{"count is expensive", "/todos/count", "/todos/count", http.StatusTooManyRequests},
+		{"facets are expensive", "/todos?facets=true", "/todos", http.StatusTooManyRequests},
+		{"list again", "/todos?limit=1", "/todos", http.StatusOK},
+		{"list over the burst", "/todos", "/todos", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := srv.Client().Get(srv.URL + BasePath + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if resp.StatusCode == http.StatusTooManyRequests {
+				retry, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+				require.NoError(t, err)
+				require.Positive(t, retry)
+			}
+			doc.checkResponse(t, http.MethodGet, BasePath+tt.template, resp)
+		})
+	}
+
+	for _, path := range []string{"/healthz", "/metrics"} {
+		t.Run(path+" is not limited", func(t *testing.T) {
+			resp, err := srv.Client().Get(srv.URL + path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			doc.checkResponse(t, http.MethodGet, path, resp)
+		})
+	}
+
+	stats := limiter.Stats()
+	require.Equal(t, int64(2), stats.Throttled[ratelimit.Expensive])
+	require.Equal(t, int64(1), stats.Throttled[ratelimit.Standard])
+}
+
+func TestServer_RateLimitAddress(t *testing.T) {
+	service := newTestService(t)
+	limiter, err := ratelimit.New(
+		ratelimit.WithLimit(ratelimit.Standard, ratelimit.Limit{Rate: 0.01, Burst: 1}),
+		ratelimit.WithLimit(ratelimit.Address, ratelimit.Limit{Rate: 0.01, Burst: 3}),
+	)
+	require.NoError(t, err)
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithAuthenticator(auth.New(service)), WithRateLimiter(limiter)).Handler())
+
+	_, token, err := service.CreateToken(t.Context(), "reader", "alice", []todo.Scope{todo.ScopeRead}, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		credential string
+		wantStatus int
+	}{
+		{"bad credential", "todoify_0000000000000000_guess", http.StatusUnauthorized},
+		{"token", token, http.StatusOK},
+		{"token over its own limit", token, http.StatusTooManyRequests},
+		{"bad credential over the address limit", "todoify_0000000000000000_guess", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+BasePath+"/todos", nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.credential)
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+
+	stats := limiter.Stats()
+	require.Equal(t, int64(1), stats.Throttled[ratelimit.Address])
+	require.Equal(t, int64(1), stats.Throttled[ratelimit.Standard])
+}
+
+func TestServer_RateLimitClient(t *testing.T) {
+	tests := []struct {
+		name         string
+		principal    *todo.Principal
+		forwardedFor bool
+		header       []string
+		want         string
+	}{
+		{"remote address", nil, false, nil, "ip:192.0.2.1"},
+		{"untrusted forwarded for", nil, false, []string{"198.51.100.7"}, "ip:192.0.2.1"},
+		{"forwarded for", nil, true, []string{"203.0.113.9, 198.51.100.7"}, "ip:198.51.100.7"},
+		{"last forwarded for header", nil, true, []string{"203.0.113.9", "198.51.100.7"}, "ip:198.51.100.7"},
+		{"token", &todo.Principal{Subject: "alice", Method: "token", TokenID: "abc"}, false, nil, "token:abc"},
+		{"jwt", &todo.Principal{Subject: "alice", Method: "jwt", Tenant: "acme"}, true, []string{"198.51.100.7"}, "jwt:acme/alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{forwardedFor: tt.forwardedFor}
+			r := httptest.NewRequest(http.MethodGet, BasePath+"/todos", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			for _, v := range tt.header {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.principal != nil {
+				r = r.WithContext(todo.WithPrincipal(r.Context(), tt.principal))
+			}
+			require.Equal(t, tt.want, s.client(r))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/repository"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// CreateTodoRequest is the body of POST /todos.
+type CreateTodoRequest struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Labels      []string       `json:"labels,omitempty"`
+	Fields      map[string]any `json:"fields,omitempty"`
+}
+
+// ChangeStatusRequest is the body of PUT /todos/{id}/status.
+type ChangeStatusRequest struct {
+	Status todo.Status `json:"status"`
+}
+
+// ListResponse is the body of GET /todos.
+type ListResponse struct {
+	Todos []*todo.Todo `json:"todos"`
+
+	// NextCursor continues cursor pagination. It is only set when the request had
+	// a cursor parameter (empty to start) and more todos follow.
+	NextCursor string `json:"nextCursor,omitempty"`
+
+	// Facets holds status, label and created-month counts when facets=true.
+	Facets *todo.Facets `json:"facets,omitempty"`
+}
+
+// CountResponse is the body of GET /todos/count.
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateTodoRequest
+	if p := decodeJSON(w, r, &req); p != nil {
+		writeProblem(w, r, p)
+		return
+	}
+
+	created, err := s.service.CreateTodo(r.Context(), req.Title, req.Description, req.Labels, todo.WithFields(req.Fields))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", BasePath+"/todos/"+created.ID.String())
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	t, err := s.service.GetTodo(r.Context(), r.PathValue("id"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var update todo.UpdateTodo
+	if p := decodeJSON(w, r, &update); p != nil {
+		writeProblem(w, r, p)
+		return
+	}
+
+	t, err := s.service.UpdateTodo(r.Context(), r.PathValue("id"), update)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleChangeStatus(w http.ResponseWriter, r *http.Request) {
+	var req ChangeStatusRequest
+	if p := decodeJSON(w, r, &req); p != nil {
+		writeProblem(w, r, p)
+		return
+	}
+
+	// An unknown status is bad input, not a disallowed transition
+	if !req.Status.IsValid() {
+		p := problemFor(todo.ErrInvalidInput)
+		p.Detail = "status must be one of pending, in_progress, completed, cancelled, blocked"
+		p.Errors = map[string]string{"status": p.Detail}
+		writeProblem(w, r, p)
+		return
+	}
+
+	t, err := s.service.ChangeStatus(r.Context(), r.PathValue("id"), req.Status)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.service.DeleteTodo(r.Context(), r.PathValue("id")); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := filterFromQuery(query)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var res ListResponse
+	switch {
+	case query.Has("cursor"):
+		page, err := s.service.ListTodosPage(r.Context(), filter)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		res.Todos, res.NextCursor = page.Todos, page.NextCursor
+
+	case query.Get("facets") == "true":
+		res.Todos, res.Facets, err = s.service.ListTodosWithFacets(r.Context(), filter)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+	default:
+		res.Todos, err = s.service.ListTodos(r.Context(), filter)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+
+	if res.Todos == nil {
+		res.Todos = []*todo.Todo{}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	filter, err := filterFromQuery(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	count, err := s.service.CountTodos(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CountResponse{Count: count})
+}
+
+// handleHealth reports the backend health. Unhealthy backends answer 503 so
+// load balancers take the instance out of rotation.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	info, err := s.service.Health(r.Context())
+	if info == nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if err != nil || info.Status == repository.HealthStatusUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, info)
+}
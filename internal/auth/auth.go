@@ -0,0 +1,91 @@
+// Package auth authenticates API callers. Callers present a bearer
+// credential, either a personal access token created with "todoify token
+// create" or a JWT signed by a key in a configured JWKS, and get a
+// todo.Principal that transports put in the request context with
+// todo.WithPrincipal.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Authenticator verifies bearer credentials.
+type Authenticator struct {
+	service *todo.Service
+	jwt     *JWTVerifier
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithJWT accepts JWTs validated by v in addition to access tokens.
+func WithJWT(v *JWTVerifier) Option {
+	return func(a *Authenticator) {
+		a.jwt = v
+	}
+}
+
+// New creates an Authenticator that checks personal access tokens against
+// the service.
+func New(service *todo.Service, opts ...Option) *Authenticator {
+	a := &Authenticator{service: service}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authenticate returns the principal of a bearer credential. Missing, unknown
+// and invalid credentials are todo.ErrUnauthenticated; other errors mean the
+// credential could not be checked.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*todo.Principal, error) {
+	if credential == "" {
+		return nil, fmt.Errorf("%w: missing bearer token", todo.ErrUnauthenticated)
+	}
+
+	if strings.HasPrefix(credential, todo.TokenPrefix) {
+		return a.service.AuthenticateToken(ctx, credential)
+	}
+	if a.jwt == nil {
+		return nil, fmt.Errorf("%w: unknown credential", todo.ErrUnauthenticated)
+	}
+	return a.jwt.Verify(credential)
+}
+
+// BearerToken returns the credential of an Authorization header value, or
+// "" when it is not a bearer credential.
+func BearerToken(authorization string) string {
+	scheme, credential, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(credential)
+}
+
+// Require checks that the principal of the context was granted scope. A
+// context without a principal is a local caller and is always allowed.
+func Require(ctx context.Context, scope todo.Scope) error {
+	p, ok := todo.PrincipalFromContext(ctx)
+	if !ok || p.HasScope(scope) {
+		return nil
+	}
+	return &ScopeError{Scope: scope}
+}
+
+// ScopeError reports a principal lacking a scope. It matches
+// todo.ErrForbidden.
+type ScopeError struct {
+	Scope todo.Scope
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("the %s scope is required", e.Scope)
+}
+
+func (e *ScopeError) Unwrap() error {
+	return todo.ErrForbidden
+}
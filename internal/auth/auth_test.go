@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	service := todo.NewService(filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json")))
+	_, token, err := service.CreateToken(ctx, "ci", "bob", []todo.Scope{todo.ScopeRead}, 0)
+	require.NoError(t, err)
+
+	keys := newTestKeys(t)
+	v, err := NewJWTVerifier(keys.set)
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+	jwt := keys.sign(t, "EdDSA", "ed", validClaims())
+
+	tests := []struct {
+		name        string
+		auth        *Authenticator
+		credential  string
+		wantSubject string
+	}{
+		{"access token", New(service), token, "bob"},
+		{"JWT", New(service, WithJWT(v)), jwt, "alice"},
+		{"JWT not configured", New(service), jwt, ""},
+		{"missing", New(service, WithJWT(v)), "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.auth.Authenticate(ctx, tt.credential)
+			if tt.wantSubject == "" {
+				require.ErrorIs(t, err, todo.ErrUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSubject, p.Subject)
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"Bearer abc", "abc"},
+		{"bearer  abc ", "abc"},
+		{"Basic abc", ""},
+		{"Bearer", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, BearerToken(tt.header), tt.header)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	reader := todo.WithPrincipal(context.Background(), &todo.Principal{Subject: "alice", Scopes: []todo.Scope{todo.ScopeRead}})
+	writer := todo.WithPrincipal(context.Background(), &todo.Principal{Subject: "bob", Scopes: []todo.Scope{todo.ScopeWrite}})
+
+	require.NoError(t, Require(context.Background(), todo.ScopeWrite))
+	require.NoError(t, Require(reader, todo.ScopeRead))
+	require.NoError(t, Require(writer, todo.ScopeRead))
+
+	var scopeErr *ScopeError
+	require.ErrorAs(t, Require(reader, todo.ScopeWrite), &scopeErr)
+	require.Equal(t, todo.ScopeWrite, scopeErr.Scope)
+}
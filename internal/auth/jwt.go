@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// DefaultLeeway is the clock skew tolerated when checking exp and nbf.
+const DefaultLeeway = time.Minute
+
+// MinRSAKeyBits is the smallest RSA modulus accepted for verifying tokens.
+const MinRSAKeyBits = 2048
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS = jose.JSONWebKeySet
+
+// signatureAlgorithms are the JWS algorithms accepted in tokens. Symmetric
+// ones are left out, since the keys of a JWKS are public.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// verificationKey is a public key of the JWKS.
+type verificationKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// LoadJWKS reads a JWKS file.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS %s: %w", path, err)
+	}
+	return &set, nil
+}
+
+// JWTVerifier validates JWT bearer tokens signed with keys of a JWKS.
+// RS256/384/512, PS256/384/512, ES256/384/512 and EdDSA are supported.
+type JWTVerifier struct {
+	keys     []verificationKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// JWTOption configures a JWTVerifier.
+type JWTOption func(*JWTVerifier)
+
+// WithIssuer requires the iss claim to equal issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience requires the aud claim to contain audience.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway sets the clock skew tolerated when checking exp and nbf.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(v *JWTVerifier) {
+		v.leeway = leeway
+	}
+}
+
+// NewJWTVerifier creates a verifier for the signing keys in set. Keys used
+// for encryption only are ignored, while symmetric keys and RSA keys
+// shorter than MinRSAKeyBits are refused.
+func NewJWTVerifier(set *JWKS, opts ...JWTOption) (*JWTVerifier, error) {
+	v := &JWTVerifier{leeway: DefaultLeeway, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := publicKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%s): %w", i, k.KeyID, err)
+		}
+		v.keys = append(v.keys, verificationKey{kid: k.KeyID, alg: k.Algorithm, key: key})
+	}
+	if len(v.keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+
+	return v, nil
+}
+
+// publicKey returns the public key of a JWK, checking it is strong enough.
+func publicKey(k jose.JSONWebKey) (crypto.PublicKey, error) {
+	if !k.Valid() {
+		return nil, errors.New("invalid key")
+	}
+	k = k.Public()
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		if bits := key.N.BitLen(); bits < MinRSAKeyBits {
+			return nil, fmt.Errorf("RSA key has %d bits, at least %d are required", bits, MinRSAKeyBits)
+		}
+		return key, nil
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", k.Key)
+	}
+}
+
+// claims are the JWT claims read by the verifier.
+type claims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+
+	// Scope is the OAuth 2.0 space-separated scope claim (RFC 8693).
+	Scope string `json:"scope"`
+
+	// Scp is the scope list used by some identity providers.
+	Scp stringList `json:"scp"`
+
+	// Tenant is the tenant the subject belongs to.
+	Tenant string `json:"tenant"`
+}
+
+// stringList is a claim that may be a string or an array of strings. A
+// string holds space-separated values.
+type stringList []string
+
+func (a *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = strings.Fields(s)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks the signature and claims of a JWT and returns its principal.
+// The token must have a subject and an expiry; its scopes come from the
+// scope or scp claim, keeping only the ones todoify knows, and its tenant
+// from the tenant claim.
+func (v *JWTVerifier) Verify(token string) (*todo.Principal, error) {
+	tok, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil || len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("%w: malformed JWT", todo.ErrUnauthenticated)
+	}
+
+	var c claims
+	if !v.verifySignature(tok, &c) {
+		return nil, fmt.Errorf("%w: invalid JWT signature or claims", todo.ErrUnauthenticated)
+	}
+	if err := v.checkClaims(c); err != nil {
+		return nil, err
+	}
+
+	var scopes []todo.Scope
+	for _, s := range append(strings.Fields(c.Scope), c.Scp...) {
+		if scope := todo.Scope(s); scope.IsValid() && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &todo.Principal{
+		Subject: c.Subject,
+		Scopes:  scopes,
+		Method:  "jwt",
+		Tenant:  c.Tenant,
+	}, nil
+}
+
+// checkClaims validates the registered claims.
+func (v *JWTVerifier) checkClaims(c claims) error {
+	now := v.now()
+
+	if c.Subject == "" {
+		return fmt.Errorf("%w: JWT has no subject", todo.ErrUnauthenticated)
+	}
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: JWT has no expiry", todo.ErrUnauthenticated)
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(v.leeway)) {
+		return fmt.Errorf("%w: JWT expired", todo.ErrUnauthenticated)
+	}
+	if c.NotBefore != nil && now.Add(v.leeway).Before(unixTime(*c.NotBefore)) {
+		return fmt.Errorf("%w: JWT not valid yet", todo.ErrUnauthenticated)
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected JWT issuer %q", todo.ErrUnauthenticated, c.Issuer)
+	}
+	if v.audience != "" && !slices.Contains(c.Audience, v.audience) {
+		return fmt.Errorf("%w: JWT not issued for this audience", todo.ErrUnauthenticated)
+	}
+	if c.Tenant != "" && todo.ValidateTenant(c.Tenant) != nil {
+		return fmt.Errorf("%w: invalid JWT tenant %q", todo.ErrUnauthenticated, c.Tenant)
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// verifySignature checks the signature with the keys matching the header's
+// kid and algorithm, decoding the claims into c once one matches. Keys
+// pinned to another algorithm are never tried, so a token cannot choose a
+// weaker one.
+func (v *JWTVerifier) verifySignature(tok *jwt.JSONWebToken, c *claims) bool {
+	h := tok.Headers[0]
+	for _, k := range v.keys {
+		if h.KeyID != "" && k.kid != "" && h.KeyID != k.kid {
+			continue
+		}
+		if k.alg != "" && k.alg != h.Algorithm {
+			continue
+		}
+		if err := tok.Claims(k.key, c); err == nil {
+			return true
+		}
+	}
+	return false
+}
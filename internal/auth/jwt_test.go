@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// testKeys are signing keys and the JWKS publishing them.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+	set     *JWKS
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return &testKeys{
+		rsa:     rsaKey,
+		ec:      ecKey,
+		ed25519: edKey,
+		set: &JWKS{Keys: []jose.JSONWebKey{
+			{Key: &rsaKey.PublicKey, KeyID: "rsa"},
+			{Key: &ecKey.PublicKey, KeyID: "ec"},
+			{Key: edPub, KeyID: "ed"},
+		}},
+	}
+}
+
+// sign creates a JWT with the claims signed by the key for alg.
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, k.rsa, crypto.SHA256, digest[:], nil)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "EdDSA":
+		signature = ed25519.Sign(k.ed25519, []byte(signed))
+	}
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "alice",
+		"iss":   "https://idp.example.com",
+		"aud":   []string{"todoify", "other"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"scope": "read write profile",
+	}
+}
+
+func withClaim(key string, value any) map[string]any {
+	c := validClaims()
+	if value == nil {
+		delete(c, key)
+	} else {
+		c[key] = value
+	}
+	return c
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	keys := newTestKeys(t)
+	other := newTestKeys(t)
+
+	v, err := NewJWTVerifier(keys.set, WithIssuer("https://idp.example.com"), WithAudience("todoify"))
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+
+	unsigned := func(claims map[string]any) string {
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		b64 := base64.RawURLEncoding.EncodeToString
+		return b64([]byte(`{"alg":"none"}`)) + "." + b64(payload) + "."
+	}
+	scpOnly := withClaim("scope", nil)
+	scpOnly["scp"] = "read"
+
+	tests := []struct {
+		name       string
+		token      string
+		wantErr    bool
+		wantScopes []todo.Scope
+	}{
+		{"RS256", keys.sign(t, "RS256", "rsa", validClaims()), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"PS256", keys.sign(t, "PS256", "rsa", validClaims()), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"ES256", keys.sign(t, "ES256", "ec", validClaims()), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"EdDSA", keys.sign(t, "EdDSA", "ed", validClaims()), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"without kid", keys.sign(t, "ES256", "", validClaims()), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"scp list", keys.sign(t, "RS256", "rsa", withClaim("scp", []string{"read"})), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"scp only", keys.sign(t, "RS256", "rsa", scpOnly), false, []todo.Scope{todo.ScopeRead}},
+		{"single audience", keys.sign(t, "RS256", "rsa", withClaim("aud", "todoify")), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"expired within leeway", keys.sign(t, "RS256", "rsa", withClaim("exp", testNow.Add(-30*time.Second).Unix())), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"expired", keys.sign(t, "RS256", "rsa", withClaim("exp", testNow.Add(-time.Hour).Unix())), true, nil},
+		{"no expiry", keys.sign(t, "RS256", "rsa", withClaim("exp", nil)), true, nil},
+		{"not yet valid", keys.sign(t, "RS256", "rsa", withClaim("nbf", testNow.Add(time.Hour).Unix())), true, nil},
+		{"no subject", keys.sign(t, "RS256", "rsa", withClaim("sub", nil)), true, nil},
+		{"wrong issuer", keys.sign(t, "RS256", "rsa", withClaim("iss", "https://evil.example.com")), true, nil},
+		{"wrong audience", keys.sign(t, "RS256", "rsa", withClaim("aud", "other")), true, nil},
+		{"unknown key", other.sign(t, "RS256", "rsa", validClaims()), true, nil},
+		{"algorithm for another key", keys.sign(t, "ES256", "rsa", validClaims()), true, nil},
+		{"alg none", unsigned(validClaims()), true, nil},
+		{"tenant", keys.sign(t, "RS256", "rsa", withClaim("tenant", "acme")), false, []todo.Scope{todo.ScopeRead, todo.ScopeWrite}},
+		{"invalid tenant", keys.sign(t, "RS256", "rsa", withClaim("tenant", "../acme")), true, nil},
+		{"malformed", "not.a-jwt", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if tt.wantErr {
+				require.ErrorIs(t, err, todo.ErrUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "alice", p.Subject)
+			require.Equal(t, "jwt", p.Method)
+			if tt.wantScopes != nil {
+				require.Equal(t, tt.wantScopes, p.Scopes)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_PinnedAlgorithm(t *testing.T) {
+	keys := newTestKeys(t)
+	keys.set.Keys[0].Algorithm = "PS256"
+
+	v, err := NewJWTVerifier(keys.set)
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+
+	_, err = v.Verify(keys.sign(t, "PS256", "rsa", validClaims()))
+	require.NoError(t, err)
+	_, err = v.Verify(keys.sign(t, "RS256", "rsa", validClaims()))
+	require.ErrorIs(t, err, todo.ErrUnauthenticated)
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	short, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	strong, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		set     *JWKS
+		wantErr bool
+	}{
+		{"empty", &JWKS{}, true},
+		{"encryption keys only", &JWKS{Keys: []jose.JSONWebKey{{Key: &strong.PublicKey, Use: "enc"}}}, true},
+		{"symmetric key", &JWKS{Keys: []jose.JSONWebKey{{Key: []byte("secret")}}}, true},
+		{"short RSA key", &JWKS{Keys: []jose.JSONWebKey{{Key: &short.PublicKey}}}, true},
+		{"short RSA private key", &JWKS{Keys: []jose.JSONWebKey{{Key: short}}}, true},
+		{"RSA", &JWKS{Keys: []jose.JSONWebKey{{Key: &strong.PublicKey}}}, false},
+		{"RSA private key", &JWKS{Keys: []jose.JSONWebKey{{Key: strong}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTVerifier(tt.set)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	short, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	b64 := base64.RawURLEncoding.EncodeToString
+	shortRSA := fmt.Sprintf(`{"keys":[{"kty":"RSA","n":%q,"e":"AQAB"}]}`, b64(short.N.Bytes()))
+
+	tests := []struct {
+		name        string
+		jwks        string
+		wantLoadErr bool
+		wantErr     bool
+	}{
+		{"unknown key type", `{"keys":[{"kty":"foo"}]}`, true, false},
+		{"unsupported curve", `{"keys":[{"kty":"EC","crv":"secp256k1","x":"AQ","y":"AQ"}]}`, true, false},
+		{"point not on curve", `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`, true, false},
+		{"short Ed25519 key", `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"AQ"}]}`, true, false},
+		{"short RSA key", shortRSA, false, true},
+		{"symmetric key", `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.jwks), 0o600))
+
+			set, err := LoadJWKS(path)
+			if tt.wantLoadErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			_, err = NewJWTVerifier(set)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
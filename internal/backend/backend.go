@@ -0,0 +1,256 @@
+// Package backend opens a todo.Repository from configuration: the root
+// command's flags and config file, a separate config file, or a URL.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	esrepo "github.com/MattDevy/es-todoify/internal/todo/repositories/elasticsearch/v9"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/spf13/viper"
+)
+
+// Supported backends.
+const (
+	Elasticsearch = "elasticsearch"
+	File          = "file"
+)
+
+// DefaultIndex is the Elasticsearch index (alias) used when none is configured.
+const DefaultIndex = "todos"
+
+// Elasticsearch tenancy modes.
+const (
+	// TenancyNone shares all todos between callers.
+	TenancyNone = "none"
+	// TenancyFilter keeps the todos of each tenant apart with a filter.
+	TenancyFilter = "filter"
+	// TenancyRouting filters like TenancyFilter and routes each tenant's
+	// todos to one shard.
+	TenancyRouting = "routing"
+)
+
+// Config selects and configures a repository backend.
+type Config struct {
+	// Backend is Elasticsearch or File.
+	Backend string
+
+	// FilePath is the store of the file backend.
+	FilePath string
+
+	// Addresses are the Elasticsearch node URLs.
+	Addresses []string
+
+	// Username and Password authenticate with Elasticsearch. Both or neither must be set.
+	Username string
+	Password string
+
+	// APIKey authenticates with Elasticsearch instead of a username and password.
+	APIKey string
+
+	// Index is the Elasticsearch index (alias) holding the todos.
+	Index string
+
+	// IndexSettings are used when creating Elasticsearch indices.
+	IndexSettings esrepo.IndexSettings
+
+	// Tenancy is TenancyNone, TenancyFilter or TenancyRouting.
+	Tenancy string
+}
+
+// FromViper reads a Config from the keys used by the root command's flags and
+// config file (backend, file-path, es-addrs, es-username, ...).
+func FromViper(v *viper.Viper) Config {
+	return Config{
+		Backend:   v.GetString("backend"),
+		FilePath:  v.GetString("file-path"),
+		Addresses: v.GetStringSlice("es-addrs"),
+		Username:  v.GetString("es-username"),
+		Password:  v.GetString("es-password"),
+		APIKey:    v.GetString("es-api-key"),
+		Index:     v.GetString("es-index"),
+		IndexSettings: esrepo.IndexSettings{
+			Shards:             v.GetInt("es-shards"),
+			Replicas:           v.GetInt("es-replicas"),
+			RefreshInterval:    v.GetString("es-refresh-interval"),
+			Analyzer:           v.GetString("es-analyzer"),
+			TrashRetentionDays: v.GetInt("es-trash-retention-days"),
+		},
+		Tenancy: v.GetString("es-tenancy"),
+	}
+}
+
+// SetDefaults sets the default for every key read by FromViper.
+func SetDefaults(v *viper.Viper, home string) {
+	v.SetDefault("backend", Elasticsearch)
+	if home != "" {
+		v.SetDefault("file-path", filepath.Join(home, ".todoify", "todos.json"))
+	}
+	v.SetDefault("es-addrs", []string{"http://localhost:9200"})
+	v.SetDefault("es-index", DefaultIndex)
+	defaults := esrepo.DefaultIndexSettings()
+	v.SetDefault("es-shards", defaults.Shards)
+	v.SetDefault("es-replicas", defaults.Replicas)
+	v.SetDefault("es-refresh-interval", defaults.RefreshInterval)
+	v.SetDefault("es-analyzer", defaults.Analyzer)
+	v.SetDefault("es-trash-retention-days", defaults.TrashRetentionDays)
+	v.SetDefault("es-tenancy", TenancyNone)
+}
+
+// Parse reads a Config from a backend URL or a config file:
+//   - file:///path/to/todos.json or file:relative/todos.json
+//   - http(s)://[user:password@]host:port[/index][?api_key=KEY] for Elasticsearch
+//   - anything else is a YAML config file with the same keys as ~/.todoify.yaml
+func Parse(spec string, home string) (Config, error) {
+	if strings.HasPrefix(spec, "file:") || strings.Contains(spec, "://") {
+		return parseURL(spec, home)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(spec)
+	if err := v.ReadInConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to read backend config %s: %w", spec, err)
+	}
+	SetDefaults(v, home)
+	return FromViper(v), nil
+}
+
+func parseURL(spec string, home string) (Config, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid backend URL %q: %w", spec, err)
+	}
+
+	v := viper.New()
+	SetDefaults(v, home)
+	cfg := FromViper(v)
+
+	switch u.Scheme {
+	case "file":
+		cfg.Backend = File
+		cfg.FilePath = u.Path
+		if u.Opaque != "" {
+			cfg.FilePath = u.Opaque
+		}
+		if rest, ok := strings.CutPrefix(cfg.FilePath, "~/"); ok && home != "" {
+			cfg.FilePath = filepath.Join(home, rest)
+		}
+
+	case "http", "https":
+		cfg.Backend = Elasticsearch
+		cfg.Addresses = []string{(&url.URL{Scheme: u.Scheme, Host: u.Host}).String()}
+		if index := strings.Trim(u.Path, "/"); index != "" {
+			cfg.Index = index
+		}
+		if u.User != nil {
+			cfg.Username = u.User.Username()
+			cfg.Password, _ = u.User.Password()
+		}
+		cfg.APIKey = u.Query().Get("api_key")
+
+	default:
+		return Config{}, fmt.Errorf("unsupported backend URL scheme %q (use file, http or https)", u.Scheme)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the configuration can open a repository.
+func (c Config) Validate() error {
+	switch c.Backend {
+	case File:
+		if c.FilePath == "" {
+			return errors.New("a file path is required for the file backend (use --file-path, TODOIFY_FILE_PATH, or config file)")
+		}
+		return nil
+
+	case Elasticsearch:
+		if len(c.Addresses) == 0 {
+			return errors.New("at least one Elasticsearch address is required (use --es-addrs, TODOIFY_ES_ADDRS, or config file)")
+		}
+
+		// Validate that both username and password are provided together
+		if (c.Username != "" && c.Password == "") || (c.Username == "" && c.Password != "") {
+			return errors.New("both es-username and es-password must be provided together")
+		}
+
+		// Validate that API key and username/password are mutually exclusive
+		if c.APIKey != "" && (c.Username != "" || c.Password != "") {
+			return errors.New("cannot use both API key and username/password authentication; choose one method")
+		}
+
+		if err := c.IndexSettings.Validate(); err != nil {
+			return fmt.Errorf("invalid Elasticsearch index settings: %w", err)
+		}
+
+		switch c.Tenancy {
+		case "", TenancyNone, TenancyFilter, TenancyRouting:
+		default:
+			return fmt.Errorf("unknown Elasticsearch tenancy %q (use none, filter or routing)", c.Tenancy)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown backend %q (use elasticsearch or file)", c.Backend)
+}
+
+// String describes the backend without credentials.
+func (c Config) String() string {
+	if c.Backend == File {
+		return "file:" + c.FilePath
+	}
+	return fmt.Sprintf("%s/%s", strings.Join(c.Addresses, ","), c.Index)
+}
+
+// Open validates the configuration and opens the repository. For
+// Elasticsearch it connects and pings the cluster.
+func Open(ctx context.Context, c Config) (todo.Repository, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.Backend == File {
+		return filerepo.NewRepository(c.FilePath), nil
+	}
+
+	// Build Elasticsearch client config
+	cfg := elasticsearch.Config{
+		Addresses: c.Addresses,
+	}
+
+	// Add authentication if provided
+	if c.APIKey != "" {
+		cfg.APIKey = c.APIKey
+	} else if c.Username != "" && c.Password != "" {
+		cfg.Username = c.Username
+		cfg.Password = c.Password
+	}
+
+	// Create typed client
+	client, err := elasticsearch.NewTypedClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Verify connection by pinging the cluster
+	if _, err := client.Info().Do(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to Elasticsearch at %v: %w\nPlease check:\n  - Elasticsearch is running\n  - Addresses are correct\n  - Credentials are valid", c.Addresses, err)
+	}
+
+	opts := []esrepo.Option{esrepo.WithIndexSettings(c.IndexSettings)}
+	switch c.Tenancy {
+	case TenancyFilter:
+		opts = append(opts, esrepo.WithTenantIsolation())
+	case TenancyRouting:
+		opts = append(opts, esrepo.WithTenantRouting())
+	}
+
+	return esrepo.NewRepository(client, c.Index, opts...), nil
+}
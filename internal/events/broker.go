@@ -0,0 +1,165 @@
+// Package events fans the change events of a todo.Service out to live
+// subscribers such as the SSE, WebSocket and GraphQL endpoints. The broker is
+// in-process: it sees the changes made through the service it is attached
+// to, not changes made by other processes sharing the backend.
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// DefaultBuffer is the number of events buffered per subscriber.
+const DefaultBuffer = 256
+
+var (
+	// ErrLagged ends a subscription that fell more than its buffer behind.
+	// Publishing never waits for slow subscribers; they are dropped instead
+	// and should resubscribe and reload what they show.
+	ErrLagged = errors.New("subscriber fell behind")
+
+	// ErrClosed ends every subscription when the broker is closed.
+	ErrClosed = errors.New("event broker closed")
+)
+
+// Broker is an in-process todo.Publisher with any number of subscribers.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// Option configures a Broker.
+type Option func(*Broker)
+
+// WithBuffer sets the number of events buffered per subscriber.
+func WithBuffer(n int) Option {
+	return func(b *Broker) {
+		if n > 0 {
+			b.buffer = n
+		}
+	}
+}
+
+// NewBroker creates a Broker.
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{
+		subs:   make(map[*Subscription]struct{}),
+		buffer: DefaultBuffer,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscription receives the events matching its filter in publish order.
+type Subscription struct {
+	broker *Broker
+	match  func(todo.Event) bool
+	events chan todo.Event
+	err    error
+}
+
+// Events returns the channel of events. It is closed when the subscription
+// ends; Err then reports why.
+func (s *Subscription) Events() <-chan todo.Event {
+	return s.events
+}
+
+// Err returns why the subscription ended: ErrLagged, ErrClosed or the error
+// of the subscribing context. It is nil while the subscription is active.
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.broker.remove(s, context.Canceled)
+}
+
+// Subscribe starts a subscription to the events accepted by match, or to
+// every event when match is nil. Only events of the tenant of ctx are
+// delivered. It ends when ctx is done.
+func (b *Broker) Subscribe(ctx context.Context, match func(todo.Event) bool) *Subscription {
+	sub := &Subscription{
+		broker: b,
+		match: func(e todo.Event) bool {
+			return e.VisibleTo(ctx) && (match == nil || match(e))
+		},
+		events: make(chan todo.Event, b.buffer),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		sub.err = ErrClosed
+		close(sub.events)
+		b.mu.Unlock()
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		b.remove(sub, ctx.Err())
+	})
+	return sub
+}
+
+// Publish delivers the event to every matching subscriber without blocking.
+// It implements todo.Publisher.
+func (b *Broker) Publish(ctx context.Context, event todo.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.end(sub, ErrLagged)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close ends every subscription with ErrClosed. Later subscriptions end
+// immediately.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.end(sub, ErrClosed)
+	}
+}
+
+func (b *Broker) remove(sub *Subscription, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		b.end(sub, err)
+	}
+}
+
+// end closes an active subscription. b.mu must be held.
+func (b *Broker) end(sub *Subscription, err error) {
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/stretchr/testify/require"
+)
+
+func event(typ todo.EventType, title string) todo.Event {
+	return todo.Event{Type: typ, Todo: &todo.Todo{Title: title}}
+}
+
+// drain returns the events still buffered in a subscription.
+func drain(sub *Subscription) []string {
+	var titles []string
+	for e := range sub.Events() {
+		titles = append(titles, e.Todo.Title)
+	}
+	return titles
+}
+
+func TestBroker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fans out to matching subscribers", func(t *testing.T) {
+		b := NewBroker()
+		all := b.Subscribe(ctx, nil)
+		deletions := b.Subscribe(ctx, func(e todo.Event) bool { return e.Type == todo.EventDeleted })
+
+		b.Publish(ctx, event(todo.EventCreated, "a"))
+		b.Publish(ctx, event(todo.EventDeleted, "b"))
+		b.Close()
+
+		require.Equal(t, []string{"a", "b"}, drain(all))
+		require.Equal(t, []string{"b"}, drain(deletions))
+		require.ErrorIs(t, all.Err(), ErrClosed)
+	})
+
+	t.Run("only delivers events of the subscriber's tenant", func(t *testing.T) {
+		b := NewBroker()
+		acme := b.Subscribe(todo.WithTenant(ctx, "acme"), nil)
+		untenanted := b.Subscribe(ctx, nil)
+
+		acmeEvent := event(todo.EventCreated, "a")
+		acmeEvent.Tenant = "acme"
+		otherEvent := event(todo.EventCreated, "b")
+		otherEvent.Tenant = "globex"
+
+		b.Publish(ctx, acmeEvent)
+		b.Publish(ctx, otherEvent)
+		b.Publish(ctx, event(todo.EventCreated, "c"))
+		b.Close()
+
+		require.Equal(t, []string{"a"}, drain(acme))
+		require.Equal(t, []string{"c"}, drain(untenanted))
+	})
+
+	t.Run("drops subscribers that fall behind", func(t *testing.T) {
+		b := NewBroker(WithBuffer(2))
+		slow := b.Subscribe(ctx, nil)
+
+		for _, title := range []string{"a", "b", "c", "d"} {
+			b.Publish(ctx, event(todo.EventCreated, title))
+		}
+
+		require.Equal(t, []string{"a", "b"}, drain(slow))
+		require.ErrorIs(t, slow.Err(), ErrLagged)
+		require.Zero(t, b.Subscribers())
+	})
+
+	t.Run("ends with the subscribing context", func(t *testing.T) {
+		b := NewBroker()
+		subCtx, cancel := context.WithCancel(ctx)
+		sub := b.Subscribe(subCtx, nil)
+		require.Equal(t, 1, b.Subscribers())
+		require.NoError(t, sub.Err())
+
+		cancel()
+		require.Empty(t, drain(sub))
+		require.ErrorIs(t, sub.Err(), context.Canceled)
+		require.Zero(t, b.Subscribers())
+	})
+
+	t.Run("subscribing after close", func(t *testing.T) {
+		b := NewBroker()
+		b.Close()
+		sub := b.Subscribe(ctx, nil)
+		require.Empty(t, drain(sub))
+		require.ErrorIs(t, sub.Err(), ErrClosed)
+	})
+}
+
+func TestBroker_ServiceEvents(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroker()
+	service := todo.NewService(filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json")), todo.WithPublisher(b))
+	sub := b.Subscribe(ctx, nil)
+
+	created, err := service.CreateTodo(ctx, "Write docs", "", []string{"docs"})
+	require.NoError(t, err)
+	title := "Write the docs"
+	_, err = service.UpdateTodo(ctx, created.ID.String(), todo.UpdateTodo{Title: &title})
+	require.NoError(t, err)
+	_, err = service.ChangeStatus(ctx, created.ID.String(), todo.StatusInProgress)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteTodo(ctx, created.ID.String()))
+	b.Close()
+
+	var got []todo.Event
+	for e := range sub.Events() {
+		got = append(got, e)
+	}
+	require.Len(t, got, 4)
+
+	require.Equal(t, todo.EventCreated, got[0].Type)
+	require.Equal(t, "Write docs", got[0].Todo.Title, "events hold a snapshot")
+	require.Equal(t, todo.EventUpdated, got[1].Type)
+	require.Equal(t, "Write the docs", got[1].Todo.Title)
+	require.Equal(t, todo.EventStatusChanged, got[2].Type)
+	require.Equal(t, todo.StatusPending, got[2].PreviousStatus)
+	require.Equal(t, todo.StatusInProgress, got[2].Todo.Status)
+	require.Equal(t, todo.EventDeleted, got[3].Type)
+	require.Equal(t, created.ID, got[3].Todo.ID)
+	require.Equal(t, []string{"docs"}, got[3].Todo.Labels)
+}
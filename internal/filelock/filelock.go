@@ -0,0 +1,35 @@
+// Package filelock serializes changes to a file shared by several processes,
+// such as the CLI and "todoify serve", with an exclusive lock on a sidecar
+// "<path>.lock" file. The lock is advisory: it only keeps out processes that
+// take it too.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock blocks until it holds the lock for the file at path, creating the
+// lock file and its directory when needed, and returns the function
+// releasing it.
+func Lock(path string) (func(), error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", lockPath, err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,81 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Error codes reported in the extensions of GraphQL errors.
+const (
+	codeNotFound      = "NOT_FOUND"
+	codeBadUserInput  = "BAD_USER_INPUT"
+	codeConflict      = "CONFLICT"
+	codeInvalidStatus = "INVALID_STATUS"
+	codeUnsupported   = "UNSUPPORTED"
+	codeForbidden     = "FORBIDDEN"
+	codeRateLimited   = "RATE_LIMITED"
+	codeInternal      = "INTERNAL_SERVER_ERROR"
+)
+
+// resolverError is a GraphQL error with a code and, for invalid input, the
+// per-field messages from todo.TranslateError in its extensions. Rate
+// limited errors carry the seconds to wait as retryAfter.
+type resolverError struct {
+	message    string
+	code       string
+	fields     map[string]string
+	retryAfter string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions implements the graphql-go extension interface.
+func (e *resolverError) Extensions() map[string]any {
+	ext := map[string]any{"code": e.code}
+	if len(e.fields) > 0 {
+		ext["fields"] = e.fields
+	}
+	if e.retryAfter != "" {
+		ext["retryAfter"] = e.retryAfter
+	}
+	return ext
+}
+
+// toError maps an error from the todo service to a GraphQL error. Unknown
+// errors are logged and reported without their details.
+func (s *Server) toError(err error) error {
+	e := &resolverError{message: err.Error()}
+
+	var exceeded *ratelimit.ExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		e.code = codeRateLimited
+		e.retryAfter = ratelimit.RetryAfter(exceeded.RetryAfter)
+	case errors.Is(err, todo.ErrNotFound):
+		e.code = codeNotFound
+	case errors.Is(err, todo.ErrInvalidInput):
+		e.code = codeBadUserInput
+		e.fields = todo.FieldMessages(err)
+	case errors.Is(err, todo.ErrConflict):
+		e.code = codeConflict
+	case errors.Is(err, todo.ErrInvalidStatus):
+		e.code = codeInvalidStatus
+	case errors.Is(err, todo.ErrUnsupported):
+		e.code = codeUnsupported
+	case errors.Is(err, todo.ErrForbidden):
+		e.code = codeForbidden
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		s.logger.Error("GraphQL request failed", "error", err)
+		e.code = codeInternal
+		e.message = "internal error"
+	}
+
+	return e
+}
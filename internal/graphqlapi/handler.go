@@ -0,0 +1,108 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxRequestBytes limits the size of a GraphQL request body.
+const maxRequestBytes = 1 << 20
+
+// request is a GraphQL request as sent over HTTP.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Handler returns the HTTP handler for the GraphQL endpoint. Requests must
+// be POSTed as application/json, which browsers cannot send cross-site
+// without a CORS preflight. Queries and mutations are answered with a JSON
+// response. Subscriptions require
+// "Accept: text/event-stream" and stream each result as a "next" event,
+// followed by a "complete" event when the subscription ends.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(s.serveHTTP)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "GraphQL requests must use POST")
+		return
+	}
+	// Other content types can be posted by any site, e.g. from a form
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "GraphQL requests must be application/json")
+		return
+	}
+
+	var req request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.subscribe(w, r, req)
+		return
+	}
+
+	resp := s.schema.Exec(s.withLoader(r.Context()), req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Error("failed to write GraphQL response", "error", err)
+	}
+}
+
+// subscribe streams the results of a subscription as server-sent events.
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request, req request) {
+	results, err := s.schema.Subscribe(s.withLoader(r.Context()), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("streaming is not supported", "error", err)
+		return
+	}
+
+	for result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			s.logger.Error("failed to encode GraphQL result", "error", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+
+	fmt.Fprint(w, "event: complete\ndata:\n\n")
+	_ = rc.Flush()
+}
+
+// writeError writes a request-level error in the GraphQL response format.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
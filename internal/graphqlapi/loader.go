@@ -0,0 +1,58 @@
+package graphqlapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long the loader collects IDs before fetching them in one
+// batch. Sibling fields resolve concurrently, so a short wait is enough.
+const loaderWait = 2 * time.Millisecond
+
+type loaderKey struct{}
+
+// withLoader returns a context with a request-scoped todo loader. Every todo
+// lookup in the request goes through it, so a query asking for many todos by
+// ID makes one batched repository request instead of one per todo.
+func (s *Server) withLoader(ctx context.Context) context.Context {
+	loader := dataloader.NewBatchedLoader(s.loadTodos, dataloader.WithWait[string, *todo.Todo](loaderWait))
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+// loadTodos is the batch function of the loader. Missing todos load as nil.
+func (s *Server) loadTodos(ctx context.Context, ids []string) []*dataloader.Result[*todo.Todo] {
+	results := make([]*dataloader.Result[*todo.Todo], len(ids))
+	todos, err := s.service.GetTodos(ctx, ids)
+	for i := range ids {
+		if err != nil {
+			results[i] = &dataloader.Result[*todo.Todo]{Error: err}
+			continue
+		}
+		results[i] = &dataloader.Result[*todo.Todo]{Data: todos[i]}
+	}
+	return results
+}
+
+// loadTodo loads a todo through the request's loader, falling back to a
+// direct lookup outside of a request. Missing todos are nil without an error.
+func (s *Server) loadTodo(ctx context.Context, id string) (*todo.Todo, error) {
+	loader, ok := ctx.Value(loaderKey{}).(*dataloader.Loader[string, *todo.Todo])
+	if !ok {
+		todos, err := s.service.GetTodos(ctx, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		return todos[0], nil
+	}
+	return loader.Load(ctx, id)()
+}
+
+// forget drops a todo from the request's loader after a mutation changed it.
+func (s *Server) forget(ctx context.Context, id string) {
+	if loader, ok := ctx.Value(loaderKey{}).(*dataloader.Loader[string, *todo.Todo]); ok {
+		loader.Clear(ctx, id)
+	}
+}
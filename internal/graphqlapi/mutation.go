@@ -0,0 +1,114 @@
+package graphqlapi
+
+import (
+	"context"
+
+	"github.com/MattDevy/es-todoify/internal/auth"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/graph-gophers/graphql-go"
+)
+
+type createTodoInput struct {
+	Title       string
+	Description *string
+	Labels      *[]string
+	Fields      *JSON
+}
+
+// CreateTodo resolves createTodo(input).
+func (r *resolver) CreateTodo(ctx context.Context, args struct{ Input createTodoInput }) (*todoResolver, error) {
+	if err := auth.Require(ctx, todo.ScopeWrite); err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	in := args.Input
+	var description string
+	if in.Description != nil {
+		description = *in.Description
+	}
+	var labels []string
+	if in.Labels != nil {
+		labels = *in.Labels
+	}
+	var fields map[string]any
+	if in.Fields != nil {
+		fields = *in.Fields
+	}
+
+	created, err := r.server.service.CreateTodo(ctx, in.Title, description, labels, todo.WithFields(fields))
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	return &todoResolver{created}, nil
+}
+
+type updateTodoInput struct {
+	Title       *string
+	Description *string
+	Labels      *[]string
+	Fields      *JSON
+}
+
+// UpdateTodo resolves updateTodo(id, input).
+func (r *resolver) UpdateTodo(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateTodoInput
+}) (*todoResolver, error) {
+	if err := auth.Require(ctx, todo.ScopeWrite); err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	in := args.Input
+	update := todo.UpdateTodo{
+		Title:       in.Title,
+		Description: in.Description,
+	}
+	if in.Labels != nil {
+		update.Labels = *in.Labels
+	}
+	if in.Fields != nil {
+		update.Fields = *in.Fields
+	}
+
+	updated, err := r.server.service.UpdateTodo(ctx, string(args.ID), update)
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	r.server.forget(ctx, string(args.ID))
+	return &todoResolver{updated}, nil
+}
+
+// ChangeStatus resolves changeStatus(id, status).
+func (r *resolver) ChangeStatus(ctx context.Context, args struct {
+	ID     graphql.ID
+	Status string
+}) (*todoResolver, error) {
+	if err := auth.Require(ctx, todo.ScopeWrite); err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	updated, err := r.server.service.ChangeStatus(ctx, string(args.ID), statusFromEnum(args.Status))
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	r.server.forget(ctx, string(args.ID))
+	return &todoResolver{updated}, nil
+}
+
+// DeleteTodo resolves deleteTodo(id).
+func (r *resolver) DeleteTodo(ctx context.Context, args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if err := auth.Require(ctx, todo.ScopeWrite); err != nil {
+		return "", r.server.toError(err)
+	}
+
+	id := string(args.ID)
+	if err := r.server.service.DeleteTodo(ctx, id); err != nil {
+		return "", r.server.toError(err)
+	}
+
+	r.server.forget(ctx, id)
+	return args.ID, nil
+}
@@ -0,0 +1,395 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// defaultPageSize is the page size of todos when first is not given.
+const defaultPageSize = 50
+
+// resolver is the root resolver for queries, mutations and subscriptions.
+type resolver struct {
+	server *Server
+}
+
+// JSON is the JSON scalar holding custom field values.
+type JSON map[string]any
+
+// ImplementsGraphQLType maps JSON to the JSON scalar.
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL accepts a JSON object.
+func (j *JSON) UnmarshalGraphQL(input any) error {
+	m, ok := input.(map[string]any)
+	if !ok {
+		return fmt.Errorf("JSON must be an object, got %T", input)
+	}
+	*j = m
+	return nil
+}
+
+var sortFields = map[string]todo.SortField{
+	"CREATE_TIME": todo.SortFieldCreateTime,
+	"UPDATE_TIME": todo.SortFieldUpdateTime,
+	"TITLE":       todo.SortFieldTitle,
+	"STATUS":      todo.SortFieldStatus,
+	"RELEVANCE":   todo.SortFieldRelevance,
+}
+
+// statusFromEnum converts a Status enum value such as IN_PROGRESS.
+func statusFromEnum(v string) todo.Status {
+	return todo.Status(strings.ToLower(v))
+}
+
+// listFilterInput is the ListFilter input type.
+type listFilterInput struct {
+	Statuses        *[]string
+	ExcludeStatuses *[]string
+	Labels          *[]string
+	AnyLabels       *[]string
+	ExcludeLabels   *[]string
+	Search          *string
+	From            *graphql.Time
+	To              *graphql.Time
+	Where           *[]string
+	Query           *string
+}
+
+// toFilter converts the input. A nil input selects every todo.
+func (in *listFilterInput) toFilter() (todo.ListFilter, error) {
+	var filter todo.ListFilter
+	if in == nil {
+		return filter, nil
+	}
+
+	if in.Statuses != nil {
+		for _, v := range *in.Statuses {
+			filter.Statuses = append(filter.Statuses, statusFromEnum(v))
+		}
+	}
+	if in.ExcludeStatuses != nil {
+		for _, v := range *in.ExcludeStatuses {
+			filter.ExcludeStatuses = append(filter.ExcludeStatuses, statusFromEnum(v))
+		}
+	}
+	if in.Labels != nil {
+		filter.Labels = *in.Labels
+	}
+	if in.AnyLabels != nil {
+		filter.AnyLabels = *in.AnyLabels
+	}
+	if in.ExcludeLabels != nil {
+		filter.ExcludeLabels = *in.ExcludeLabels
+	}
+	if in.Search != nil {
+		filter.SearchQuery = *in.Search
+	}
+	if in.From != nil {
+		filter.FromDate = &in.From.Time
+	}
+	if in.To != nil {
+		filter.ToDate = &in.To.Time
+	}
+
+	if in.Where != nil {
+		for _, expr := range *in.Where {
+			cond, err := todo.ParseFieldCondition(expr)
+			if err != nil {
+				return filter, err
+			}
+			filter.Where = append(filter.Where, cond)
+		}
+	}
+
+	if in.Query != nil && *in.Query != "" {
+		q, err := todo.ParseQuery(*in.Query)
+		if err != nil {
+			return filter, err
+		}
+		filter.Query = q
+	}
+
+	return filter, nil
+}
+
+// Todo resolves todo(id). Missing todos resolve to null.
+func (r *resolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	id := string(args.ID)
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, r.server.toError(fmt.Errorf("%w: invalid id format", todo.ErrInvalidInput))
+	}
+
+	t, err := r.server.loadTodo(ctx, id)
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+	if t == nil {
+		return nil, nil
+	}
+	return &todoResolver{t}, nil
+}
+
+// TodosByIds resolves todosByIds(ids). Every ID goes through the loader, so
+// they are fetched in one batch.
+func (r *resolver) TodosByIds(ctx context.Context, args struct{ IDs []graphql.ID }) ([]*todoResolver, error) {
+	ids := make([]string, len(args.IDs))
+	for i, id := range args.IDs {
+		ids[i] = string(id)
+		if _, err := uuid.Parse(ids[i]); err != nil {
+			return nil, r.server.toError(fmt.Errorf("%w: invalid id format %q", todo.ErrInvalidInput, ids[i]))
+		}
+	}
+
+	type loaded struct {
+		t   *todo.Todo
+		err error
+	}
+	results := make([]loaded, len(ids))
+	done := make(chan struct{})
+	for i, id := range ids {
+		go func() {
+			t, err := r.server.loadTodo(ctx, id)
+			results[i] = loaded{t, err}
+			done <- struct{}{}
+		}()
+	}
+	for range ids {
+		<-done
+	}
+
+	resolvers := make([]*todoResolver, len(ids))
+	for i, res := range results {
+		if res.err != nil {
+			return nil, r.server.toError(res.err)
+		}
+		if res.t != nil {
+			resolvers[i] = &todoResolver{res.t}
+		}
+	}
+	return resolvers, nil
+}
+
+type todosArgs struct {
+	Filter    *listFilterInput
+	First     *int32
+	After     *string
+	SortBy    *string
+	SortOrder *string
+}
+
+// Todos resolves todos(...) as a connection backed by cursor pagination.
+func (r *resolver) Todos(ctx context.Context, args todosArgs) (*connectionResolver, error) {
+	filter, err := args.Filter.toFilter()
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+	// Like the REST API's search and q parameters, searches are expensive
+	if filter.SearchQuery != "" || filter.Query != nil {
+		if err := ratelimit.Take(ctx, ratelimit.Expensive); err != nil {
+			return nil, r.server.toError(err)
+		}
+	}
+
+	filter.Limit = defaultPageSize
+	if args.First != nil {
+		if *args.First < 0 {
+			return nil, r.server.toError(fmt.Errorf("%w: first must not be negative", todo.ErrInvalidInput))
+		}
+		filter.Limit = int(*args.First)
+	}
+	if args.SortBy != nil {
+		filter.SortBy = sortFields[*args.SortBy]
+	}
+	if args.SortOrder != nil {
+		filter.SortOrder = todo.SortOrder(strings.ToLower(*args.SortOrder))
+	}
+
+	countFilter := filter
+	if args.After != nil {
+		filter.Cursor = *args.After
+	}
+
+	page, err := r.server.service.ListTodosPage(ctx, filter)
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+	return &connectionResolver{server: r.server, page: page, filter: countFilter}, nil
+}
+
+// Count resolves count(filter).
+func (r *resolver) Count(ctx context.Context, args struct{ Filter *listFilterInput }) (int32, error) {
+	filter, err := args.Filter.toFilter()
+	if err != nil {
+		return 0, r.server.toError(err)
+	}
+	if err := ratelimit.Take(ctx, ratelimit.Expensive); err != nil {
+		return 0, r.server.toError(err)
+	}
+
+	count, err := r.server.service.CountTodos(ctx, filter)
+	if err != nil {
+		return 0, r.server.toError(err)
+	}
+	return int32(count), nil
+}
+
+// Health resolves health.
+func (r *resolver) Health(ctx context.Context) (*healthResolver, error) {
+	info, err := r.server.service.Health(ctx)
+	if info == nil {
+		if err == nil {
+			err = fmt.Errorf("no health information")
+		}
+		return nil, r.server.toError(err)
+	}
+	return &healthResolver{
+		status:       string(info.Status),
+		available:    info.Available,
+		responseTime: info.ResponseTime.String(),
+		version:      info.Version,
+	}, nil
+}
+
+// todoResolver resolves the Todo type.
+type todoResolver struct {
+	t *todo.Todo
+}
+
+func (r *todoResolver) ID() graphql.ID {
+	return graphql.ID(r.t.ID.String())
+}
+
+func (r *todoResolver) Title() string {
+	return r.t.Title
+}
+
+func (r *todoResolver) Description() *string {
+	if r.t.Description == "" {
+		return nil
+	}
+	return &r.t.Description
+}
+
+func (r *todoResolver) Labels() []string {
+	if r.t.Labels == nil {
+		return []string{}
+	}
+	return r.t.Labels
+}
+
+func (r *todoResolver) Status() string {
+	return strings.ToUpper(string(r.t.Status))
+}
+
+func (r *todoResolver) CreateTime() graphql.Time {
+	return graphql.Time{Time: r.t.CreateTime}
+}
+
+func (r *todoResolver) UpdateTime() graphql.Time {
+	return graphql.Time{Time: r.t.UpdateTime}
+}
+
+func (r *todoResolver) Fields() *JSON {
+	if len(r.t.Fields) == 0 {
+		return nil
+	}
+	fields := JSON(r.t.Fields)
+	return &fields
+}
+
+// connectionResolver resolves TodoConnection for one page.
+type connectionResolver struct {
+	server *Server
+	page   *todo.Page
+	filter todo.ListFilter
+}
+
+func (r *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(r.page.Todos))
+	for i, t := range r.page.Todos {
+		edges[i] = &edgeResolver{&todoResolver{t}}
+	}
+	return edges
+}
+
+func (r *connectionResolver) Nodes() []*todoResolver {
+	nodes := make([]*todoResolver, len(r.page.Todos))
+	for i, t := range r.page.Todos {
+		nodes[i] = &todoResolver{t}
+	}
+	return nodes
+}
+
+func (r *connectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{r.page.NextCursor}
+}
+
+// TotalCount counts the matching todos; it is only queried when selected.
+func (r *connectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	if err := ratelimit.Take(ctx, ratelimit.Expensive); err != nil {
+		return 0, r.server.toError(err)
+	}
+	count, err := r.server.service.CountTodos(ctx, r.filter)
+	if err != nil {
+		return 0, r.server.toError(err)
+	}
+	return int32(count), nil
+}
+
+type edgeResolver struct {
+	node *todoResolver
+}
+
+func (r *edgeResolver) Node() *todoResolver {
+	return r.node
+}
+
+type pageInfoResolver struct {
+	nextCursor string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.nextCursor != ""
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	if r.nextCursor == "" {
+		return nil
+	}
+	return &r.nextCursor
+}
+
+type healthResolver struct {
+	status       string
+	available    bool
+	responseTime string
+	version      string
+}
+
+func (r *healthResolver) Status() string {
+	return r.status
+}
+
+func (r *healthResolver) Available() bool {
+	return r.available
+}
+
+func (r *healthResolver) ResponseTime() string {
+	return r.responseTime
+}
+
+func (r *healthResolver) Version() *string {
+	if r.version == "" {
+		return nil
+	}
+	return &r.version
+}
@@ -0,0 +1,74 @@
+// Package graphqlapi serves todo.Service as a GraphQL API with queries,
+// connection-style pagination, mutations and subscriptions.
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"log/slog"
+
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Schema is the GraphQL schema served by the API.
+//
+//go:embed schema.graphql
+var Schema string
+
+// maxDepth limits how deeply queries may nest.
+const maxDepth = 10
+
+// Server is the GraphQL API for a todo service.
+type Server struct {
+	service *todo.Service
+	logger  *slog.Logger
+	events  *events.Broker
+	schema  *graphql.Schema
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger used for internal errors and panics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithEvents enables the todoChanged subscription with the events of the
+// broker, which should be the publisher of the server's service.
+func WithEvents(broker *events.Broker) Option {
+	return func(s *Server) {
+		s.events = broker
+	}
+}
+
+// NewServer creates a Server for the service.
+func NewServer(service *todo.Service, opts ...Option) *Server {
+	s := &Server{
+		service: service,
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.schema = graphql.MustParseSchema(Schema, &resolver{server: s},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(maxDepth),
+		graphql.Logger(panicLogger{s.logger}),
+	)
+	return s
+}
+
+// panicLogger reports resolver panics through slog.
+type panicLogger struct {
+	logger *slog.Logger
+}
+
+func (l panicLogger) LogPanic(ctx context.Context, value any) {
+	l.logger.Error("panic resolving GraphQL request", "panic", value)
+}
@@ -0,0 +1,413 @@
+package graphqlapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts batched lookups.
+type countingRepository struct {
+	*filerepo.Repository
+	batches atomic.Int32
+}
+
+func (r *countingRepository) GetMany(ctx context.Context, ids []string) ([]*todo.Todo, error) {
+	r.batches.Add(1)
+	return r.Repository.GetMany(ctx, ids)
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *todo.Service, *countingRepository) {
+	t.Helper()
+	repo := &countingRepository{Repository: testutil.NewFileRepository(t)}
+	broker := events.NewBroker()
+	service := todo.NewService(repo, todo.WithPublisher(broker))
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithEvents(broker)).Handler())
+	t.Cleanup(broker.Close)
+	return srv, service, repo
+}
+
+type gqlError struct {
+	Message    string         `json:"message"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []gqlError      `json:"errors"`
+}
+
+// exec sends a GraphQL request and decodes its data into out.
+func exec(t *testing.T, srv *httptest.Server, query string, variables map[string]any, out any) gqlResponse {
+	t.Helper()
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	require.NoError(t, err)
+
+	resp, err := srv.Client().Post(srv.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var res gqlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	if out != nil {
+		require.Empty(t, res.Errors)
+		require.NoError(t, json.Unmarshal(res.Data, out))
+	}
+	return res
+}
+
+type todoData struct {
+	ID          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description *string        `json:"description"`
+	Labels      []string       `json:"labels"`
+	Status      string         `json:"status"`
+	Fields      map[string]any `json:"fields"`
+}
+
+const todoFields = `id title description labels status fields`
+
+func createTodo(t *testing.T, srv *httptest.Server, title string, labels ...string) todoData {
+	t.Helper()
+	var out struct{ CreateTodo todoData }
+	exec(t, srv, `mutation($input: CreateTodoInput!) { createTodo(input: $input) { `+todoFields+` } }`,
+		map[string]any{"input": map[string]any{"title": title, "labels": labels}}, &out)
+	return out.CreateTodo
+}
+
+func TestServer_Mutations(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	created := createTodo(t, srv, "Write docs", "docs")
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, "PENDING", created.Status)
+	require.Equal(t, []string{"docs"}, created.Labels)
+	require.Nil(t, created.Description)
+
+	var updated struct{ UpdateTodo todoData }
+	exec(t, srv, `mutation($id: ID!) { updateTodo(id: $id, input: {description: "all of them"}) { `+todoFields+` } }`,
+		map[string]any{"id": created.ID}, &updated)
+	require.Equal(t, "Write docs", updated.UpdateTodo.Title)
+	require.Equal(t, "all of them", *updated.UpdateTodo.Description)
+
+	var changed struct{ ChangeStatus todoData }
+	exec(t, srv, `mutation($id: ID!) { changeStatus(id: $id, status: IN_PROGRESS) { status } }`,
+		map[string]any{"id": created.ID}, &changed)
+	require.Equal(t, "IN_PROGRESS", changed.ChangeStatus.Status)
+
+	var got struct{ Todo *todoData }
+	exec(t, srv, `query($id: ID!) { todo(id: $id) { `+todoFields+` } }`, map[string]any{"id": created.ID}, &got)
+	require.Equal(t, "IN_PROGRESS", got.Todo.Status)
+
+	var deleted struct{ DeleteTodo string }
+	exec(t, srv, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]any{"id": created.ID}, &deleted)
+	require.Equal(t, created.ID, deleted.DeleteTodo)
+
+	exec(t, srv, `query($id: ID!) { todo(id: $id) { id } }`, map[string]any{"id": created.ID}, &got)
+	require.Nil(t, got.Todo)
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	created := createTodo(t, srv, "Ship it")
+	exec(t, srv, `mutation($id: ID!) { changeStatus(id: $id, status: COMPLETED) { id } }`,
+		map[string]any{"id": created.ID}, nil)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		code      string
+		fields    []string
+	}{
+		{
+			name:  "malformed id",
+			query: `{ todo(id: "nope") { id } }`,
+			code:  codeBadUserInput,
+		},
+		{
+			name:  "unknown todo",
+			query: `mutation { deleteTodo(id: "5b1f7a9e-3f0c-4a51-9d5e-2f1c0a7b8e11") }`,
+			code:  codeNotFound,
+		},
+		{
+			name:      "invalid update",
+			query:     `mutation($id: ID!) { updateTodo(id: $id, input: {title: ""}) { id } }`,
+			variables: map[string]any{"id": created.ID},
+			code:      codeBadUserInput,
+			fields:    []string{"Title"},
+		},
+		{
+			name:      "disallowed transition",
+			query:     `mutation($id: ID!) { changeStatus(id: $id, status: BLOCKED) { id } }`,
+			variables: map[string]any{"id": created.ID},
+			code:      codeInvalidStatus,
+		},
+		{
+			name:  "invalid query language",
+			query: `{ count(filter: {query: "status:"}) }`,
+			code:  codeBadUserInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := exec(t, srv, tt.query, tt.variables, nil)
+			require.Len(t, res.Errors, 1)
+			require.Equal(t, tt.code, res.Errors[0].Extensions["code"])
+			for _, field := range tt.fields {
+				require.Contains(t, res.Errors[0].Extensions["fields"], field)
+			}
+		})
+	}
+
+	t.Run("schema validation", func(t *testing.T) {
+		res := exec(t, srv, `{ todo(id: "x") { nope } }`, nil, nil)
+		require.NotEmpty(t, res.Errors)
+	})
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	service := testutil.NewFileService(t)
+	limiter, err := ratelimit.New(ratelimit.WithLimit(ratelimit.Expensive, ratelimit.Limit{Rate: 0.01, Burst: 1}))
+	require.NoError(t, err)
+	h := NewServer(service, WithLogger(testutil.Logger())).Handler()
+	// The API server adds the quota of each client
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ratelimit.WithQuota(r.Context(), func(class ratelimit.Class) (bool, time.Duration) {
+			return limiter.Allow("alice", class)
+		})
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name        string
+		query       string
+		variables   map[string]any
+		rateLimited bool
+	}{
+		{"list", `{ todos { nodes { id } } }`, nil, false},
+		{"search", `{ todos(filter: {search: "docs"}) { nodes { id } } }`, nil, false},
+		{"count", `{ count }`, nil, true},
+		{"total count", `{ todos { totalCount } }`, nil, true},
+		{"query in variables", `query($f: ListFilter) { todos(filter: $f) { nodes { id } } }`, map[string]any{"f": map[string]any{"query": "status:pending"}}, true},
+		{"list again", `{ todos(filter: {labels: ["docs"]}) { nodes { id } } }`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := exec(t, srv, tt.query, tt.variables, nil)
+			if !tt.rateLimited {
+				require.Empty(t, res.Errors)
+				return
+			}
+			require.Len(t, res.Errors, 1)
+			require.Equal(t, codeRateLimited, res.Errors[0].Extensions["code"])
+			require.NotEmpty(t, res.Errors[0].Extensions["retryAfter"])
+		})
+	}
+}
+
+func TestServer_Connection(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		createTodo(t, srv, title, "paged")
+	}
+	createTodo(t, srv, "other")
+
+	type page struct {
+		Todos struct {
+			Edges []struct {
+				Node todoData `json:"node"`
+			} `json:"edges"`
+			PageInfo struct {
+				HasNextPage bool    `json:"hasNextPage"`
+				EndCursor   *string `json:"endCursor"`
+			} `json:"pageInfo"`
+			TotalCount int `json:"totalCount"`
+		}
+	}
+	query := `query($after: String) {
+		todos(filter: {labels: ["paged"]}, first: 2, after: $after, sortBy: TITLE, sortOrder: ASC) {
+			edges { node { title } }
+			pageInfo { hasNextPage endCursor }
+			totalCount
+		}
+	}`
+
+	var titles []string
+	variables := map[string]any{}
+	for {
+		var out page
+		exec(t, srv, query, variables, &out)
+		require.Equal(t, 5, out.Todos.TotalCount)
+		for _, e := range out.Todos.Edges {
+			titles = append(titles, e.Node.Title)
+		}
+		if !out.Todos.PageInfo.HasNextPage {
+			require.Nil(t, out.Todos.PageInfo.EndCursor)
+			break
+		}
+		variables["after"] = *out.Todos.PageInfo.EndCursor
+	}
+	require.Equal(t, []string{"a", "b", "c", "d", "e"}, titles)
+
+	var count struct{ Count int }
+	exec(t, srv, `{ count(filter: {query: "labels:paged AND title:a"}) }`, nil, &count)
+	require.Equal(t, 1, count.Count)
+}
+
+func TestServer_BatchedLoading(t *testing.T) {
+	srv, _, repo := newTestServer(t)
+	first := createTodo(t, srv, "first")
+	second := createTodo(t, srv, "second")
+	missing := "5b1f7a9e-3f0c-4a51-9d5e-2f1c0a7b8e11"
+
+	var out struct {
+		A     *todoData
+		B     *todoData
+		ByIDs []*todoData
+	}
+	exec(t, srv, `query($a: ID!, $b: ID!, $ids: [ID!]!) {
+		a: todo(id: $a) { title }
+		b: todo(id: $b) { title }
+		byIDs: todosByIds(ids: $ids) { title }
+	}`, map[string]any{
+		"a":   first.ID,
+		"b":   second.ID,
+		"ids": []string{second.ID, missing, first.ID},
+	}, &out)
+
+	require.Equal(t, "first", out.A.Title)
+	require.Equal(t, "second", out.B.Title)
+	require.Len(t, out.ByIDs, 3)
+	require.Equal(t, "second", out.ByIDs[0].Title)
+	require.Nil(t, out.ByIDs[1])
+	require.Equal(t, "first", out.ByIDs[2].Title)
+	require.EqualValues(t, 1, repo.batches.Load(), "all lookups should share one batch")
+}
+
+func TestServer_Subscription(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(request{Query: `subscription { todoChanged(filter: {labels: ["watched"]}) { type id previousStatus todo { title status } } }`})
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The subscription is registered once the headers are flushed
+	createTodo(t, srv, "ignored")
+	watched := createTodo(t, srv, "watched", "watched")
+	exec(t, srv, `mutation($id: ID!) { changeStatus(id: $id, status: BLOCKED) { id } }`, map[string]any{"id": watched.ID}, nil)
+	exec(t, srv, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]any{"id": watched.ID}, nil)
+
+	type event struct {
+		Data struct {
+			TodoChanged struct {
+				Type           string   `json:"type"`
+				ID             string   `json:"id"`
+				PreviousStatus *string  `json:"previousStatus"`
+				Todo           todoData `json:"todo"`
+			} `json:"todoChanged"`
+		} `json:"data"`
+	}
+
+	var events []event
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < 3 && scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e event
+		require.NoError(t, json.Unmarshal([]byte(data), &e))
+		events = append(events, e)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, events, 3)
+
+	require.Equal(t, "CREATED", events[0].Data.TodoChanged.Type)
+	require.Equal(t, "watched", events[0].Data.TodoChanged.Todo.Title)
+	require.Nil(t, events[0].Data.TodoChanged.PreviousStatus)
+	require.Equal(t, "STATUS_CHANGED", events[1].Data.TodoChanged.Type)
+	require.Equal(t, "PENDING", *events[1].Data.TodoChanged.PreviousStatus)
+	require.Equal(t, "BLOCKED", events[1].Data.TodoChanged.Todo.Status)
+	require.Equal(t, "DELETED", events[2].Data.TodoChanged.Type)
+	require.Equal(t, watched.ID, events[2].Data.TodoChanged.ID)
+}
+
+func TestServer_Handler(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	t.Run("GET is not allowed", func(t *testing.T) {
+		resp, err := srv.Client().Get(srv.URL + "?query={count}")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		require.Equal(t, http.MethodPost, resp.Header.Get("Allow"))
+	})
+
+	t.Run("cross-site text/plain mutation", func(t *testing.T) {
+		created := createTodo(t, srv, "Keep me")
+		body, err := json.Marshal(request{Query: `mutation($id: ID!) { deleteTodo(id: $id) }`, Variables: map[string]any{"id": created.ID}})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+		var out struct{ Todo *todoData }
+		exec(t, srv, `query($id: ID!) { todo(id: $id) { id } }`, map[string]any{"id": created.ID}, &out)
+		require.NotNil(t, out.Todo)
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		resp, err := srv.Client().Post(srv.URL, "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("health", func(t *testing.T) {
+		var out struct {
+			Health struct {
+				Status    string `json:"status"`
+				Available bool   `json:"available"`
+			}
+		}
+		exec(t, srv, `{ health { status available responseTime } }`, nil, &out)
+		require.True(t, out.Health.Available)
+		require.Equal(t, "healthy", out.Health.Status)
+	})
+}
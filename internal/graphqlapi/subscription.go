@@ -0,0 +1,82 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// TodoChanged resolves the todoChanged(filter) subscription. It delivers
+// changes to todos matching the filter until the client goes away or the
+// event broker ends the subscription.
+func (r *resolver) TodoChanged(ctx context.Context, args struct{ Filter *listFilterInput }) (<-chan *changeResolver, error) {
+	if r.server.events == nil {
+		return nil, r.server.toError(fmt.Errorf("%w: subscriptions are not enabled", todo.ErrUnsupported))
+	}
+
+	filter, err := args.Filter.toFilter()
+	if err != nil {
+		return nil, r.server.toError(err)
+	}
+	if filter, err = r.server.service.ResolveFilter(ctx, filter); err != nil {
+		return nil, r.server.toError(err)
+	}
+
+	sub := r.server.events.Subscribe(ctx, func(e todo.Event) bool {
+		return filter.Matches(e.Todo)
+	})
+	out := make(chan *changeResolver)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		for e := range sub.Events() {
+			select {
+			case out <- &changeResolver{e}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// changeResolver resolves the TodoChange type.
+type changeResolver struct {
+	e todo.Event
+}
+
+// Type returns the ChangeType enum value, e.g. STATUS_CHANGED.
+func (r *changeResolver) Type() string {
+	return strings.ToUpper(string(r.e.Type))
+}
+
+func (r *changeResolver) ID() graphql.ID {
+	return graphql.ID(r.e.Todo.ID.String())
+}
+
+func (r *changeResolver) Todo() *todoResolver {
+	return &todoResolver{r.e.Todo}
+}
+
+func (r *changeResolver) PreviousStatus() *string {
+	if r.e.PreviousStatus == "" {
+		return nil
+	}
+	status := strings.ToUpper(string(r.e.PreviousStatus))
+	return &status
+}
+
+func (r *changeResolver) Actor() *string {
+	if r.e.Actor == "" {
+		return nil
+	}
+	return &r.e.Actor
+}
+
+func (r *changeResolver) Time() graphql.Time {
+	return graphql.Time{Time: r.e.Time}
+}
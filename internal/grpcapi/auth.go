@@ -0,0 +1,89 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/MattDevy/es-todoify/internal/auth"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	pb "github.com/MattDevy/es-todoify/pkg/todoifyv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Authenticator verifies the bearer credential of a call, as
+// auth.Authenticator does.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*todo.Principal, error)
+}
+
+// WithAuthenticator requires every TodoService call to carry an
+// "authorization: Bearer" metadata credential accepted by a, and the scope
+// the method needs. The health and reflection services stay public.
+func WithAuthenticator(a Authenticator) Option {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// methodScopes is the scope each TodoService method requires.
+var methodScopes = map[string]todo.Scope{
+	pb.TodoService_Create_FullMethodName:       todo.ScopeWrite,
+	pb.TodoService_Get_FullMethodName:          todo.ScopeRead,
+	pb.TodoService_Update_FullMethodName:       todo.ScopeWrite,
+	pb.TodoService_ChangeStatus_FullMethodName: todo.ScopeWrite,
+	pb.TodoService_Delete_FullMethodName:       todo.ScopeWrite,
+	pb.TodoService_List_FullMethodName:         todo.ScopeRead,
+	pb.TodoService_Count_FullMethodName:        todo.ScopeRead,
+}
+
+// authenticate returns the context of a call with its principal, or the
+// error to fail the call with.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	scope, ok := methodScopes[method]
+	if s.auth == nil || !ok {
+		return ctx, nil
+	}
+
+	var credential string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			credential = auth.BearerToken(values[0])
+		}
+	}
+
+	p, err := s.auth.Authenticate(ctx, credential)
+	if err != nil {
+		return nil, s.toError(method, err)
+	}
+	ctx = todo.WithPrincipal(ctx, p)
+	if err := auth.Require(ctx, scope); err != nil {
+		return nil, s.toError(method, err)
+	}
+	return ctx, nil
+}
+
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream is a server stream whose context carries the principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
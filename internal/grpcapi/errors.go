@@ -0,0 +1,79 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFor maps an error from the todo service to a gRPC status. Invalid
+// input carries the per-field messages from todo.TranslateError as
+// google.rpc.BadRequest field violations. Unknown errors are internal errors
+// whose details are not exposed.
+func statusFor(err error) *status.Status {
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, todo.ErrInvalidInput):
+		st := status.New(codes.InvalidArgument, err.Error())
+		violations := fieldViolations(err)
+		if len(violations) == 0 {
+			return st
+		}
+		if detailed, derr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); derr == nil {
+			return detailed
+		}
+		return st
+	case errors.Is(err, todo.ErrConflict):
+		return status.New(codes.AlreadyExists, err.Error())
+	case errors.Is(err, todo.ErrInvalidStatus):
+		return status.New(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, todo.ErrUnsupported):
+		return status.New(codes.Unimplemented, err.Error())
+	case errors.Is(err, todo.ErrUnauthenticated):
+		return status.New(codes.Unauthenticated, err.Error())
+	case errors.Is(err, todo.ErrForbidden):
+		return status.New(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.New(codes.Internal, "internal error")
+	}
+}
+
+// fieldViolations returns the per-field validation messages of an error,
+// sorted by field, or nil when it carries none beyond its own message.
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	errs := todo.FieldMessages(err)
+	if errs == nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+	for _, field := range fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: errs[field]})
+	}
+	return violations
+}
+
+// toError converts a service error to a gRPC error, logging unexpected ones.
+func (s *Server) toError(method string, err error) error {
+	st := statusFor(err)
+	if st.Code() == codes.Internal {
+		s.logger.Error("request failed", "method", method, "error", err)
+	}
+	return st.Err()
+}
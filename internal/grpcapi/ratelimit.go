@@ -0,0 +1,148 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	pb "github.com/MattDevy/es-todoify/pkg/todoifyv1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimiter decides whether a client may make a call, as
+// ratelimit.Limiter does.
+type RateLimiter interface {
+	Allow(client string, class ratelimit.Class) (bool, time.Duration)
+}
+
+// WithRateLimiter throttles TodoService calls like the HTTP API does. Clients
+// are authenticated principals, or peer IP addresses when authentication is
+// disabled. Counts, and lists that search, are ratelimit.Expensive;
+// everything else is ratelimit.Standard. With authentication, every call is
+// also counted per peer address as ratelimit.Address before its credential
+// is checked. Throttled calls fail with ResourceExhausted and a
+// google.rpc.RetryInfo. The health and reflection services are not limited.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(s *Server) {
+		s.limiter = l
+	}
+}
+
+// allow takes a token for a call, or returns the error to fail it with.
+func (s *Server) allow(client string, class ratelimit.Class) error {
+	ok, retry := s.limiter.Allow(client, class)
+	if ok {
+		return nil
+	}
+	exceeded := &ratelimit.ExceededError{Class: class, RetryAfter: retry}
+	st := status.New(codes.ResourceExhausted, exceeded.Error())
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retry)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// throttled reports whether calls of a method are rate limited.
+func (s *Server) throttled(method string) bool {
+	_, ok := methodScopes[method]
+	return s.limiter != nil && ok
+}
+
+// limitAddress checks the ratelimit.Address limit of a call before it is
+// authenticated, so guessing credentials is throttled too.
+func (s *Server) limitAddress(ctx context.Context, method string) error {
+	if s.auth == nil || !s.throttled(method) {
+		return nil
+	}
+	return s.allow(address(ctx), ratelimit.Address)
+}
+
+func (s *Server) limitAddressUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.limitAddress(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) limitAddressStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.limitAddress(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (s *Server) limitUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.throttled(info.FullMethod) {
+		if err := s.allow(client(ctx), callClass(req)); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) limitStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !s.throttled(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	return handler(srv, &limitedStream{ServerStream: ss, server: s})
+}
+
+// limitedStream takes a token for the request of a server stream once it is
+// received, since its class depends on the request.
+type limitedStream struct {
+	grpc.ServerStream
+	server *Server
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.server.allow(client(s.Context()), callClass(m))
+}
+
+// callClass returns the rate limit class of a request: counts, and lists
+// that search, are expensive.
+func callClass(req any) ratelimit.Class {
+	switch req := req.(type) {
+	case *pb.CountRequest:
+		return ratelimit.Expensive
+	case *pb.ListRequest:
+		if f := req.GetFilter(); f.GetSearch() != "" || f.GetQuery() != "" {
+			return ratelimit.Expensive
+		}
+	}
+	return ratelimit.Standard
+}
+
+// client identifies the caller of a call for rate limiting, as the HTTP API
+// does.
+func client(ctx context.Context) string {
+	if p, ok := todo.PrincipalFromContext(ctx); ok {
+		if p.TokenID != "" {
+			return "token:" + p.TokenID
+		}
+		return p.Method + ":" + p.Tenant + "/" + p.Subject
+	}
+	return address(ctx)
+}
+
+// address identifies the IP address a call comes from.
+func address(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
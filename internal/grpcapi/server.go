@@ -0,0 +1,144 @@
+// Package grpcapi serves todo.Service over gRPC using the TodoService
+// definition in proto/todoify/v1.
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/repository"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	pb "github.com/MattDevy/es-todoify/pkg/todoifyv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the health check name of the todo service.
+const ServiceName = "todoify.v1.TodoService"
+
+// Server implements the TodoService gRPC API for a todo service.
+type Server struct {
+	pb.UnimplementedTodoServiceServer
+
+	service *todo.Service
+	logger  *slog.Logger
+	health  *health.Server
+	auth    Authenticator
+	limiter RateLimiter
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger used for request logs and internal errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer creates a Server for the service.
+func NewServer(service *todo.Service, opts ...Option) *Server {
+	s := &Server{
+		service: service,
+		logger:  slog.Default(),
+		health:  health.NewServer(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ServerOptions returns the interceptors for logging, panic recovery,
+// authentication and rate limiting. Pass them to grpc.NewServer.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.logUnary, s.recoverUnary, s.limitAddressUnary, s.authUnary, s.limitUnary),
+		grpc.ChainStreamInterceptor(s.logStream, s.recoverStream, s.limitAddressStream, s.authStream, s.limitStream),
+	}
+}
+
+// Register adds the todo service, the standard health service and server
+// reflection to a gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	pb.RegisterTodoServiceServer(gs, s)
+	healthpb.RegisterHealthServer(gs, s.health)
+	reflection.Register(gs)
+}
+
+// WatchHealth checks the backend health every interval and reports it through
+// the health service until ctx is done. Unhealthy backends are NOT_SERVING so
+// load balancers take the instance out of rotation.
+func (s *Server) WatchHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.checkHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports NOT_SERVING for every service so clients stop sending
+// new requests while the server drains.
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+}
+
+func (s *Server) checkHealth(ctx context.Context) {
+	serving := healthpb.HealthCheckResponse_SERVING
+	info, err := s.service.Health(ctx)
+	if err != nil || info == nil || info.Status == repository.HealthStatusUnhealthy {
+		if err != nil {
+			s.logger.Warn("health check failed", "error", err)
+		}
+		serving = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	s.health.SetServingStatus("", serving)
+	s.health.SetServingStatus(ServiceName, serving)
+}
+
+func (s *Server) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.logger.Info("request", "method", info.FullMethod, "code", status.Code(err), "duration", time.Since(start))
+	return resp, err
+}
+
+func (s *Server) logStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	s.logger.Info("request", "method", info.FullMethod, "code", status.Code(err), "duration", time.Since(start))
+	return err
+}
+
+func (s *Server) recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.logger.Error("panic serving request", "method", info.FullMethod, "panic", v)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (s *Server) recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.logger.Error("panic serving request", "method", info.FullMethod, "panic", v)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(srv, ss)
+}
@@ -0,0 +1,231 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	pb "github.com/MattDevy/es-todoify/pkg/todoifyv1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listPageSize is the number of todos List fetches per page while streaming.
+const listPageSize = 500
+
+var statusToProto = map[todo.Status]pb.Status{
+	todo.StatusPending:    pb.Status_STATUS_PENDING,
+	todo.StatusInProgress: pb.Status_STATUS_IN_PROGRESS,
+	todo.StatusCompleted:  pb.Status_STATUS_COMPLETED,
+	todo.StatusCancelled:  pb.Status_STATUS_CANCELLED,
+	todo.StatusBlocked:    pb.Status_STATUS_BLOCKED,
+}
+
+var statusFromProto = map[pb.Status]todo.Status{
+	pb.Status_STATUS_PENDING:     todo.StatusPending,
+	pb.Status_STATUS_IN_PROGRESS: todo.StatusInProgress,
+	pb.Status_STATUS_COMPLETED:   todo.StatusCompleted,
+	pb.Status_STATUS_CANCELLED:   todo.StatusCancelled,
+	pb.Status_STATUS_BLOCKED:     todo.StatusBlocked,
+}
+
+func (s *Server) Create(ctx context.Context, req *pb.CreateRequest) (*pb.Todo, error) {
+	created, err := s.service.CreateTodo(ctx, req.GetTitle(), req.GetDescription(), req.GetLabels(), todo.WithFields(req.GetFields().AsMap()))
+	if err != nil {
+		return nil, s.toError("Create", err)
+	}
+	return s.todoToProto("Create", created)
+}
+
+func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.Todo, error) {
+	t, err := s.service.GetTodo(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toError("Get", err)
+	}
+	return s.todoToProto("Get", t)
+}
+
+func (s *Server) Update(ctx context.Context, req *pb.UpdateRequest) (*pb.Todo, error) {
+	update := todo.UpdateTodo{
+		Title:       req.Title,
+		Description: req.Description,
+		Labels:      req.GetLabels(),
+	}
+	if req.GetFields() != nil {
+		update.Fields = req.GetFields().AsMap()
+	}
+
+	t, err := s.service.UpdateTodo(ctx, req.GetId(), update)
+	if err != nil {
+		return nil, s.toError("Update", err)
+	}
+	return s.todoToProto("Update", t)
+}
+
+func (s *Server) ChangeStatus(ctx context.Context, req *pb.ChangeStatusRequest) (*pb.Todo, error) {
+	newStatus, ok := statusFromProto[req.GetStatus()]
+	if !ok {
+		// An unknown status is bad input, not a disallowed transition
+		err := todo.FieldErrors{"status": "status must be one of pending, in_progress, completed, cancelled, blocked"}
+		return nil, s.toError("ChangeStatus", fmt.Errorf("%w: %w", todo.ErrInvalidInput, err))
+	}
+
+	t, err := s.service.ChangeStatus(ctx, req.GetId(), newStatus)
+	if err != nil {
+		return nil, s.toError("ChangeStatus", err)
+	}
+	return s.todoToProto("ChangeStatus", t)
+}
+
+func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := s.service.DeleteTodo(ctx, req.GetId()); err != nil {
+		return nil, s.toError("Delete", err)
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+// List streams matching todos page by page, so memory stays bounded however
+// many todos match.
+func (s *Server) List(req *pb.ListRequest, stream pb.TodoService_ListServer) error {
+	ctx := stream.Context()
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		return s.toError("List", err)
+	}
+
+	filter.SortBy = todo.SortField(req.GetSortBy())
+	if filter.SortBy != "" && !filter.SortBy.IsValid() {
+		return s.toError("List", fmt.Errorf("%w: sort_by must be one of createTime, updateTime, title, status, _score", todo.ErrInvalidInput))
+	}
+	filter.SortOrder = todo.SortOrder(req.GetSortOrder())
+	if filter.SortOrder != "" && !filter.SortOrder.IsValid() {
+		return s.toError("List", fmt.Errorf("%w: sort_order must be asc or desc", todo.ErrInvalidInput))
+	}
+	if req.GetLimit() < 0 {
+		return s.toError("List", fmt.Errorf("%w: limit must not be negative", todo.ErrInvalidInput))
+	}
+
+	remaining := int(req.GetLimit())
+	for {
+		filter.Limit = listPageSize
+		if remaining > 0 && remaining < listPageSize {
+			filter.Limit = remaining
+		}
+
+		page, err := s.service.ListTodosPage(ctx, filter)
+		if err != nil {
+			return s.toError("List", err)
+		}
+		for _, t := range page.Todos {
+			msg, err := s.todoToProto("List", t)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+
+		if req.GetLimit() > 0 {
+			remaining -= len(page.Todos)
+			if remaining <= 0 {
+				return nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func (s *Server) Count(ctx context.Context, req *pb.CountRequest) (*pb.CountResponse, error) {
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		return nil, s.toError("Count", err)
+	}
+
+	count, err := s.service.CountTodos(ctx, filter)
+	if err != nil {
+		return nil, s.toError("Count", err)
+	}
+	return &pb.CountResponse{Count: int64(count)}, nil
+}
+
+// todoToProto converts a todo, failing when its custom fields cannot be
+// represented as a protobuf Struct.
+func (s *Server) todoToProto(method string, t *todo.Todo) (*pb.Todo, error) {
+	msg := &pb.Todo{
+		Id:          t.ID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+		Labels:      t.Labels,
+		Status:      statusToProto[t.Status],
+		CreateTime:  timestamppb.New(t.CreateTime),
+		UpdateTime:  timestamppb.New(t.UpdateTime),
+	}
+	if len(t.Fields) > 0 {
+		fields, err := structpb.NewStruct(t.Fields)
+		if err != nil {
+			return nil, s.toError(method, fmt.Errorf("failed to convert fields of todo %s: %w", t.ID, err))
+		}
+		msg.Fields = fields
+	}
+	return msg, nil
+}
+
+// filterFromProto converts a protobuf filter. Unlike the CLI, terminal
+// statuses are not hidden by default.
+func filterFromProto(f *pb.ListFilter) (todo.ListFilter, error) {
+	var filter todo.ListFilter
+	var err error
+
+	if filter.Statuses, err = statusesFromProto("statuses", f.GetStatuses()); err != nil {
+		return filter, err
+	}
+	if filter.ExcludeStatuses, err = statusesFromProto("exclude_statuses", f.GetExcludeStatuses()); err != nil {
+		return filter, err
+	}
+
+	filter.Labels = f.GetLabels()
+	filter.AnyLabels = f.GetAnyLabels()
+	filter.ExcludeLabels = f.GetExcludeLabels()
+	filter.SearchQuery = f.GetSearch()
+
+	if f.GetFrom() != nil {
+		from := f.GetFrom().AsTime()
+		filter.FromDate = &from
+	}
+	if f.GetTo() != nil {
+		to := f.GetTo().AsTime()
+		filter.ToDate = &to
+	}
+
+	for _, expr := range f.GetWhere() {
+		cond, err := todo.ParseFieldCondition(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = append(filter.Where, cond)
+	}
+
+	if q := f.GetQuery(); q != "" {
+		if filter.Query, err = todo.ParseQuery(q); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+func statusesFromProto(name string, values []pb.Status) ([]todo.Status, error) {
+	var statuses []todo.Status
+	for _, v := range values {
+		status, ok := statusFromProto[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: invalid status %s", todo.ErrInvalidInput, name, v)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,58 @@
+package mcpapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Error codes of failed tool calls, as in the GraphQL API.
+const (
+	codeNotFound      = "NOT_FOUND"
+	codeBadUserInput  = "BAD_USER_INPUT"
+	codeConflict      = "CONFLICT"
+	codeInvalidStatus = "INVALID_STATUS"
+	codeUnsupported   = "UNSUPPORTED"
+	codeForbidden     = "FORBIDDEN"
+	codeCanceled      = "CANCELED"
+	codeInternal      = "INTERNAL_SERVER_ERROR"
+)
+
+// toolError is the structured content of a failed tool call. Invalid input
+// carries the per-field messages from todo.TranslateError.
+type toolError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// toError maps an error from the todo service to a tool error. Unknown
+// errors are logged and reported without their details.
+func (s *Server) toError(err error) *toolError {
+	e := &toolError{Message: err.Error()}
+
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		e.Code = codeNotFound
+	case errors.Is(err, todo.ErrInvalidInput):
+		e.Code = codeBadUserInput
+		e.Errors = todo.FieldMessages(err)
+	case errors.Is(err, todo.ErrConflict):
+		e.Code = codeConflict
+	case errors.Is(err, todo.ErrInvalidStatus):
+		e.Code = codeInvalidStatus
+	case errors.Is(err, todo.ErrUnsupported):
+		e.Code = codeUnsupported
+	case errors.Is(err, todo.ErrForbidden):
+		e.Code = codeForbidden
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		e.Code = codeCanceled
+	default:
+		s.logger.Error("MCP tool call failed", "error", err)
+		e.Code = codeInternal
+		e.Message = "internal error"
+	}
+
+	return e
+}
@@ -0,0 +1,138 @@
+package mcpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Resource URIs.
+const (
+	viewURIPrefix = "todoify://views/"
+	todoURIPrefix = "todoify://todos/"
+)
+
+// viewLimit is how many todos reading a view returns.
+const viewLimit = 100
+
+// resource is a readable resource listed by resources/list.
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+// resourceTemplate describes resources that are not listed, such as todos.
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+// resourceContents is the JSON text of a read resource.
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+var resourceTemplates = []resourceTemplate{{
+	URITemplate: todoURIPrefix + "{id}",
+	Name:        "todo",
+	Title:       "Todo",
+	Description: "A todo by ID",
+	MimeType:    "application/json",
+}}
+
+// resources lists the saved views by name.
+func (s *Server) resources() []resource {
+	names := make([]string, 0, len(s.views))
+	for name := range s.views {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	resources := make([]resource, len(names))
+	for i, name := range names {
+		resources[i] = resource{
+			URI:         viewURIPrefix + name,
+			Name:        name,
+			Title:       "View " + name,
+			Description: "Todos matching " + s.views[name],
+			MimeType:    "application/json",
+		}
+	}
+	return resources
+}
+
+// readResource reads a saved view or a todo.
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	var value any
+	var err error
+	switch {
+	case strings.HasPrefix(p.URI, viewURIPrefix):
+		value, err = s.readView(ctx, strings.TrimPrefix(p.URI, viewURIPrefix))
+	case strings.HasPrefix(p.URI, todoURIPrefix):
+		value, err = s.service.GetTodo(ctx, strings.TrimPrefix(p.URI, todoURIPrefix))
+	default:
+		err = todo.ErrNotFound
+	}
+	if err != nil {
+		return nil, s.resourceError(p.URI, err)
+	}
+
+	text, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Error("failed to encode resource", "uri", p.URI, "error", err)
+		return nil, errorf(codeInternalError, "internal error")
+	}
+	return map[string]any{
+		"contents": []resourceContents{{URI: p.URI, MimeType: "application/json", Text: string(text)}},
+	}, nil
+}
+
+// readView lists the first todos matching a saved view.
+func (s *Server) readView(ctx context.Context, name string) (*listResult, error) {
+	expr, ok := s.views[name]
+	if !ok {
+		return nil, todo.ErrNotFound
+	}
+	q, err := todo.ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.service.ListTodos(ctx, todo.ListFilter{Query: q, Limit: viewLimit})
+	if err != nil {
+		return nil, err
+	}
+	return &listResult{Todos: nonNil(todos)}, nil
+}
+
+// resourceError maps an error reading a resource to a JSON-RPC error with
+// the tool error in its data.
+func (s *Server) resourceError(uri string, err error) *rpcError {
+	if errors.Is(err, todo.ErrNotFound) {
+		return &rpcError{Code: codeResourceNotFound, Message: "resource not found", Data: map[string]string{"uri": uri}}
+	}
+	e := s.toError(err)
+	code := codeInternalError
+	if e.Code == codeBadUserInput {
+		code = codeInvalidParams
+	}
+	return &rpcError{Code: code, Message: e.Message, Data: e}
+}
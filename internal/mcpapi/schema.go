@@ -0,0 +1,167 @@
+package mcpapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Schema is a JSON Schema, as used for tool inputs.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// enums lists the values of the string types that only allow some.
+var enums = map[reflect.Type][]string{
+	reflect.TypeFor[todo.Status]():    stringValues(todo.AllStatuses()),
+	reflect.TypeFor[todo.SortField](): stringValues(todo.AllSortFields()),
+	reflect.TypeFor[todo.SortOrder](): {string(todo.SortOrderAsc), string(todo.SortOrderDesc)},
+}
+
+func stringValues[T ~string](values []T) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = string(v)
+	}
+	return s
+}
+
+// schemaFor derives the schema of a struct from its field tags, so tool
+// inputs are described by the same types that decode them:
+//
+//	json        property name; "-" skips the field, embedded structs are inlined
+//	validate    required, min and max (lengths, item counts or values), oneof
+//	jsonschema  the property description
+//
+// Unknown properties are not allowed, as arguments are decoded strictly.
+func schemaFor(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: new(bool)}
+	addProperties(s, t)
+	return s
+}
+
+func addProperties(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addProperties(s, field.Type)
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := typeSchema(field.Type)
+		prop.Description = field.Tag.Get("jsonschema")
+		if applyRules(prop, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// typeSchema returns the schema of a Go type, without validation rules.
+func typeSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		// Custom field values may be of any type
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return schemaFor(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// applyRules adds the constraints of a validate tag to a property schema and
+// reports whether the property is required.
+func applyRules(s *Schema, tag string) bool {
+	var required bool
+	for rule := range strings.SplitSeq(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(s, name == "min", n)
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "dive":
+			// Later rules apply to the items
+			return required
+		}
+	}
+	return required
+}
+
+// setBound sets the minimum or maximum that validator's min and max mean for
+// the schema's type.
+func setBound(s *Schema, lower bool, n int) {
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if lower {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if lower {
+			s.Minimum = &f
+		} else {
+			s.Maximum = &f
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mcpapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaFor(t *testing.T) {
+	type embedded struct {
+		ID string `json:"id" validate:"required" jsonschema:"The ID"`
+	}
+	type args struct {
+		embedded
+		Title    *string        `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+		Labels   []string       `json:"labels,omitempty" validate:"omitempty,min=1,max=10,dive,max=20"`
+		Statuses []todo.Status  `json:"statuses,omitempty"`
+		Limit    int            `json:"limit" validate:"min=0,max=1000"`
+		Mode     string         `json:"mode" validate:"required,oneof=fast slow"`
+		Fields   map[string]any `json:"fields,omitempty"`
+		Skipped  string         `json:"-"`
+		internal string
+	}
+
+	got, err := json.Marshal(schemaFor(reflect.TypeFor[args]()))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["id", "mode"],
+		"properties": {
+			"id": {"type": "string", "description": "The ID"},
+			"title": {"type": "string", "minLength": 1, "maxLength": 255},
+			"labels": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 10},
+			"statuses": {"type": "array", "items": {"type": "string", "enum": ["pending", "in_progress", "completed", "cancelled", "blocked"]}},
+			"limit": {"type": "integer", "minimum": 0, "maximum": 1000},
+			"mode": {"type": "string", "enum": ["fast", "slow"]},
+			"fields": {"type": "object"}
+		}
+	}`, string(got))
+}
+
+func TestSchemaFor_UpdateTodo(t *testing.T) {
+	schema := schemaFor(reflect.TypeFor[updateTodoArgs]())
+
+	require.Equal(t, []string{"id"}, schema.Required)
+	require.ElementsMatch(t, []string{"id", "title", "description", "labels", "fields"}, keys(schema.Properties))
+	require.Equal(t, 255, *schema.Properties["title"].MaxLength)
+	require.Equal(t, 1024, *schema.Properties["description"].MaxLength)
+	require.Equal(t, 10, *schema.Properties["labels"].MaxItems)
+}
+
+func keys[V any](m map[string]V) []string {
+	var k []string
+	for key := range m {
+		k = append(k, key)
+	}
+	return k
+}
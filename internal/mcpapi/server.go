@@ -0,0 +1,265 @@
+// Package mcpapi serves todo.Service to assistants and editor integrations as
+// a Model Context Protocol (MCP) server: JSON-RPC 2.0 messages, one per line,
+// over a pair of streams such as stdin and stdout.
+package mcpapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// ProtocolVersion is the latest MCP revision the server implements.
+const ProtocolVersion = "2025-06-18"
+
+// protocolVersions are the MCP revisions the server can speak, newest first.
+var protocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// instructions tell the client's model how to use the server.
+const instructions = `Manage todos with the *_todo tools. list_todos and stats take the same
+filters; q is the todoify query language, e.g. "status:pending label:bug updated<7d".
+Failed calls return isError with a code and, for invalid input, per-field messages in errors.
+Saved views are resources listing the todos they match.`
+
+// Server is the MCP server for a todo service.
+type Server struct {
+	service *todo.Service
+	logger  *slog.Logger
+	views   map[string]string
+	version string
+	tools   []*tool
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger used for call logs and internal errors. It must
+// not write to the server's output stream.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithViews serves saved views as resources: each maps a name to a query
+// language expression, and reading the view lists the todos it matches.
+func WithViews(views map[string]string) Option {
+	return func(s *Server) {
+		s.views = views
+	}
+}
+
+// NewServer creates a Server for the service.
+func NewServer(service *todo.Service, opts ...Option) *Server {
+	s := &Server{
+		service: service,
+		logger:  slog.Default(),
+		version: "devel",
+		tools:   tools,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		s.version = info.Main.Version
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Serve reads requests from in and writes responses to out until in ends or
+// ctx is done. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			line, err := r.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read message: %w", err)
+		case line := <-lines:
+			resp := s.handle(ctx, line)
+			if resp == nil {
+				continue
+			}
+			if err := enc.Encode(resp); err != nil {
+				return fmt.Errorf("failed to write message: %w", err)
+			}
+		}
+	}
+}
+
+// handle answers one message. Notifications get no response.
+func (s *Server) handle(ctx context.Context, data []byte) *response {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return &response{JSONRPC: "2.0", Error: errorf(codeParseError, "invalid message: %v", err)}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: errorf(codeInvalidRequest, "not a JSON-RPC 2.0 request")}
+	}
+
+	result, rpcErr := s.call(ctx, &req)
+	if req.isNotification() {
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if rpcErr != nil {
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	return resp
+}
+
+// call runs the method of a request, recovering from panics.
+func (s *Server) call(ctx context.Context, req *request) (result any, rpcErr *rpcError) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.logger.Error("panic handling MCP request", "method", req.Method, "panic", v)
+			result, rpcErr = nil, errorf(codeInternalError, "internal error")
+		}
+	}()
+
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "resources/list":
+		return map[string]any{"resources": s.resources()}, nil
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		return s.readResource(ctx, req.Params)
+	}
+
+	// Notifications such as notifications/initialized need no action
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, errorf(codeMethodNotFound, "unknown method %q", req.Method)
+}
+
+// implementation names the server in the initialize result.
+type implementation struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version"`
+}
+
+// initializeResult is the result of the initialize handshake.
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// initialize agrees on the protocol revision: the client's if the server
+// speaks it, otherwise the latest.
+func (s *Server) initialize(params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	version := ProtocolVersion
+	if slices.Contains(protocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+	return &initializeResult{
+		ProtocolVersion: version,
+		Capabilities: map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		ServerInfo:   implementation{Name: "todoify", Title: "Todoify", Version: s.version},
+		Instructions: instructions,
+	}, nil
+}
+
+// callToolResult is the result of tools/call.
+type callToolResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// content is a text content block.
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callTool runs a tool. Errors of the todo service are results with isError
+// set, so the client's model can see and correct them; only unknown tools are
+// protocol errors.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(s.tools, func(t *tool) bool { return t.Name == p.Name })
+	if i < 0 {
+		return nil, errorf(codeInvalidParams, "unknown tool %q", p.Name)
+	}
+
+	start := time.Now()
+	out, err := s.tools[i].call(s, ctx, p.Arguments)
+	s.logger.Info("tool call", "tool", p.Name, "failed", err != nil, "duration", time.Since(start))
+
+	result := &callToolResult{StructuredContent: out}
+	if err != nil {
+		result.StructuredContent = s.toError(err)
+		result.IsError = true
+	}
+	text, jsonErr := json.Marshal(result.StructuredContent)
+	if jsonErr != nil {
+		s.logger.Error("failed to encode tool result", "tool", p.Name, "error", jsonErr)
+		return nil, errorf(codeInternalError, "internal error")
+	}
+	result.Content = []content{{Type: "text", Text: string(text)}}
+	return result, nil
+}
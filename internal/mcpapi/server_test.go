@@ -0,0 +1,257 @@
+package mcpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	service := testutil.NewFileService(t)
+	return NewServer(service, append([]Option{WithLogger(testutil.Logger())}, opts...)...)
+}
+
+// exchange sends messages to the server, one per line, and returns its
+// responses by ID.
+func exchange(t *testing.T, s *Server, messages ...string) map[string]*response {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(t.Context(), strings.NewReader(strings.Join(messages, "\n")+"\n"), &out))
+
+	responses := make(map[string]*response)
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp struct {
+			response
+			Result json.RawMessage `json:"result"`
+		}
+		require.NoError(t, dec.Decode(&resp))
+		resp.response.Result = resp.Result
+		responses[string(resp.ID)] = &resp.response
+	}
+	return responses
+}
+
+// call calls a tool and decodes its result.
+func call(t *testing.T, s *Server, name string, arguments any) (*callToolResult, json.RawMessage) {
+	t.Helper()
+	args, err := json.Marshal(arguments)
+	require.NoError(t, err)
+	responses := exchange(t, s, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, name, args))
+	resp := responses["1"]
+	require.NotNil(t, resp)
+	require.Nil(t, resp.Error)
+
+	var result struct {
+		callToolResult
+		StructuredContent json.RawMessage `json:"structuredContent"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Result.(json.RawMessage), &result))
+	require.Len(t, result.Content, 1)
+	require.JSONEq(t, string(result.StructuredContent), result.Content[0].Text)
+	return &result.callToolResult, result.StructuredContent
+}
+
+func TestServer_Protocol(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"no/such/method"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"no_such_tool"}}`,
+		`{"id":6,"method":"ping"}`,
+		`not json`,
+	)
+	require.Len(t, responses, 7, "the notification gets no response")
+
+	var init initializeResult
+	require.NoError(t, json.Unmarshal(responses["1"].Result.(json.RawMessage), &init))
+	require.Equal(t, "2025-03-26", init.ProtocolVersion)
+	require.Equal(t, "todoify", init.ServerInfo.Name)
+	require.Contains(t, init.Capabilities, "tools")
+	require.Contains(t, init.Capabilities, "resources")
+
+	require.NoError(t, json.Unmarshal(responses[`"two"`].Result.(json.RawMessage), &init))
+	require.Equal(t, ProtocolVersion, init.ProtocolVersion)
+
+	require.JSONEq(t, `{}`, string(responses["3"].Result.(json.RawMessage)))
+	require.Equal(t, codeMethodNotFound, responses["4"].Error.Code)
+	require.Equal(t, codeInvalidParams, responses["5"].Error.Code)
+	require.Equal(t, codeInvalidRequest, responses["6"].Error.Code)
+	require.Equal(t, codeParseError, responses["null"].Error.Code)
+}
+
+func TestServer_ListTools(t *testing.T) {
+	s := newTestServer(t)
+	responses := exchange(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+
+	var result struct {
+		Tools []struct {
+			Name        string          `json:"name"`
+			InputSchema Schema          `json:"inputSchema"`
+			Annotations toolAnnotations `json:"annotations"`
+		} `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(responses["1"].Result.(json.RawMessage), &result))
+
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+		require.Equal(t, "object", tool.InputSchema.Type, tool.Name)
+	}
+	require.Equal(t, []string{"create_todo", "list_todos", "get_todo", "update_todo", "change_status", "delete_todo", "stats"}, names)
+	require.True(t, result.Tools[1].Annotations.ReadOnlyHint)
+	require.True(t, result.Tools[5].Annotations.DestructiveHint)
+}
+
+func TestServer_Tools(t *testing.T) {
+	s := newTestServer(t)
+
+	result, out := call(t, s, "create_todo", map[string]any{"title": "Write docs", "labels": []string{"docs"}})
+	require.False(t, result.IsError, string(out))
+	var created todo.Todo
+	require.NoError(t, json.Unmarshal(out, &created))
+	require.Equal(t, "Write docs", created.Title)
+	id := created.ID.String()
+
+	_, out = call(t, s, "create_todo", map[string]any{"title": "Fix login", "labels": []string{"bug"}})
+	var bug todo.Todo
+	require.NoError(t, json.Unmarshal(out, &bug))
+
+	result, out = call(t, s, "update_todo", map[string]any{"id": id, "description": "For the API"})
+	require.False(t, result.IsError, string(out))
+	require.Contains(t, string(out), `"description":"For the API"`)
+
+	result, out = call(t, s, "change_status", map[string]any{"id": bug.ID.String(), "status": "blocked"})
+	require.False(t, result.IsError, string(out))
+	require.Contains(t, string(out), `"status":"blocked"`)
+
+	result, out = call(t, s, "get_todo", map[string]any{"id": id})
+	require.False(t, result.IsError, string(out))
+	require.Contains(t, string(out), `"title":"Write docs"`)
+
+	result, out = call(t, s, "list_todos", map[string]any{"q": "label:bug", "status": []string{"blocked"}})
+	require.False(t, result.IsError, string(out))
+	var list listResult
+	require.NoError(t, json.Unmarshal(out, &list))
+	require.Len(t, list.Todos, 1)
+	require.Equal(t, bug.ID, list.Todos[0].ID)
+
+	result, out = call(t, s, "list_todos", map[string]any{"limit": 1, "cursor": ""})
+	require.False(t, result.IsError, string(out))
+	require.NoError(t, json.Unmarshal(out, &list))
+	require.Len(t, list.Todos, 1)
+
+	result, out = call(t, s, "stats", map[string]any{})
+	require.False(t, result.IsError, string(out))
+	var stats struct {
+		Total    int                `json:"total"`
+		Statuses []todo.FacetBucket `json:"statuses"`
+	}
+	require.NoError(t, json.Unmarshal(out, &stats))
+	require.Equal(t, 2, stats.Total)
+	require.Len(t, stats.Statuses, 2)
+
+	result, out = call(t, s, "delete_todo", map[string]any{"id": id})
+	require.False(t, result.IsError, string(out))
+	require.JSONEq(t, fmt.Sprintf(`{"id":%q,"deleted":true}`, id), string(out))
+}
+
+func TestServer_ToolErrors(t *testing.T) {
+	s := newTestServer(t)
+	_, out := call(t, s, "create_todo", map[string]any{"title": "Done"})
+	var done todo.Todo
+	require.NoError(t, json.Unmarshal(out, &done))
+	_, _ = call(t, s, "change_status", map[string]any{"id": done.ID.String(), "status": "completed"})
+	missing := "00000000-0000-0000-0000-000000000000"
+
+	tests := []struct {
+		name       string
+		tool       string
+		arguments  any
+		wantCode   string
+		wantErrors map[string]string
+	}{
+		{"missing required", "get_todo", map[string]any{}, codeBadUserInput, map[string]string{"id": "id is a required field"}},
+		{"unknown argument", "get_todo", map[string]any{"id": missing, "verbose": true}, codeBadUserInput, nil},
+		{"not an object", "get_todo", []string{"x"}, codeBadUserInput, nil},
+		{"not found", "get_todo", map[string]any{"id": missing}, codeNotFound, nil},
+		{"validation", "update_todo", map[string]any{"id": done.ID.String(), "title": ""}, codeBadUserInput, nil},
+		{"too many labels", "update_todo", map[string]any{"id": done.ID.String(), "labels": strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")}, codeBadUserInput, map[string]string{"Labels": "Labels must contain at maximum 10 items"}},
+		{"status transition", "change_status", map[string]any{"id": done.ID.String(), "status": "blocked"}, codeInvalidStatus, nil},
+		{"invalid status filter", "list_todos", map[string]any{"status": []string{"x"}}, codeBadUserInput, nil},
+		{"invalid query", "stats", map[string]any{"q": "status:"}, codeBadUserInput, nil},
+		{"invalid date", "list_todos", map[string]any{"from": "yesterday"}, codeBadUserInput, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, out := call(t, s, tt.tool, tt.arguments)
+			require.True(t, result.IsError, string(out))
+
+			var e toolError
+			require.NoError(t, json.Unmarshal(out, &e))
+			require.Equal(t, tt.wantCode, e.Code, e.Message)
+			require.NotEmpty(t, e.Message)
+			if tt.wantErrors != nil {
+				require.Equal(t, tt.wantErrors, e.Errors)
+			}
+		})
+	}
+}
+
+func TestServer_Resources(t *testing.T) {
+	s := newTestServer(t, WithViews(map[string]string{"bugs": "label:bug", "blocked": "status:blocked"}))
+	_, out := call(t, s, "create_todo", map[string]any{"title": "Fix login", "labels": []string{"bug"}})
+	var bug todo.Todo
+	require.NoError(t, json.Unmarshal(out, &bug))
+	_, _ = call(t, s, "create_todo", map[string]any{"title": "Write docs"})
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"todoify://views/bugs"}}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"todoify://todos/%s"}}`, bug.ID),
+		`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"todoify://views/none"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/read","params":{"uri":"todoify://todos/00000000-0000-0000-0000-000000000000"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/read","params":{"uri":"https://example.com"}}`,
+	)
+
+	var list struct {
+		Resources []resource `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(responses["1"].Result.(json.RawMessage), &list))
+	require.Len(t, list.Resources, 2)
+	require.Equal(t, "todoify://views/blocked", list.Resources[0].URI)
+	require.Equal(t, "todoify://views/bugs", list.Resources[1].URI)
+
+	require.Contains(t, string(responses["2"].Result.(json.RawMessage)), `"uriTemplate":"todoify://todos/{id}"`)
+
+	var read struct {
+		Contents []resourceContents `json:"contents"`
+	}
+	require.NoError(t, json.Unmarshal(responses["3"].Result.(json.RawMessage), &read))
+	require.Len(t, read.Contents, 1)
+	var view listResult
+	require.NoError(t, json.Unmarshal([]byte(read.Contents[0].Text), &view))
+	require.Len(t, view.Todos, 1)
+	require.Equal(t, bug.ID, view.Todos[0].ID)
+
+	require.NoError(t, json.Unmarshal(responses["4"].Result.(json.RawMessage), &read))
+	require.Contains(t, read.Contents[0].Text, `"title":"Fix login"`)
+
+	for _, id := range []string{"5", "6", "7"} {
+		require.NotNil(t, responses[id].Error, id)
+		require.Equal(t, codeResourceNotFound, responses[id].Error.Code, id)
+	}
+}
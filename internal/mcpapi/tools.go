@@ -0,0 +1,289 @@
+package mcpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// tool is an MCP tool: a named call with a JSON Schema for its arguments.
+type tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	InputSchema *Schema         `json:"inputSchema"`
+	Annotations toolAnnotations `json:"annotations"`
+
+	call func(s *Server, ctx context.Context, arguments json.RawMessage) (any, error)
+}
+
+// toolAnnotations tell clients how a tool affects the todos.
+type toolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+var (
+	readOnly    = toolAnnotations{ReadOnlyHint: true, IdempotentHint: true}
+	additive    = toolAnnotations{}
+	destructive = toolAnnotations{DestructiveHint: true, IdempotentHint: true}
+)
+
+// newTool defines a tool whose arguments decode into A. Its input schema is
+// derived from A, see schemaFor.
+func newTool[A any](name, title, description string, hints toolAnnotations, call func(*Server, context.Context, A) (any, error)) *tool {
+	schema := schemaFor(reflect.TypeFor[A]())
+	return &tool{
+		Name:        name,
+		Title:       title,
+		Description: description,
+		InputSchema: schema,
+		Annotations: hints,
+		call: func(s *Server, ctx context.Context, arguments json.RawMessage) (any, error) {
+			var args A
+			if err := decodeArguments(arguments, schema, &args); err != nil {
+				return nil, err
+			}
+			return call(s, ctx, args)
+		},
+	}
+}
+
+// decodeArguments strictly decodes tool arguments, reporting unknown and
+// missing required properties as invalid input.
+func decodeArguments(arguments json.RawMessage, schema *Schema, v any) error {
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = []byte("{}")
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(arguments, &present); err != nil {
+		return fmt.Errorf("%w: arguments must be an object", todo.ErrInvalidInput)
+	}
+	missing := todo.FieldErrors{}
+	for _, name := range schema.Required {
+		if value, ok := present[name]; !ok || string(value) == "null" {
+			missing[name] = name + " is a required field"
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %w", todo.ErrInvalidInput, missing)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(arguments))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid arguments: %v", todo.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// tools are the tools served by every Server.
+var tools = []*tool{
+	newTool("create_todo", "Create todo", "Create a pending todo and return it.", additive, (*Server).createTodo),
+	newTool("list_todos", "List todos", "List todos matching a filter, newest first unless sorted. Pass nextCursor back as cursor for the next page.", readOnly, (*Server).listTodos),
+	newTool("get_todo", "Get todo", "Get a todo by ID.", readOnly, (*Server).getTodo),
+	newTool("update_todo", "Update todo", "Change the title, description, labels or custom fields of a todo. Omitted properties are left unchanged.", destructive, (*Server).updateTodo),
+	newTool("change_status", "Change status", "Change the status of a todo. Completed and cancelled todos cannot be blocked.", destructive, (*Server).changeStatus),
+	newTool("delete_todo", "Delete todo", "Delete a todo.", destructive, (*Server).deleteTodo),
+	newTool("stats", "Todo statistics", "Count the todos matching a filter per status, label and creation month.", readOnly, (*Server).stats),
+}
+
+// idArgs are the arguments of tools that act on one todo.
+type idArgs struct {
+	ID string `json:"id" validate:"required" jsonschema:"ID of the todo"`
+}
+
+type createTodoArgs struct {
+	Title       string         `json:"title" validate:"required" jsonschema:"Short summary of the todo"`
+	Description string         `json:"description,omitempty" jsonschema:"Longer details"`
+	Labels      []string       `json:"labels,omitempty" jsonschema:"Labels to group the todo by"`
+	Fields      map[string]any `json:"fields,omitempty" jsonschema:"Values of custom fields defined with todoify field define"`
+}
+
+func (s *Server) createTodo(ctx context.Context, args createTodoArgs) (any, error) {
+	return s.service.CreateTodo(ctx, args.Title, args.Description, args.Labels, todo.WithFields(args.Fields))
+}
+
+func (s *Server) getTodo(ctx context.Context, args idArgs) (any, error) {
+	return s.service.GetTodo(ctx, args.ID)
+}
+
+// updateTodoArgs are the properties of todo.UpdateTodo for a todo ID.
+type updateTodoArgs struct {
+	idArgs
+	todo.UpdateTodo
+}
+
+func (s *Server) updateTodo(ctx context.Context, args updateTodoArgs) (any, error) {
+	return s.service.UpdateTodo(ctx, args.ID, args.UpdateTodo)
+}
+
+type changeStatusArgs struct {
+	idArgs
+	Status todo.Status `json:"status" validate:"required" jsonschema:"The new status"`
+}
+
+func (s *Server) changeStatus(ctx context.Context, args changeStatusArgs) (any, error) {
+	return s.service.ChangeStatus(ctx, args.ID, args.Status)
+}
+
+// deleteResult is the result of delete_todo.
+type deleteResult struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+func (s *Server) deleteTodo(ctx context.Context, args idArgs) (any, error) {
+	if err := s.service.DeleteTodo(ctx, args.ID); err != nil {
+		return nil, err
+	}
+	return &deleteResult{ID: args.ID, Deleted: true}, nil
+}
+
+// filterArgs select todos like the list filter parameters of the REST API.
+// Unlike the CLI, terminal statuses are not hidden by default.
+type filterArgs struct {
+	Status    []todo.Status `json:"status,omitempty" jsonschema:"Only todos with one of these statuses"`
+	NotStatus []todo.Status `json:"notStatus,omitempty" jsonschema:"Leave out todos with these statuses"`
+	Labels    []string      `json:"labels,omitempty" jsonschema:"Only todos with all of these labels"`
+	AnyLabels []string      `json:"anyLabels,omitempty" jsonschema:"Only todos with at least one of these labels"`
+	NotLabels []string      `json:"notLabels,omitempty" jsonschema:"Leave out todos with any of these labels"`
+	Search    string        `json:"search,omitempty" jsonschema:"Full-text search of titles and descriptions"`
+	From      string        `json:"from,omitempty" jsonschema:"Only todos created on or after this date (YYYY-MM-DD or RFC3339)"`
+	To        string        `json:"to,omitempty" jsonschema:"Only todos created on or before this date (YYYY-MM-DD or RFC3339)"`
+	Where     []string      `json:"where,omitempty" jsonschema:"Custom field conditions such as priority>=2, all of which must match"`
+	Q         string        `json:"q,omitempty" jsonschema:"Query language expression, e.g. status:pending label:bug -label:wontfix updated<7d sort:title"`
+}
+
+// toFilter converts the arguments.
+func (a filterArgs) toFilter() (todo.ListFilter, error) {
+	filter := todo.ListFilter{
+		Statuses:        a.Status,
+		ExcludeStatuses: a.NotStatus,
+		Labels:          a.Labels,
+		AnyLabels:       a.AnyLabels,
+		ExcludeLabels:   a.NotLabels,
+		SearchQuery:     a.Search,
+	}
+
+	for _, s := range slices.Concat(a.Status, a.NotStatus) {
+		if !s.IsValid() {
+			return filter, fmt.Errorf("%w: invalid status %q (valid: pending, in_progress, completed, cancelled, blocked)", todo.ErrInvalidInput, s)
+		}
+	}
+
+	var err error
+	if filter.FromDate, err = parseDate("from", a.From); err != nil {
+		return filter, err
+	}
+	if filter.ToDate, err = parseDate("to", a.To); err != nil {
+		return filter, err
+	}
+
+	for _, expr := range a.Where {
+		cond, err := todo.ParseFieldCondition(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = append(filter.Where, cond)
+	}
+
+	if a.Q != "" {
+		q, err := todo.ParseQuery(a.Q)
+		if err != nil {
+			return filter, err
+		}
+		filter.Query = q
+	}
+
+	return filter, nil
+}
+
+// parseDate parses an optional date argument.
+func parseDate(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := todo.ParseDate(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a date (YYYY-MM-DD or RFC3339)", todo.ErrInvalidInput, name)
+	}
+	return &t, nil
+}
+
+type listTodosArgs struct {
+	filterArgs
+	Limit     int            `json:"limit,omitempty" validate:"min=0,max=1000" jsonschema:"Todos per page (default 50)"`
+	Offset    int            `json:"offset,omitempty" validate:"min=0" jsonschema:"Todos to skip; use cursor to page deeper"`
+	Cursor    string         `json:"cursor,omitempty" jsonschema:"nextCursor of the previous page"`
+	SortBy    todo.SortField `json:"sortBy,omitempty" jsonschema:"Field to sort by"`
+	SortOrder todo.SortOrder `json:"sortOrder,omitempty" jsonschema:"Sort direction (default desc)"`
+}
+
+// listResult is the result of list_todos.
+type listResult struct {
+	Todos []*todo.Todo `json:"todos"`
+
+	// NextCursor continues with the next page while more todos follow.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (s *Server) listTodos(ctx context.Context, args listTodosArgs) (any, error) {
+	filter, err := args.toFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = args.Limit
+	filter.Offset = args.Offset
+	filter.Cursor = args.Cursor
+	filter.SortBy = args.SortBy
+	filter.SortOrder = args.SortOrder
+
+	page, err := s.service.ListTodosPage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &listResult{Todos: nonNil(page.Todos), NextCursor: page.NextCursor}, nil
+}
+
+// statsResult is the result of stats.
+type statsResult struct {
+	Total int `json:"total"`
+	*todo.Facets
+}
+
+func (s *Server) stats(ctx context.Context, args filterArgs) (any, error) {
+	filter, err := args.toFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.service.CountTodos(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	// Only the facets are needed, not the page
+	filter.Limit = 1
+	_, facets, err := s.service.ListTodosWithFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &statsResult{Total: total, Facets: facets}, nil
+}
+
+// nonNil returns an empty slice for nil, so results have [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExceededError reports a request refused by the rate limit of its class.
+type ExceededError struct {
+	Class      Class
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("the %s rate limit was exceeded, retry in %s", e.Class, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Quota takes a token of a class from the bucket of the client making a
+// request, as Limiter.Allow does for a fixed client.
+type Quota func(class Class) (bool, time.Duration)
+
+type quotaKey struct{}
+
+// WithQuota returns a context carrying the quota of the client making a
+// request. API servers add it so handlers that only learn how expensive a
+// request is while serving it, such as GraphQL resolvers, can charge it.
+func WithQuota(ctx context.Context, q Quota) context.Context {
+	return context.WithValue(ctx, quotaKey{}, q)
+}
+
+// Take takes a token of class from the quota in ctx, returning an
+// *ExceededError when the bucket is empty. Contexts without a quota are not
+// limited.
+func Take(ctx context.Context, class Class) error {
+	q, ok := ctx.Value(quotaKey{}).(Quota)
+	if !ok {
+		return nil
+	}
+	if ok, retry := q(class); !ok {
+		return &ExceededError{Class: class, RetryAfter: retry}
+	}
+	return nil
+}
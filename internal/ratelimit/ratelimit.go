@@ -0,0 +1,224 @@
+// Package ratelimit throttles API clients with a token bucket per client and
+// request class. It knows nothing about todos: the API servers decide who a
+// client is and which requests are expensive, so local CLI commands are never
+// limited.
+package ratelimit
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultIdleTimeout is how long the bucket of a client that sends no
+// requests is kept. A new bucket starts full, so forgetting an idle one never
+// throttles a client sooner.
+const DefaultIdleTimeout = 10 * time.Minute
+
+// Class groups requests that share a limit.
+type Class string
+
+const (
+	// Standard is every request that is not expensive.
+	Standard Class = "standard"
+	// Expensive is a request that costs the backend much more than reading
+	// or changing a single todo, such as full-text searches and counts.
+	Expensive Class = "expensive"
+	// Address is every request to a server that requires authentication,
+	// counted per IP address before the credential is checked, so bad
+	// credentials are throttled too.
+	Address Class = "address"
+)
+
+// Classes returns all request classes.
+func Classes() []Class {
+	return []Class{Standard, Expensive, Address}
+}
+
+// Limit is a token bucket: it refills at Rate requests per second and holds
+// at most Burst requests. A zero Rate means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Validate checks that a limit can be enforced.
+func (l Limit) Validate() error {
+	if l.Rate < 0 {
+		return fmt.Errorf("rate must not be negative, got %g", l.Rate)
+	}
+	if l.Rate > 0 && l.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1, got %d", l.Burst)
+	}
+	return nil
+}
+
+// Limiter keeps a token bucket per client and class.
+type Limiter struct {
+	limits map[Class]Limit
+	idle   time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	allowed   map[Class]int64
+	throttled map[Class]int64
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	client string
+	class  Class
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithLimit sets the limit of a request class. Classes without a limit are
+// not throttled.
+func WithLimit(class Class, limit Limit) Option {
+	return func(l *Limiter) {
+		l.limits[class] = limit
+	}
+}
+
+// WithIdleTimeout sets how long the bucket of an idle client is kept.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(l *Limiter) {
+		l.idle = d
+	}
+}
+
+// New creates a Limiter with valid limits.
+func New(opts ...Option) (*Limiter, error) {
+	l := &Limiter{
+		limits:    make(map[Class]Limit),
+		idle:      DefaultIdleTimeout,
+		now:       time.Now,
+		buckets:   make(map[bucketKey]*bucket),
+		allowed:   make(map[Class]int64),
+		throttled: make(map[Class]int64),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	for class, limit := range l.limits {
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s limit: %w", class, err)
+		}
+	}
+	return l, nil
+}
+
+// Allow takes a token for a request of client in class. When the bucket is
+// empty the request is refused with the time until a token is available.
+func (l *Limiter) Allow(client string, class Class) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limits[class]
+	if !ok || limit.Rate == 0 {
+		l.allowed[class]++
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	key := bucketKey{client: client, class: class}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		l.throttled[class]++
+		return false, delay
+	}
+	l.allowed[class]++
+	return true, 0
+}
+
+// sweep forgets the buckets of idle clients, at most once per idle timeout.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Stats are the request counters of a Limiter.
+type Stats struct {
+	// Allowed and Throttled count requests per class.
+	Allowed   map[Class]int64 `json:"allowed"`
+	Throttled map[Class]int64 `json:"throttled"`
+
+	// Clients is the number of buckets currently kept.
+	Clients int `json:"clients"`
+}
+
+// Stats returns a snapshot of the request counters.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := Stats{
+		Allowed:   make(map[Class]int64, len(l.allowed)),
+		Throttled: make(map[Class]int64, len(l.throttled)),
+		Clients:   len(l.buckets),
+	}
+	for _, class := range Classes() {
+		s.Allowed[class] = l.allowed[class]
+		s.Throttled[class] = l.throttled[class]
+	}
+	return s
+}
+
+// WriteMetrics writes the counters in the Prometheus text format.
+func (l *Limiter) WriteMetrics(w io.Writer) error {
+	s := l.Stats()
+	classes := Classes()
+	slices.Sort(classes)
+
+	var err error
+	write := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	write("# HELP todoify_ratelimit_requests_total Requests checked by the rate limiter.\n")
+	write("# TYPE todoify_ratelimit_requests_total counter\n")
+	for _, class := range classes {
+		write("todoify_ratelimit_requests_total{class=%q,result=\"allowed\"} %d\n", class, s.Allowed[class])
+		write("todoify_ratelimit_requests_total{class=%q,result=\"throttled\"} %d\n", class, s.Throttled[class])
+	}
+	write("# HELP todoify_ratelimit_clients Clients with a token bucket.\n")
+	write("# TYPE todoify_ratelimit_clients gauge\n")
+	write("todoify_ratelimit_clients %d\n", s.Clients)
+	return err
+}
+
+// RetryAfter formats a delay as a Retry-After header value: whole seconds,
+// rounded up so clients do not retry too early.
+func RetryAfter(delay time.Duration) string {
+	seconds := int64((delay + time.Second - 1) / time.Second)
+	return fmt.Sprint(max(seconds, 1))
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a fake time source for a Limiter.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(t *testing.T, opts ...Option) (*Limiter, *clock) {
+	t.Helper()
+	l, err := New(opts...)
+	require.NoError(t, err)
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l.now = c.now
+	return l, c
+}
+
+func TestLimit_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   Limit
+		wantErr bool
+	}{
+		{"unlimited", Limit{}, false},
+		{"rate and burst", Limit{Rate: 5, Burst: 10}, false},
+		{"fractional rate", Limit{Rate: 0.5, Burst: 1}, false},
+		{"negative rate", Limit{Rate: -1, Burst: 1}, true},
+		{"no burst", Limit{Rate: 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limit.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := New(WithLimit(Expensive, Limit{Rate: 1}))
+	require.ErrorContains(t, err, "invalid expensive limit")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Run("refills at the rate after the burst", func(t *testing.T) {
+		l, c := newTestLimiter(t, WithLimit(Standard, Limit{Rate: 2, Burst: 3}))
+
+		for range 3 {
+			ok, _ := l.Allow("alice", Standard)
+			require.True(t, ok)
+		}
+		ok, retry := l.Allow("alice", Standard)
+		require.False(t, ok)
+		require.Equal(t, 500*time.Millisecond, retry)
+
+		// A refused request takes no token
+		c.advance(500 * time.Millisecond)
+		ok, _ = l.Allow("alice", Standard)
+		require.True(t, ok)
+	})
+
+	t.Run("keeps a bucket per client and class", func(t *testing.T) {
+		l, _ := newTestLimiter(t,
+			WithLimit(Standard, Limit{Rate: 1, Burst: 1}),
+			WithLimit(Expensive, Limit{Rate: 1, Burst: 1}),
+		)
+
+		for _, req := range []struct {
+			client string
+			class  Class
+		}{{"alice", Standard}, {"bob", Standard}, {"alice", Expensive}} {
+			ok, _ := l.Allow(req.client, req.class)
+			require.True(t, ok, "%s %s", req.client, req.class)
+		}
+		ok, _ := l.Allow("alice", Expensive)
+		require.False(t, ok)
+	})
+
+	t.Run("classes without a limit are not throttled", func(t *testing.T) {
+		l, _ := newTestLimiter(t, WithLimit(Expensive, Limit{Rate: 1, Burst: 1}))
+		for range 100 {
+			ok, _ := l.Allow("alice", Standard)
+			require.True(t, ok)
+		}
+		require.Zero(t, l.Stats().Clients)
+	})
+
+	t.Run("forgets idle clients", func(t *testing.T) {
+		l, c := newTestLimiter(t, WithLimit(Standard, Limit{Rate: 1, Burst: 1}), WithIdleTimeout(time.Minute))
+
+		l.Allow("alice", Standard)
+		c.advance(30 * time.Second)
+		l.Allow("bob", Standard)
+		require.Equal(t, 2, l.Stats().Clients)
+
+		c.advance(45 * time.Second)
+		l.Allow("bob", Standard)
+		require.Equal(t, 1, l.Stats().Clients)
+	})
+}
+
+func TestLimiter_Metrics(t *testing.T) {
+	l, _ := newTestLimiter(t, WithLimit(Standard, Limit{Rate: 1, Burst: 1}))
+	l.Allow("alice", Standard)
+	l.Allow("alice", Standard)
+	l.Allow("alice", Expensive)
+
+	stats := l.Stats()
+	require.Equal(t, map[Class]int64{Standard: 1, Expensive: 1, Address: 0}, stats.Allowed)
+	require.Equal(t, map[Class]int64{Standard: 1, Expensive: 0, Address: 0}, stats.Throttled)
+
+	var b strings.Builder
+	require.NoError(t, l.WriteMetrics(&b))
+	require.Contains(t, b.String(), `todoify_ratelimit_requests_total{class="standard",result="throttled"} 1`+"\n")
+	require.Contains(t, b.String(), `todoify_ratelimit_requests_total{class="expensive",result="allowed"} 1`+"\n")
+	require.Contains(t, b.String(), "todoify_ratelimit_clients 1\n")
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, "1", RetryAfter(10*time.Millisecond))
+	require.Equal(t, "1", RetryAfter(time.Second))
+	require.Equal(t, "2", RetryAfter(1500*time.Millisecond))
+	require.Equal(t, "1", RetryAfter(0))
+}
+
+func TestTake(t *testing.T) {
+	require.NoError(t, Take(context.Background(), Expensive), "no quota")
+
+	l, _ := newTestLimiter(t, WithLimit(Expensive, Limit{Rate: 1, Burst: 1}))
+	ctx := WithQuota(context.Background(), func(class Class) (bool, time.Duration) {
+		return l.Allow("alice", class)
+	})
+	require.NoError(t, Take(ctx, Expensive))
+	require.NoError(t, Take(ctx, Standard))
+
+	err := Take(ctx, Expensive)
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, Expensive, exceeded.Class)
+	require.Equal(t, time.Second, exceeded.RetryAfter)
+	require.EqualError(t, err, "the expensive rate limit was exceeded, retry in 1s")
+}
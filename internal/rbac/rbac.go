@@ -0,0 +1,196 @@
+// Package rbac authorizes todo service operations by role. A Policy binds
+// subjects (user names, token subjects and JWT subjects) to roles, for every
+// todo or for the todos of a project, and the Authorizer checks the principal
+// of each call against it.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Role is a set of allowed actions.
+type Role string
+
+const (
+	// RoleViewer may read todos.
+	RoleViewer Role = "viewer"
+	// RoleEditor may read and change todos.
+	RoleEditor Role = "editor"
+	// RoleAdmin may do anything, including defining fields, managing access
+	// tokens and running backend operations.
+	RoleAdmin Role = "admin"
+)
+
+// IsValid checks if the role is one of the defined roles.
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleViewer, RoleEditor, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// Allows reports whether the role permits an action.
+func (r Role) Allows(action todo.Action) bool {
+	switch action {
+	case todo.ActionRead:
+		return r.IsValid()
+	case todo.ActionWrite:
+		return r == RoleEditor || r == RoleAdmin
+	case todo.ActionAdmin:
+		return r == RoleAdmin
+	default:
+		return false
+	}
+}
+
+// Binding grants a role to a subject.
+type Binding struct {
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+
+	// Project limits the role to the todos of a project, which are those
+	// with the project's label. Project roles never allow admin actions.
+	Project string `json:"project,omitempty"`
+}
+
+// Policy maps subjects to roles.
+type Policy struct {
+	// Default is the role of subjects without a binding; empty denies them
+	// everything.
+	Default Role `json:"default,omitempty"`
+
+	Bindings []Binding `json:"bindings"`
+}
+
+// Validate checks that every role is known and every binding has a subject.
+func (p *Policy) Validate() error {
+	if p.Default != "" && !p.Default.IsValid() {
+		return fmt.Errorf("invalid default role %q (valid: viewer, editor, admin)", p.Default)
+	}
+	for i, b := range p.Bindings {
+		if b.Subject == "" {
+			return fmt.Errorf("binding %d: subject is required", i)
+		}
+		if !b.Role.IsValid() {
+			return fmt.Errorf("binding %d: invalid role %q (valid: viewer, editor, admin)", i, b.Role)
+		}
+	}
+	return nil
+}
+
+// RoleOf returns the role of a subject for every todo. A subject bound more
+// than once gets the most privileged role.
+func (p *Policy) RoleOf(subject string) Role {
+	return p.roleIn(subject, "")
+}
+
+// ProjectRoles returns the roles of a subject by project, each the most
+// privileged of its project bindings.
+func (p *Policy) ProjectRoles(subject string) map[string]Role {
+	roles := make(map[string]Role)
+	for _, b := range p.Bindings {
+		if b.Subject == subject && b.Project != "" {
+			roles[b.Project] = p.roleIn(subject, b.Project)
+		}
+	}
+	return roles
+}
+
+// roleIn returns the most privileged role of the subject's bindings for a
+// project, or of those for every todo and the default when project is empty.
+func (p *Policy) roleIn(subject, project string) Role {
+	var role Role
+	if project == "" {
+		role = p.Default
+	}
+	for _, b := range p.Bindings {
+		if b.Subject == subject && b.Project == project && rank(b.Role) > rank(role) {
+			role = b.Role
+		}
+	}
+	return role
+}
+
+func rank(r Role) int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Authorizer implements todo.ProjectAuthorizer with a Policy.
+type Authorizer struct {
+	policy *Policy
+}
+
+// NewAuthorizer creates an Authorizer for a valid policy.
+func NewAuthorizer(policy *Policy) (*Authorizer, error) {
+	if policy == nil {
+		return nil, errors.New("policy is required")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return &Authorizer{policy: policy}, nil
+}
+
+// Authorize checks the roles of the context's principal. An action its role
+// for every todo does not allow is allowed when a project role allows it,
+// and the service then limits it to the todos of those projects. Calls
+// without a principal, such as those to a server without authentication,
+// are refused.
+func (a *Authorizer) Authorize(ctx context.Context, action todo.Action) error {
+	p, ok := todo.PrincipalFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: the rbac policy requires an authenticated caller", todo.ErrUnauthenticated)
+	}
+
+	role := a.policy.RoleOf(p.Subject)
+	if role.Allows(action) {
+		return nil
+	}
+	if projects, _ := a.Projects(ctx, action); len(projects) > 0 {
+		return nil
+	}
+	if role == "" {
+		return fmt.Errorf("%w: %s has no role", todo.ErrForbidden, p.Subject)
+	}
+	return fmt.Errorf("%w: the %s role does not allow %s operations", todo.ErrForbidden, role, action)
+}
+
+// Projects returns the projects in which the context's principal may
+// perform action, and true when its role allows it on every todo.
+func (a *Authorizer) Projects(ctx context.Context, action todo.Action) ([]string, bool) {
+	p, ok := todo.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	if a.policy.RoleOf(p.Subject).Allows(action) {
+		return nil, true
+	}
+	if action == todo.ActionAdmin {
+		return nil, false
+	}
+
+	var projects []string
+	for project, role := range a.policy.ProjectRoles(p.Subject) {
+		if role.Allows(action) {
+			projects = append(projects, project)
+		}
+	}
+	slices.Sort(projects)
+	return projects, false
+}
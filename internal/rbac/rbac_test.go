@@ -0,0 +1,196 @@
+package rbac
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role  Role
+		read  bool
+		write bool
+		admin bool
+	}{
+		{RoleViewer, true, false, false},
+		{RoleEditor, true, true, false},
+		{RoleAdmin, true, true, true},
+		{"", false, false, false},
+		{"owner", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			require.Equal(t, tt.read, tt.role.Allows(todo.ActionRead))
+			require.Equal(t, tt.write, tt.role.Allows(todo.ActionWrite))
+			require.Equal(t, tt.admin, tt.role.Allows(todo.ActionAdmin))
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"empty", Policy{}, false},
+		{"valid", Policy{Default: RoleViewer, Bindings: []Binding{{Subject: "alice", Role: RoleAdmin}}}, false},
+		{"unknown default", Policy{Default: "owner"}, true},
+		{"unknown role", Policy{Bindings: []Binding{{Subject: "alice", Role: "owner"}}}, true},
+		{"missing subject", Policy{Bindings: []Binding{{Role: RoleAdmin}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{
+		Default: RoleViewer,
+		Bindings: []Binding{
+			{Subject: "alice", Role: RoleAdmin},
+			{Subject: "bob", Role: RoleViewer},
+			{Subject: "bob", Role: RoleEditor},
+		},
+	})
+	require.NoError(t, err)
+	strict, err := NewAuthorizer(&Policy{Bindings: []Binding{
+		{Subject: "alice", Role: RoleAdmin},
+		{Subject: "dave", Role: RoleAdmin, Project: "web"},
+	}})
+	require.NoError(t, err)
+
+	as := func(subject string) context.Context {
+		return todo.WithPrincipal(context.Background(), &todo.Principal{Subject: subject})
+	}
+
+	tests := []struct {
+		name    string
+		auth    *Authorizer
+		ctx     context.Context
+		action  todo.Action
+		wantErr error
+	}{
+		{"no principal", strict, context.Background(), todo.ActionRead, todo.ErrUnauthenticated},
+		{"admin", a, as("alice"), todo.ActionAdmin, nil},
+		{"most privileged binding", a, as("bob"), todo.ActionWrite, nil},
+		{"editor cannot administer", a, as("bob"), todo.ActionAdmin, todo.ErrForbidden},
+		{"default role reads", a, as("carol"), todo.ActionRead, nil},
+		{"default role cannot write", a, as("carol"), todo.ActionWrite, todo.ErrForbidden},
+		{"no default", strict, as("carol"), todo.ActionRead, todo.ErrForbidden},
+		{"project role writes", strict, as("dave"), todo.ActionWrite, nil},
+		{"project role cannot administer", strict, as("dave"), todo.ActionAdmin, todo.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.Authorize(tt.ctx, tt.action)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthorizer_Projects(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Bindings: []Binding{
+		{Subject: "alice", Role: RoleAdmin},
+		{Subject: "carol", Role: RoleEditor, Project: "web"},
+		{Subject: "carol", Role: RoleViewer, Project: "docs"},
+	}})
+	require.NoError(t, err)
+	service := todo.NewService(filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json")), todo.WithAuthorizer(a))
+
+	as := func(subject string) context.Context {
+		return todo.WithPrincipal(context.Background(), &todo.Principal{Subject: subject})
+	}
+	alice, carol := as("alice"), as("carol")
+
+	projects, all := a.Projects(carol, todo.ActionRead)
+	require.False(t, all)
+	require.Equal(t, []string{"docs", "web"}, projects)
+	projects, all = a.Projects(carol, todo.ActionWrite)
+	require.False(t, all)
+	require.Equal(t, []string{"web"}, projects)
+	_, all = a.Projects(alice, todo.ActionAdmin)
+	require.True(t, all)
+
+	home, err := service.CreateTodo(alice, "Home page", "", []string{"web"})
+	require.NoError(t, err)
+	guide, err := service.CreateTodo(alice, "Guide", "", []string{"docs"})
+	require.NoError(t, err)
+	secret, err := service.CreateTodo(alice, "Rotate keys", "", []string{"infra"})
+	require.NoError(t, err)
+
+	t.Run("lists and counts the todos of readable projects", func(t *testing.T) {
+		todos, err := service.ListTodos(carol, todo.ListFilter{})
+		require.NoError(t, err)
+		var titles []string
+		for _, td := range todos {
+			titles = append(titles, td.Title)
+		}
+		require.ElementsMatch(t, []string{home.Title, guide.Title}, titles)
+
+		q, err := todo.ParseQuery("label:web OR label:infra")
+		require.NoError(t, err)
+		count, err := service.CountTodos(carol, todo.ListFilter{Query: q})
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		count, err = service.CountTodos(alice, todo.ListFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+	})
+
+	t.Run("todos of other projects do not exist", func(t *testing.T) {
+		_, err := service.GetTodo(carol, secret.ID.String())
+		require.ErrorIs(t, err, todo.ErrNotFound)
+		require.ErrorIs(t, service.DeleteTodo(carol, secret.ID.String()), todo.ErrNotFound)
+
+		got, err := service.GetTodos(carol, []string{guide.ID.String(), secret.ID.String()})
+		require.NoError(t, err)
+		require.Equal(t, guide.ID, got[0].ID)
+		require.Nil(t, got[1])
+	})
+
+	t.Run("writes need a writable project", func(t *testing.T) {
+		_, err := service.ChangeStatus(carol, home.ID.String(), todo.StatusInProgress)
+		require.NoError(t, err)
+		_, err = service.ChangeStatus(carol, guide.ID.String(), todo.StatusInProgress)
+		require.ErrorIs(t, err, todo.ErrForbidden)
+
+		_, err = service.CreateTodo(carol, "Footer", "", []string{"web", "ui"})
+		require.NoError(t, err)
+		_, err = service.CreateTodo(carol, "Untracked", "", nil)
+		require.ErrorIs(t, err, todo.ErrForbidden)
+		_, err = service.CreateTodo(carol, "Tutorial", "", []string{"docs"})
+		require.ErrorIs(t, err, todo.ErrForbidden)
+
+		_, err = service.UpdateTodo(carol, home.ID.String(), todo.UpdateTodo{Labels: []string{"docs"}})
+		require.ErrorIs(t, err, todo.ErrForbidden)
+		got, err := service.GetTodo(carol, home.ID.String())
+		require.NoError(t, err)
+		require.Equal(t, []string{"web"}, got.Labels)
+	})
+
+	t.Run("project roles do not administer", func(t *testing.T) {
+		_, err := service.DefineField(carol, todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber})
+		require.ErrorIs(t, err, todo.ErrForbidden)
+	})
+}
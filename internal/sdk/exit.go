@@ -0,0 +1,25 @@
+package sdk
+
+import (
+	"errors"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Exit codes of todoify commands.
+const (
+	// ExitFailure is the exit code of a failed command.
+	ExitFailure = 1
+
+	// ExitForbidden is the exit code of a command the user is not allowed to
+	// run (EX_NOPERM in sysexits.h).
+	ExitForbidden = 77
+)
+
+// ExitCode returns the exit code for a command that failed with err.
+func ExitCode(err error) int {
+	if errors.Is(err, todo.ErrForbidden) {
+		return ExitForbidden
+	}
+	return ExitFailure
+}
@@ -0,0 +1,42 @@
+// Package testutil holds test setup shared by the transport packages: a
+// service on a file repository in a temporary directory and an HTTP server
+// for a handler.
+package testutil
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+)
+
+// NewFileRepository returns a file repository in a temporary directory that
+// is removed when the test ends.
+func NewFileRepository(t testing.TB) *filerepo.Repository {
+	t.Helper()
+	return filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json"))
+}
+
+// NewFileService returns a service on a NewFileRepository.
+func NewFileService(t testing.TB, opts ...todo.ServiceOption) *todo.Service {
+	t.Helper()
+	return todo.NewService(NewFileRepository(t), opts...)
+}
+
+// Logger returns a logger that discards everything.
+func Logger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Serve starts an HTTP server for the handler and closes it when the test ends.
+func Serve(t testing.TB, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
@@ -0,0 +1,112 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Action is a class of service operation checked by an Authorizer.
+type Action string
+
+const (
+	// ActionRead covers getting, listing, counting and searching todos.
+	ActionRead Action = "read"
+	// ActionWrite covers creating, changing, merging and deleting todos.
+	ActionWrite Action = "write"
+	// ActionAdmin covers custom field definitions, access tokens and backend
+	// operations such as migrations and backups.
+	ActionAdmin Action = "admin"
+)
+
+// Authorizer decides whether the principal of a context may perform an
+// action. It returns an error matching ErrForbidden when it may not.
+type Authorizer interface {
+	Authorize(ctx context.Context, action Action) error
+}
+
+// ProjectAuthorizer is implemented by Authorizers that may allow an action
+// only within some projects. A project is a label: the todos of a project are
+// those with its label. Authorize allows such an action, and the service
+// limits it to the todos of the projects.
+type ProjectAuthorizer interface {
+	Authorizer
+
+	// Projects returns the projects in which the principal of ctx may
+	// perform action, and true when it may perform it on every todo.
+	Projects(ctx context.Context, action Action) (projects []string, all bool)
+}
+
+// WithAuthorizer checks every service operation with a before running it.
+func WithAuthorizer(a Authorizer) ServiceOption {
+	return func(s *Service) {
+		s.authorizer = a
+	}
+}
+
+// Authorize checks that the caller of ctx may perform action. Without an
+// Authorizer every action is allowed. Commands that bypass the service, such
+// as backend operations, call it directly.
+func (s *Service) Authorize(ctx context.Context, action Action) error {
+	if s.authorizer == nil {
+		return nil
+	}
+	return s.authorizer.Authorize(ctx, action)
+}
+
+// projects returns the projects the caller of ctx may perform action in, and
+// whether it is limited to them.
+func (s *Service) projects(ctx context.Context, action Action) ([]string, bool) {
+	pa, ok := s.authorizer.(ProjectAuthorizer)
+	if !ok {
+		return nil, false
+	}
+	projects, all := pa.Projects(ctx, action)
+	return projects, !all
+}
+
+// permits reports whether the caller of ctx may perform action on t.
+func (s *Service) permits(ctx context.Context, action Action, t *Todo) bool {
+	projects, limited := s.projects(ctx, action)
+	return !limited || slices.ContainsFunc(t.Labels, func(label string) bool {
+		return slices.Contains(projects, label)
+	})
+}
+
+// checkTodo checks that the caller of ctx may perform action on t. Todos of
+// projects it may not read do not exist for it.
+func (s *Service) checkTodo(ctx context.Context, action Action, t *Todo) error {
+	if !s.permits(ctx, ActionRead, t) {
+		return ErrNotFound
+	}
+	if !s.permits(ctx, action, t) {
+		return fmt.Errorf("%w: todo %s is not in a project you may %s", ErrForbidden, t.ID, action)
+	}
+	return nil
+}
+
+// restrictFilter limits a filter to the projects the caller of ctx may read.
+func (s *Service) restrictFilter(ctx context.Context, filter ListFilter) (ListFilter, error) {
+	projects, limited := s.projects(ctx, ActionRead)
+	if !limited {
+		return filter, nil
+	}
+	if len(projects) == 0 {
+		return filter, fmt.Errorf("%w: no project may be read", ErrForbidden)
+	}
+
+	visible := &OrExpr{}
+	for _, project := range projects {
+		visible.Operands = append(visible.Operands, &TermExpr{Field: QueryFieldLabel, Op: QueryOpMatch, Value: project})
+	}
+
+	query := &Query{Expr: visible}
+	if filter.Query != nil {
+		query.SortBy, query.SortOrder = filter.Query.SortBy, filter.Query.SortOrder
+		if filter.Query.Expr != nil {
+			query.Expr = &AndExpr{Operands: []QueryExpr{filter.Query.Expr, visible}}
+		}
+	}
+	filter.Query = query
+	return filter, nil
+}
@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// actionAuthorizer allows only the listed actions.
+type actionAuthorizer []Action
+
+func (a actionAuthorizer) Authorize(ctx context.Context, action Action) error {
+	for _, allowed := range a {
+		if allowed == action {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrForbidden, action)
+}
+
+func TestService_Authorize(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	service := NewService(repo, WithAuthorizer(actionAuthorizer{ActionRead}))
+
+	_, err := service.CreateTodo(ctx, "a", "", nil)
+	require.ErrorIs(t, err, ErrForbidden)
+	_, err = service.UpdateTodo(ctx, validUUID(), UpdateTodo{})
+	require.ErrorIs(t, err, ErrForbidden)
+	_, err = service.ChangeStatus(ctx, validUUID(), StatusCompleted)
+	require.ErrorIs(t, err, ErrForbidden)
+	require.ErrorIs(t, service.DeleteTodo(ctx, validUUID()), ErrForbidden)
+	_, err = service.MergeTodos(ctx, validUUID(), nil)
+	require.ErrorIs(t, err, ErrForbidden)
+	_, err = service.DefineField(ctx, FieldDefinition{Name: "points", Type: FieldTypeNumber})
+	require.ErrorIs(t, err, ErrForbidden)
+	_, _, err = service.CreateToken(ctx, "ci", "bob", []Scope{ScopeRead}, 0)
+	require.ErrorIs(t, err, ErrForbidden)
+	require.ErrorIs(t, service.Authorize(ctx, ActionAdmin), ErrForbidden)
+	repo.AssertExpectations(t)
+
+	repo.On("Count", ctx, ListFilter{}).Return(3, nil)
+	count, err := service.CountTodos(ctx, ListFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	service = NewService(repo)
+	require.NoError(t, service.Authorize(ctx, ActionAdmin))
+}
@@ -0,0 +1,90 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// MaxResultWindow is the deepest page (Offset+Limit) reachable with offset
+// pagination. Deeper results must be fetched with a cursor.
+const MaxResultWindow = 10000
+
+// Page is one page of todos with the cursor for the next one.
+type Page struct {
+	// Todos are the todos on this page.
+	Todos []*Todo `json:"todos"`
+
+	// NextCursor continues after the last todo on this page. It is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Pager is implemented by repositories that paginate with their own cursors,
+// e.g. search_after over a point-in-time snapshot. Repositories without it
+// fall back to cursors that encode an offset.
+type Pager interface {
+	// ListPage retrieves one page of todos matching the filter, continuing from
+	// filter.Cursor when it is set.
+	ListPage(ctx context.Context, filter ListFilter) (*Page, error)
+}
+
+// EncodeCursor encodes a backend's cursor state as an opaque, URL-safe string.
+func EncodeCursor(state any) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor into state.
+// Numbers are kept as json.Number so large sort values survive the round trip.
+func DecodeCursor(cursor string, state any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(state); err != nil {
+		return fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	return nil
+}
+
+// offsetCursor is the cursor state used for repositories that are not Pagers.
+type offsetCursor struct {
+	Offset int `json:"offset"`
+}
+
+// listOffsetPage pages through a plain repository using offset cursors.
+func listOffsetPage(ctx context.Context, repo Repository, filter ListFilter) (*Page, error) {
+	if filter.Cursor != "" {
+		var cur offsetCursor
+		if err := DecodeCursor(filter.Cursor, &cur); err != nil {
+			return nil, err
+		}
+		if cur.Offset < 0 {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+		}
+		filter.Offset = cur.Offset
+	}
+
+	todos, err := repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Todos: todos}
+	if len(todos) == filter.Limit {
+		page.NextCursor, err = EncodeCursor(offsetCursor{Offset: filter.Offset + len(todos)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,109 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPagerRepository is a mock repository that also implements Pager.
+type MockPagerRepository struct {
+	MockRepository
+}
+
+func (m *MockPagerRepository) ListPage(ctx context.Context, filter ListFilter) (*Page, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Page), args.Error(1)
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	type state struct {
+		PIT   string `json:"pit"`
+		After []any  `json:"after"`
+	}
+
+	// Large sort values must not lose precision
+	in := state{PIT: "abc==", After: []any{int64(1736899200000), int64(9007199254740993)}}
+	cursor, err := EncodeCursor(in)
+	require.NoError(t, err)
+	require.NotContains(t, cursor, "=")
+
+	var out state
+	require.NoError(t, DecodeCursor(cursor, &out))
+	require.Equal(t, in.PIT, out.PIT)
+	require.Equal(t, []any{json.Number("1736899200000"), json.Number("9007199254740993")}, out.After)
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", "bm90IGpzb24", "eyJ1bmtub3duIjoxfQ"} {
+			require.ErrorIs(t, DecodeCursor(cursor, &out), ErrInvalidInput, cursor)
+		}
+	})
+}
+
+func TestService_ListTodosPage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uses pager", func(t *testing.T) {
+		repo := new(MockPagerRepository)
+		want := &Page{Todos: []*Todo{newValidTodo(t)}, NextCursor: "next"}
+		repo.On("ListPage", ctx, mock.MatchedBy(func(f ListFilter) bool {
+			return f.Cursor == "abc" && f.Limit == 50
+		})).Return(want, nil)
+		service := NewService(repo)
+
+		page, err := service.ListTodosPage(ctx, ListFilter{Cursor: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, want, page)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("falls back to offset cursors", func(t *testing.T) {
+		service, repo := newTestService(t)
+		first := []*Todo{newValidTodo(t), newValidTodo(t)}
+		repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool { return f.Offset == 0 })).Return(first, nil)
+		repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool { return f.Offset == 2 })).Return([]*Todo{newValidTodo(t)}, nil)
+
+		page, err := service.ListTodosPage(ctx, ListFilter{Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, first, page.Todos)
+		require.NotEmpty(t, page.NextCursor)
+
+		page, err = service.ListTodosPage(ctx, ListFilter{Limit: 2, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		require.Len(t, page.Todos, 1)
+		require.Empty(t, page.NextCursor)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cursor cannot be combined with offset", func(t *testing.T) {
+		service, repo := newTestService(t)
+
+		_, err := service.ListTodosPage(ctx, ListFilter{Cursor: "abc", Offset: 10})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		service, repo := newTestService(t)
+
+		_, err := service.ListTodosPage(ctx, ListFilter{Cursor: "%%%"})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_ListTodos_ResultWindow(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t)
+
+	_, err := service.ListTodos(ctx, ListFilter{Limit: 100, Offset: MaxResultWindow - 50})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	require.ErrorContains(t, err, "use a cursor")
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,159 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DuplicateCandidate is an existing todo that looks like a duplicate of another.
+type DuplicateCandidate struct {
+	// Todo is the existing todo.
+	Todo *Todo `json:"todo"`
+
+	// Score is the similarity score reported by the backend. Scores are only
+	// comparable within one backend.
+	Score float64 `json:"score"`
+}
+
+// DuplicateFinder is implemented by repositories that can find similar todos natively.
+// Repositories without it fall back to comparing words against open todos.
+type DuplicateFinder interface {
+	// FindSimilar returns up to limit open todos similar to t, excluding t itself, most similar first.
+	FindSimilar(ctx context.Context, t *Todo, limit int) ([]*DuplicateCandidate, error)
+}
+
+// DuplicateError is returned when a create is refused because likely duplicates exist.
+// It wraps ErrConflict.
+type DuplicateError struct {
+	Candidates []*DuplicateCandidate
+}
+
+// Error implements the error interface.
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%d likely duplicate(s) found", len(e.Candidates))
+}
+
+// Unwrap allows errors.Is(err, ErrConflict).
+func (e *DuplicateError) Unwrap() error {
+	return ErrConflict
+}
+
+// RefuseDuplicates is a duplicate handler for WithDuplicateCheck that refuses
+// the create with a DuplicateError.
+func RefuseDuplicates(candidates []*DuplicateCandidate) error {
+	return &DuplicateError{Candidates: candidates}
+}
+
+// WithDuplicateCheck looks for likely duplicates among open todos before the
+// todo is persisted. When candidates are found, onDuplicates is called with
+// them; returning an error aborts the create (see RefuseDuplicates), returning
+// nil lets it proceed (e.g. after printing a warning).
+func WithDuplicateCheck(onDuplicates func([]*DuplicateCandidate) error) CreateOption {
+	return func(o *createOptions) {
+		o.onDuplicates = onDuplicates
+	}
+}
+
+// DuplicateCluster is a group of todos that look like duplicates of each other.
+type DuplicateCluster struct {
+	// Primary is the oldest todo in the cluster, which the others merge into.
+	Primary *Todo `json:"primary"`
+
+	// Duplicates are the other todos with their similarity score to the primary.
+	Duplicates []*DuplicateCandidate `json:"duplicates"`
+}
+
+// maxDuplicateCandidates is the number of candidates considered per todo.
+const maxDuplicateCandidates = 5
+
+// fallbackSimilarity is the minimum word similarity for the fallback duplicate finder.
+const fallbackSimilarity = 0.6
+
+// similarity returns the Jaccard similarity of the words in two todos' titles and descriptions.
+func similarity(a, b *Todo) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for w := range wa {
+		if _, ok := wb[w]; ok {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+func words(t *Todo) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.FieldsFunc(strings.ToLower(t.Title+" "+t.Description), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	}) {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// findSimilarIn returns todos from candidates whose word similarity to t meets the threshold.
+func findSimilarIn(t *Todo, candidates []*Todo, limit int) []*DuplicateCandidate {
+	var found []*DuplicateCandidate
+	for _, c := range candidates {
+		if c.ID == t.ID {
+			continue
+		}
+		if score := similarity(t, c); score >= fallbackSimilarity {
+			found = append(found, &DuplicateCandidate{Todo: c, Score: score})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Score > found[j].Score })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// mergeInto combines duplicates into primary: labels are unioned, custom fields
+// missing on primary are copied, and an empty description is filled from the
+// first duplicate that has one.
+func mergeInto(primary *Todo, duplicates []*Todo) UpdateTodo {
+	labels := slices.Clone(primary.Labels)
+	fields := map[string]any{}
+	description := primary.Description
+
+	for _, d := range duplicates {
+		for _, label := range d.Labels {
+			if !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+		for name, value := range d.Fields {
+			if _, ok := primary.Fields[name]; ok {
+				continue
+			}
+			if _, ok := fields[name]; !ok {
+				fields[name] = value
+			}
+		}
+		if description == "" {
+			description = d.Description
+		}
+	}
+
+	update := UpdateTodo{}
+	if len(labels) > 0 {
+		update.Labels = labels
+	}
+	if len(fields) > 0 {
+		update.Fields = fields
+	}
+	if description != primary.Description {
+		update.Description = &description
+	}
+	return update
+}
@@ -0,0 +1,234 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDuplicateRepository is a mock repository that also implements DuplicateFinder.
+type MockDuplicateRepository struct {
+	MockRepository
+}
+
+func (m *MockDuplicateRepository) FindSimilar(ctx context.Context, t *Todo, limit int) ([]*DuplicateCandidate, error) {
+	args := m.Called(ctx, t, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DuplicateCandidate), args.Error(1)
+}
+
+func newTodoWith(t *testing.T, title, description string, labels ...string) *Todo {
+	t.Helper()
+	todo, err := NewTodo(title, description, labels)
+	require.NoError(t, err)
+	return todo
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    [2]string
+		wantMin float64
+		wantMax float64
+	}{
+		{"identical", [2]string{"Fix login bug", ""}, [2]string{"fix login bug", ""}, 1, 1},
+		{"punctuation ignored", [2]string{"Fix login-bug!", ""}, [2]string{"fix login bug", ""}, 1, 1},
+		{"partial overlap", [2]string{"Fix login bug", ""}, [2]string{"Fix logout bug", ""}, 0.5, 0.5},
+		{"no overlap", [2]string{"Buy milk", ""}, [2]string{"Fix login bug", ""}, 0, 0},
+		{"description counts", [2]string{"Login", "fails on mobile"}, [2]string{"Login fails on mobile", ""}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Todo{Title: tt.a[0], Description: tt.a[1]}
+			b := &Todo{Title: tt.b[0], Description: tt.b[1]}
+			got := similarity(a, b)
+			require.GreaterOrEqual(t, got, tt.wantMin)
+			require.LessOrEqual(t, got, tt.wantMax)
+		})
+	}
+}
+
+func TestFindSimilarIn(t *testing.T) {
+	target := newTodoWith(t, "Fix login bug", "")
+	near := newTodoWith(t, "Fix the login bug", "")
+	exact := newTodoWith(t, "fix login bug", "")
+	unrelated := newTodoWith(t, "Buy milk", "")
+
+	found := findSimilarIn(target, []*Todo{target, near, unrelated, exact}, 5)
+	require.Len(t, found, 2)
+	require.Equal(t, exact, found[0].Todo)
+	require.Equal(t, near, found[1].Todo)
+
+	require.Len(t, findSimilarIn(target, []*Todo{near, exact}, 1), 1)
+}
+
+func TestMergeInto(t *testing.T) {
+	primary := newTodoWith(t, "Fix login bug", "", "bug")
+	primary.Fields = map[string]any{"priority": 1.0}
+	dup1 := newTodoWith(t, "Fix login bug", "Fails on mobile", "bug", "mobile")
+	dup1.Fields = map[string]any{"priority": 3.0, "owner": "sam"}
+	dup2 := newTodoWith(t, "login bug", "Other text", "urgent")
+
+	update := mergeInto(primary, []*Todo{dup1, dup2})
+	require.Equal(t, []string{"bug", "mobile", "urgent"}, update.Labels)
+	require.Equal(t, map[string]any{"owner": "sam"}, update.Fields)
+	require.NotNil(t, update.Description)
+	require.Equal(t, "Fails on mobile", *update.Description)
+
+	t.Run("nothing to merge", func(t *testing.T) {
+		update := mergeInto(dup1, []*Todo{primary})
+		require.Nil(t, update.Description)
+		require.Nil(t, update.Fields)
+		require.Equal(t, []string{"bug", "mobile"}, update.Labels)
+	})
+}
+
+func TestService_CreateTodo_DuplicateCheck(t *testing.T) {
+	ctx := context.Background()
+	existing := newTodoWith(t, "Fix login bug", "")
+
+	t.Run("strict refuses duplicates", func(t *testing.T) {
+		service, repo := newTestService(t)
+		repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool {
+			return len(f.ExcludeStatuses) == len(TerminalStatuses())
+		})).Return([]*Todo{existing}, nil)
+
+		_, err := service.CreateTodo(ctx, "fix login bug", "", nil, WithDuplicateCheck(RefuseDuplicates))
+		require.ErrorIs(t, err, ErrConflict)
+
+		var dupErr *DuplicateError
+		require.True(t, errors.As(err, &dupErr))
+		require.Equal(t, existing, dupErr.Candidates[0].Todo)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("warning lets the create proceed", func(t *testing.T) {
+		service, repo := newTestService(t)
+		repo.On("List", ctx, mock.AnythingOfType("todo.ListFilter")).Return([]*Todo{existing}, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*todo.Todo")).Return(nil)
+
+		var warned []*DuplicateCandidate
+		created, err := service.CreateTodo(ctx, "fix login bug", "", nil, WithDuplicateCheck(func(c []*DuplicateCandidate) error {
+			warned = c
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		require.Len(t, warned, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no duplicates", func(t *testing.T) {
+		service, repo := newTestService(t)
+		repo.On("List", ctx, mock.AnythingOfType("todo.ListFilter")).Return([]*Todo{existing}, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*todo.Todo")).Return(nil)
+
+		_, err := service.CreateTodo(ctx, "Buy milk", "", nil, WithDuplicateCheck(RefuseDuplicates))
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("uses duplicate finder", func(t *testing.T) {
+		repo := new(MockDuplicateRepository)
+		repo.On("FindSimilar", ctx, mock.AnythingOfType("*todo.Todo"), maxDuplicateCandidates).
+			Return([]*DuplicateCandidate{{Todo: existing, Score: 4.2}}, nil)
+		service := NewService(repo)
+
+		_, err := service.CreateTodo(ctx, "Login is broken", "", nil, WithDuplicateCheck(RefuseDuplicates))
+		require.ErrorIs(t, err, ErrConflict)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_FindDuplicateClusters(t *testing.T) {
+	ctx := context.Background()
+	a := newTodoWith(t, "Fix login bug", "")
+	b := newTodoWith(t, "fix the login bug", "")
+	c := newTodoWith(t, "Buy milk", "")
+	d := newTodoWith(t, "buy milk", "")
+	e := newTodoWith(t, "Write report", "")
+
+	service, repo := newTestService(t)
+	repo.On("List", ctx, mock.AnythingOfType("todo.ListFilter")).Return([]*Todo{a, b, c, d, e}, nil)
+
+	clusters, err := service.FindDuplicateClusters(ctx)
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	require.Equal(t, a, clusters[0].Primary)
+	require.Equal(t, b, clusters[0].Duplicates[0].Todo)
+	require.Equal(t, c, clusters[1].Primary)
+	require.Equal(t, d, clusters[1].Duplicates[0].Todo)
+}
+
+func TestService_FindDuplicateClusters_Finder(t *testing.T) {
+	ctx := context.Background()
+	report := newTodoWith(t, "Write quarterly report", "")
+	card := newTodoWith(t, "Write birthday card", "")
+	login := newTodoWith(t, "Fix login bug", "")
+	loginAgain := newTodoWith(t, "fix the login bug", "")
+	loginMobile := newTodoWith(t, "fix the login bug on mobile", "")
+
+	// The finder reports todos sharing a single word, and chains the login
+	// todos through their middle one
+	similar := map[*Todo][]*Todo{
+		report:      {card},
+		card:        {report},
+		login:       {loginAgain},
+		loginAgain:  {login, loginMobile},
+		loginMobile: {loginAgain},
+	}
+	repo := new(MockDuplicateRepository)
+	repo.On("List", ctx, mock.AnythingOfType("todo.ListFilter")).Return([]*Todo{report, card, login, loginAgain, loginMobile}, nil)
+	for todo, found := range similar {
+		var candidates []*DuplicateCandidate
+		for _, f := range found {
+			candidates = append(candidates, &DuplicateCandidate{Todo: f, Score: 2})
+		}
+		repo.On("FindSimilar", ctx, todo, maxDuplicateCandidates).Return(candidates, nil)
+	}
+	service := NewService(repo)
+
+	clusters, err := service.FindDuplicateClusters(ctx)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	require.Equal(t, login, clusters[0].Primary)
+	require.Len(t, clusters[0].Duplicates, 1)
+	require.Equal(t, loginAgain, clusters[0].Duplicates[0].Todo)
+}
+
+func TestService_MergeTodos(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("merges and deletes duplicates", func(t *testing.T) {
+		service, repo := newTestService(t)
+		primary := newTodoWith(t, "Fix login bug", "", "bug")
+		dup := newTodoWith(t, "fix login bug", "Fails on mobile", "mobile")
+		repo.On("Get", ctx, primary.ID.String()).Return(primary, nil)
+		repo.On("Get", ctx, dup.ID.String()).Return(dup, nil)
+		repo.On("Update", ctx, mock.MatchedBy(func(t *Todo) bool {
+			return t.ID == primary.ID && len(t.Labels) == 2 && t.Description == "Fails on mobile"
+		})).Return(nil)
+		repo.On("Delete", ctx, dup.ID.String()).Return(nil)
+
+		merged, err := service.MergeTodos(ctx, primary.ID.String(), []string{dup.ID.String()})
+		require.NoError(t, err)
+		require.Equal(t, []string{"bug", "mobile"}, merged.Labels)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cannot merge into itself", func(t *testing.T) {
+		service, repo := newTestService(t)
+		primary := newTodoWith(t, "Fix login bug", "")
+		repo.On("Get", ctx, primary.ID.String()).Return(primary, nil)
+
+		_, err := service.MergeTodos(ctx, primary.ID.String(), []string{primary.ID.String()})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -14,4 +14,13 @@ var (
 
 	// ErrInvalidStatus is returned when a status transition is not allowed.
 	ErrInvalidStatus = errors.New("invalid status transition")
+
+	// ErrUnsupported is returned when the repository backend does not support an operation.
+	ErrUnsupported = errors.New("operation not supported by repository")
+
+	// ErrUnauthenticated is returned when credentials are missing or invalid.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is returned when the caller is not allowed to perform an operation.
+	ErrForbidden = errors.New("forbidden")
 )
@@ -0,0 +1,92 @@
+package todo
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+)
+
+// EventType is the kind of change an Event reports.
+type EventType string
+
+const (
+	EventCreated       EventType = "created"
+	EventUpdated       EventType = "updated"
+	EventStatusChanged EventType = "status_changed"
+	EventDeleted       EventType = "deleted"
+)
+
+// IsValid checks if the event type is one of the defined types.
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventCreated, EventUpdated, EventStatusChanged, EventDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event is a change to a todo made through a Service.
+type Event struct {
+	Type EventType `json:"type"`
+	// Todo is the todo after the change, or as it was before a deletion.
+	Todo *Todo `json:"todo"`
+	// PreviousStatus is set for status changes.
+	PreviousStatus Status `json:"previousStatus,omitempty"`
+	// Actor is the subject of the principal that made the change, if any.
+	Actor string `json:"actor,omitempty"`
+	// Tenant is the tenant the change was made for, if any.
+	Tenant string    `json:"tenant,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// VisibleTo reports whether a subscriber acting with ctx may see the event:
+// events of a tenant only go to subscribers of the same tenant.
+func (e Event) VisibleTo(ctx context.Context) bool {
+	tenant, _ := TenantFromContext(ctx)
+	return e.Tenant == tenant
+}
+
+// Publisher receives the events of a Service. Publish is called after the
+// change is stored and must not wait for slow consumers.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// ServiceOption configures a Service.
+type ServiceOption func(*Service)
+
+// WithPublisher publishes an Event for every todo the service creates,
+// updates, changes the status of or deletes. It may be given several times.
+func WithPublisher(p Publisher) ServiceOption {
+	return func(s *Service) {
+		s.publishers = append(s.publishers, p)
+	}
+}
+
+// publish sends an event for a snapshot of the todo, so subscribers never
+// see later changes made by the caller.
+func (s *Service) publish(ctx context.Context, typ EventType, t *Todo, previous Status) {
+	if len(s.publishers) == 0 {
+		return
+	}
+
+	snapshot := *t
+	snapshot.Labels = slices.Clone(t.Labels)
+	snapshot.Fields = maps.Clone(t.Fields)
+
+	event := Event{
+		Type:           typ,
+		Todo:           &snapshot,
+		PreviousStatus: previous,
+		Time:           time.Now().UTC(),
+	}
+	if p, ok := PrincipalFromContext(ctx); ok {
+		event.Actor = p.Subject
+	}
+	event.Tenant, _ = TenantFromContext(ctx)
+	for _, p := range s.publishers {
+		p.Publish(ctx, event)
+	}
+}
@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// MaxLabelFacets is the number of label buckets returned, most frequent first.
+const MaxLabelFacets = 20
+
+// createdMonthLayout is the key format of the created-month facet.
+const createdMonthLayout = "2006-01"
+
+// FacetBucket is the number of todos sharing a value.
+type FacetBucket struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Facets holds counts over every todo matching a filter, not just the returned page.
+type Facets struct {
+	// Statuses counts todos per status, most frequent first.
+	Statuses []FacetBucket `json:"statuses"`
+
+	// Labels counts todos per label, most frequent first, limited to the top labels.
+	Labels []FacetBucket `json:"labels"`
+
+	// CreatedMonths counts todos per creation month (YYYY-MM), oldest first.
+	CreatedMonths []FacetBucket `json:"createdMonths"`
+}
+
+// Faceter is implemented by repositories that can compute facets in the same
+// request as the page of todos. Repositories without it fall back to scanning
+// every matching todo.
+type Faceter interface {
+	// ListWithFacets retrieves a page of todos matching the filter together with
+	// facets over all matching todos.
+	ListWithFacets(ctx context.Context, filter ListFilter) ([]*Todo, *Facets, error)
+}
+
+// facetsOf counts statuses, labels and creation months over todos.
+func facetsOf(todos []*Todo) *Facets {
+	statuses := map[string]int{}
+	labels := map[string]int{}
+	months := map[string]int{}
+	for _, t := range todos {
+		statuses[t.Status.String()]++
+		for _, label := range t.Labels {
+			labels[label]++
+		}
+		months[t.CreateTime.UTC().Format(createdMonthLayout)]++
+	}
+
+	byCount := func(a, b FacetBucket) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Key, b.Key))
+	}
+
+	facets := &Facets{
+		Statuses:      sortedBuckets(statuses, byCount),
+		Labels:        sortedBuckets(labels, byCount),
+		CreatedMonths: sortedBuckets(months, func(a, b FacetBucket) int { return cmp.Compare(a.Key, b.Key) }),
+	}
+	if len(facets.Labels) > MaxLabelFacets {
+		facets.Labels = facets.Labels[:MaxLabelFacets]
+	}
+	return facets
+}
+
+func sortedBuckets(counts map[string]int, compare func(a, b FacetBucket) int) []FacetBucket {
+	buckets := make([]FacetBucket, 0, len(counts))
+	for key, count := range counts {
+		buckets = append(buckets, FacetBucket{Key: key, Count: count})
+	}
+	slices.SortFunc(buckets, compare)
+	return buckets
+}
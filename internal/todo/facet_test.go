@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFacetRepository is a mock repository that also implements Faceter.
+type MockFacetRepository struct {
+	MockRepository
+}
+
+func (m *MockFacetRepository) ListWithFacets(ctx context.Context, filter ListFilter) ([]*Todo, *Facets, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*Todo), args.Get(1).(*Facets), args.Error(2)
+}
+
+func TestFacetsOf(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return ts
+	}
+	todos := []*Todo{
+		{Status: StatusPending, Labels: []string{"bug", "urgent"}, CreateTime: at("2025-02-10")},
+		{Status: StatusPending, Labels: []string{"bug"}, CreateTime: at("2025-01-05")},
+		{Status: StatusCompleted, Labels: []string{"docs"}, CreateTime: at("2025-02-28")},
+	}
+
+	facets := facetsOf(todos)
+	require.Equal(t, []FacetBucket{{"pending", 2}, {"completed", 1}}, facets.Statuses)
+	require.Equal(t, []FacetBucket{{"bug", 2}, {"docs", 1}, {"urgent", 1}}, facets.Labels)
+	require.Equal(t, []FacetBucket{{"2025-01", 1}, {"2025-02", 2}}, facets.CreatedMonths)
+
+	t.Run("empty", func(t *testing.T) {
+		facets := facetsOf(nil)
+		require.Empty(t, facets.Statuses)
+		require.Empty(t, facets.Labels)
+		require.Empty(t, facets.CreatedMonths)
+	})
+}
+
+func TestService_ListTodosWithFacets(t *testing.T) {
+	ctx := context.Background()
+	testTodo := newValidTodo(t)
+
+	t.Run("uses faceter", func(t *testing.T) {
+		repo := new(MockFacetRepository)
+		want := &Facets{Statuses: []FacetBucket{{"pending", 1}}}
+		repo.On("ListWithFacets", ctx, mock.MatchedBy(func(f ListFilter) bool {
+			return f.Limit == 50 && f.SortBy == SortFieldCreateTime
+		})).Return([]*Todo{testTodo}, want, nil)
+		service := NewService(repo)
+
+		todos, facets, err := service.ListTodosWithFacets(ctx, ListFilter{})
+		require.NoError(t, err)
+		require.Equal(t, []*Todo{testTodo}, todos)
+		require.Equal(t, want, facets)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("falls back to counting every matching todo", func(t *testing.T) {
+		service, repo := newTestService(t)
+		repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool { return f.Limit == 1 })).
+			Return([]*Todo{testTodo}, nil)
+		repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool { return f.Limit == 1000 && f.Offset == 0 })).
+			Return([]*Todo{testTodo, newValidTodo(t)}, nil)
+
+		todos, facets, err := service.ListTodosWithFacets(ctx, ListFilter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		require.Equal(t, []FacetBucket{{"pending", 2}}, facets.Statuses)
+		require.Equal(t, []FacetBucket{{"test", 2}}, facets.Labels)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		service, repo := newTestService(t)
+
+		_, _, err := service.ListTodosWithFacets(ctx, ListFilter{Limit: -1})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		repo.AssertExpectations(t)
+	})
+}
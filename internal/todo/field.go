@@ -0,0 +1,294 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType represents the type of a custom field value.
+// This is a value object in DDD terminology.
+type FieldType string
+
+const (
+	FieldTypeString FieldType = "string"
+	FieldTypeNumber FieldType = "number"
+	FieldTypeDate   FieldType = "date"
+	FieldTypeEnum   FieldType = "enum"
+	FieldTypeBool   FieldType = "bool"
+)
+
+// IsValid checks if the field type is one of the defined values.
+func (f FieldType) IsValid() bool {
+	switch f {
+	case FieldTypeString, FieldTypeNumber, FieldTypeDate, FieldTypeEnum, FieldTypeBool:
+		return true
+	}
+	return false
+}
+
+// String returns the string representation of the FieldType.
+func (f FieldType) String() string {
+	return string(f)
+}
+
+// AllFieldTypes returns all valid field type values.
+func AllFieldTypes() []FieldType {
+	return []FieldType{
+		FieldTypeString,
+		FieldTypeNumber,
+		FieldTypeDate,
+		FieldTypeEnum,
+		FieldTypeBool,
+	}
+}
+
+// FieldDefinition describes a user-defined field that can be attached to todos.
+// Definitions are stored in the backend so every client shares the same schema.
+type FieldDefinition struct {
+	Name          string    `json:"name" validate:"required,max=64,fieldname"`
+	Type          FieldType `json:"type" validate:"required,oneof=string number date enum bool"`
+	Required      bool      `json:"required,omitempty"`
+	AllowedValues []string  `json:"allowedValues,omitempty" validate:"required_if=Type enum,excluded_unless=Type enum,dive,required,excludesall=0x20"`
+	CreateTime    time.Time `json:"createTime"`
+}
+
+// Validate checks that the definition is well formed.
+func (d FieldDefinition) Validate() error {
+	return validate.Struct(d)
+}
+
+// Coerce converts a raw value into the canonical representation for the field type.
+// Strings are parsed (as given on the command line), native JSON values are checked.
+// Dates are stored as RFC3339 strings in UTC so they round-trip through JSON unchanged.
+func (d FieldDefinition) Coerce(value any) (any, error) {
+	switch d.Type {
+	case FieldTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", d.Name)
+		}
+		return s, nil
+
+	case FieldTypeEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", d.Name)
+		}
+		return s, nil
+
+	case FieldTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", d.Name)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("%s must be a number", d.Name)
+
+	case FieldTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", d.Name)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("%s must be true or false", d.Name)
+
+	case FieldTypeDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().Format(time.RFC3339), nil
+		case string:
+			t, err := ParseDate(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD or RFC3339)", d.Name)
+			}
+			return t.UTC().Format(time.RFC3339), nil
+		}
+		return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD or RFC3339)", d.Name)
+	}
+
+	return nil, fmt.Errorf("%s has unknown type %q", d.Name, d.Type)
+}
+
+// ParseDate parses a date given either as YYYY-MM-DD or as RFC3339.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// ParseFieldAssignment splits a key=value expression as given to --field.
+func ParseFieldAssignment(expr string) (string, string, error) {
+	key, value, ok := strings.Cut(expr, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("%w: field must be in the form key=value, got %q", ErrInvalidInput, expr)
+	}
+	return key, value, nil
+}
+
+// FieldOperator is a comparison operator used when filtering on custom fields.
+type FieldOperator string
+
+const (
+	FieldOpEq  FieldOperator = "="
+	FieldOpNe  FieldOperator = "!="
+	FieldOpGt  FieldOperator = ">"
+	FieldOpGte FieldOperator = ">="
+	FieldOpLt  FieldOperator = "<"
+	FieldOpLte FieldOperator = "<="
+)
+
+// IsValid checks if the operator is one of the defined values.
+func (o FieldOperator) IsValid() bool {
+	switch o {
+	case FieldOpEq, FieldOpNe, FieldOpGt, FieldOpGte, FieldOpLt, FieldOpLte:
+		return true
+	}
+	return false
+}
+
+// IsRange returns true for the ordering operators (>, >=, <, <=).
+func (o FieldOperator) IsRange() bool {
+	switch o {
+	case FieldOpGt, FieldOpGte, FieldOpLt, FieldOpLte:
+		return true
+	}
+	return false
+}
+
+// FieldCondition filters todos on the value of a custom field.
+type FieldCondition struct {
+	// Field is the custom field name.
+	Field string
+
+	// Op is the comparison operator.
+	Op FieldOperator
+
+	// Value is the value to compare against. It is a raw string when parsed from
+	// the command line and is coerced to the field's type by the service.
+	Value any
+}
+
+// ParseFieldCondition parses a key<op>value expression as given to --where.
+func ParseFieldCondition(expr string) (FieldCondition, error) {
+	// Two character operators must be tried before their one character prefixes.
+	for _, op := range []FieldOperator{FieldOpNe, FieldOpGte, FieldOpLte, FieldOpEq, FieldOpGt, FieldOpLt} {
+		key, value, ok := strings.Cut(expr, string(op))
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			break
+		}
+		return FieldCondition{Field: key, Op: op, Value: strings.TrimSpace(value)}, nil
+	}
+
+	return FieldCondition{}, fmt.Errorf("%w: condition must be in the form key<op>value (ops: = != > >= < <=), got %q", ErrInvalidInput, expr)
+}
+
+// validateFields coerces values to their declared types and checks allowed values.
+// Unknown field names are rejected and nil values are kept as removals. Required
+// fields are checked against current (the todo's existing fields) merged with values.
+// It returns the coerced values.
+func validateFields(defs []*FieldDefinition, values map[string]any, current map[string]any) (map[string]any, error) {
+	byName := make(map[string]*FieldDefinition, len(defs))
+	for _, d := range defs {
+		byName[d.Name] = d
+	}
+
+	errs := FieldErrors{}
+	coerced := make(map[string]any, len(values))
+	for name, value := range values {
+		def, ok := byName[name]
+		if !ok {
+			errs[name] = fmt.Sprintf("%s is not a defined field", name)
+			continue
+		}
+
+		if value == nil {
+			coerced[name] = nil
+			continue
+		}
+
+		v, err := def.Coerce(value)
+		if err != nil {
+			errs[name] = err.Error()
+			continue
+		}
+
+		if def.Type == FieldTypeEnum {
+			if msg := validateVar(name, v, "oneof="+strings.Join(def.AllowedValues, " ")); msg != "" {
+				errs[name] = msg
+				continue
+			}
+		}
+
+		coerced[name] = v
+	}
+
+	for _, def := range defs {
+		if !def.Required {
+			continue
+		}
+		value, ok := values[def.Name]
+		if !ok {
+			value = current[def.Name]
+		}
+		if value == nil {
+			errs[def.Name] = validateVar(def.Name, nil, "required")
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if len(coerced) == 0 {
+		return nil, nil
+	}
+
+	return coerced, nil
+}
+
+// resolveConditions coerces the values of field conditions to their declared types.
+func resolveConditions(defs []*FieldDefinition, conds []FieldCondition) ([]FieldCondition, error) {
+	resolved := make([]FieldCondition, 0, len(conds))
+	for _, c := range conds {
+		i := slices.IndexFunc(defs, func(d *FieldDefinition) bool { return d.Name == c.Field })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s is not a defined field", ErrInvalidInput, c.Field)
+		}
+		def := defs[i]
+
+		if c.Op.IsRange() && (def.Type == FieldTypeBool || def.Type == FieldTypeEnum) {
+			return nil, fmt.Errorf("%w: operator %s is not supported for %s fields", ErrInvalidInput, c.Op, def.Type)
+		}
+
+		v, err := def.Coerce(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		c.Value = v
+		resolved = append(resolved, c)
+	}
+
+	return resolved, nil
+}
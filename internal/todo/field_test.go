@@ -0,0 +1,273 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFieldRepository is a mock repository that also implements FieldRepository.
+type MockFieldRepository struct {
+	MockRepository
+}
+
+func (m *MockFieldRepository) PutField(ctx context.Context, def *FieldDefinition) error {
+	args := m.Called(ctx, def)
+	return args.Error(0)
+}
+
+func (m *MockFieldRepository) ListFields(ctx context.Context) ([]*FieldDefinition, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*FieldDefinition), args.Error(1)
+}
+
+func (m *MockFieldRepository) DeleteField(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func testFieldDefinitions() []*FieldDefinition {
+	return []*FieldDefinition{
+		{Name: "points", Type: FieldTypeNumber},
+		{Name: "severity", Type: FieldTypeEnum, Required: true, AllowedValues: []string{"low", "high"}},
+		{Name: "due", Type: FieldTypeDate},
+		{Name: "customer", Type: FieldTypeString},
+		{Name: "billable", Type: FieldTypeBool},
+	}
+}
+
+func TestFieldDefinition_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      FieldDefinition
+		wantErrs map[string]string
+	}{
+		{
+			name: "valid number field",
+			def:  FieldDefinition{Name: "story_points", Type: FieldTypeNumber},
+		},
+		{
+			name: "valid enum field",
+			def:  FieldDefinition{Name: "severity", Type: FieldTypeEnum, AllowedValues: []string{"low", "high"}},
+		},
+		{
+			name: "invalid name",
+			def:  FieldDefinition{Name: "Story Points", Type: FieldTypeNumber},
+			wantErrs: map[string]string{
+				"Name": "Name must start with a lowercase letter and contain only lowercase letters, digits and underscores",
+			},
+		},
+		{
+			name: "invalid type",
+			def:  FieldDefinition{Name: "points", Type: FieldType("float")},
+			wantErrs: map[string]string{
+				"Type": "Type must be one of [string number date enum bool]",
+			},
+		},
+		{
+			name: "enum without allowed values",
+			def:  FieldDefinition{Name: "severity", Type: FieldTypeEnum},
+			wantErrs: map[string]string{
+				"AllowedValues": "AllowedValues is a required field",
+			},
+		},
+		{
+			name:     "allowed values on non-enum field",
+			def:      FieldDefinition{Name: "points", Type: FieldTypeNumber, AllowedValues: []string{"1"}},
+			wantErrs: map[string]string{"AllowedValues": "AllowedValues is an excluded field"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.Validate()
+			if tt.wantErrs == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Equal(t, tt.wantErrs, TranslateError(err))
+		})
+	}
+}
+
+func TestFieldDefinition_Coerce(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     FieldDefinition
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "number from string", def: FieldDefinition{Name: "n", Type: FieldTypeNumber}, value: "3.5", want: 3.5},
+		{name: "number from float", def: FieldDefinition{Name: "n", Type: FieldTypeNumber}, value: 2.0, want: 2.0},
+		{name: "number invalid", def: FieldDefinition{Name: "n", Type: FieldTypeNumber}, value: "abc", wantErr: true},
+		{name: "bool from string", def: FieldDefinition{Name: "b", Type: FieldTypeBool}, value: "true", want: true},
+		{name: "bool invalid", def: FieldDefinition{Name: "b", Type: FieldTypeBool}, value: "maybe", wantErr: true},
+		{name: "date only", def: FieldDefinition{Name: "d", Type: FieldTypeDate}, value: "2025-01-15", want: "2025-01-15T00:00:00Z"},
+		{name: "date rfc3339 normalised to utc", def: FieldDefinition{Name: "d", Type: FieldTypeDate}, value: "2025-01-15T10:00:00+02:00", want: "2025-01-15T08:00:00Z"},
+		{name: "date invalid", def: FieldDefinition{Name: "d", Type: FieldTypeDate}, value: "tomorrow", wantErr: true},
+		{name: "string from number", def: FieldDefinition{Name: "s", Type: FieldTypeString}, value: 1.0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.def.Coerce(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseFieldCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    FieldCondition
+		wantErr bool
+	}{
+		{expr: "points>=3", want: FieldCondition{Field: "points", Op: FieldOpGte, Value: "3"}},
+		{expr: "points>3", want: FieldCondition{Field: "points", Op: FieldOpGt, Value: "3"}},
+		{expr: "severity!=low", want: FieldCondition{Field: "severity", Op: FieldOpNe, Value: "low"}},
+		{expr: "due<=2025-01-01", want: FieldCondition{Field: "due", Op: FieldOpLte, Value: "2025-01-01"}},
+		{expr: "customer=acme", want: FieldCondition{Field: "customer", Op: FieldOpEq, Value: "acme"}},
+		{expr: "points", wantErr: true},
+		{expr: "=3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseFieldCondition(tt.expr)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidInput)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	defs := testFieldDefinitions()
+
+	tests := []struct {
+		name     string
+		values   map[string]any
+		current  map[string]any
+		want     map[string]any
+		wantErrs map[string]string
+	}{
+		{
+			name:   "valid values are coerced",
+			values: map[string]any{"points": "5", "severity": "high", "billable": "false"},
+			want:   map[string]any{"points": 5.0, "severity": "high", "billable": false},
+		},
+		{
+			name:     "missing required field",
+			values:   map[string]any{"points": "5"},
+			wantErrs: map[string]string{"severity": "severity is a required field"},
+		},
+		{
+			name:    "required field satisfied by current values",
+			values:  map[string]any{"points": "5"},
+			current: map[string]any{"severity": "low"},
+			want:    map[string]any{"points": 5.0},
+		},
+		{
+			name:     "removing a required field",
+			values:   map[string]any{"severity": nil},
+			current:  map[string]any{"severity": "low"},
+			wantErrs: map[string]string{"severity": "severity is a required field"},
+		},
+		{
+			name:     "value not allowed",
+			values:   map[string]any{"severity": "urgent"},
+			wantErrs: map[string]string{"severity": "severity must be one of [low high]"},
+		},
+		{
+			name:   "unknown field and bad type",
+			values: map[string]any{"sprint": "12", "points": "lots", "severity": "low"},
+			wantErrs: map[string]string{
+				"sprint": "sprint is not a defined field",
+				"points": "points must be a number",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateFields(defs, tt.values, tt.current)
+			if tt.wantErrs != nil {
+				require.Error(t, err)
+				require.Equal(t, tt.wantErrs, TranslateError(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_CreateTodo_WithFields(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fields are validated and stored", func(t *testing.T) {
+		repo := new(MockFieldRepository)
+		repo.On("ListFields", ctx).Return(testFieldDefinitions(), nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*todo.Todo")).Return(nil)
+		service := NewService(repo)
+
+		todo, err := service.CreateTodo(ctx, "Test", "", nil, WithFields(map[string]any{"points": "3", "severity": "low"}))
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"points": 3.0, "severity": "low"}, todo.Fields)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("validation errors are translatable", func(t *testing.T) {
+		repo := new(MockFieldRepository)
+		repo.On("ListFields", ctx).Return(testFieldDefinitions(), nil)
+		service := NewService(repo)
+
+		_, err := service.CreateTodo(ctx, "Test", "", nil)
+		require.ErrorIs(t, err, ErrInvalidInput)
+		require.Equal(t, map[string]string{"severity": "severity is a required field"}, TranslateError(err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository without field support", func(t *testing.T) {
+		service, repo := newTestService(t)
+
+		_, err := service.CreateTodo(ctx, "Test", "", nil, WithFields(map[string]any{"points": "3"}))
+		require.ErrorIs(t, err, ErrUnsupported)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_ListTodos_ResolvesConditions(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockFieldRepository)
+	repo.On("ListFields", ctx).Return(testFieldDefinitions(), nil)
+	repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool {
+		return len(f.Where) == 1 && f.Where[0].Value == 3.0
+	})).Return([]*Todo{}, nil)
+	service := NewService(repo)
+
+	filter := DefaultListFilter()
+	filter.Where = []FieldCondition{{Field: "points", Op: FieldOpGt, Value: "3"}}
+	_, err := service.ListTodos(ctx, filter)
+	require.NoError(t, err)
+
+	filter.Where = []FieldCondition{{Field: "billable", Op: FieldOpGt, Value: "true"}}
+	_, err = service.ListTodos(ctx, filter)
+	require.ErrorIs(t, err, ErrInvalidInput)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,143 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HideTerminal excludes completed and cancelled todos, as the CLI does by
+// default, unless the filter already constrains the status with Statuses,
+// ExcludeStatuses or a status term in its query.
+func (f *ListFilter) HideTerminal() {
+	if f.Status != "" || len(f.Statuses) > 0 || len(f.ExcludeStatuses) > 0 || f.Query.HasField(QueryFieldStatus) {
+		return
+	}
+	f.ExcludeStatuses = TerminalStatuses()
+}
+
+// Matches reports whether the todo satisfies every condition of the filter.
+// It lets backends without a query engine evaluate filters in memory; the
+// full-text search is a case-insensitive match of every word against the
+// title or description rather than a scored, fuzzy match.
+func (f ListFilter) Matches(t *Todo) bool {
+	if f.Status != "" && t.Status != f.Status {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, t.Status) {
+		return false
+	}
+	if slices.Contains(f.ExcludeStatuses, t.Status) {
+		return false
+	}
+
+	for _, label := range f.Labels {
+		if !slices.Contains(t.Labels, label) {
+			return false
+		}
+	}
+	if len(f.AnyLabels) > 0 && !slices.ContainsFunc(f.AnyLabels, func(l string) bool { return slices.Contains(t.Labels, l) }) {
+		return false
+	}
+	if slices.ContainsFunc(f.ExcludeLabels, func(l string) bool { return slices.Contains(t.Labels, l) }) {
+		return false
+	}
+
+	for _, word := range strings.Fields(f.SearchQuery) {
+		if !matchText(t.Title, word, false) && !matchText(t.Description, word, false) {
+			return false
+		}
+	}
+
+	if f.FromDate != nil && t.CreateTime.Before(*f.FromDate) {
+		return false
+	}
+	if f.ToDate != nil && t.CreateTime.After(*f.ToDate) {
+		return false
+	}
+
+	for _, c := range f.Where {
+		if !c.Matches(t.Fields) {
+			return false
+		}
+	}
+
+	return f.Query.Matches(t)
+}
+
+// Matches reports whether the custom field values satisfy the condition.
+// The condition's value must already be coerced to the field's type.
+// A missing field only matches !=.
+func (c FieldCondition) Matches(fields map[string]any) bool {
+	value, ok := fields[c.Field]
+	if !ok || value == nil {
+		return c.Op == FieldOpNe
+	}
+
+	switch c.Op {
+	case FieldOpEq:
+		return fmt.Sprint(value) == fmt.Sprint(c.Value)
+	case FieldOpNe:
+		return fmt.Sprint(value) != fmt.Sprint(c.Value)
+	}
+
+	// Numbers compare numerically, dates as RFC3339 strings in UTC
+	var order int
+	switch v := value.(type) {
+	case float64:
+		want, ok := c.Value.(float64)
+		if !ok {
+			return false
+		}
+		order = cmp.Compare(v, want)
+	case string:
+		want, ok := c.Value.(string)
+		if !ok {
+			return false
+		}
+		order = cmp.Compare(v, want)
+	default:
+		return false
+	}
+
+	switch c.Op {
+	case FieldOpGt:
+		return order > 0
+	case FieldOpGte:
+		return order >= 0
+	case FieldOpLt:
+		return order < 0
+	case FieldOpLte:
+		return order <= 0
+	}
+	return false
+}
+
+// SortTodos sorts todos in place by a field. Relevance cannot be computed in
+// memory, so sorting by it keeps the current order. Ties are broken by ID so
+// pages are stable.
+func SortTodos(todos []*Todo, by SortField, order SortOrder) {
+	if by == SortFieldRelevance {
+		return
+	}
+
+	slices.SortStableFunc(todos, func(a, b *Todo) int {
+		var c int
+		switch by {
+		case SortFieldUpdateTime:
+			c = a.UpdateTime.Compare(b.UpdateTime)
+		case SortFieldTitle:
+			c = strings.Compare(a.Title, b.Title)
+		case SortFieldStatus:
+			c = strings.Compare(string(a.Status), string(b.Status))
+		default:
+			c = a.CreateTime.Compare(b.CreateTime)
+		}
+		c = cmp.Or(c, strings.Compare(a.ID.String(), b.ID.String()))
+		if order == SortOrderDesc {
+			return -c
+		}
+		return c
+	})
+}
@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFilter_Matches(t *testing.T) {
+	created := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	todo := newTodoWith(t, "Fix login bug", "Users cannot sign in", "bug", "auth")
+	todo.Status = StatusInProgress
+	todo.CreateTime = created
+	todo.Fields = map[string]any{"points": 3.0, "severity": "high"}
+
+	before := created.Add(-time.Hour)
+	after := created.Add(time.Hour)
+	query, err := ParseQuery("title:login")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   bool
+	}{
+		{"empty filter", ListFilter{}, true},
+		{"status", ListFilter{Status: StatusInProgress}, true},
+		{"other status", ListFilter{Status: StatusPending}, false},
+		{"any of statuses", ListFilter{Statuses: []Status{StatusPending, StatusInProgress}}, true},
+		{"excluded status", ListFilter{ExcludeStatuses: []Status{StatusInProgress}}, false},
+		{"all labels", ListFilter{Labels: []string{"bug", "auth"}}, true},
+		{"missing label", ListFilter{Labels: []string{"bug", "ui"}}, false},
+		{"any labels", ListFilter{AnyLabels: []string{"ui", "auth"}}, true},
+		{"no matching any label", ListFilter{AnyLabels: []string{"ui"}}, false},
+		{"excluded label", ListFilter{ExcludeLabels: []string{"auth"}}, false},
+		{"search in title and description", ListFilter{SearchQuery: "LOGIN sign"}, true},
+		{"search word missing", ListFilter{SearchQuery: "login logout"}, false},
+		{"inside date range", ListFilter{FromDate: &before, ToDate: &after}, true},
+		{"before date range", ListFilter{FromDate: &after}, false},
+		{"number condition", ListFilter{Where: []FieldCondition{{Field: "points", Op: FieldOpGte, Value: 3.0}}}, true},
+		{"failed number condition", ListFilter{Where: []FieldCondition{{Field: "points", Op: FieldOpGt, Value: 3.0}}}, false},
+		{"equality condition", ListFilter{Where: []FieldCondition{{Field: "severity", Op: FieldOpEq, Value: "high"}}}, true},
+		{"missing field only matches !=", ListFilter{Where: []FieldCondition{{Field: "team", Op: FieldOpNe, Value: "core"}}}, true},
+		{"query", ListFilter{Query: query}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Matches(todo))
+		})
+	}
+}
+
+func TestSortTodos(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newTodoWith(t, "b task", "")
+	a.CreateTime = base
+	b := newTodoWith(t, "a task", "")
+	b.CreateTime = base.Add(time.Hour)
+	c := newTodoWith(t, "c task", "")
+	c.CreateTime = base.Add(2 * time.Hour)
+
+	todos := []*Todo{c, a, b}
+	SortTodos(todos, SortFieldCreateTime, SortOrderAsc)
+	require.Equal(t, []*Todo{a, b, c}, todos)
+
+	SortTodos(todos, SortFieldTitle, SortOrderDesc)
+	require.Equal(t, []*Todo{c, a, b}, todos)
+
+	SortTodos(todos, SortFieldRelevance, SortOrderDesc)
+	require.Equal(t, []*Todo{c, a, b}, todos, "relevance keeps the current order")
+}
+
+func TestListFilter_HideTerminal(t *testing.T) {
+	completed := mustParseQuery(t, "status:completed")
+	notBlocked := mustParseQuery(t, "label:bug -status:blocked")
+	text := mustParseQuery(t, "login label:bug")
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   []Status
+	}{
+		{"no status", ListFilter{}, TerminalStatuses()},
+		{"statuses", ListFilter{Statuses: []Status{StatusCompleted}}, nil},
+		{"excluded statuses", ListFilter{ExcludeStatuses: []Status{StatusBlocked}}, []Status{StatusBlocked}},
+		{"status in the query", ListFilter{Query: completed}, nil},
+		{"negated status in the query", ListFilter{Query: notBlocked}, nil},
+		{"query without status", ListFilter{Query: text}, TerminalStatuses()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.HideTerminal()
+			require.Equal(t, tt.want, tt.filter.ExcludeStatuses)
+		})
+	}
+}
+
+func TestListFilter_HideTerminal_StatusQuery(t *testing.T) {
+	done := &Todo{Title: "Ship it", Status: StatusCompleted}
+
+	// As "todoify list status:completed" builds it
+	filter := DefaultListFilter()
+	filter.Query = mustParseQuery(t, "status:completed")
+	filter.HideTerminal()
+	require.True(t, filter.Matches(done))
+	require.True(t, filter.Query.Matches(done))
+}
+
+func mustParseQuery(t *testing.T, input string) *Query {
+	t.Helper()
+	q, err := ParseQuery(input)
+	require.NoError(t, err)
+	return q
+}
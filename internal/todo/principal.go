@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"context"
+	"slices"
+)
+
+// Scope is a permission granted to a principal.
+type Scope string
+
+const (
+	// ScopeRead allows reading todos.
+	ScopeRead Scope = "read"
+	// ScopeWrite allows creating, changing and deleting todos. It implies ScopeRead.
+	ScopeWrite Scope = "write"
+)
+
+// IsValid checks if the scope is one of the defined scopes.
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeRead, ScopeWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllScopes returns all valid scopes.
+func AllScopes() []Scope {
+	return []Scope{ScopeRead, ScopeWrite}
+}
+
+// Principal is the authenticated caller of a request served over the API.
+// Local CLI commands run without one unless an rbac policy is configured.
+type Principal struct {
+	// Subject identifies the caller, such as a user name or JWT subject.
+	Subject string `json:"subject"`
+
+	// Scopes are the permissions granted to the caller.
+	Scopes []Scope `json:"scopes,omitempty"`
+
+	// Method is how the caller authenticated: "token" or "jwt", or "local"
+	// for the OS user running a CLI command.
+	Method string `json:"method"`
+
+	// TokenID is the personal access token used, if any.
+	TokenID string `json:"tokenId,omitempty"`
+
+	// Tenant is the tenant the caller belongs to, if any. See WithTenant.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// HasScope reports whether the principal was granted a scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	if slices.Contains(p.Scopes, scope) {
+		return true
+	}
+	return scope == ScopeRead && slices.Contains(p.Scopes, ScopeWrite)
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the principal of the context, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,734 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed query language expression used by list and search.
+//
+// The language is a sequence of terms that are AND-ed together:
+//
+//	status:pending label:bug -label:wontfix (title:"login" OR desc:oauth) created>2025-01-01 updated<7d sort:title
+//
+// Terms are either free text (matched against title and description) or
+// field:value pairs. Terms can be negated with a leading "-" or NOT, combined
+// with OR, and grouped with parentheses. Quoted values match as phrases.
+//
+// Date fields (created, updated) accept >, >=, <, <= with a date
+// (YYYY-MM-DD or RFC3339) or a relative age such as 12h, 7d or 2w. Ages read
+// naturally: updated<7d means "updated less than 7 days ago". With ":" a date
+// matches the whole calendar day.
+//
+// sort:<field> sets the sort field; prefix the field with "-" to sort descending.
+type Query struct {
+	// Expr is the filter expression (nil = match all).
+	Expr QueryExpr
+
+	// SortBy is set when the query contains a sort term.
+	SortBy SortField
+
+	// SortOrder is set when the query contains a sort term.
+	SortOrder SortOrder
+}
+
+// String returns the canonical form of the query, useful for debugging and tests.
+func (q *Query) String() string {
+	var parts []string
+	if q.Expr != nil {
+		parts = append(parts, q.Expr.String())
+	}
+	if q.SortBy != "" {
+		prefix := ""
+		if q.SortOrder == SortOrderDesc {
+			prefix = "-"
+		}
+		parts = append(parts, "sort:"+prefix+string(q.SortBy))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Matches reports whether the todo satisfies the query expression.
+// Backends that cannot translate the query natively use it as a predicate.
+func (q *Query) Matches(t *Todo) bool {
+	if q == nil || q.Expr == nil {
+		return true
+	}
+	return q.Expr.Matches(t)
+}
+
+// HasField reports whether any term of the query, negated or not, is on
+// field.
+func (q *Query) HasField(field QueryField) bool {
+	return q != nil && q.Expr != nil && exprHasField(q.Expr, field)
+}
+
+func exprHasField(e QueryExpr, field QueryField) bool {
+	switch e := e.(type) {
+	case *AndExpr:
+		return slices.ContainsFunc(e.Operands, func(op QueryExpr) bool { return exprHasField(op, field) })
+	case *OrExpr:
+		return slices.ContainsFunc(e.Operands, func(op QueryExpr) bool { return exprHasField(op, field) })
+	case *NotExpr:
+		return exprHasField(e.Operand, field)
+	case *TermExpr:
+		return e.Field == field
+	default:
+		return false
+	}
+}
+
+// QueryExpr is a node in the query abstract syntax tree.
+type QueryExpr interface {
+	// Matches reports whether the todo satisfies the expression.
+	Matches(t *Todo) bool
+
+	// String returns the canonical form of the expression.
+	String() string
+}
+
+// AndExpr matches when all of its operands match.
+type AndExpr struct {
+	Operands []QueryExpr
+}
+
+// Matches implements QueryExpr.
+func (e *AndExpr) Matches(t *Todo) bool {
+	for _, op := range e.Operands {
+		if !op.Matches(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements QueryExpr.
+func (e *AndExpr) String() string {
+	return "(AND " + joinExprs(e.Operands) + ")"
+}
+
+// OrExpr matches when any of its operands match.
+type OrExpr struct {
+	Operands []QueryExpr
+}
+
+// Matches implements QueryExpr.
+func (e *OrExpr) Matches(t *Todo) bool {
+	for _, op := range e.Operands {
+		if op.Matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements QueryExpr.
+func (e *OrExpr) String() string {
+	return "(OR " + joinExprs(e.Operands) + ")"
+}
+
+// NotExpr matches when its operand does not match.
+type NotExpr struct {
+	Operand QueryExpr
+}
+
+// Matches implements QueryExpr.
+func (e *NotExpr) Matches(t *Todo) bool {
+	return !e.Operand.Matches(t)
+}
+
+// String implements QueryExpr.
+func (e *NotExpr) String() string {
+	return "(NOT " + e.Operand.String() + ")"
+}
+
+func joinExprs(exprs []QueryExpr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// QueryField is a field that can be used in a query term.
+type QueryField string
+
+const (
+	QueryFieldText        QueryField = ""
+	QueryFieldStatus      QueryField = "status"
+	QueryFieldLabel       QueryField = "label"
+	QueryFieldTitle       QueryField = "title"
+	QueryFieldDescription QueryField = "desc"
+	QueryFieldCreated     QueryField = "created"
+	QueryFieldUpdated     QueryField = "updated"
+)
+
+// queryFieldAliases maps accepted field names to their canonical QueryField.
+var queryFieldAliases = map[string]QueryField{
+	"status":      QueryFieldStatus,
+	"label":       QueryFieldLabel,
+	"labels":      QueryFieldLabel,
+	"title":       QueryFieldTitle,
+	"desc":        QueryFieldDescription,
+	"description": QueryFieldDescription,
+	"created":     QueryFieldCreated,
+	"updated":     QueryFieldUpdated,
+}
+
+// IsDate returns true for fields holding timestamps.
+func (f QueryField) IsDate() bool {
+	return f == QueryFieldCreated || f == QueryFieldUpdated
+}
+
+// QueryOp is the comparison operator of a query term.
+type QueryOp string
+
+const (
+	QueryOpMatch QueryOp = ":"
+	QueryOpGt    QueryOp = ">"
+	QueryOpGte   QueryOp = ">="
+	QueryOpLt    QueryOp = "<"
+	QueryOpLte   QueryOp = "<="
+)
+
+// TermExpr matches a single field against a value.
+type TermExpr struct {
+	// Field is the field to match (QueryFieldText for free text).
+	Field QueryField
+
+	// Op is the comparison operator. For relative ages it has already been
+	// inverted so it always compares the timestamp against Time.
+	Op QueryOp
+
+	// Value is the raw value as written in the query.
+	Value string
+
+	// Phrase is true when the value was quoted.
+	Phrase bool
+
+	// Time is the resolved timestamp for date fields.
+	Time time.Time
+}
+
+// DayEnd returns the exclusive end of the calendar day matched by a date term using ":".
+func (e *TermExpr) DayEnd() time.Time {
+	return e.Time.AddDate(0, 0, 1)
+}
+
+// Matches implements QueryExpr.
+func (e *TermExpr) Matches(t *Todo) bool {
+	switch e.Field {
+	case QueryFieldStatus:
+		return t.Status == Status(e.Value)
+	case QueryFieldLabel:
+		return slices.Contains(t.Labels, e.Value)
+	case QueryFieldTitle:
+		return matchText(t.Title, e.Value, e.Phrase)
+	case QueryFieldDescription:
+		return matchText(t.Description, e.Value, e.Phrase)
+	case QueryFieldText:
+		return matchText(t.Title, e.Value, e.Phrase) || matchText(t.Description, e.Value, e.Phrase)
+	case QueryFieldCreated:
+		return e.matchTime(t.CreateTime)
+	case QueryFieldUpdated:
+		return e.matchTime(t.UpdateTime)
+	}
+	return false
+}
+
+func (e *TermExpr) matchTime(ts time.Time) bool {
+	switch e.Op {
+	case QueryOpMatch:
+		return !ts.Before(e.Time) && ts.Before(e.DayEnd())
+	case QueryOpGt:
+		return ts.After(e.Time)
+	case QueryOpGte:
+		return !ts.Before(e.Time)
+	case QueryOpLt:
+		return ts.Before(e.Time)
+	case QueryOpLte:
+		return !ts.After(e.Time)
+	}
+	return false
+}
+
+// matchText approximates full-text matching: a phrase must appear as-is and
+// otherwise every word must appear, ignoring case.
+func matchText(text, value string, phrase bool) bool {
+	text = strings.ToLower(text)
+	value = strings.ToLower(value)
+	if phrase {
+		return strings.Contains(text, value)
+	}
+	for _, word := range strings.Fields(value) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements QueryExpr.
+func (e *TermExpr) String() string {
+	value := e.Value
+	if e.Phrase {
+		value = strconv.Quote(value)
+	}
+	if e.Field.IsDate() {
+		value = e.Time.UTC().Format(time.RFC3339)
+	}
+	if e.Field == QueryFieldText {
+		return value
+	}
+	return string(e.Field) + string(e.Op) + value
+}
+
+// QueryError is returned when a query cannot be parsed.
+// It wraps ErrInvalidInput and points at the offending column.
+type QueryError struct {
+	// Input is the query that failed to parse.
+	Input string
+
+	// Column is the 1-based column of the offending character.
+	Column int
+
+	// Msg describes the problem.
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query error at column %d: %s", e.Column, e.Msg)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidInput).
+func (e *QueryError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// Pointer renders the query with a caret under the offending column.
+func (e *QueryError) Pointer() string {
+	return e.Input + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
+
+// ParseQuery parses a query language expression.
+// Relative ages are resolved against the current time.
+func ParseQuery(input string) (*Query, error) {
+	return parseQueryAt(input, time.Now())
+}
+
+// maxQueryDepth is how deeply negations and parentheses may nest, so a
+// crafted query cannot exhaust the stack of the parser or the backend.
+const maxQueryDepth = 32
+
+func parseQueryAt(input string, now time.Time) (*Query, error) {
+	p := &queryParser{input: input, src: []rune(input), now: now, query: &Query{}}
+
+	p.skipSpace()
+	if p.eof() {
+		return p.query, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", string(p.src[p.pos]))
+	}
+
+	p.query.Expr = expr
+	return p.query, nil
+}
+
+// queryParser is a recursive descent parser for the query language:
+//
+//	query   := or EOF
+//	or      := and ( "OR" and )*
+//	and     := unary ( ["AND"] unary )*
+//	unary   := ( "-" | "NOT" ) unary | primary
+//	primary := "(" or ")" | term
+//	term    := [ field op ] value
+type queryParser struct {
+	input string
+	src   []rune
+	pos   int
+	depth int
+	now   time.Time
+	query *Query
+}
+
+func (p *queryParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...any) error {
+	return &QueryError{Input: p.input, Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// enter descends into a nested expression that starts at pos, failing when
+// it is nested too deeply. Callers decrement depth when they return.
+func (p *queryParser) enter(pos int) error {
+	if p.depth >= maxQueryDepth {
+		return p.errorf(pos, "query is nested more than %d levels deep", maxQueryDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *queryParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword reports whether the upcoming bare word is the given keyword.
+func (p *queryParser) keyword(kw string) bool {
+	end := p.pos + len(kw)
+	if end > len(p.src) || string(p.src[p.pos:end]) != kw {
+		return false
+	}
+	return end == len(p.src) || unicode.IsSpace(p.src[end]) || p.src[end] == '('
+}
+
+func (p *queryParser) parseOr() (QueryExpr, error) {
+	start := p.pos
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []QueryExpr{left}
+	for {
+		p.skipSpace()
+		if !p.keyword("OR") {
+			break
+		}
+		p.pos += len("OR")
+		p.skipSpace()
+
+		start := p.pos
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if right == nil {
+			return nil, p.errorf(start, "expected a search term after OR")
+		}
+		operands = append(operands, right)
+	}
+
+	if len(operands) == 1 {
+		return left, nil
+	}
+	if left == nil {
+		return nil, p.errorf(start, "expected a search term before OR")
+	}
+	return &OrExpr{Operands: operands}, nil
+}
+
+func (p *queryParser) parseAnd() (QueryExpr, error) {
+	start := p.pos
+	var operands []QueryExpr
+	for {
+		p.skipSpace()
+		if p.eof() || p.src[p.pos] == ')' || p.keyword("OR") {
+			break
+		}
+		if p.keyword("AND") {
+			p.pos += len("AND")
+			continue
+		}
+
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if expr != nil {
+			operands = append(operands, expr)
+		}
+	}
+
+	switch len(operands) {
+	case 0:
+		if p.depth == 0 && p.eof() && p.query.SortBy != "" {
+			// A query consisting only of sort terms
+			return nil, nil
+		}
+		return nil, p.errorf(start, "expected a search term")
+	case 1:
+		return operands[0], nil
+	}
+	return &AndExpr{Operands: operands}, nil
+}
+
+func (p *queryParser) parseUnary() (QueryExpr, error) {
+	negation := p.pos
+	negate := false
+	if p.keyword("NOT") {
+		p.pos += len("NOT")
+		p.skipSpace()
+		negate = true
+	} else if p.src[p.pos] == '-' && p.pos+1 < len(p.src) && !unicode.IsSpace(p.src[p.pos+1]) {
+		p.pos++
+		negate = true
+	}
+
+	if !negate {
+		return p.parsePrimary()
+	}
+
+	start := p.pos
+	if p.eof() {
+		return nil, p.errorf(start, "expected a search term after negation")
+	}
+	if err := p.enter(negation); err != nil {
+		return nil, err
+	}
+	operand, err := p.parseUnary()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+	if operand == nil {
+		return nil, p.errorf(start, "sort cannot be negated")
+	}
+	return &NotExpr{Operand: operand}, nil
+}
+
+func (p *queryParser) parsePrimary() (QueryExpr, error) {
+	if p.src[p.pos] != '(' {
+		return p.parseTerm()
+	}
+
+	open := p.pos
+	if err := p.enter(open); err != nil {
+		return nil, err
+	}
+	p.pos++
+	expr, err := p.parseOr()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.eof() || p.src[p.pos] != ')' {
+		return nil, p.errorf(open, "missing closing ')'")
+	}
+	p.pos++
+
+	return expr, nil
+}
+
+func (p *queryParser) parseTerm() (QueryExpr, error) {
+	start := p.pos
+
+	// Try to read "field op"
+	i := p.pos
+	for i < len(p.src) && (unicode.IsLetter(p.src[i]) || p.src[i] == '_') {
+		i++
+	}
+	if i > p.pos && i < len(p.src) {
+		if op, n := readQueryOp(p.src[i:]); n > 0 {
+			name := string(p.src[p.pos:i])
+			p.pos = i + n
+			return p.parseFieldTerm(start, name, op)
+		}
+	}
+
+	// Free text
+	value, phrase, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &TermExpr{Field: QueryFieldText, Op: QueryOpMatch, Value: value, Phrase: phrase}, nil
+}
+
+func readQueryOp(src []rune) (QueryOp, int) {
+	switch src[0] {
+	case ':':
+		return QueryOpMatch, 1
+	case '>', '<':
+		if len(src) > 1 && src[1] == '=' {
+			return QueryOp(string(src[:2])), 2
+		}
+		return QueryOp(string(src[0])), 1
+	}
+	return "", 0
+}
+
+func (p *queryParser) parseFieldTerm(start int, name string, op QueryOp) (QueryExpr, error) {
+	valuePos := p.pos
+	if p.eof() || unicode.IsSpace(p.src[p.pos]) || p.src[p.pos] == ')' {
+		return nil, p.errorf(valuePos, "expected a value after %s%s", name, op)
+	}
+
+	value, phrase, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(name) == "sort" {
+		return nil, p.applySort(start, valuePos, value, op)
+	}
+
+	field, ok := queryFieldAliases[strings.ToLower(name)]
+	if !ok {
+		return nil, p.errorf(start, "unknown field %q (valid: status, label, title, desc, created, updated, sort)", name)
+	}
+
+	term := &TermExpr{Field: field, Op: op, Value: value, Phrase: phrase}
+
+	if op != QueryOpMatch && !field.IsDate() {
+		return nil, p.errorf(start, "operator %s is only supported for created and updated", op)
+	}
+
+	switch {
+	case field == QueryFieldStatus:
+		if !Status(value).IsValid() {
+			return nil, p.errorf(valuePos, "invalid status %q (valid: pending, in_progress, completed, cancelled, blocked)", value)
+		}
+	case field.IsDate():
+		if err := p.resolveTime(term, valuePos); err != nil {
+			return nil, err
+		}
+	}
+
+	return term, nil
+}
+
+func (p *queryParser) applySort(start, valuePos int, value string, op QueryOp) error {
+	if op != QueryOpMatch {
+		return p.errorf(start, "sort must be written as sort:<field>")
+	}
+	if p.depth > 0 {
+		return p.errorf(start, "sort is only allowed at the top level of a query")
+	}
+
+	order := SortOrderAsc
+	if strings.HasPrefix(value, "-") {
+		order = SortOrderDesc
+		value = value[1:]
+	}
+
+	field, ok := sortFieldAliases[value]
+	if !ok {
+		return p.errorf(valuePos, "invalid sort field %q (valid: created, updated, title, status, score)", value)
+	}
+
+	p.query.SortBy = field
+	p.query.SortOrder = order
+	return nil
+}
+
+// sortFieldAliases maps query language sort names to SortField values.
+var sortFieldAliases = map[string]SortField{
+	"created":    SortFieldCreateTime,
+	"createTime": SortFieldCreateTime,
+	"updated":    SortFieldUpdateTime,
+	"updateTime": SortFieldUpdateTime,
+	"title":      SortFieldTitle,
+	"status":     SortFieldStatus,
+	"score":      SortFieldRelevance,
+	"relevance":  SortFieldRelevance,
+}
+
+// resolveTime parses a date or relative age and stores the absolute time on the term.
+// Relative ages invert the operator: updated<7d means updateTime > now-7d.
+func (p *queryParser) resolveTime(term *TermExpr, valuePos int) error {
+	if age, ok := parseAge(term.Value); ok {
+		if term.Op == QueryOpMatch {
+			return p.errorf(valuePos, "relative ages need a comparison, e.g. %s<%s", term.Field, term.Value)
+		}
+		term.Time = p.now.Add(-age)
+		term.Op = invertQueryOp(term.Op)
+		return nil
+	}
+
+	t, err := ParseDate(term.Value)
+	if err != nil {
+		return p.errorf(valuePos, "invalid date %q (use YYYY-MM-DD, RFC3339 or a relative age like 7d)", term.Value)
+	}
+	term.Time = t
+	return nil
+}
+
+func invertQueryOp(op QueryOp) QueryOp {
+	switch op {
+	case QueryOpGt:
+		return QueryOpLt
+	case QueryOpGte:
+		return QueryOpLte
+	case QueryOpLt:
+		return QueryOpGt
+	case QueryOpLte:
+		return QueryOpGte
+	}
+	return op
+}
+
+// parseAge parses relative ages such as 12h, 7d and 2w.
+func parseAge(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	switch s[len(s)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, true
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, true
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// parseValue reads a quoted string or a bare word.
+func (p *queryParser) parseValue() (string, bool, error) {
+	if p.src[p.pos] == '"' {
+		open := p.pos
+		p.pos++
+		var b strings.Builder
+		for !p.eof() {
+			r := p.src[p.pos]
+			if r == '\\' && p.pos+1 < len(p.src) {
+				b.WriteRune(p.src[p.pos+1])
+				p.pos += 2
+				continue
+			}
+			if r == '"' {
+				p.pos++
+				return b.String(), true, nil
+			}
+			b.WriteRune(r)
+			p.pos++
+		}
+		return "", false, p.errorf(open, "unterminated quoted string")
+	}
+
+	start := p.pos
+	for !p.eof() {
+		r := p.src[p.pos]
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+		if r == '"' {
+			return "", false, p.errorf(p.pos, "unexpected quote inside a word")
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", false, p.errorf(start, "expected a value")
+	}
+	return string(p.src[start:p.pos]), false, nil
+}
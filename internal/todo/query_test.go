@@ -0,0 +1,159 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var queryNow = time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty query",
+			input: "  ",
+			want:  "",
+		},
+		{
+			name:  "single term",
+			input: "status:pending",
+			want:  "status:pending",
+		},
+		{
+			name:  "full example",
+			input: `status:pending label:bug -label:wontfix (title:"login" OR desc:oauth) created>2025-01-01 updated<7d sort:title`,
+			want:  `(AND status:pending label:bug (NOT label:wontfix) (OR title:"login" desc:oauth) created>2025-01-01T00:00:00Z updated>2025-06-08T12:00:00Z) sort:title`,
+		},
+		{
+			name:  "free text and phrase",
+			input: `oauth "refresh token"`,
+			want:  `(AND oauth "refresh token")`,
+		},
+		{
+			name:  "explicit AND and NOT keyword",
+			input: "label:a AND NOT label:b",
+			want:  "(AND label:a (NOT label:b))",
+		},
+		{
+			name:  "OR binds looser than AND",
+			input: "label:a label:b OR label:c",
+			want:  "(OR (AND label:a label:b) label:c)",
+		},
+		{
+			name:  "relative age inverts comparison",
+			input: "created>=2w",
+			want:  "created<=2025-06-01T12:00:00Z",
+		},
+		{
+			name:  "descending sort only",
+			input: "sort:-updated",
+			want:  "sort:-updateTime",
+		},
+		{
+			name:  "hyphenated free text is not negation",
+			input: "- foo-bar",
+			want:  "(AND - foo-bar)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseQueryAt(tt.input, queryNow)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, q.String())
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		column  int
+		message string
+	}{
+		{name: "unknown field", input: "status:pending priority:high", column: 16, message: `unknown field "priority"`},
+		{name: "invalid status", input: "status:done", column: 8, message: `invalid status "done"`},
+		{name: "missing closing paren", input: "label:a (label:b OR label:c", column: 9, message: "missing closing ')'"},
+		{name: "unexpected closing paren", input: "label:a)", column: 8, message: `unexpected ")"`},
+		{name: "unterminated quote", input: `title:"login`, column: 7, message: "unterminated quoted string"},
+		{name: "missing value", input: "label: bug", column: 7, message: "expected a value after label:"},
+		{name: "invalid date", input: "created>yesterday", column: 9, message: `invalid date "yesterday"`},
+		{name: "comparison on non-date", input: "label>bug", column: 1, message: "operator > is only supported"},
+		{name: "nested sort", input: "(label:a sort:title)", column: 10, message: "sort is only allowed at the top level"},
+		{name: "dangling OR", input: "label:a OR", column: 11, message: "expected a search term"},
+		{name: "empty group", input: "label:a ()", column: 10, message: "expected a search term"},
+		{name: "deeply nested groups", input: strings.Repeat("(", 40) + "label:a" + strings.Repeat(")", 40), column: 33, message: "nested more than 32 levels"},
+		{name: "deeply nested negations", input: "label:a " + strings.Repeat("-", 40) + "label:b", column: 41, message: "nested more than 32 levels"},
+		{name: "deeply nested mix", input: strings.Repeat("NOT (", 20) + "label:a" + strings.Repeat(")", 20), column: 81, message: "nested more than 32 levels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQueryAt(tt.input, queryNow)
+			require.ErrorIs(t, err, ErrInvalidInput)
+
+			var qe *QueryError
+			require.True(t, errors.As(err, &qe))
+			require.Equal(t, tt.column, qe.Column)
+			require.Contains(t, qe.Msg, tt.message)
+		})
+	}
+
+	t.Run("nesting at the limit", func(t *testing.T) {
+		input := strings.Repeat("(", maxQueryDepth) + "label:a" + strings.Repeat(")", maxQueryDepth)
+		_, err := parseQueryAt(input, queryNow)
+		require.NoError(t, err)
+	})
+}
+
+func TestQueryError_Pointer(t *testing.T) {
+	_, err := parseQueryAt("status:done", queryNow)
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe))
+	require.Equal(t, "status:done\n       ^", qe.Pointer())
+}
+
+func TestQuery_Matches(t *testing.T) {
+	todo := &Todo{
+		Title:       "Fix login page",
+		Description: "OAuth redirect is broken",
+		Labels:      []string{"bug", "frontend"},
+		Status:      StatusPending,
+		CreateTime:  time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		UpdateTime:  time.Date(2025, 6, 14, 9, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "", want: true},
+		{input: "status:pending label:bug", want: true},
+		{input: "status:pending -label:bug", want: false},
+		{input: `title:"login page"`, want: true},
+		{input: `title:"page login"`, want: false},
+		{input: "oauth broken", want: true},
+		{input: "label:wontfix OR desc:oauth", want: true},
+		{input: "created:2025-03-01", want: true},
+		{input: "created:2025-03-02", want: false},
+		{input: "created>2025-01-01 updated<7d", want: true},
+		{input: "updated>7d", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := parseQueryAt(tt.input, queryNow)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, q.Matches(todo))
+		})
+	}
+}
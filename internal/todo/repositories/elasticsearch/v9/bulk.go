@@ -0,0 +1,87 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// PutMany stores todos in a single bulk request, keeping their IDs and
+// timestamps. Without overwrite, todos are written with op_type create and
+// existing IDs are reported as conflicts. With tenant isolation, todos of
+// another tenant are conflicts too and are never overwritten. The request
+// waits for a refresh so the todos are searchable when it returns.
+func (r *Repository) PutMany(ctx context.Context, todos []*todo.Todo, overwrite bool) ([]string, error) {
+	if len(todos) == 0 {
+		return nil, nil
+	}
+
+	res, conflicts, err := r.putMany(ctx, todos, overwrite)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || !res.Errors {
+		return conflicts, nil
+	}
+
+	for _, item := range res.Items {
+		for _, result := range item {
+			switch {
+			case result.Error == nil:
+			case result.Status == http.StatusConflict && !overwrite:
+				conflicts = append(conflicts, *result.Id_)
+			default:
+				return conflicts, fmt.Errorf("failed to write todo %s: %s", *result.Id_, errorReason(result.Error))
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// ExportSchema returns the live mappings and settings of the index behind the
+// alias as returned by Elasticsearch.
+func (r *Repository) ExportSchema(ctx context.Context) (mappings, settings json.RawMessage, err error) {
+	plan, err := r.PlanMigration(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if plan.CurrentIndex == "" {
+		return nil, nil, fmt.Errorf("index %s does not exist", r.indexName)
+	}
+
+	mappingRes, err := r.client.Indices.GetMapping().Index(plan.CurrentIndex).Perform(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	defer mappingRes.Body.Close()
+	if mappingRes.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("failed to get mapping: status %d", mappingRes.StatusCode)
+	}
+	var mappingsByIndex map[string]struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.NewDecoder(mappingRes.Body).Decode(&mappingsByIndex); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode mapping: %w", err)
+	}
+
+	settingsRes, err := r.client.Indices.GetSettings().Index(plan.CurrentIndex).Perform(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	defer settingsRes.Body.Close()
+	if settingsRes.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("failed to get settings: status %d", settingsRes.StatusCode)
+	}
+	var settingsByIndex map[string]struct {
+		Settings json.RawMessage `json:"settings"`
+	}
+	if err := json.NewDecoder(settingsRes.Body).Decode(&settingsByIndex); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+
+	return mappingsByIndex[plan.CurrentIndex].Mappings, settingsByIndex[plan.CurrentIndex].Settings, nil
+}
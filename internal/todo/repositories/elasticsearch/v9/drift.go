@@ -0,0 +1,190 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// MappingDrift lists the differences between a live index and the embedded definition.
+type MappingDrift struct {
+	// Index is the concrete index that was checked.
+	Index string `json:"index"`
+
+	// Missing are fields defined in the embedded mapping but absent from the index.
+	Missing []string `json:"missing,omitempty"`
+
+	// Mismatched are fields and settings whose live value differs from the embedded one.
+	Mismatched []FieldMismatch `json:"mismatched,omitempty"`
+
+	// Unexpected are fields in the index that the embedded mapping does not define,
+	// usually added by dynamic mapping. Custom fields matched by a dynamic template are expected.
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+// FieldMismatch is a field type or setting that differs from the embedded definition.
+type FieldMismatch struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// HasDrift returns true when the index differs from the embedded definition.
+func (d *MappingDrift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Mismatched) > 0 || len(d.Unexpected) > 0
+}
+
+// CheckMapping compares the live mapping of index with the embedded todo index
+// definition and its settings with the configured ones.
+func (r *Repository) CheckMapping(ctx context.Context, index string) (*MappingDrift, error) {
+	mappingRes, err := r.client.Indices.GetMapping().Index(index).Perform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	defer mappingRes.Body.Close()
+	if mappingRes.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get mapping: status %d", mappingRes.StatusCode)
+	}
+	var mappings map[string]struct {
+		Mappings map[string]any `json:"mappings"`
+	}
+	if err := json.NewDecoder(mappingRes.Body).Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("failed to decode mapping: %w", err)
+	}
+
+	settingsRes, err := r.client.Indices.GetSettings().Index(index).Perform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	defer settingsRes.Body.Close()
+	if settingsRes.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get settings: status %d", settingsRes.StatusCode)
+	}
+	var settings map[string]struct {
+		Settings map[string]any `json:"settings"`
+	}
+	if err := json.NewDecoder(settingsRes.Body).Decode(&settings); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+
+	var expected struct {
+		Mappings map[string]any `json:"mappings"`
+	}
+	if err := json.Unmarshal(todoIndex, &expected); err != nil {
+		return nil, fmt.Errorf("failed to decode index definition: %w", err)
+	}
+
+	drift := compareMappings(expected.Mappings, mappings[index].Mappings)
+	drift.Mismatched = append(drift.Mismatched, compareSettings(r.settings.body(), settings[index].Settings)...)
+	drift.Index = index
+
+	return drift, nil
+}
+
+// compareMappings reports fields missing from live, fields whose type differs
+// and live fields the expected mapping does not define.
+func compareMappings(expected, live map[string]any) *MappingDrift {
+	want := map[string]string{}
+	flattenProperties(expected["properties"], "", want)
+	got := map[string]string{}
+	flattenProperties(live["properties"], "", got)
+
+	// Fields matching a dynamic template are created on demand
+	var dynamic []string
+	templates, _ := expected["dynamic_templates"].([]any)
+	for _, t := range templates {
+		named, _ := t.(map[string]any)
+		for _, def := range named {
+			if m, ok := def.(map[string]any); ok {
+				if p, ok := m["path_match"].(string); ok {
+					dynamic = append(dynamic, p)
+				}
+			}
+		}
+	}
+
+	drift := &MappingDrift{}
+	for _, field := range slices.Sorted(maps.Keys(want)) {
+		actual, ok := got[field]
+		switch {
+		case !ok:
+			drift.Missing = append(drift.Missing, field)
+		case actual != want[field]:
+			drift.Mismatched = append(drift.Mismatched, FieldMismatch{Field: field, Expected: want[field], Actual: actual})
+		}
+	}
+	for _, field := range slices.Sorted(maps.Keys(got)) {
+		if _, ok := want[field]; ok {
+			continue
+		}
+		if slices.ContainsFunc(dynamic, func(pattern string) bool {
+			matched, _ := path.Match(pattern, field)
+			return matched
+		}) {
+			continue
+		}
+		drift.Unexpected = append(drift.Unexpected, field)
+	}
+
+	return drift
+}
+
+// flattenProperties collects dotted field paths and their types, including multi-fields.
+// Objects without an explicit type are reported as "object".
+func flattenProperties(properties any, prefix string, out map[string]string) {
+	props, _ := properties.(map[string]any)
+	for name, raw := range props {
+		def, _ := raw.(map[string]any)
+		field := prefix + name
+
+		typ, _ := def["type"].(string)
+		if typ == "" {
+			typ = "object"
+		}
+		out[field] = typ
+
+		flattenProperties(def["fields"], field+".", out)
+		flattenProperties(def["properties"], field+".", out)
+	}
+}
+
+// compareSettings reports expected settings whose live value differs. Live
+// settings are nested under "index" and hold every value as a string.
+func compareSettings(expected, live map[string]any) []FieldMismatch {
+	want := map[string]string{}
+	flattenSettings(expected, "index.", want)
+	got := map[string]string{}
+	flattenSettings(live, "", got)
+
+	var mismatched []FieldMismatch
+	for _, key := range slices.Sorted(maps.Keys(want)) {
+		if got[key] != want[key] {
+			actual := got[key]
+			if actual == "" {
+				actual = "(unset)"
+			}
+			mismatched = append(mismatched, FieldMismatch{Field: "settings." + key, Expected: want[key], Actual: actual})
+		}
+	}
+	return mismatched
+}
+
+func flattenSettings(settings map[string]any, prefix string, out map[string]string) {
+	for key, value := range settings {
+		// Expected settings may already be written with the index. prefix
+		full := prefix + key
+		if prefix == "index." && strings.HasPrefix(key, "index.") {
+			full = key
+		}
+
+		if nested, ok := value.(map[string]any); ok {
+			flattenSettings(nested, full+".", out)
+			continue
+		}
+		out[full] = fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,66 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSON(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestCompareMappings(t *testing.T) {
+	var embedded struct {
+		Mappings map[string]any `json:"mappings"`
+	}
+	require.NoError(t, json.Unmarshal(todoIndex, &embedded))
+
+	t.Run("embedded mapping has no drift against itself", func(t *testing.T) {
+		drift := compareMappings(embedded.Mappings, embedded.Mappings)
+		require.False(t, drift.HasDrift(), "%+v", drift)
+	})
+
+	t.Run("reports missing, mismatched and unexpected fields", func(t *testing.T) {
+		live := decodeJSON(t, `{
+			"properties": {
+				"id": {"type": "keyword"},
+				"tenant": {"type": "keyword"},
+				"title": {"type": "text", "fields": {"keyword": {"type": "keyword"}}},
+				"description": {"type": "text"},
+				"labels": {"type": "text"},
+				"status": {"type": "keyword"},
+				"createTime": {"type": "date"},
+				"updateTime": {"type": "date"},
+				"priority": {"type": "long"},
+				"fields": {"properties": {"points": {"type": "double"}}}
+			}
+		}`)
+
+		drift := compareMappings(embedded.Mappings, live)
+		require.Equal(t, []string{"title.suggest"}, drift.Missing)
+		require.Equal(t, []FieldMismatch{{Field: "labels", Expected: "keyword", Actual: "text"}}, drift.Mismatched)
+		require.Equal(t, []string{"priority"}, drift.Unexpected)
+	})
+}
+
+func TestCompareSettings(t *testing.T) {
+	expected := decodeJSON(t, `{"number_of_shards": 1, "number_of_replicas": 1, "analysis": {"analyzer": {"default": {"type": "standard"}}}}`)
+
+	t.Run("matching settings", func(t *testing.T) {
+		live := decodeJSON(t, `{"index": {"number_of_shards": "1", "number_of_replicas": "1", "uuid": "x", "analysis": {"analyzer": {"default": {"type": "standard"}}}}}`)
+		require.Empty(t, compareSettings(expected, live))
+	})
+
+	t.Run("differing settings", func(t *testing.T) {
+		live := decodeJSON(t, `{"index": {"number_of_shards": "1", "number_of_replicas": "0"}}`)
+		require.Equal(t, []FieldMismatch{
+			{Field: "settings.index.analysis.analyzer.default.type", Expected: "standard", Actual: "(unset)"},
+			{Field: "settings.index.number_of_replicas", Expected: "1", Actual: "0"},
+		}, compareSettings(expected, live))
+	})
+}
@@ -0,0 +1,74 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+)
+
+// duplicateMinimumShouldMatch is the share of selected terms a candidate must contain.
+const duplicateMinimumShouldMatch = "30%"
+
+// FindSimilar returns open todos similar to t using a more_like_this query on the
+// title and description, excluding t itself.
+func (r *Repository) FindSimilar(ctx context.Context, t *todo.Todo, limit int) ([]*todo.DuplicateCandidate, error) {
+	text := strings.TrimSpace(t.Title + " " + t.Description)
+	if text == "" {
+		return []*todo.DuplicateCandidate{}, nil
+	}
+
+	res, err := r.search(ctx, &search.Request{
+		Query: buildSimilarQuery(t, text),
+		Size:  &limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar todos: %w", err)
+	}
+
+	candidates := make([]*todo.DuplicateCandidate, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var found todo.Todo
+		if err := json.Unmarshal(hit.Source_, &found); err != nil {
+			return nil, fmt.Errorf("failed to parse todo document: %w", err)
+		}
+
+		candidate := &todo.DuplicateCandidate{Todo: &found}
+		if hit.Score_ != nil {
+			candidate.Score = float64(*hit.Score_)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+// buildSimilarQuery matches open todos sharing terms with text, excluding t itself.
+// Todos are small, so term and document frequency thresholds are lowered to 1.
+func buildSimilarQuery(t *todo.Todo, text string) *types.Query {
+	minFreq := 1
+
+	return &types.Query{
+		Bool: &types.BoolQuery{
+			Must: []types.Query{
+				{
+					MoreLikeThis: &types.MoreLikeThisQuery{
+						Fields:             []string{"title", "description"},
+						Like:               []types.Like{text},
+						MinTermFreq:        &minFreq,
+						MinDocFreq:         &minFreq,
+						MinimumShouldMatch: duplicateMinimumShouldMatch,
+					},
+				},
+			},
+			MustNot: []types.Query{
+				buildTerms("status", statusStrings(todo.TerminalStatuses())),
+				{Ids: &types.IdsQuery{Values: []string{t.ID.String()}}},
+			},
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/calendarinterval"
+)
+
+// Aggregation names used for facets.
+const (
+	statusFacet       = "statuses"
+	labelFacet        = "labels"
+	createdMonthFacet = "createdMonths"
+)
+
+// ListWithFacets retrieves a page of todos and computes status, label and
+// created-month counts with aggregations in the same search request.
+func (r *Repository) ListWithFacets(ctx context.Context, filter todo.ListFilter) ([]*todo.Todo, *todo.Facets, error) {
+	req := buildSearchRequest(filter)
+	req.Aggregations = buildFacetAggregations()
+
+	res, err := r.search(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+
+	todos := make([]*todo.Todo, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var t todo.Todo
+		if err := json.Unmarshal(hit.Source_, &t); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse todo document: %w", err)
+		}
+		todos = append(todos, &t)
+	}
+
+	facets := &todo.Facets{
+		Statuses:      termsBuckets(res.Aggregations[statusFacet]),
+		Labels:        termsBuckets(res.Aggregations[labelFacet]),
+		CreatedMonths: dateHistogramBuckets(res.Aggregations[createdMonthFacet]),
+	}
+
+	return todos, facets, nil
+}
+
+func buildFacetAggregations() map[string]types.Aggregations {
+	statusField := "status"
+	statusSize := len(todo.AllStatuses())
+	labelField := "labels"
+	labelSize := todo.MaxLabelFacets
+	createField := "createTime"
+	monthFormat := "yyyy-MM"
+	minDocCount := 1
+
+	return map[string]types.Aggregations{
+		statusFacet: {
+			Terms: &types.TermsAggregation{Field: &statusField, Size: &statusSize},
+		},
+		labelFacet: {
+			Terms: &types.TermsAggregation{Field: &labelField, Size: &labelSize},
+		},
+		createdMonthFacet: {
+			DateHistogram: &types.DateHistogramAggregation{
+				Field:            &createField,
+				CalendarInterval: &calendarinterval.Month,
+				Format:           &monthFormat,
+				MinDocCount:      &minDocCount,
+			},
+		},
+	}
+}
+
+// termsBuckets converts a string terms aggregation to facet buckets.
+func termsBuckets(agg types.Aggregate) []todo.FacetBucket {
+	facets := []todo.FacetBucket{}
+	terms, ok := agg.(*types.StringTermsAggregate)
+	if !ok {
+		return facets
+	}
+
+	buckets, ok := terms.Buckets.([]types.StringTermsBucket)
+	if !ok {
+		return facets
+	}
+
+	for _, b := range buckets {
+		facets = append(facets, todo.FacetBucket{Key: fmt.Sprint(b.Key), Count: int(b.DocCount)})
+	}
+
+	return facets
+}
+
+// dateHistogramBuckets converts a date histogram aggregation to facet buckets keyed by formatted date.
+func dateHistogramBuckets(agg types.Aggregate) []todo.FacetBucket {
+	facets := []todo.FacetBucket{}
+	histogram, ok := agg.(*types.DateHistogramAggregate)
+	if !ok {
+		return facets
+	}
+
+	buckets, ok := histogram.Buckets.([]types.DateHistogramBucket)
+	if !ok {
+		return facets
+	}
+
+	for _, b := range buckets {
+		key := fmt.Sprint(b.Key)
+		if b.KeyAsString != nil {
+			key = *b.KeyAsString
+		}
+		facets = append(facets, todo.FacetBucket{Key: key, Count: int(b.DocCount)})
+	}
+
+	return facets
+}
@@ -0,0 +1,29 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields_MissingIndex(t *testing.T) {
+	cluster, client := newFakeCluster(t, nil)
+	r := NewRepository(client, "todos")
+	cluster.missing = map[string]bool{r.fieldsIndexName(): true}
+	ctx := context.Background()
+
+	// Indices created before the registry existed have no definitions
+	defs, err := r.ListFields(ctx)
+	require.NoError(t, err)
+	require.Empty(t, defs)
+
+	err = r.DeleteField(ctx, "points")
+	require.ErrorIs(t, err, todo.ErrNotFound)
+
+	// So creating todos does not need the migration
+	service := todo.NewService(r)
+	_, err = service.CreateTodo(ctx, "Write docs", "", nil)
+	require.NoError(t, err)
+}
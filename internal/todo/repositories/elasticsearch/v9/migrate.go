@@ -0,0 +1,463 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/reindex"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/indices/updatealiases"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/optype"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+)
+
+// reconcileBatchSize is how many migrated todos are checked against the source at once.
+const reconcileBatchSize = 1000
+
+// reindexPollInterval is how often a running reindex task is checked for progress.
+const reindexPollInterval = time.Second
+
+// versionedIndexPattern matches versioned index names such as todos-v2.
+var versionedIndexPattern = regexp.MustCompile(`-v(\d+)$`)
+
+// MigrationPlan describes what Migrate will do to bring the todo index up to date.
+type MigrationPlan struct {
+	// Alias is the name the repository reads and writes through.
+	Alias string `json:"alias"`
+
+	// CurrentIndex is the index behind the alias, empty when nothing exists yet.
+	CurrentIndex string `json:"currentIndex,omitempty"`
+
+	// CurrentVersion is the mapping version of CurrentIndex. It is 0 for a
+	// concrete index created before versioned indices were introduced.
+	CurrentVersion int `json:"currentVersion"`
+
+	// TargetIndex is the index created from the embedded mapping.
+	TargetIndex string `json:"targetIndex"`
+
+	// TargetVersion is the version of the embedded mapping.
+	TargetVersion int `json:"targetVersion"`
+
+	// Docs is the number of documents to reindex.
+	Docs int64 `json:"docs"`
+}
+
+// UpToDate returns true when the alias already points at the target version.
+func (p *MigrationPlan) UpToDate() bool {
+	return p.CurrentIndex != "" && p.CurrentVersion >= p.TargetVersion
+}
+
+// Legacy returns true when the current index is a concrete index named like the alias.
+func (p *MigrationPlan) Legacy() bool {
+	return p.CurrentIndex == p.Alias
+}
+
+// MigrationProgress reports how far a reindex has got.
+type MigrationProgress struct {
+	Total   int64 `json:"total"`
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
+
+// PlanMigration detects the current mapping version behind the alias and
+// compares it with the embedded mapping.
+func (r *Repository) PlanMigration(ctx context.Context) (*MigrationPlan, error) {
+	target, err := mappingVersion(todoIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{
+		Alias:         r.indexName,
+		TargetIndex:   versionedIndexName(r.indexName, target),
+		TargetVersion: target,
+	}
+
+	exists, err := r.client.Indices.Exists(r.indexName).IsSuccess(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check index: %w", err)
+	}
+	if !exists {
+		return plan, nil
+	}
+
+	// Resolving the alias returns its backing indices; a concrete index returns itself
+	res, err := r.client.Indices.Get(r.indexName).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+	for name := range res {
+		version := 0
+		if m := versionedIndexPattern.FindStringSubmatch(name); m != nil && name != r.indexName {
+			version, _ = strconv.Atoi(m[1])
+		}
+		if plan.CurrentIndex == "" || version > plan.CurrentVersion {
+			plan.CurrentIndex = name
+			plan.CurrentVersion = version
+		}
+	}
+
+	count, err := r.client.Count().Index(plan.CurrentIndex).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+	plan.Docs = count.Count
+
+	return plan, nil
+}
+
+// Migrate applies a plan: it creates the target index from the embedded
+// mapping, reindexes the current index into it and atomically moves the alias.
+// Writes that land during the copy are caught up by copying recently updated
+// todos again and deleting todos that are gone from the current index. The
+// current index refuses writes from the catch-up until the swap, so nothing
+// is lost; the block is lifted again on failure and on a kept index. The
+// previous versioned index is kept for rollback; a legacy
+// concrete index is removed in the same atomic step, as the alias cannot share
+// its name.
+// ILM policies and index templates are installed first, the custom field
+// registry and access token indices are created when missing, new access
+// token fields are added to an existing token index, and an
+// up-to-date index gets the configured replicas and refresh interval.
+func (r *Repository) Migrate(ctx context.Context, plan *MigrationPlan, progress func(MigrationProgress)) (err error) {
+	if err := r.InstallTemplates(ctx); err != nil {
+		return err
+	}
+
+	if err := r.ensureIndex(ctx, r.fieldsIndexName(), fieldsIndex); err != nil {
+		return err
+	}
+	if err := r.ensureIndex(ctx, r.tokensIndexName(), tokensIndex); err != nil {
+		return err
+	}
+	if err := r.putMapping(ctx, r.tokensIndexName(), tokensIndex); err != nil {
+		return err
+	}
+
+	// Replicas and refresh interval can change in place, the rest needs a new index
+	if plan.UpToDate() {
+		return r.ApplyDynamicSettings(ctx, plan.CurrentIndex)
+	}
+
+	// The target may be left over from an interrupted migration; copying again is idempotent
+	if err := r.ensureIndex(ctx, plan.TargetIndex, todoIndex); err != nil {
+		return err
+	}
+
+	// Fresh install: nothing to copy
+	if plan.CurrentIndex == "" {
+		return r.swapAlias(ctx, plan)
+	}
+
+	started := time.Now().UTC()
+	if err := r.reindex(ctx, plan.CurrentIndex, plan.TargetIndex, nil, progress); err != nil {
+		return err
+	}
+
+	// Refuse writes to the source so nothing lands between the catch-up and the swap
+	if err := r.blockWrites(ctx, plan.CurrentIndex, true); err != nil {
+		return err
+	}
+	swapped := false
+	defer func() {
+		// A legacy index is gone after the swap; otherwise lift the block, also on failure
+		if swapped && plan.Legacy() {
+			return
+		}
+		if unblockErr := r.blockWrites(context.WithoutCancel(ctx), plan.CurrentIndex, false); unblockErr != nil {
+			err = errors.Join(err, unblockErr)
+		}
+	}()
+
+	// Copy todos created or updated while the first pass was running and drop
+	// those deleted meanwhile, then swap
+	since := types.Query{
+		Range: map[string]types.RangeQuery{
+			"updateTime": types.DateRangeQuery{Gte: ptr(started.Format(time.RFC3339))},
+		},
+	}
+	if err := r.reindex(ctx, plan.CurrentIndex, plan.TargetIndex, &since, nil); err != nil {
+		return err
+	}
+	if err := r.removeDeleted(ctx, plan.CurrentIndex, plan.TargetIndex); err != nil {
+		return err
+	}
+
+	if err := r.swapAlias(ctx, plan); err != nil {
+		return err
+	}
+	swapped = true
+
+	return nil
+}
+
+// blockWrites sets or lifts the write block on an index. Reads and metadata
+// changes such as alias updates still work on a blocked index.
+func (r *Repository) blockWrites(ctx context.Context, index string, block bool) error {
+	// null resets the setting rather than leaving an explicit false behind
+	var value any
+	if block {
+		value = true
+	}
+	body, err := json.Marshal(map[string]any{"index.blocks.write": value})
+	if err != nil {
+		return fmt.Errorf("failed to encode write block: %w", err)
+	}
+
+	res, err := r.client.Indices.PutSettings().Indices(index).Raw(bytes.NewReader(body)).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update write block of %s: %w", index, err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to update write block of %s: not acknowledged", index)
+	}
+
+	return nil
+}
+
+// removeDeleted deletes documents from dest that no longer exist in source,
+// paging dest by ID and checking each page against source. Only removeDeleted
+// writes to dest at this point, so the ID order stays stable between pages.
+func (r *Repository) removeDeleted(ctx context.Context, source, dest string) error {
+	if _, err := r.client.Indices.Refresh().Index(source + "," + dest).Do(ctx); err != nil {
+		return fmt.Errorf("failed to refresh indices: %w", err)
+	}
+
+	size := reconcileBatchSize
+	order := sortorder.Asc
+	var after []types.FieldValue
+	for {
+		res, err := r.client.Search().Index(dest).Request(&search.Request{
+			Size:    &size,
+			Source_: false,
+			Sort: []types.SortCombinations{
+				types.SortOptions{SortOptions: map[string]types.FieldSort{"id": {Order: &order}}},
+			},
+			SearchAfter: after,
+		}).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list migrated todos: %w", err)
+		}
+		hits := res.Hits.Hits
+		if len(hits) == 0 {
+			return nil
+		}
+
+		if err := r.deleteMissing(ctx, source, dest, hits); err != nil {
+			return err
+		}
+
+		if len(hits) < size {
+			return nil
+		}
+		after = hits[len(hits)-1].Sort
+	}
+}
+
+// deleteMissing deletes the hits from dest whose IDs are not found in source.
+func (r *Repository) deleteMissing(ctx context.Context, source, dest string, hits []types.Hit) error {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Id_ != nil {
+			ids = append(ids, *hit.Id_)
+		}
+	}
+
+	size := len(ids)
+	res, err := r.client.Search().Index(source).Request(&search.Request{
+		Query:   &types.Query{Ids: &types.IdsQuery{Values: ids}},
+		Size:    &size,
+		Source_: false,
+	}).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check source todos: %w", err)
+	}
+	found := make(map[string]bool, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		if hit.Id_ != nil {
+			found[*hit.Id_] = true
+		}
+	}
+
+	req := r.client.Bulk().Index(dest)
+	deleted := 0
+	for _, hit := range hits {
+		if hit.Id_ == nil || found[*hit.Id_] {
+			continue
+		}
+		if err := req.DeleteOp(types.DeleteOperation{Id_: hit.Id_, Routing: hit.Routing_}); err != nil {
+			return fmt.Errorf("failed to encode delete of %s: %w", *hit.Id_, err)
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	bulk, err := req.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete removed todos: %w", err)
+	}
+	if bulk.Errors {
+		return fmt.Errorf("failed to delete removed todos: bulk request reported errors")
+	}
+
+	return nil
+}
+
+// putMapping adds the fields of an embedded index definition to an existing
+// index. Fields can be added in place, so small indices such as the access
+// token index are not versioned.
+func (r *Repository) putMapping(ctx context.Context, name string, definition []byte) error {
+	var def struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal(definition, &def); err != nil {
+		return fmt.Errorf("failed to decode index %s: %w", name, err)
+	}
+
+	res, err := r.client.Indices.PutMapping(name).Raw(bytes.NewReader(def.Mappings)).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update mapping of %s: %w", name, err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to update mapping of %s: not acknowledged", name)
+	}
+
+	return nil
+}
+
+// ensureIndex creates an index unless it already exists.
+func (r *Repository) ensureIndex(ctx context.Context, name string, definition []byte) error {
+	exists, err := r.client.Indices.Exists(name).IsSuccess(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check index: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	return r.createIndex(ctx, name, definition)
+}
+
+// reindex copies documents from source to dest as a background task, polling it for progress.
+func (r *Repository) reindex(ctx context.Context, source, dest string, query *types.Query, progress func(MigrationProgress)) error {
+	res, err := r.client.Reindex().
+		Request(&reindex.Request{
+			Source: types.ReindexSource{Index: []string{source}, Query: query},
+			Dest:   types.ReindexDestination{Index: dest, OpType: &optype.Index},
+		}).
+		WaitForCompletion(false).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start reindex: %w", err)
+	}
+	if res.Task == nil {
+		return fmt.Errorf("failed to start reindex: no task returned")
+	}
+
+	ticker := time.NewTicker(reindexPollInterval)
+	defer ticker.Stop()
+	for {
+		task, err := r.client.Tasks.Get(*res.Task).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get reindex task: %w", err)
+		}
+
+		var status MigrationProgress
+		if len(task.Task.Status) > 0 {
+			if err := json.Unmarshal(task.Task.Status, &status); err != nil {
+				return fmt.Errorf("failed to parse reindex status: %w", err)
+			}
+		}
+		if progress != nil {
+			progress(status)
+		}
+
+		if task.Completed {
+			if task.Error != nil {
+				return fmt.Errorf("reindex failed: %s", errorReason(task.Error))
+			}
+			var result reindex.Response
+			if err := json.Unmarshal(task.Response, &result); err == nil && len(result.Failures) > 0 {
+				return fmt.Errorf("reindex failed for %d document(s)", len(result.Failures))
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// swapAlias points the alias at the target index in a single atomic request.
+func (r *Repository) swapAlias(ctx context.Context, plan *MigrationPlan) error {
+	isWriteIndex := true
+	actions := []types.IndicesAction{
+		{Add: &types.AddAction{Index: &plan.TargetIndex, Alias: &plan.Alias, IsWriteIndex: &isWriteIndex}},
+	}
+
+	switch {
+	case plan.CurrentIndex == "":
+	case plan.Legacy():
+		actions = append(actions, types.IndicesAction{RemoveIndex: &types.RemoveIndexAction{Index: &plan.CurrentIndex}})
+	default:
+		actions = append(actions, types.IndicesAction{Remove: &types.RemoveAction{Index: &plan.CurrentIndex, Alias: &plan.Alias}})
+	}
+
+	res, err := r.client.Indices.UpdateAliases().
+		Request(&updatealiases.Request{Actions: actions}).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update aliases: %w", err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to update aliases: not acknowledged")
+	}
+
+	return nil
+}
+
+// mappingVersion reads mappings._meta.version from an embedded index definition.
+func mappingVersion(definition []byte) (int, error) {
+	var def struct {
+		Mappings struct {
+			Meta struct {
+				Version int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal(definition, &def); err != nil {
+		return 0, fmt.Errorf("failed to decode index definition: %w", err)
+	}
+	if def.Mappings.Meta.Version < 1 {
+		return 0, fmt.Errorf("index definition has no mappings._meta.version")
+	}
+	return def.Mappings.Meta.Version, nil
+}
+
+// versionedIndexName returns the concrete index name for a mapping version, e.g. todos-v2.
+func versionedIndexName(alias string, version int) string {
+	return fmt.Sprintf("%s-v%d", alias, version)
+}
+
+// errorReason returns the most useful message from an error cause.
+func errorReason(cause *types.ErrorCause) string {
+	if cause.Reason != nil {
+		return *cause.Reason
+	}
+	return cause.Type
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,65 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveDeleted(t *testing.T) {
+	// The target holds a and b; b was deleted from the source during the copy
+	var bulk string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+
+		shards := map[string]any{"total": 1, "successful": 1, "skipped": 0, "failed": 0}
+		hits := func(hits ...map[string]any) map[string]any {
+			return map[string]any{
+				"took": 1, "timed_out": false, "_shards": shards, "pit_id": "pit",
+				"hits": map[string]any{"total": map[string]any{"value": len(hits), "relation": "eq"}, "hits": hits},
+			}
+		}
+
+		var resp any
+		switch r.URL.Path {
+		case "/todos-v1,todos-v2/_refresh":
+			resp = map[string]any{"_shards": shards}
+		case "/todos-v2/_search":
+			resp = hits(
+				map[string]any{"_index": "todos-v2", "_id": "a", "_score": nil, "sort": []any{"a"}},
+				map[string]any{"_index": "todos-v2", "_id": "b", "_routing": "acme", "_score": nil, "sort": []any{"b"}},
+			)
+		case "/todos-v1/_search":
+			require.Contains(t, string(body), `"values":["a","b"]`)
+			resp = hits(map[string]any{"_index": "todos-v1", "_id": "a", "_score": 1.0})
+		case "/todos-v2/_bulk":
+			bulk = string(body)
+			resp = map[string]any{"took": 1, "errors": false, "items": []any{}}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			resp = map[string]any{"error": map[string]any{"type": "not_found", "reason": r.URL.Path}, "status": 404}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewTypedClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	require.NoError(t, err)
+	r := NewRepository(client, "todos")
+
+	require.NoError(t, r.removeDeleted(context.Background(), "todos-v1", "todos-v2"))
+
+	lines := strings.Split(strings.TrimSpace(bulk), "\n")
+	require.Len(t, lines, 1)
+	require.JSONEq(t, `{"delete":{"_id":"b","routing":"acme"}}`, lines[0])
+}
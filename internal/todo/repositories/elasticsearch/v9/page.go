@@ -0,0 +1,98 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/closepointintime"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+)
+
+// pitKeepAlive is how long a point in time stays open between pages.
+const pitKeepAlive = "5m"
+
+// pitCursor is the state encoded in cursors: the point in time and the sort
+// values of the last hit on the previous page.
+type pitCursor struct {
+	PIT   string             `json:"pit"`
+	After []types.FieldValue `json:"after"`
+}
+
+// ListPage retrieves one page of todos using search_after over a point in time,
+// so pages stay consistent while data changes and can go past the result window.
+// The point in time is opened on the first page and closed after the last one.
+func (r *Repository) ListPage(ctx context.Context, filter todo.ListFilter) (*todo.Page, error) {
+	var cur pitCursor
+	if filter.Cursor != "" {
+		if err := todo.DecodeCursor(filter.Cursor, &cur); err != nil {
+			return nil, err
+		}
+		if cur.PIT == "" || len(cur.After) == 0 {
+			return nil, fmt.Errorf("%w: malformed cursor", todo.ErrInvalidInput)
+		}
+	} else {
+		pit, err := r.openPointInTime(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open point in time: %w", err)
+		}
+		cur.PIT = pit
+	}
+
+	// A point in time replaces the index and sorts ties by shard document implicitly.
+	// The first page skips the offset; later ones continue after the last hit.
+	req := buildSearchRequest(filter)
+	if filter.Cursor != "" {
+		req.From = nil
+	}
+	req.Pit = &types.PointInTimeReference{Id: cur.PIT, KeepAlive: pitKeepAlive}
+	req.SearchAfter = cur.After
+
+	res, err := r.search(ctx, req)
+	if err != nil {
+		// The point in time opened for the first page has no cursor to carry it
+		if filter.Cursor == "" {
+			r.closePointInTime(ctx, cur.PIT)
+		}
+		var esErr *types.ElasticsearchError
+		if errors.As(err, &esErr) && esErr.Status == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: cursor has expired, start again without it", todo.ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	if res.PitId != nil {
+		cur.PIT = *res.PitId
+	}
+
+	page := &todo.Page{Todos: make([]*todo.Todo, 0, len(res.Hits.Hits))}
+	for _, hit := range res.Hits.Hits {
+		var t todo.Todo
+		if err := json.Unmarshal(hit.Source_, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse todo document: %w", err)
+		}
+		page.Todos = append(page.Todos, &t)
+	}
+
+	// Last page: release the point in time instead of waiting for it to expire
+	if len(res.Hits.Hits) < filter.Limit {
+		r.closePointInTime(ctx, cur.PIT)
+		return page, nil
+	}
+
+	cur.After = res.Hits.Hits[len(res.Hits.Hits)-1].Sort
+	page.NextCursor, err = todo.EncodeCursor(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// closePointInTime releases a point in time. A failed close is not worth
+// failing the request for, the keep-alive cleans up.
+func (r *Repository) closePointInTime(ctx context.Context, pit string) {
+	_, _ = r.client.ClosePointInTime().Request(&closepointintime.Request{Id: pit}).Do(ctx)
+}
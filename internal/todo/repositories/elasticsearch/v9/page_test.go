@@ -0,0 +1,88 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPage_Offset(t *testing.T) {
+	cluster, client := newFakeCluster(t, nil)
+	r := NewRepository(client, "todos")
+	ctx := context.Background()
+
+	cursor, err := todo.EncodeCursor(pitCursor{PIT: "pit", After: []types.FieldValue{"1"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter todo.ListFilter
+		// wantFrom is the expected from, or -1 when it must be left out
+		wantFrom int
+	}{
+		{"first page skips the offset", todo.ListFilter{Limit: 10, Offset: 20}, 20},
+		{"first page without offset", todo.ListFilter{Limit: 10}, 0},
+		{"later pages continue after the cursor", todo.ListFilter{Limit: 10, Cursor: cursor}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster.take()
+			_, err := r.ListPage(ctx, tt.filter)
+			require.NoError(t, err)
+
+			var searched bool
+			for _, req := range cluster.take() {
+				if !strings.HasSuffix(req.Path, "/_search") {
+					continue
+				}
+				searched = true
+				var body struct {
+					From        *int  `json:"from"`
+					SearchAfter []any `json:"search_after"`
+					Pit         any   `json:"pit"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(req.Body), &body), req.Body)
+				if tt.wantFrom < 0 {
+					require.Nil(t, body.From, req.Body)
+				} else {
+					require.NotNil(t, body.From, req.Body)
+					require.Equal(t, tt.wantFrom, *body.From)
+				}
+				require.NotNil(t, body.Pit)
+				require.Equal(t, tt.filter.Cursor != "", body.SearchAfter != nil)
+			}
+			require.True(t, searched)
+		})
+	}
+}
+
+func TestListPage_ClosesPointInTime(t *testing.T) {
+	cluster, client := newFakeCluster(t, nil)
+	r := NewRepository(client, "todos")
+	ctx := context.Background()
+
+	closed := func() bool {
+		return slices.ContainsFunc(cluster.take(), func(req recordedRequest) bool {
+			return req.Method == http.MethodDelete && req.Path == "/_pit"
+		})
+	}
+
+	// The last page releases it
+	_, err := r.ListPage(ctx, todo.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	require.True(t, closed())
+
+	// So does a first page that fails
+	cluster.failing = map[string]bool{"_search": true}
+	_, err = r.ListPage(ctx, todo.ListFilter{Limit: 10})
+	require.Error(t, err)
+	require.True(t, closed())
+}
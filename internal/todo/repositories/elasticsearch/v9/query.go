@@ -0,0 +1,119 @@
+package elasticsearch
+
+import (
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/operator"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/textquerytype"
+)
+
+// queryFieldNames maps query language fields to Elasticsearch document fields.
+var queryFieldNames = map[todo.QueryField]string{
+	todo.QueryFieldStatus:      "status",
+	todo.QueryFieldLabel:       "labels",
+	todo.QueryFieldTitle:       "title",
+	todo.QueryFieldDescription: "description",
+	todo.QueryFieldCreated:     "createTime",
+	todo.QueryFieldUpdated:     "updateTime",
+}
+
+// buildQueryExpr translates a query language expression into an Elasticsearch query.
+func buildQueryExpr(expr todo.QueryExpr) types.Query {
+	switch e := expr.(type) {
+	case *todo.AndExpr:
+		must := make([]types.Query, 0, len(e.Operands))
+		for _, op := range e.Operands {
+			must = append(must, buildQueryExpr(op))
+		}
+		return types.Query{Bool: &types.BoolQuery{Must: must}}
+
+	case *todo.OrExpr:
+		should := make([]types.Query, 0, len(e.Operands))
+		for _, op := range e.Operands {
+			should = append(should, buildQueryExpr(op))
+		}
+		return types.Query{Bool: &types.BoolQuery{Should: should, MinimumShouldMatch: 1}}
+
+	case *todo.NotExpr:
+		return types.Query{Bool: &types.BoolQuery{MustNot: []types.Query{buildQueryExpr(e.Operand)}}}
+
+	case *todo.TermExpr:
+		return buildTermExpr(e)
+	}
+
+	// Unknown nodes match nothing rather than everything
+	return types.Query{MatchNone: &types.MatchNoneQuery{}}
+}
+
+// buildTermExpr translates a single query term.
+func buildTermExpr(e *todo.TermExpr) types.Query {
+	field := queryFieldNames[e.Field]
+
+	switch e.Field {
+	case todo.QueryFieldStatus, todo.QueryFieldLabel:
+		return types.Query{
+			Term: map[string]types.TermQuery{
+				field: {Value: e.Value},
+			},
+		}
+
+	case todo.QueryFieldTitle, todo.QueryFieldDescription:
+		if e.Phrase {
+			return types.Query{
+				MatchPhrase: map[string]types.MatchPhraseQuery{
+					field: {Query: e.Value},
+				},
+			}
+		}
+		return types.Query{
+			Match: map[string]types.MatchQuery{
+				field: {Query: e.Value, Operator: &operator.And, Fuzziness: fuzzinessAuto},
+			},
+		}
+
+	case todo.QueryFieldText:
+		mm := &types.MultiMatchQuery{
+			Query:    e.Value,
+			Fields:   []string{"title^2", "description"},
+			Operator: &operator.And,
+		}
+		if e.Phrase {
+			mm.Type = &textquerytype.Phrase
+		} else {
+			mm.Fuzziness = fuzzinessAuto
+		}
+		return types.Query{MultiMatch: mm}
+
+	case todo.QueryFieldCreated, todo.QueryFieldUpdated:
+		return types.Query{
+			Range: map[string]types.RangeQuery{
+				field: buildTimeRange(e),
+			},
+		}
+	}
+
+	return types.Query{MatchNone: &types.MatchNoneQuery{}}
+}
+
+// buildTimeRange constructs a date range for a date term.
+func buildTimeRange(e *todo.TermExpr) types.DateRangeQuery {
+	ts := e.Time.Format(time.RFC3339)
+	q := types.DateRangeQuery{}
+	switch e.Op {
+	case todo.QueryOpMatch:
+		end := e.DayEnd().Format(time.RFC3339)
+		q.Gte = &ts
+		q.Lt = &end
+	case todo.QueryOpGt:
+		q.Gt = &ts
+	case todo.QueryOpGte:
+		q.Gte = &ts
+	case todo.QueryOpLt:
+		q.Lt = &ts
+	case todo.QueryOpLte:
+		q.Lte = &ts
+	}
+	return q
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/MattDevy/es-todoify/internal/todo"
 	"github.com/elastic/go-elasticsearch/v9"
 	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/indices/create"
 	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/refresh"
 	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
 
 	_ "embed"
@@ -22,27 +22,71 @@ import (
 //go:embed indices/todo.json
 var todoIndex []byte
 
+//go:embed indices/fields.json
+var fieldsIndex []byte
+
+// fieldsIndexSuffix is appended to the todo index name to form the custom field registry index.
+const fieldsIndexSuffix = "-fields"
+
+// fuzzinessAuto lets full-text queries tolerate typos, scaled by term length.
+const fuzzinessAuto = "AUTO"
+
+// maxFieldDefinitions is the maximum number of custom field definitions returned by ListFields.
+const maxFieldDefinitions = 1000
+
 // Repository is the implementation of the Repository interface for Elasticsearch.
 type Repository struct {
 	client    *elasticsearch.TypedClient
 	indexName string
+	settings  IndexSettings
+
+	// tenants isolates the todos of each tenant, optionally routed to one
+	// shard per tenant. See WithTenantIsolation.
+	tenants       bool
+	tenantRouting bool
 }
 
 // NewRepository creates a new Repository.
-func NewRepository(client *elasticsearch.TypedClient, indexName string) *Repository {
-	return &Repository{
+func NewRepository(client *elasticsearch.TypedClient, indexName string, opts ...Option) *Repository {
+	r := &Repository{
 		client:    client,
 		indexName: indexName,
+		settings:  DefaultIndexSettings(),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
-// CreateIndices creates the indices for the repository.
+// CreateIndices creates the indices for the repository, or brings existing
+// ones up to the embedded mapping version (see Migrate).
 func (r *Repository) CreateIndices(ctx context.Context) error {
-	cr := &create.Request{}
-	if err := json.NewDecoder(bytes.NewReader(todoIndex)).Decode(cr); err != nil {
-		return fmt.Errorf("failed to decode todo index: %w", err)
+	plan, err := r.PlanMigration(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.Migrate(ctx, plan, nil)
+}
+
+// createIndex creates a single index from an embedded index definition and the configured settings.
+func (r *Repository) createIndex(ctx context.Context, name string, definition []byte) error {
+	if err := r.settings.Validate(); err != nil {
+		return fmt.Errorf("invalid index settings: %w", err)
 	}
-	res, err := r.client.Indices.Create(r.indexName).Request(cr).Do(ctx)
+
+	var def map[string]any
+	if err := json.Unmarshal(definition, &def); err != nil {
+		return fmt.Errorf("failed to decode index %s: %w", name, err)
+	}
+	def["settings"] = r.settings.body()
+	body, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to encode index %s: %w", name, err)
+	}
+
+	res, err := r.client.Indices.Create(name).Raw(bytes.NewReader(body)).Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
 	}
@@ -54,9 +98,13 @@ func (r *Repository) CreateIndices(ctx context.Context) error {
 	return nil
 }
 
+// fieldsIndexName returns the name of the custom field registry index.
+func (r *Repository) fieldsIndexName() string {
+	return r.indexName + fieldsIndexSuffix
+}
+
 func (r *Repository) Create(ctx context.Context, t *todo.Todo) error {
-	_, err := r.client.Create(r.indexName, t.ID.String()).Document(t).Do(ctx)
-	if err != nil {
+	if err := r.create(ctx, t); err != nil {
 		return fmt.Errorf("failed to create todo: %w", err)
 	}
 
@@ -64,33 +112,20 @@ func (r *Repository) Create(ctx context.Context, t *todo.Todo) error {
 }
 
 func (r *Repository) Get(ctx context.Context, id string) (*todo.Todo, error) {
-	res, err := r.client.Get(r.indexName, id).Do(ctx)
-	if err != nil {
-		// Check for 404 error
-		if errors.Is(err, &types.ElasticsearchError{}) || res == nil {
-			return nil, todo.ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to get todo: %w", err)
-	}
-
-	if !res.Found {
-		return nil, todo.ErrNotFound
-	}
-
-	var t todo.Todo
-	if err := json.Unmarshal(res.Source_, &t); err != nil {
-		return nil, fmt.Errorf("failed to decode todo: %w", err)
-	}
+	t, _, err := r.get(ctx, id)
+	return t, err
+}
 
-	return &t, nil
+// GetMany retrieves the todos with the given IDs in one multi-get request.
+func (r *Repository) GetMany(ctx context.Context, ids []string) ([]*todo.Todo, error) {
+	return r.getMany(ctx, ids)
 }
 
 func (r *Repository) Update(ctx context.Context, t *todo.Todo) error {
-	_, err := r.client.Index(r.indexName).
-		Id(t.ID.String()).
-		Document(t).
-		Do(ctx)
-	if err != nil {
+	if err := r.update(ctx, t); err != nil {
+		if errors.Is(err, todo.ErrNotFound) {
+			return err
+		}
 		return fmt.Errorf("failed to update todo: %w", err)
 	}
 
@@ -98,77 +133,180 @@ func (r *Repository) Update(ctx context.Context, t *todo.Todo) error {
 }
 
 func (r *Repository) Delete(ctx context.Context, id string) error {
-	res, err := r.client.Delete(r.indexName, id).Do(ctx)
-	if err != nil {
+	if err := r.delete(ctx, id); err != nil {
+		if errors.Is(err, todo.ErrNotFound) {
+			return err
+		}
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 
-	// Check if document was found and deleted
-	if res.Result.Name == "not_found" {
-		return todo.ErrNotFound
-	}
-
 	return nil
 }
 
 func (r *Repository) List(ctx context.Context, filter todo.ListFilter) ([]*todo.Todo, error) {
-	// Build query
-	query := buildQuery(filter)
-
-	// Build sort
-	sortOptions := buildSort(filter)
-
-	// Execute search
-	req := &search.Request{
-		Query: query,
-		Size:  &filter.Limit,
-		From:  &filter.Offset,
+	res, err := r.search(ctx, buildSearchRequest(filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
 	}
 
-	if len(sortOptions) > 0 {
-		req.Sort = sortOptions
+	// Parse results
+	var todos []*todo.Todo
+	for _, hit := range res.Hits.Hits {
+		var t todo.Todo
+		if err := json.Unmarshal(hit.Source_, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse todo document: %w", err)
+		}
+		todos = append(todos, &t)
 	}
 
-	res, err := r.client.Search().
-		Index(r.indexName).
-		Request(req).
-		Do(ctx)
+	return todos, nil
+}
+
+// Search retrieves todos matching the filter with relevance scores and highlighted fragments.
+func (r *Repository) Search(ctx context.Context, filter todo.ListFilter) ([]*todo.SearchResult, error) {
+	req := buildSearchRequest(filter)
+	req.Highlight = buildHighlight()
+
+	// Scores are not computed when sorting on a field unless explicitly requested
+	trackScores := true
+	req.TrackScores = &trackScores
 
+	res, err := r.search(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search todos: %w", err)
 	}
 
-	// Parse results
-	var todos []*todo.Todo
+	results := make([]*todo.SearchResult, 0, len(res.Hits.Hits))
 	for _, hit := range res.Hits.Hits {
 		var t todo.Todo
 		if err := json.Unmarshal(hit.Source_, &t); err != nil {
 			return nil, fmt.Errorf("failed to parse todo document: %w", err)
 		}
-		todos = append(todos, &t)
+
+		result := &todo.SearchResult{
+			Todo:       &t,
+			Highlights: hit.Highlight,
+		}
+		if hit.Score_ != nil {
+			result.Score = float64(*hit.Score_)
+		}
+		results = append(results, result)
 	}
 
-	return todos, nil
+	return results, nil
 }
 
 func (r *Repository) Count(ctx context.Context, filter todo.ListFilter) (int, error) {
-	query := buildQuery(filter)
+	count, err := r.count(ctx, buildQuery(filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count todos: %w", err)
+	}
 
-	res, err := r.client.Count().
-		Index(r.indexName).
-		Query(query).
+	return int(count), nil
+}
+
+// PutField creates or replaces a custom field definition.
+func (r *Repository) PutField(ctx context.Context, def *todo.FieldDefinition) error {
+	_, err := r.client.Index(r.fieldsIndexName()).
+		Id(def.Name).
+		Document(def).
+		Refresh(refresh.True).
 		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to put field: %w", err)
+	}
+
+	return nil
+}
 
+// ListFields returns all custom field definitions ordered by name. Until
+// migrate creates the registry index there are none, as with a missing file.
+func (r *Repository) ListFields(ctx context.Context) ([]*todo.FieldDefinition, error) {
+	size := maxFieldDefinitions
+	order := sortorder.Asc
+	res, err := r.client.Search().
+		Index(r.fieldsIndexName()).
+		Request(&search.Request{
+			Query: &types.Query{MatchAll: &types.MatchAllQuery{}},
+			Size:  &size,
+			Sort: []types.SortCombinations{
+				types.SortOptions{
+					SortOptions: map[string]types.FieldSort{
+						"name": {Order: &order},
+					},
+				},
+			},
+		}).
+		Do(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count todos: %w", err)
+		if indexNotFound(err) {
+			return []*todo.FieldDefinition{}, nil
+		}
+		return nil, fmt.Errorf("failed to list fields: %w", err)
+	}
+
+	defs := make([]*todo.FieldDefinition, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var def todo.FieldDefinition
+		if err := json.Unmarshal(hit.Source_, &def); err != nil {
+			return nil, fmt.Errorf("failed to parse field definition: %w", err)
+		}
+		defs = append(defs, &def)
+	}
+
+	return defs, nil
+}
+
+// DeleteField removes a custom field definition by name.
+func (r *Repository) DeleteField(ctx context.Context, name string) error {
+	res, err := r.client.Delete(r.fieldsIndexName(), name).
+		Refresh(refresh.True).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete field: %w", err)
+	}
+
+	// A missing registry index leaves the result empty
+	if res.Result.Name != "deleted" {
+		return todo.ErrNotFound
 	}
 
-	return int(res.Count), nil
+	return nil
+}
+
+// buildSearchRequest constructs a paginated and sorted search request from a ListFilter.
+func buildSearchRequest(filter todo.ListFilter) *search.Request {
+	req := &search.Request{
+		Query: buildQuery(filter),
+		Size:  &filter.Limit,
+		From:  &filter.Offset,
+	}
+
+	if sortOptions := buildSort(filter); len(sortOptions) > 0 {
+		req.Sort = sortOptions
+	}
+
+	return req
+}
+
+// buildHighlight requests highlighted fragments for the full-text fields.
+func buildHighlight() *types.Highlight {
+	// Titles are short, so highlight them whole rather than as fragments
+	wholeField := 0
+	return &types.Highlight{
+		PreTags:  []string{todo.HighlightPreTag},
+		PostTags: []string{todo.HighlightPostTag},
+		Fields: []map[string]types.HighlightField{
+			{"title": {NumberOfFragments: &wholeField}},
+			{"description": {}},
+		},
+	}
 }
 
 // buildQuery constructs an Elasticsearch query from a ListFilter.
 func buildQuery(filter todo.ListFilter) *types.Query {
 	var must []types.Query
+	var mustNot []types.Query
 
 	// Status filter
 	if filter.Status != "" {
@@ -179,6 +317,14 @@ func buildQuery(filter todo.ListFilter) *types.Query {
 		})
 	}
 
+	// Status set filters
+	if len(filter.Statuses) > 0 {
+		must = append(must, buildTerms("status", statusStrings(filter.Statuses)))
+	}
+	if len(filter.ExcludeStatuses) > 0 {
+		mustNot = append(mustNot, buildTerms("status", statusStrings(filter.ExcludeStatuses)))
+	}
+
 	// Labels filter (must have all specified labels)
 	for _, label := range filter.Labels {
 		must = append(must, types.Query{
@@ -188,12 +334,36 @@ func buildQuery(filter todo.ListFilter) *types.Query {
 		})
 	}
 
+	// Any-of labels filter (must have at least one of the specified labels)
+	if len(filter.AnyLabels) > 0 {
+		should := make([]types.Query, 0, len(filter.AnyLabels))
+		for _, label := range filter.AnyLabels {
+			should = append(should, types.Query{
+				Term: map[string]types.TermQuery{
+					"labels": {Value: label},
+				},
+			})
+		}
+		must = append(must, types.Query{
+			Bool: &types.BoolQuery{
+				Should:             should,
+				MinimumShouldMatch: 1,
+			},
+		})
+	}
+
+	// Excluded labels filter (must have none of the specified labels)
+	if len(filter.ExcludeLabels) > 0 {
+		mustNot = append(mustNot, buildTerms("labels", filter.ExcludeLabels))
+	}
+
 	// Full-text search
 	if filter.SearchQuery != "" {
 		must = append(must, types.Query{
 			MultiMatch: &types.MultiMatchQuery{
-				Query:  filter.SearchQuery,
-				Fields: []string{"title^2", "description"}, // Boost title matches
+				Query:     filter.SearchQuery,
+				Fields:    []string{"title^2", "description"}, // Boost title matches
+				Fuzziness: fuzzinessAuto,                      // Tolerate typos
 			},
 		})
 	}
@@ -218,8 +388,38 @@ func buildQuery(filter todo.ListFilter) *types.Query {
 		})
 	}
 
+	// Query language expression
+	if filter.Query != nil && filter.Query.Expr != nil {
+		must = append(must, buildQueryExpr(filter.Query.Expr))
+	}
+
+	// Custom field conditions
+	for _, c := range filter.Where {
+		field := "fields." + c.Field
+		switch c.Op {
+		case todo.FieldOpEq:
+			must = append(must, types.Query{
+				Term: map[string]types.TermQuery{
+					field: {Value: c.Value},
+				},
+			})
+		case todo.FieldOpNe:
+			mustNot = append(mustNot, types.Query{
+				Term: map[string]types.TermQuery{
+					field: {Value: c.Value},
+				},
+			})
+		default:
+			must = append(must, types.Query{
+				Range: map[string]types.RangeQuery{
+					field: buildFieldRange(c),
+				},
+			})
+		}
+	}
+
 	// If no filters, match all
-	if len(must) == 0 {
+	if len(must) == 0 && len(mustNot) == 0 {
 		return &types.Query{
 			MatchAll: &types.MatchAllQuery{},
 		}
@@ -228,11 +428,72 @@ func buildQuery(filter todo.ListFilter) *types.Query {
 	// Combine all must clauses
 	return &types.Query{
 		Bool: &types.BoolQuery{
-			Must: must,
+			Must:    must,
+			MustNot: mustNot,
+		},
+	}
+}
+
+// buildTerms constructs a terms query matching any of the values.
+func buildTerms(field string, values []string) types.Query {
+	fieldValues := make([]types.FieldValue, len(values))
+	for i, v := range values {
+		fieldValues[i] = v
+	}
+
+	return types.Query{
+		Terms: &types.TermsQuery{
+			TermsQuery: map[string]types.TermsQueryField{
+				field: fieldValues,
+			},
 		},
 	}
 }
 
+// statusStrings converts statuses to their string values.
+func statusStrings(statuses []todo.Status) []string {
+	values := make([]string, len(statuses))
+	for i, s := range statuses {
+		values[i] = s.String()
+	}
+	return values
+}
+
+// buildFieldRange constructs a range query for an ordering condition on a custom field.
+// Numbers use a numeric range; dates and strings are sent as strings and interpreted
+// by Elasticsearch according to the field's mapped type.
+func buildFieldRange(c todo.FieldCondition) types.RangeQuery {
+	if n, ok := c.Value.(float64); ok {
+		v := types.Float64(n)
+		q := types.NumberRangeQuery{}
+		switch c.Op {
+		case todo.FieldOpGt:
+			q.Gt = &v
+		case todo.FieldOpGte:
+			q.Gte = &v
+		case todo.FieldOpLt:
+			q.Lt = &v
+		case todo.FieldOpLte:
+			q.Lte = &v
+		}
+		return q
+	}
+
+	v := fmt.Sprint(c.Value)
+	q := types.TermRangeQuery{}
+	switch c.Op {
+	case todo.FieldOpGt:
+		q.Gt = &v
+	case todo.FieldOpGte:
+		q.Gte = &v
+	case todo.FieldOpLt:
+		q.Lt = &v
+	case todo.FieldOpLte:
+		q.Lte = &v
+	}
+	return q
+}
+
 // buildSort constructs sort parameters from a ListFilter.
 func buildSort(filter todo.ListFilter) []types.SortCombinations {
 	if filter.SortBy == "" {
@@ -244,6 +505,15 @@ func buildSort(filter todo.ListFilter) []types.SortCombinations {
 		order = sortorder.Asc
 	}
 
+	// Relevance is expressed with the special _score sort
+	if filter.SortBy == todo.SortFieldRelevance {
+		return []types.SortCombinations{
+			types.SortOptions{
+				Score_: &types.ScoreSort{Order: &order},
+			},
+		}
+	}
+
 	// Map domain sort fields to Elasticsearch fields
 	field := string(filter.SortBy)
 
@@ -295,8 +565,19 @@ func (r *Repository) Health(ctx context.Context) (*repository.HealthInfo, error)
 
 	version := infoResp.Version.Int
 
-	// Map Elasticsearch cluster status to our generic health status
-	status := mapESStatusToHealthStatus(healthResp.Status.Name)
+	// Our index's own health decides the status, other indices in the cluster do not
+	indexDetails, status, err := r.indexHealth(ctx)
+	if err != nil {
+		return &repository.HealthInfo{
+			Status:       repository.HealthStatusUnhealthy,
+			Available:    true,
+			ResponseTime: time.Since(start),
+			Version:      version,
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		}, err
+	}
 
 	// Convert node count
 	nodeCount := healthResp.NumberOfNodes
@@ -323,12 +604,87 @@ func (r *Repository) Health(ctx context.Context) (*repository.HealthInfo, error)
 			"number_of_in_flight_fetch":    healthResp.NumberOfInFlightFetch,
 			"task_max_waiting_in_queue_ms": healthResp.TaskMaxWaitingInQueueMillis,
 			"active_shards_percent":        healthResp.ActiveShardsPercentAsNumber,
+			"index":                        indexDetails,
 		},
 	}
 
 	return healthInfo, nil
 }
 
+// indexHealth reports shard health, document count, size and mapping drift for
+// the index behind the alias. The status is degraded when the mapping drifted
+// or a migration is pending, and unhealthy when the index does not exist.
+func (r *Repository) indexHealth(ctx context.Context) (map[string]interface{}, repository.HealthStatus, error) {
+	plan, err := r.PlanMigration(ctx)
+	if err != nil {
+		return nil, repository.HealthStatusUnhealthy, err
+	}
+
+	details := map[string]interface{}{
+		"alias":           plan.Alias,
+		"exists":          plan.CurrentIndex != "",
+		"mapping_version": plan.CurrentVersion,
+	}
+	if plan.CurrentIndex == "" {
+		return details, repository.HealthStatusUnhealthy, nil
+	}
+	details["name"] = plan.CurrentIndex
+
+	healthResp, err := r.client.Cluster.Health().Index(plan.CurrentIndex).Do(ctx)
+	if err != nil {
+		return nil, repository.HealthStatusUnhealthy, fmt.Errorf("failed to get index health: %w", err)
+	}
+	status := mapESStatusToHealthStatus(healthResp.Status.Name)
+	details["status"] = healthResp.Status
+	details["active_shards"] = healthResp.ActiveShards
+	details["active_primary_shards"] = healthResp.ActivePrimaryShards
+	details["initializing_shards"] = healthResp.InitializingShards
+	details["relocating_shards"] = healthResp.RelocatingShards
+	details["unassigned_shards"] = healthResp.UnassignedShards
+
+	statsResp, err := r.client.Indices.Stats().Index(plan.CurrentIndex).Metric("docs,store").Do(ctx)
+	if err != nil {
+		return nil, repository.HealthStatusUnhealthy, fmt.Errorf("failed to get index stats: %w", err)
+	}
+	if stats, ok := statsResp.Indices[plan.CurrentIndex]; ok {
+		if stats.Primaries != nil && stats.Primaries.Docs != nil {
+			details["doc_count"] = stats.Primaries.Docs.Count
+		}
+		if stats.Total != nil && stats.Total.Store != nil {
+			details["size_in_bytes"] = stats.Total.Store.SizeInBytes
+		}
+	}
+
+	if !plan.UpToDate() {
+		details["migration_pending"] = plan.TargetIndex
+		status = worseHealthStatus(status, repository.HealthStatusDegraded)
+	}
+
+	drift, err := r.CheckMapping(ctx, plan.CurrentIndex)
+	if err != nil {
+		return nil, repository.HealthStatusUnhealthy, err
+	}
+	if drift.HasDrift() {
+		details["mapping_drift"] = drift
+		status = worseHealthStatus(status, repository.HealthStatusDegraded)
+	}
+
+	return details, status, nil
+}
+
+// worseHealthStatus returns the more severe of two statuses.
+func worseHealthStatus(a, b repository.HealthStatus) repository.HealthStatus {
+	severity := map[repository.HealthStatus]int{
+		repository.HealthStatusHealthy:   0,
+		repository.HealthStatusDegraded:  1,
+		repository.HealthStatusUnhealthy: 2,
+	}
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
 // mapESStatusToHealthStatus maps Elasticsearch cluster status to generic health status.
 func mapESStatusToHealthStatus(esStatus string) repository.HealthStatus {
 	switch esStatus {
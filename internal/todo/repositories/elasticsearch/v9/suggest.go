@@ -0,0 +1,167 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/textquerytype"
+)
+
+// idPrefixPattern matches prefixes that could be the start of a UUID.
+var idPrefixPattern = regexp.MustCompile(`^[0-9a-f-]+$`)
+
+// Suggest returns todo title and label completions for a prefix.
+// Titles are matched with a bool_prefix query on the search_as_you_type subfield,
+// labels with a terms aggregation restricted to the prefix.
+func (r *Repository) Suggest(ctx context.Context, prefix string, limit int) (*todo.Suggestions, error) {
+	todos, err := r.suggestTodos(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := r.suggestLabels(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &todo.Suggestions{Todos: todos, Labels: labels}, nil
+}
+
+func (r *Repository) suggestTodos(ctx context.Context, prefix string, limit int) ([]todo.TodoSuggestion, error) {
+	req := &search.Request{
+		Query:   buildSuggestQuery(prefix),
+		Size:    &limit,
+		Source_: []string{"id", "title"},
+	}
+
+	// Without a prefix, suggest the most recently updated todos
+	if prefix == "" {
+		order := sortorder.Desc
+		req.Sort = []types.SortCombinations{
+			types.SortOptions{
+				SortOptions: map[string]types.FieldSort{
+					"updateTime": {Order: &order},
+				},
+			},
+		}
+	}
+
+	res, err := r.search(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest todos: %w", err)
+	}
+
+	suggestions := make([]todo.TodoSuggestion, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var s todo.TodoSuggestion
+		if err := json.Unmarshal(hit.Source_, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse todo suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, nil
+}
+
+func (r *Repository) suggestLabels(ctx context.Context, prefix string, limit int) ([]string, error) {
+	size := 0
+	field := "labels"
+	terms := &types.TermsAggregation{
+		Field: &field,
+		Size:  &limit,
+	}
+	query := &types.Query{MatchAll: &types.MatchAllQuery{}}
+	if prefix != "" {
+		terms.Include = quoteLuceneRegexp(prefix) + ".*"
+		query = &types.Query{
+			Prefix: map[string]types.PrefixQuery{
+				"labels": {Value: prefix},
+			},
+		}
+	}
+
+	res, err := r.search(ctx, &search.Request{
+		Query: query,
+		Size:  &size,
+		Aggregations: map[string]types.Aggregations{
+			"labels": {Terms: terms},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest labels: %w", err)
+	}
+
+	return termsKeys(res.Aggregations["labels"]), nil
+}
+
+// buildSuggestQuery matches titles as-you-type and, for hex prefixes, todo IDs.
+func buildSuggestQuery(prefix string) *types.Query {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return &types.Query{MatchAll: &types.MatchAllQuery{}}
+	}
+
+	should := []types.Query{
+		{
+			MultiMatch: &types.MultiMatchQuery{
+				Query:  prefix,
+				Type:   &textquerytype.Boolprefix,
+				Fields: []string{"title.suggest", "title.suggest._2gram", "title.suggest._3gram"},
+			},
+		},
+	}
+
+	if idPrefixPattern.MatchString(prefix) {
+		should = append(should, types.Query{
+			Prefix: map[string]types.PrefixQuery{
+				"id": {Value: prefix},
+			},
+		})
+	}
+
+	return &types.Query{
+		Bool: &types.BoolQuery{
+			Should:             should,
+			MinimumShouldMatch: 1,
+		},
+	}
+}
+
+// quoteLuceneRegexp escapes characters that are reserved in Lucene regular expressions.
+func quoteLuceneRegexp(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// termsKeys returns the bucket keys of a string terms aggregation.
+func termsKeys(agg types.Aggregate) []string {
+	keys := []string{}
+	terms, ok := agg.(*types.StringTermsAggregate)
+	if !ok {
+		return keys
+	}
+
+	buckets, ok := terms.Buckets.([]types.StringTermsBucket)
+	if !ok {
+		return keys
+	}
+
+	for _, b := range buckets {
+		keys = append(keys, fmt.Sprint(b.Key))
+	}
+
+	return keys
+}
@@ -0,0 +1,253 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Suffixes of the lifecycle-managed indices installed next to the todo index.
+const (
+	historySuffix = "-history"
+	auditSuffix   = "-audit"
+	trashSuffix   = "-trash"
+)
+
+// Rollover thresholds for the history and audit data streams.
+const (
+	rolloverMaxAge              = "30d"
+	rolloverMaxPrimaryShardSize = "50gb"
+)
+
+// templatePriority keeps our templates ahead of the built-in ones.
+const templatePriority = 200
+
+// IndexSettings configures the indices and templates installed by migrations.
+type IndexSettings struct {
+	// Shards is the number of primary shards.
+	Shards int
+
+	// Replicas is the number of replica shards. Use 0 on a single-node cluster
+	// so it reports green rather than yellow.
+	Replicas int
+
+	// RefreshInterval is how often new writes become searchable, e.g. "1s".
+	RefreshInterval string
+
+	// Analyzer is the built-in analyzer used for full-text fields, e.g. "standard" or "english".
+	Analyzer string
+
+	// TrashRetentionDays is how long trashed todos are kept before deletion.
+	TrashRetentionDays int
+}
+
+// DefaultIndexSettings returns the settings used when none are configured.
+func DefaultIndexSettings() IndexSettings {
+	return IndexSettings{
+		Shards:             1,
+		Replicas:           1,
+		RefreshInterval:    "1s",
+		Analyzer:           "standard",
+		TrashRetentionDays: 30,
+	}
+}
+
+// Validate checks that the settings can be applied.
+func (s IndexSettings) Validate() error {
+	var errs []error
+	if s.Shards < 1 {
+		errs = append(errs, fmt.Errorf("shards must be at least 1, got %d", s.Shards))
+	}
+	if s.Replicas < 0 {
+		errs = append(errs, fmt.Errorf("replicas must not be negative, got %d", s.Replicas))
+	}
+	if s.RefreshInterval == "" {
+		errs = append(errs, errors.New("refresh interval is required"))
+	}
+	if s.Analyzer == "" {
+		errs = append(errs, errors.New("analyzer is required"))
+	}
+	if s.TrashRetentionDays < 1 {
+		errs = append(errs, fmt.Errorf("trash retention must be at least 1 day, got %d", s.TrashRetentionDays))
+	}
+	return errors.Join(errs...)
+}
+
+// body returns the index settings as sent to Elasticsearch.
+func (s IndexSettings) body() map[string]any {
+	return map[string]any{
+		"number_of_shards":   s.Shards,
+		"number_of_replicas": s.Replicas,
+		"refresh_interval":   s.RefreshInterval,
+		"analysis": map[string]any{
+			"analyzer": map[string]any{
+				"default": map[string]any{"type": s.Analyzer},
+			},
+		},
+	}
+}
+
+// dynamicBody returns the settings that can be changed on an open index.
+func (s IndexSettings) dynamicBody() map[string]any {
+	return map[string]any{
+		"number_of_replicas": s.Replicas,
+		"refresh_interval":   s.RefreshInterval,
+	}
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithIndexSettings sets the settings used when creating indices and templates.
+func WithIndexSettings(settings IndexSettings) Option {
+	return func(r *Repository) {
+		r.settings = settings
+	}
+}
+
+// InstallTemplates installs the ILM policies and composable index templates:
+//   - <alias> for the versioned todo indices, so any index created for a
+//     migration gets the configured settings and the embedded mapping
+//   - <alias>-history and <alias>-audit data streams, rolled over by age and size
+//   - <alias>-trash indices, deleted after the retention period
+func (r *Repository) InstallTemplates(ctx context.Context) error {
+	if err := r.settings.Validate(); err != nil {
+		return fmt.Errorf("invalid index settings: %w", err)
+	}
+
+	rolloverPolicy := r.indexName + "-rollover"
+	if err := r.putLifecyclePolicy(ctx, rolloverPolicy, map[string]any{
+		"hot": map[string]any{
+			"actions": map[string]any{
+				"rollover": map[string]any{
+					"max_age":                rolloverMaxAge,
+					"max_primary_shard_size": rolloverMaxPrimaryShardSize,
+				},
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	trashPolicy := r.indexName + trashSuffix
+	if err := r.putLifecyclePolicy(ctx, trashPolicy, map[string]any{
+		"delete": map[string]any{
+			"min_age": fmt.Sprintf("%dd", r.settings.TrashRetentionDays),
+			"actions": map[string]any{"delete": map[string]any{}},
+		},
+	}); err != nil {
+		return err
+	}
+
+	var todoDef struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal(todoIndex, &todoDef); err != nil {
+		return fmt.Errorf("failed to decode index definition: %w", err)
+	}
+
+	if err := r.putIndexTemplate(ctx, r.indexName, map[string]any{
+		"index_patterns": []string{r.indexName + "-v*"},
+		"template": map[string]any{
+			"settings": r.settings.body(),
+			"mappings": todoDef.Mappings,
+		},
+	}); err != nil {
+		return err
+	}
+
+	// History and audit entries are append-only events, a good fit for data streams
+	eventMappings := map[string]any{
+		"properties": map[string]any{
+			"@timestamp": map[string]any{"type": "date"},
+			"todoId":     map[string]any{"type": "keyword"},
+		},
+	}
+	for _, suffix := range []string{historySuffix, auditSuffix} {
+		settings := r.settings.body()
+		settings["index.lifecycle.name"] = rolloverPolicy
+		if err := r.putIndexTemplate(ctx, r.indexName+suffix, map[string]any{
+			"index_patterns": []string{r.indexName + suffix},
+			"data_stream":    map[string]any{},
+			"template": map[string]any{
+				"settings": settings,
+				"mappings": eventMappings,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	settings := r.settings.body()
+	settings["index.lifecycle.name"] = trashPolicy
+	return r.putIndexTemplate(ctx, r.indexName+trashSuffix, map[string]any{
+		"index_patterns": []string{r.indexName + trashSuffix + "*"},
+		"template": map[string]any{
+			"settings": settings,
+			"mappings": todoDef.Mappings,
+		},
+	})
+}
+
+// ApplyDynamicSettings updates the settings that do not need a reindex
+// (replicas and refresh interval) on an existing index.
+func (r *Repository) ApplyDynamicSettings(ctx context.Context, index string) error {
+	body, err := json.Marshal(r.settings.dynamicBody())
+	if err != nil {
+		return fmt.Errorf("failed to encode index settings: %w", err)
+	}
+
+	res, err := r.client.Indices.PutSettings().Indices(index).Raw(bytes.NewReader(body)).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update index settings: %w", err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to update index settings: not acknowledged")
+	}
+
+	return nil
+}
+
+func (r *Repository) putLifecyclePolicy(ctx context.Context, name string, phases map[string]any) error {
+	body, err := json.Marshal(map[string]any{
+		"policy": map[string]any{
+			"phases": phases,
+			"_meta":  map[string]any{"managed_by": "todoify"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode lifecycle policy %s: %w", name, err)
+	}
+
+	res, err := r.client.Ilm.PutLifecycle(name).Raw(bytes.NewReader(body)).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to put lifecycle policy %s: %w", name, err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to put lifecycle policy %s: not acknowledged", name)
+	}
+
+	return nil
+}
+
+func (r *Repository) putIndexTemplate(ctx context.Context, name string, template map[string]any) error {
+	template["priority"] = templatePriority
+	template["_meta"] = map[string]any{"managed_by": "todoify"}
+
+	body, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("failed to encode index template %s: %w", name, err)
+	}
+
+	res, err := r.client.Indices.PutIndexTemplate(name).Raw(bytes.NewReader(body)).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to put index template %s: %w", name, err)
+	}
+	if !res.Acknowledged {
+		return fmt.Errorf("failed to put index template %s: not acknowledged", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *IndexSettings)
+		wantErr bool
+	}{
+		{"defaults", func(s *IndexSettings) {}, false},
+		{"single node", func(s *IndexSettings) { s.Replicas = 0 }, false},
+		{"no shards", func(s *IndexSettings) { s.Shards = 0 }, true},
+		{"negative replicas", func(s *IndexSettings) { s.Replicas = -1 }, true},
+		{"no refresh interval", func(s *IndexSettings) { s.RefreshInterval = "" }, true},
+		{"no analyzer", func(s *IndexSettings) { s.Analyzer = "" }, true},
+		{"no trash retention", func(s *IndexSettings) { s.TrashRetentionDays = 0 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultIndexSettings()
+			tt.modify(&s)
+			err := s.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIndexSettings_Body(t *testing.T) {
+	s := DefaultIndexSettings()
+	s.Replicas = 0
+	s.Analyzer = "english"
+
+	// The live settings of an index created with body() must not drift from it
+	live := map[string]any{"index": map[string]any{
+		"number_of_shards":   "1",
+		"number_of_replicas": "0",
+		"refresh_interval":   "1s",
+		"analysis":           map[string]any{"analyzer": map[string]any{"default": map[string]any{"type": "english"}}},
+	}}
+	require.Empty(t, compareSettings(s.body(), live))
+}
@@ -0,0 +1,431 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/bulk"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/count"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/refresh"
+)
+
+// Every read and write of the todo index goes through this file. With tenant
+// isolation, todos are stamped with the tenant of the context, searches and
+// counts are filtered on it, and todos of other tenants are not found. Code
+// elsewhere must use these helpers instead of querying r.indexName directly;
+// TestTenantIsolation_IndexAccess enforces it.
+
+// tenantField is the todo document field holding the tenant.
+const tenantField = "tenant"
+
+// WithTenantIsolation keeps the todos of each tenant apart: every operation
+// needs a tenant in its context (see todo.WithTenant) and only sees and
+// changes that tenant's todos. Custom field definitions and access tokens
+// stay shared.
+func WithTenantIsolation() Option {
+	return func(r *Repository) {
+		r.tenants = true
+	}
+}
+
+// WithTenantRouting isolates tenants like WithTenantIsolation and also routes
+// each tenant's todos to a single shard, so its searches only hit that shard.
+// Enable it on a new index: todos written without routing are not found by
+// ID afterwards.
+func WithTenantRouting() Option {
+	return func(r *Repository) {
+		r.tenants = true
+		r.tenantRouting = true
+	}
+}
+
+// document is a todo as stored in the index, with the tenant that owns it.
+type document struct {
+	*todo.Todo
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// seqNo identifies the version of a stored todo for optimistic concurrency
+// control, so a write only applies to the document that was checked.
+type seqNo struct {
+	seqNo       string
+	primaryTerm string
+}
+
+// tenant returns the tenant of ctx. It is empty when tenants are not
+// isolated, and required when they are.
+func (r *Repository) tenant(ctx context.Context) (string, error) {
+	if !r.tenants {
+		return "", nil
+	}
+	tenant, ok := todo.TenantFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("%w: a tenant is required", todo.ErrForbidden)
+	}
+	if err := todo.ValidateTenant(tenant); err != nil {
+		return "", err
+	}
+	return tenant, nil
+}
+
+// routing returns the routing value for a tenant's documents, if any.
+func (r *Repository) routing(tenant string) string {
+	if r.tenantRouting {
+		return tenant
+	}
+	return ""
+}
+
+// document returns what is stored for a todo of a tenant. Without isolation
+// the todo is stored as is.
+func (r *Repository) document(t *todo.Todo, tenant string) any {
+	if tenant == "" {
+		return t
+	}
+	return document{Todo: t, Tenant: tenant}
+}
+
+// decode parses a stored todo and reports whether it belongs to tenant.
+func decode(source json.RawMessage, tenant string) (*todo.Todo, bool, error) {
+	doc := document{Todo: &todo.Todo{}}
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return nil, false, err
+	}
+	return doc.Todo, doc.Tenant == tenant, nil
+}
+
+// scopeQuery restricts a query to the todos of a tenant. The tenant is a
+// filter clause next to the query, so no part of the query can widen it.
+func scopeQuery(q *types.Query, tenant string) *types.Query {
+	if tenant == "" {
+		return q
+	}
+
+	scoped := &types.BoolQuery{
+		Filter: []types.Query{{
+			Term: map[string]types.TermQuery{
+				tenantField: {Value: tenant},
+			},
+		}},
+	}
+	if q != nil {
+		scoped.Must = []types.Query{*q}
+	}
+	return &types.Query{Bool: scoped}
+}
+
+// checkScopable refuses the parts of a search request that are not limited by
+// its query and would see the documents of every tenant.
+func checkScopable(req *search.Request) error {
+	switch {
+	case len(req.Knn) > 0:
+		return errors.New("tenant isolation: knn searches are not filtered by the query")
+	case req.Retriever != nil:
+		return errors.New("tenant isolation: retrievers are not filtered by the query")
+	case req.Suggest != nil:
+		return errors.New("tenant isolation: suggesters are not filtered by the query")
+	case req.PostFilter != nil && req.Query == nil:
+		return errors.New("tenant isolation: a post filter needs a query")
+	}
+	return checkAggregations(req.Aggregations)
+}
+
+// checkAggregations refuses global aggregations at any depth, as they ignore
+// the query.
+func checkAggregations(aggs map[string]types.Aggregations) error {
+	for name, agg := range aggs {
+		if agg.Global != nil {
+			return fmt.Errorf("tenant isolation: global aggregation %q is not filtered by the query", name)
+		}
+		if err := checkAggregations(agg.Aggregations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// search runs a search on the todo index, or on the point in time of the
+// request, limited to the tenant of ctx.
+func (r *Repository) search(ctx context.Context, req *search.Request) (*search.Response, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != "" {
+		if err := checkScopable(req); err != nil {
+			return nil, err
+		}
+		req.Query = scopeQuery(req.Query, tenant)
+	}
+
+	s := r.client.Search().Request(req)
+	if req.Pit != nil {
+		// A point in time already names the index and its routing
+		return s.Do(ctx)
+	}
+	s = s.Index(r.indexName)
+	if routing := r.routing(tenant); routing != "" {
+		s = s.Routing(routing)
+	}
+	return s.Do(ctx)
+}
+
+// count counts the todos of the tenant of ctx matching a query.
+func (r *Repository) count(ctx context.Context, q *types.Query) (int64, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c := r.client.Count().Index(r.indexName).Request(&count.Request{Query: scopeQuery(q, tenant)})
+	if routing := r.routing(tenant); routing != "" {
+		c = c.Routing(routing)
+	}
+	res, err := c.Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// openPointInTime opens a point in time on the todo index. Searches on it are
+// still filtered by tenant.
+func (r *Repository) openPointInTime(ctx context.Context) (string, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	o := r.client.OpenPointInTime(r.indexName).KeepAlive(pitKeepAlive)
+	if routing := r.routing(tenant); routing != "" {
+		o = o.Routing(routing)
+	}
+	res, err := o.Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	return res.Id, nil
+}
+
+// create stores a new todo for the tenant of ctx.
+func (r *Repository) create(ctx context.Context, t *todo.Todo) error {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	c := r.client.Create(r.indexName, t.ID.String()).Document(r.document(t, tenant))
+	if routing := r.routing(tenant); routing != "" {
+		c = c.Routing(routing)
+	}
+	_, err = c.Do(ctx)
+	return err
+}
+
+// get retrieves a todo of the tenant of ctx with its sequence number. Todos
+// of other tenants are ErrNotFound.
+func (r *Repository) get(ctx context.Context, id string) (*todo.Todo, seqNo, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return nil, seqNo{}, err
+	}
+
+	g := r.client.Get(r.indexName, id)
+	if routing := r.routing(tenant); routing != "" {
+		g = g.Routing(routing)
+	}
+	res, err := g.Do(ctx)
+	if err != nil {
+		// Check for 404 error
+		if errors.Is(err, &types.ElasticsearchError{}) || res == nil {
+			return nil, seqNo{}, todo.ErrNotFound
+		}
+		return nil, seqNo{}, fmt.Errorf("failed to get todo: %w", err)
+	}
+	if !res.Found {
+		return nil, seqNo{}, todo.ErrNotFound
+	}
+
+	t, owned, err := decode(res.Source_, tenant)
+	if err != nil {
+		return nil, seqNo{}, fmt.Errorf("failed to decode todo: %w", err)
+	}
+	if !owned && r.tenants {
+		return nil, seqNo{}, todo.ErrNotFound
+	}
+
+	var version seqNo
+	if res.SeqNo_ != nil && res.PrimaryTerm_ != nil {
+		version = seqNo{
+			seqNo:       strconv.FormatInt(*res.SeqNo_, 10),
+			primaryTerm: strconv.FormatInt(*res.PrimaryTerm_, 10),
+		}
+	}
+	return t, version, nil
+}
+
+// getMany retrieves the todos of the tenant of ctx with the given IDs,
+// skipping missing ones and those of other tenants.
+func (r *Repository) getMany(ctx context.Context, ids []string) ([]*todo.Todo, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := r.client.Mget().Index(r.indexName).Ids(ids...)
+	if routing := r.routing(tenant); routing != "" {
+		m = m.Routing(routing)
+	}
+	res, err := m.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	var todos []*todo.Todo
+	for _, item := range res.Docs {
+		doc, ok := item.(*types.GetResult)
+		if !ok || !doc.Found {
+			continue
+		}
+		t, owned, err := decode(doc.Source_, tenant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode todo %s: %w", doc.Id_, err)
+		}
+		if owned || !r.tenants {
+			todos = append(todos, t)
+		}
+	}
+
+	return todos, nil
+}
+
+// update replaces a todo. Without isolation it is written blindly, creating
+// it if needed. With isolation the todo must exist for the tenant of ctx and
+// is only replaced if it has not changed since it was checked.
+func (r *Repository) update(ctx context.Context, t *todo.Todo) error {
+	i := r.client.Index(r.indexName).Id(t.ID.String())
+	if !r.tenants {
+		_, err := i.Document(t).Do(ctx)
+		return err
+	}
+
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	_, version, err := r.get(ctx, t.ID.String())
+	if err != nil {
+		return err
+	}
+
+	i = i.Document(r.document(t, tenant)).IfSeqNo(version.seqNo).IfPrimaryTerm(version.primaryTerm)
+	if routing := r.routing(tenant); routing != "" {
+		i = i.Routing(routing)
+	}
+	_, err = i.Do(ctx)
+	return err
+}
+
+// delete removes a todo, reporting ErrNotFound when it does not exist or
+// belongs to another tenant.
+func (r *Repository) delete(ctx context.Context, id string) error {
+	d := r.client.Delete(r.indexName, id)
+	if r.tenants {
+		tenant, err := r.tenant(ctx)
+		if err != nil {
+			return err
+		}
+		_, version, err := r.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		d = d.IfSeqNo(version.seqNo).IfPrimaryTerm(version.primaryTerm)
+		if routing := r.routing(tenant); routing != "" {
+			d = d.Routing(routing)
+		}
+	}
+
+	res, err := d.Do(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Result.Name == "not_found" {
+		return todo.ErrNotFound
+	}
+	return nil
+}
+
+// putMany writes todos for the tenant of ctx in one bulk request. With
+// overwrite, todos that exist for another tenant are not written and are
+// returned as foreign. The response is nil when nothing was written.
+func (r *Repository) putMany(ctx context.Context, todos []*todo.Todo, overwrite bool) (*bulk.Response, []string, error) {
+	tenant, err := r.tenant(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var routing *string
+	if v := r.routing(tenant); v != "" {
+		routing = &v
+	}
+
+	// Overwriting must not take over another tenant's todos
+	var foreign []string
+	if overwrite && r.tenants {
+		ids := make([]string, len(todos))
+		for i, t := range todos {
+			ids[i] = t.ID.String()
+		}
+		m := r.client.Mget().Index(r.indexName).Ids(ids...).SourceIncludes_(tenantField)
+		if routing != nil {
+			m = m.Routing(*routing)
+		}
+		existing, err := m.Do(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check todos: %w", err)
+		}
+		for _, item := range existing.Docs {
+			doc, ok := item.(*types.GetResult)
+			if !ok || !doc.Found {
+				continue
+			}
+			if _, owned, err := decode(doc.Source_, tenant); err != nil || !owned {
+				foreign = append(foreign, doc.Id_)
+			}
+		}
+	}
+
+	req := r.client.Bulk().Index(r.indexName).Refresh(refresh.Waitfor)
+	written := 0
+	for _, t := range todos {
+		id := t.ID.String()
+		if slices.Contains(foreign, id) {
+			continue
+		}
+		var err error
+		if overwrite {
+			err = req.IndexOp(types.IndexOperation{Id_: &id, Routing: routing}, r.document(t, tenant))
+		} else {
+			err = req.CreateOp(types.CreateOperation{Id_: &id, Routing: routing}, r.document(t, tenant))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode todo %s: %w", id, err)
+		}
+		written++
+	}
+	if written == 0 {
+		return nil, foreign, nil
+	}
+
+	res, err := req.Do(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write todos: %w", err)
+	}
+	return res, foreign, nil
+}
@@ -0,0 +1,521 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is a request received by fakeCluster.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  map[string]string
+	Body   string
+}
+
+// fakeCluster answers the document APIs used by the repository and records
+// every request. Documents are owned by the tenants in owners; searches and
+// counts find nothing, as only their requests are of interest.
+type fakeCluster struct {
+	t      *testing.T
+	owners map[string]string
+
+	// missing are indices answered with index_not_found_exception.
+	missing map[string]bool
+
+	// failing are endpoints, such as "_search", answered with a server error.
+	failing map[string]bool
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newFakeCluster(t *testing.T, owners map[string]string) (*fakeCluster, *elasticsearch.TypedClient) {
+	t.Helper()
+	c := &fakeCluster{t: t, owners: owners}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewTypedClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	require.NoError(t, err)
+	return c, client
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := map[string]string{}
+	for k := range r.URL.Query() {
+		query[k] = r.URL.Query().Get(k)
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Query: query, Body: string(body)})
+	c.mu.Unlock()
+
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+
+	shards := map[string]any{"total": 1, "successful": 1, "skipped": 0, "failed": 0}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	endpoint := parts[len(parts)-1]
+	if len(parts) >= 3 {
+		endpoint = parts[1]
+	}
+
+	if c.missing[parts[0]] {
+		w.WriteHeader(http.StatusNotFound)
+		require.NoError(c.t, json.NewEncoder(w).Encode(map[string]any{
+			"error":  map[string]any{"type": "index_not_found_exception", "reason": "no such index [" + parts[0] + "]"},
+			"status": 404,
+		}))
+		return
+	}
+
+	if c.failing[endpoint] {
+		w.WriteHeader(http.StatusInternalServerError)
+		require.NoError(c.t, json.NewEncoder(w).Encode(map[string]any{
+			"error":  map[string]any{"type": "search_phase_execution_exception", "reason": "all shards failed"},
+			"status": 500,
+		}))
+		return
+	}
+
+	var resp any
+	switch endpoint {
+	case "_search":
+		resp = map[string]any{
+			"took": 1, "timed_out": false, "_shards": shards, "pit_id": "pit",
+			"hits": map[string]any{"total": map[string]any{"value": 0, "relation": "eq"}, "hits": []any{}},
+		}
+	case "_count":
+		resp = map[string]any{"count": 0, "_shards": shards}
+	case "_pit":
+		resp = map[string]any{"id": "pit", "_shards": shards}
+	case "_bulk":
+		resp = map[string]any{"took": 1, "errors": false, "items": []any{}}
+	case "_mget":
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		require.NoError(c.t, json.Unmarshal(body, &req))
+		docs := make([]any, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			docs = append(docs, c.getResult(id))
+		}
+		resp = map[string]any{"docs": docs}
+	case "_doc", "_create":
+		id := parts[2]
+		switch r.Method {
+		case http.MethodGet:
+			resp = c.getResult(id)
+			if c.owners[id] == "" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodDelete:
+			resp = map[string]any{"_index": parts[0], "_id": id, "_version": 2, "result": "deleted", "_shards": shards, "_seq_no": 4, "_primary_term": 1}
+		default:
+			resp = map[string]any{"_index": parts[0], "_id": id, "_version": 1, "result": "created", "_shards": shards, "_seq_no": 4, "_primary_term": 1}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		resp = map[string]any{"error": map[string]any{"type": "not_found", "reason": r.URL.Path}, "status": 404}
+	}
+
+	require.NoError(c.t, json.NewEncoder(w).Encode(resp))
+}
+
+// getResult returns a stored todo, or a miss for an unknown ID.
+func (c *fakeCluster) getResult(id string) map[string]any {
+	tenant, ok := c.owners[id]
+	if !ok {
+		return map[string]any{"_index": "todos", "_id": id, "found": false}
+	}
+	return map[string]any{
+		"_index": "todos", "_id": id, "_version": 1, "_seq_no": 3, "_primary_term": 1, "found": true,
+		"_source": map[string]any{"id": id, "title": "Todo of " + tenant, "status": "pending", "tenant": tenant},
+	}
+}
+
+// take returns and forgets the recorded requests.
+func (c *fakeCluster) take() []recordedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	requests := c.requests
+	c.requests = nil
+	return requests
+}
+
+// requireTenantFilter checks that a search or count body filters on the tenant.
+func requireTenantFilter(t *testing.T, req recordedRequest, tenant string) {
+	t.Helper()
+	var body struct {
+		Query struct {
+			Bool struct {
+				Filter []map[string]map[string]map[string]any `json:"filter"`
+			} `json:"bool"`
+		} `json:"query"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(req.Body), &body), req.Body)
+	require.Contains(t, body.Query.Bool.Filter, map[string]map[string]map[string]any{
+		"term": {tenantField: {"value": tenant}},
+	}, "%s %s is not filtered on the tenant: %s", req.Method, req.Path, req.Body)
+}
+
+func TestTenantIsolation_Reads(t *testing.T) {
+	cluster, client := newFakeCluster(t, nil)
+	r := NewRepository(client, "todos", WithTenantIsolation())
+	ctx := todo.WithTenant(context.Background(), "acme")
+	filter := todo.ListFilter{Limit: 10, SearchQuery: "report", Labels: []string{"ops"}}
+
+	// Every repository method that searches or counts todos. A new one
+	// belongs here as well.
+	reads := map[string]func() error{
+		"List":   func() error { _, err := r.List(ctx, filter); return err },
+		"Search": func() error { _, err := r.Search(ctx, filter); return err },
+		"Count":  func() error { _, err := r.Count(ctx, filter); return err },
+		"Count without filter": func() error {
+			_, err := r.Count(ctx, todo.ListFilter{})
+			return err
+		},
+		"ListWithFacets": func() error { _, _, err := r.ListWithFacets(ctx, filter); return err },
+		"ListPage":       func() error { _, err := r.ListPage(ctx, filter); return err },
+		"ListPage with cursor": func() error {
+			cursor, err := todo.EncodeCursor(pitCursor{PIT: "pit", After: []types.FieldValue{"1"}})
+			require.NoError(t, err)
+			f := filter
+			f.Cursor = cursor
+			_, err = r.ListPage(ctx, f)
+			return err
+		},
+		"FindSimilar": func() error {
+			_, err := r.FindSimilar(ctx, &todo.Todo{ID: uuid.New(), Title: "quarterly report"}, 5)
+			return err
+		},
+		"Suggest":           func() error { _, err := r.Suggest(ctx, "rep", 5); return err },
+		"Suggest no prefix": func() error { _, err := r.Suggest(ctx, "", 5); return err },
+	}
+
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			cluster.take()
+			require.NoError(t, read())
+
+			queried := 0
+			for _, req := range cluster.take() {
+				if strings.HasSuffix(req.Path, "/_search") || strings.HasSuffix(req.Path, "/_count") {
+					requireTenantFilter(t, req, "acme")
+					queried++
+				}
+			}
+			require.NotZero(t, queried)
+		})
+	}
+}
+
+func TestTenantIsolation_Documents(t *testing.T) {
+	acmeID, globexID := uuid.New(), uuid.New()
+	cluster, client := newFakeCluster(t, map[string]string{
+		acmeID.String():   "acme",
+		globexID.String(): "globex",
+	})
+	r := NewRepository(client, "todos", WithTenantIsolation())
+	ctx := todo.WithTenant(context.Background(), "acme")
+
+	writes := func(requests []recordedRequest) []recordedRequest {
+		var found []recordedRequest
+		for _, req := range requests {
+			if req.Method != http.MethodGet && !strings.HasSuffix(req.Path, "/_mget") {
+				found = append(found, req)
+			}
+		}
+		return found
+	}
+
+	t.Run("get", func(t *testing.T) {
+		got, err := r.Get(ctx, acmeID.String())
+		require.NoError(t, err)
+		require.Equal(t, acmeID, got.ID)
+
+		_, err = r.Get(ctx, globexID.String())
+		require.ErrorIs(t, err, todo.ErrNotFound)
+	})
+
+	t.Run("get many skips other tenants", func(t *testing.T) {
+		got, err := r.GetMany(ctx, []string{acmeID.String(), globexID.String()})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		require.Equal(t, acmeID, got[0].ID)
+	})
+
+	t.Run("create stamps the tenant", func(t *testing.T) {
+		cluster.take()
+		created := &todo.Todo{ID: uuid.New(), Title: "New", Status: todo.StatusPending}
+		require.NoError(t, r.Create(ctx, created))
+
+		requests := writes(cluster.take())
+		require.Len(t, requests, 1)
+		require.Equal(t, "acme", decodeJSON(t, requests[0].Body)[tenantField])
+	})
+
+	t.Run("update of own todo is conditional", func(t *testing.T) {
+		cluster.take()
+		require.NoError(t, r.Update(ctx, &todo.Todo{ID: acmeID, Title: "Changed", Status: todo.StatusPending}))
+
+		requests := writes(cluster.take())
+		require.Len(t, requests, 1)
+		require.Equal(t, "3", requests[0].Query["if_seq_no"])
+		require.Equal(t, "1", requests[0].Query["if_primary_term"])
+		require.Equal(t, "acme", decodeJSON(t, requests[0].Body)[tenantField])
+	})
+
+	t.Run("update and delete of another tenant's todo are not found", func(t *testing.T) {
+		cluster.take()
+		require.ErrorIs(t, r.Update(ctx, &todo.Todo{ID: globexID, Title: "Stolen", Status: todo.StatusPending}), todo.ErrNotFound)
+		require.ErrorIs(t, r.Delete(ctx, globexID.String()), todo.ErrNotFound)
+		require.ErrorIs(t, r.Update(ctx, &todo.Todo{ID: uuid.New(), Title: "Missing", Status: todo.StatusPending}), todo.ErrNotFound)
+		require.Empty(t, writes(cluster.take()))
+	})
+
+	t.Run("delete of own todo is conditional", func(t *testing.T) {
+		cluster.take()
+		require.NoError(t, r.Delete(ctx, acmeID.String()))
+
+		requests := writes(cluster.take())
+		require.Len(t, requests, 1)
+		require.Equal(t, http.MethodDelete, requests[0].Method)
+		require.Equal(t, "3", requests[0].Query["if_seq_no"])
+	})
+
+	t.Run("bulk overwrite skips other tenants' todos", func(t *testing.T) {
+		cluster.take()
+		conflicts, err := r.PutMany(ctx, []*todo.Todo{
+			{ID: acmeID, Title: "Restored", Status: todo.StatusPending},
+			{ID: globexID, Title: "Stolen", Status: todo.StatusPending},
+		}, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{globexID.String()}, conflicts)
+
+		requests := writes(cluster.take())
+		require.Len(t, requests, 1)
+		require.Contains(t, requests[0].Body, acmeID.String())
+		require.Contains(t, requests[0].Body, `"tenant":"acme"`)
+		require.NotContains(t, requests[0].Body, globexID.String())
+	})
+}
+
+func TestTenantIsolation_RequiresTenant(t *testing.T) {
+	cluster, client := newFakeCluster(t, map[string]string{})
+	r := NewRepository(client, "todos", WithTenantIsolation())
+	ctx := context.Background()
+
+	_, err := r.List(ctx, todo.ListFilter{Limit: 10})
+	require.ErrorIs(t, err, todo.ErrForbidden)
+	_, err = r.Count(ctx, todo.ListFilter{})
+	require.ErrorIs(t, err, todo.ErrForbidden)
+	_, err = r.Get(ctx, uuid.NewString())
+	require.ErrorIs(t, err, todo.ErrForbidden)
+	require.ErrorIs(t, r.Create(ctx, &todo.Todo{ID: uuid.New(), Title: "x"}), todo.ErrForbidden)
+	_, err = r.PutMany(ctx, []*todo.Todo{{ID: uuid.New(), Title: "x"}}, false)
+	require.ErrorIs(t, err, todo.ErrForbidden)
+
+	require.Empty(t, cluster.take())
+}
+
+func TestTenantIsolation_Routing(t *testing.T) {
+	cluster, client := newFakeCluster(t, map[string]string{})
+	r := NewRepository(client, "todos", WithTenantRouting())
+	ctx := todo.WithTenant(context.Background(), "acme")
+
+	_, err := r.List(ctx, todo.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	_, err = r.Count(ctx, todo.ListFilter{})
+	require.NoError(t, err)
+	require.NoError(t, r.Create(ctx, &todo.Todo{ID: uuid.New(), Title: "x"}))
+
+	requests := cluster.take()
+	require.Len(t, requests, 3)
+	for _, req := range requests {
+		require.Equal(t, "acme", req.Query["routing"], "%s %s", req.Method, req.Path)
+	}
+	requireTenantFilter(t, requests[0], "acme")
+}
+
+func TestTenantIsolation_Disabled(t *testing.T) {
+	cluster, client := newFakeCluster(t, map[string]string{})
+	r := NewRepository(client, "todos")
+	ctx := context.Background()
+
+	_, err := r.Count(ctx, todo.ListFilter{})
+	require.NoError(t, err)
+	require.NoError(t, r.Create(ctx, &todo.Todo{ID: uuid.New(), Title: "x"}))
+
+	requests := cluster.take()
+	require.Len(t, requests, 2)
+	require.NotContains(t, requests[0].Body, tenantField)
+	require.NotContains(t, requests[1].Body, tenantField)
+}
+
+func TestScopeQuery(t *testing.T) {
+	q := buildQuery(todo.ListFilter{Status: todo.StatusPending})
+
+	require.Same(t, q, scopeQuery(q, ""))
+
+	scoped := scopeQuery(q, "acme")
+	require.Equal(t, []types.Query{*q}, scoped.Bool.Must)
+	require.Equal(t, "acme", scoped.Bool.Filter[0].Term[tenantField].Value)
+
+	// A request without a query matches the tenant's todos only
+	require.Empty(t, scopeQuery(nil, "acme").Bool.Must)
+}
+
+func TestCheckScopable(t *testing.T) {
+	field := "labels"
+	terms := types.Aggregations{Terms: &types.TermsAggregation{Field: &field}}
+	global := types.Aggregations{Global: &types.GlobalAggregation{}}
+
+	tests := []struct {
+		name    string
+		req     *search.Request
+		wantErr bool
+	}{
+		{"query", &search.Request{Query: &types.Query{MatchAll: &types.MatchAllQuery{}}}, false},
+		{"terms aggregation", &search.Request{Aggregations: map[string]types.Aggregations{"labels": terms}}, false},
+		{"facets", &search.Request{Aggregations: buildFacetAggregations()}, false},
+		{"global aggregation", &search.Request{Aggregations: map[string]types.Aggregations{"all": global}}, true},
+		{"nested global aggregation", &search.Request{Aggregations: map[string]types.Aggregations{
+			"labels": {Terms: terms.Terms, Aggregations: map[string]types.Aggregations{"all": global}},
+		}}, true},
+		{"knn", &search.Request{Knn: []types.KnnSearch{{Field: "embedding"}}}, true},
+		{"retriever", &search.Request{Retriever: &types.RetrieverContainer{}}, true},
+		{"suggester", &search.Request{Suggest: &types.Suggester{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkScopable(tt.req)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// documentAPIs are the client APIs that read or write documents.
+var documentAPIs = map[string]bool{
+	"Bulk": true, "Count": true, "Create": true, "Delete": true, "DeleteByQuery": true,
+	"Explain": true, "Get": true, "GetSource": true, "Index": true, "KnnSearch": true,
+	"Mget": true, "Msearch": true, "MsearchTemplate": true, "Mtermvectors": true,
+	"OpenPointInTime": true, "Search": true, "SearchMvt": true,
+	"SearchTemplate": true, "TermsEnum": true, "Termvectors": true, "Update": true,
+	"UpdateByQuery": true,
+}
+
+// TestTenantIsolation_IndexAccess checks that only tenant.go reads or writes
+// the todo index, so no query path can skip the tenant filter. Calls on other
+// indices, such as the field registry, token and migration indices, are fine,
+// and reindexing during migrations copies every tenant on purpose.
+func TestTenantIsolation_IndexAccess(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") || name == "tenant.go" {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+
+		seen := map[*ast.CallExpr]bool{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || seen[call] {
+				return true
+			}
+			root, chain := callChain(call)
+			if root == nil || !documentAPIs[clientMethod(root)] {
+				return true
+			}
+			for _, c := range chain {
+				seen[c] = true
+			}
+
+			pos := fset.Position(call.Pos())
+			named := len(root.Args) > 0
+			for _, c := range chain {
+				if sel, ok := c.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Index" && c != root {
+					named = true
+				}
+			}
+			require.True(t, named, "%s: %s call does not name an index; use the helpers in tenant.go", pos, clientMethod(root))
+			require.False(t, mentionsTodoIndex(call), "%s: %s call on the todo index; use the helpers in tenant.go", pos, clientMethod(root))
+			return true
+		})
+	}
+}
+
+// callChain returns the innermost call of a method chain such as
+// r.client.Search().Index(i).Do(ctx) and every call in the chain.
+func callChain(call *ast.CallExpr) (*ast.CallExpr, []*ast.CallExpr) {
+	chain := []*ast.CallExpr{call}
+	for {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil, nil
+		}
+		inner, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return call, chain
+		}
+		call = inner
+		chain = append(chain, call)
+	}
+}
+
+// clientMethod returns M for a call of r.client.M, or "".
+func clientMethod(call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	client, ok := sel.X.(*ast.SelectorExpr)
+	if !ok || client.Sel.Name != "client" {
+		return ""
+	}
+	if recv, ok := client.X.(*ast.Ident); !ok || recv.Name != "r" {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// mentionsTodoIndex reports whether an expression uses r.indexName.
+func mentionsTodoIndex(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "indexName" {
+			if recv, ok := sel.X.(*ast.Ident); ok && recv.Name == "r" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
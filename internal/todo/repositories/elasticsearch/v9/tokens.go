@@ -0,0 +1,137 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/refresh"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+
+	_ "embed"
+)
+
+//go:embed indices/tokens.json
+var tokensIndex []byte
+
+// tokensIndexSuffix is appended to the todo index name to form the personal access token index.
+const tokensIndexSuffix = "-tokens"
+
+// maxTokens is the maximum number of tokens returned by ListTokens.
+const maxTokens = 1000
+
+// tokensIndexName returns the name of the personal access token index.
+func (r *Repository) tokensIndexName() string {
+	return r.indexName + tokensIndexSuffix
+}
+
+// PutToken stores a personal access token.
+func (r *Repository) PutToken(ctx context.Context, t *todo.Token) error {
+	_, err := r.client.Create(r.tokensIndexName(), t.ID).
+		Document(t).
+		Refresh(refresh.True).
+		Do(ctx)
+	if err != nil {
+		if esStatus(err) == http.StatusConflict {
+			return todo.ErrConflict
+		}
+		return fmt.Errorf("failed to put token: %w", err)
+	}
+
+	return nil
+}
+
+// GetToken retrieves a personal access token by ID.
+func (r *Repository) GetToken(ctx context.Context, id string) (*todo.Token, error) {
+	res, err := r.client.Get(r.tokensIndexName(), id).Do(ctx)
+	if err != nil {
+		// The index is created by migrate; until then there are no tokens
+		if esStatus(err) == http.StatusNotFound {
+			return nil, todo.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if !res.Found {
+		return nil, todo.ErrNotFound
+	}
+
+	var t todo.Token
+	if err := json.Unmarshal(res.Source_, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	return &t, nil
+}
+
+// ListTokens returns all personal access tokens ordered by creation time.
+func (r *Repository) ListTokens(ctx context.Context) ([]*todo.Token, error) {
+	size := maxTokens
+	order := sortorder.Asc
+	res, err := r.client.Search().
+		Index(r.tokensIndexName()).
+		Request(&search.Request{
+			Query: &types.Query{MatchAll: &types.MatchAllQuery{}},
+			Size:  &size,
+			Sort: []types.SortCombinations{
+				types.SortOptions{
+					SortOptions: map[string]types.FieldSort{
+						"createTime": {Order: &order},
+					},
+				},
+			},
+		}).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	tokens := make([]*todo.Token, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var t todo.Token
+		if err := json.Unmarshal(hit.Source_, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse token: %w", err)
+		}
+		tokens = append(tokens, &t)
+	}
+
+	return tokens, nil
+}
+
+// DeleteToken removes a personal access token by ID.
+func (r *Repository) DeleteToken(ctx context.Context, id string) error {
+	res, err := r.client.Delete(r.tokensIndexName(), id).
+		Refresh(refresh.True).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	if res.Result.Name == "not_found" {
+		return todo.ErrNotFound
+	}
+
+	return nil
+}
+
+// esStatus returns the HTTP status of an Elasticsearch error response, or 0
+// for other errors.
+func esStatus(err error) int {
+	var esErr *types.ElasticsearchError
+	if errors.As(err, &esErr) {
+		return esErr.Status
+	}
+	return 0
+}
+
+// indexNotFound reports whether err is Elasticsearch refusing a request
+// because its index does not exist.
+func indexNotFound(err error) bool {
+	var esErr *types.ElasticsearchError
+	return errors.As(err, &esErr) && esErr.ErrorCause.Type == "index_not_found_exception"
+}
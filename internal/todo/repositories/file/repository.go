@@ -0,0 +1,390 @@
+// Package file implements todo.Repository on a single local JSON file.
+// It needs no server, which makes it useful for offline use, tests and as a
+// target when moving data out of Elasticsearch.
+package file
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/filelock"
+	"github.com/MattDevy/es-todoify/internal/repository"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// formatVersion is the version of the on-disk document layout.
+const formatVersion = 1
+
+// document is the on-disk layout of the store.
+type document struct {
+	Version int                     `json:"version"`
+	Todos   []*todo.Todo            `json:"todos"`
+	Fields  []*todo.FieldDefinition `json:"fields,omitempty"`
+	Tokens  []*todo.Token           `json:"tokens,omitempty"`
+}
+
+// Repository is the implementation of the Repository interface for a local JSON file.
+// Every operation reads the file and writes it back atomically, and changes
+// hold a lock file meanwhile, so several processes may share it.
+type Repository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewRepository creates a new Repository stored at path. The file and its
+// directory are created on the first write.
+func NewRepository(path string) *Repository {
+	return &Repository{path: path}
+}
+
+// Path returns the location of the store.
+func (r *Repository) Path() string {
+	return r.path
+}
+
+// load reads the store. A missing file is an empty store.
+func (r *Repository) load() (*document, error) {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &document{Version: formatVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", r.path, err)
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", r.path, err)
+	}
+	if doc.Version > formatVersion {
+		return nil, fmt.Errorf("%s has format version %d, this version of todoify supports up to %d", r.path, doc.Version, formatVersion)
+	}
+
+	return &doc, nil
+}
+
+// save writes the store to a temporary file and renames it over the old one,
+// so readers never see a partial write.
+func (r *Repository) save(doc *document) error {
+	doc.Version = formatVersion
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+
+	return nil
+}
+
+// modify loads the store, applies fn and saves the result unless fn fails.
+// The lock file is held throughout, so changes made by other processes in
+// the meantime are not overwritten.
+func (r *Repository) modify(fn func(doc *document) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := filelock.Lock(r.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	doc, err := r.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+	return r.save(doc)
+}
+
+// read loads the store for a read-only operation. Writes replace the file
+// atomically, so reads need no lock file.
+func (r *Repository) read() (*document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load()
+}
+
+// index returns the position of the todo with the given ID, or -1.
+func (d *document) index(id string) int {
+	return slices.IndexFunc(d.Todos, func(t *todo.Todo) bool { return t.ID.String() == id })
+}
+
+func (r *Repository) Create(ctx context.Context, t *todo.Todo) error {
+	return r.modify(func(doc *document) error {
+		if doc.index(t.ID.String()) >= 0 {
+			return fmt.Errorf("%w: %s", todo.ErrConflict, t.ID)
+		}
+		doc.Todos = append(doc.Todos, t)
+		return nil
+	})
+}
+
+func (r *Repository) Get(ctx context.Context, id string) (*todo.Todo, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	i := doc.index(id)
+	if i < 0 {
+		return nil, todo.ErrNotFound
+	}
+	return doc.Todos[i], nil
+}
+
+// GetMany retrieves the todos with the given IDs with a single read of the file.
+func (r *Repository) GetMany(ctx context.Context, ids []string) ([]*todo.Todo, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []*todo.Todo
+	for _, id := range ids {
+		if i := doc.index(id); i >= 0 {
+			todos = append(todos, doc.Todos[i])
+		}
+	}
+	return todos, nil
+}
+
+func (r *Repository) Update(ctx context.Context, t *todo.Todo) error {
+	return r.modify(func(doc *document) error {
+		i := doc.index(t.ID.String())
+		if i < 0 {
+			return todo.ErrNotFound
+		}
+		doc.Todos[i] = t
+		return nil
+	})
+}
+
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	return r.modify(func(doc *document) error {
+		i := doc.index(id)
+		if i < 0 {
+			return todo.ErrNotFound
+		}
+		doc.Todos = slices.Delete(doc.Todos, i, i+1)
+		return nil
+	})
+}
+
+func (r *Repository) List(ctx context.Context, filter todo.ListFilter) ([]*todo.Todo, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	todos := matching(doc.Todos, filter)
+	todo.SortTodos(todos, filter.SortBy, filter.SortOrder)
+
+	start := min(filter.Offset, len(todos))
+	end := len(todos)
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, end)
+	}
+	return todos[start:end], nil
+}
+
+func (r *Repository) Count(ctx context.Context, filter todo.ListFilter) (int, error) {
+	doc, err := r.read()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(matching(doc.Todos, filter)), nil
+}
+
+// matching returns the todos that satisfy the filter.
+func matching(todos []*todo.Todo, filter todo.ListFilter) []*todo.Todo {
+	var matched []*todo.Todo
+	for _, t := range todos {
+		if filter.Matches(t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// PutMany stores todos as given, keeping their IDs and timestamps.
+func (r *Repository) PutMany(ctx context.Context, todos []*todo.Todo, overwrite bool) ([]string, error) {
+	var conflicts []string
+	err := r.modify(func(doc *document) error {
+		for _, t := range todos {
+			i := doc.index(t.ID.String())
+			switch {
+			case i < 0:
+				doc.Todos = append(doc.Todos, t)
+			case overwrite:
+				doc.Todos[i] = t
+			default:
+				conflicts = append(conflicts, t.ID.String())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+// PutField creates or replaces a custom field definition.
+func (r *Repository) PutField(ctx context.Context, def *todo.FieldDefinition) error {
+	return r.modify(func(doc *document) error {
+		i := slices.IndexFunc(doc.Fields, func(d *todo.FieldDefinition) bool { return d.Name == def.Name })
+		if i >= 0 {
+			doc.Fields[i] = def
+		} else {
+			doc.Fields = append(doc.Fields, def)
+		}
+		return nil
+	})
+}
+
+// ListFields returns all custom field definitions ordered by name.
+func (r *Repository) ListFields(ctx context.Context) ([]*todo.FieldDefinition, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := slices.Clone(doc.Fields)
+	slices.SortFunc(defs, func(a, b *todo.FieldDefinition) int { return cmp.Compare(a.Name, b.Name) })
+	return defs, nil
+}
+
+// DeleteField removes a custom field definition by name.
+func (r *Repository) DeleteField(ctx context.Context, name string) error {
+	return r.modify(func(doc *document) error {
+		i := slices.IndexFunc(doc.Fields, func(d *todo.FieldDefinition) bool { return d.Name == name })
+		if i < 0 {
+			return todo.ErrNotFound
+		}
+		doc.Fields = slices.Delete(doc.Fields, i, i+1)
+		return nil
+	})
+}
+
+// PutToken stores a personal access token.
+func (r *Repository) PutToken(ctx context.Context, t *todo.Token) error {
+	return r.modify(func(doc *document) error {
+		if slices.ContainsFunc(doc.Tokens, func(x *todo.Token) bool { return x.ID == t.ID }) {
+			return todo.ErrConflict
+		}
+		doc.Tokens = append(doc.Tokens, t)
+		return nil
+	})
+}
+
+// GetToken retrieves a personal access token by ID.
+func (r *Repository) GetToken(ctx context.Context, id string) (*todo.Token, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(doc.Tokens, func(t *todo.Token) bool { return t.ID == id })
+	if i < 0 {
+		return nil, todo.ErrNotFound
+	}
+	return doc.Tokens[i], nil
+}
+
+// ListTokens returns all personal access tokens ordered by creation time.
+func (r *Repository) ListTokens(ctx context.Context) ([]*todo.Token, error) {
+	doc, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := slices.Clone(doc.Tokens)
+	slices.SortStableFunc(tokens, func(a, b *todo.Token) int { return a.CreateTime.Compare(b.CreateTime) })
+	return tokens, nil
+}
+
+// DeleteToken removes a personal access token by ID.
+func (r *Repository) DeleteToken(ctx context.Context, id string) error {
+	return r.modify(func(doc *document) error {
+		i := slices.IndexFunc(doc.Tokens, func(t *todo.Token) bool { return t.ID == id })
+		if i < 0 {
+			return todo.ErrNotFound
+		}
+		doc.Tokens = slices.Delete(doc.Tokens, i, i+1)
+		return nil
+	})
+}
+
+// Health checks that the store can be read.
+func (r *Repository) Health(ctx context.Context) (*repository.HealthInfo, error) {
+	start := time.Now()
+
+	doc, err := r.read()
+	if err != nil {
+		return &repository.HealthInfo{
+			Status:       repository.HealthStatusUnhealthy,
+			Available:    false,
+			ResponseTime: time.Since(start),
+			Details: map[string]interface{}{
+				"path":  r.path,
+				"error": err.Error(),
+			},
+		}, err
+	}
+
+	details := map[string]interface{}{
+		"path":           r.path,
+		"format_version": formatVersion,
+		"todo_count":     len(doc.Todos),
+		"field_count":    len(doc.Fields),
+		"token_count":    len(doc.Tokens),
+	}
+	if info, err := os.Stat(r.path); err == nil {
+		details["size_in_bytes"] = info.Size()
+	} else {
+		details["exists"] = false
+	}
+
+	return &repository.HealthInfo{
+		Status:       repository.HealthStatusHealthy,
+		Available:    true,
+		ResponseTime: time.Since(start),
+		Details:      details,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/repository"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	return NewRepository(filepath.Join(t.TempDir(), "store", "todos.json"))
+}
+
+func newTodo(t *testing.T, title string, labels ...string) *todo.Todo {
+	t.Helper()
+	td, err := todo.NewTodo(title, "", labels)
+	require.NoError(t, err)
+	return td
+}
+
+func TestRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	td := newTodo(t, "Write docs", "docs")
+	require.NoError(t, repo.Create(ctx, td))
+	require.ErrorIs(t, repo.Create(ctx, td), todo.ErrConflict)
+
+	got, err := repo.Get(ctx, td.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, td.Title, got.Title)
+	require.True(t, td.CreateTime.Equal(got.CreateTime))
+
+	got.Title = "Write more docs"
+	require.NoError(t, repo.Update(ctx, got))
+	got, err = repo.Get(ctx, td.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, "Write more docs", got.Title)
+
+	require.NoError(t, repo.Delete(ctx, td.ID.String()))
+	_, err = repo.Get(ctx, td.ID.String())
+	require.ErrorIs(t, err, todo.ErrNotFound)
+	require.ErrorIs(t, repo.Delete(ctx, td.ID.String()), todo.ErrNotFound)
+	require.ErrorIs(t, repo.Update(ctx, td), todo.ErrNotFound)
+}
+
+func TestRepository_SharedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todos.json")
+	// Separate repositories only share the lock file, like separate processes
+	repos := []*Repository{NewRepository(path), NewRepository(path)}
+
+	const perRepo = 20
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		wg.Go(func() {
+			for range perRepo {
+				require.NoError(t, repo.Create(ctx, newTodo(t, "Write docs")))
+			}
+		})
+	}
+	wg.Wait()
+
+	count, err := NewRepository(path).Count(ctx, todo.ListFilter{})
+	require.NoError(t, err)
+	require.Equal(t, len(repos)*perRepo, count)
+}
+
+func TestRepository_ListAndCount(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var todos []*todo.Todo
+	for i, title := range []string{"first", "second", "third"} {
+		td := newTodo(t, title, "work")
+		td.CreateTime = base.Add(time.Duration(i) * time.Hour)
+		todos = append(todos, td)
+	}
+	todos[1].Labels = []string{"home"}
+	conflicts, err := repo.PutMany(ctx, todos, false)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+
+	got, err := repo.List(ctx, todo.ListFilter{Labels: []string{"work"}, SortBy: todo.SortFieldCreateTime, SortOrder: todo.SortOrderDesc})
+	require.NoError(t, err)
+	require.Equal(t, []string{"third", "first"}, titles(got))
+
+	got, err = repo.List(ctx, todo.ListFilter{Limit: 1, Offset: 1, SortBy: todo.SortFieldCreateTime, SortOrder: todo.SortOrderAsc})
+	require.NoError(t, err)
+	require.Equal(t, []string{"second"}, titles(got))
+
+	count, err := repo.Count(ctx, todo.ListFilter{Labels: []string{"work"}})
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestRepository_PutMany(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	td := newTodo(t, "original")
+	require.NoError(t, repo.Create(ctx, td))
+
+	changed := *td
+	changed.Title = "changed"
+	conflicts, err := repo.PutMany(ctx, []*todo.Todo{&changed}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{td.ID.String()}, conflicts)
+	got, err := repo.Get(ctx, td.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, "original", got.Title)
+
+	conflicts, err = repo.PutMany(ctx, []*todo.Todo{&changed}, true)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	got, err = repo.Get(ctx, td.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, "changed", got.Title)
+}
+
+func TestRepository_GetMany(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	one, two := newTodo(t, "one"), newTodo(t, "two")
+	require.NoError(t, repo.Create(ctx, one))
+	require.NoError(t, repo.Create(ctx, two))
+
+	todos, err := repo.GetMany(ctx, []string{two.ID.String(), "00000000-0000-0000-0000-000000000000", one.ID.String()})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"one", "two"}, titles(todos))
+}
+
+func TestRepository_Fields(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	require.NoError(t, repo.PutField(ctx, &todo.FieldDefinition{Name: "severity", Type: todo.FieldTypeString}))
+	require.NoError(t, repo.PutField(ctx, &todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber}))
+	require.NoError(t, repo.PutField(ctx, &todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber, Required: true}))
+
+	defs, err := repo.ListFields(ctx)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	require.Equal(t, "points", defs[0].Name)
+	require.True(t, defs[0].Required)
+
+	require.NoError(t, repo.DeleteField(ctx, "points"))
+	require.ErrorIs(t, repo.DeleteField(ctx, "points"), todo.ErrNotFound)
+}
+
+func TestRepository_Tokens(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	now := time.Now().UTC()
+	older := &todo.Token{ID: "b1", Name: "ci", Subject: "bob", Scopes: []todo.Scope{todo.ScopeWrite}, Hash: "h1", CreateTime: now.Add(-time.Hour)}
+	newer := &todo.Token{ID: "a2", Name: "dashboard", Subject: "alice", Scopes: []todo.Scope{todo.ScopeRead}, Hash: "h2", CreateTime: now}
+	require.NoError(t, repo.PutToken(ctx, newer))
+	require.NoError(t, repo.PutToken(ctx, older))
+	require.ErrorIs(t, repo.PutToken(ctx, older), todo.ErrConflict)
+
+	got, err := repo.GetToken(ctx, "a2")
+	require.NoError(t, err)
+	require.Equal(t, "dashboard", got.Name)
+	require.Equal(t, "h2", got.Hash)
+
+	tokens, err := repo.ListTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, "b1", tokens[0].ID)
+
+	require.NoError(t, repo.DeleteToken(ctx, "b1"))
+	require.ErrorIs(t, repo.DeleteToken(ctx, "b1"), todo.ErrNotFound)
+	_, err = repo.GetToken(ctx, "b1")
+	require.ErrorIs(t, err, todo.ErrNotFound)
+}
+
+func TestRepository_Health(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	info, err := repo.Health(ctx)
+	require.NoError(t, err)
+	require.Equal(t, repository.HealthStatusHealthy, info.Status)
+	require.Equal(t, false, info.Details["exists"])
+
+	require.NoError(t, repo.Create(ctx, newTodo(t, "one")))
+	info, err = repo.Health(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, info.Details["todo_count"])
+}
+
+func titles(todos []*todo.Todo) []string {
+	var out []string
+	for _, t := range todos {
+		out = append(out, t.Title)
+	}
+	return out
+}
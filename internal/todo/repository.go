@@ -2,6 +2,7 @@ package todo
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/MattDevy/es-todoify/internal/repository"
@@ -35,14 +36,56 @@ type Repository interface {
 	Count(ctx context.Context, filter ListFilter) (int, error)
 }
 
+// FieldRepository defines persistence for the custom field registry.
+// Backends that can store field definitions implement it alongside Repository.
+type FieldRepository interface {
+	// PutField creates or replaces a field definition.
+	PutField(ctx context.Context, def *FieldDefinition) error
+
+	// ListFields returns all field definitions ordered by name.
+	ListFields(ctx context.Context) ([]*FieldDefinition, error)
+
+	// DeleteField removes a field definition by name.
+	// Returns ErrNotFound if the field doesn't exist.
+	DeleteField(ctx context.Context, name string) error
+}
+
+// BulkWriter is implemented by repositories that can write many todos in one
+// request. Todos are stored as given, keeping their IDs and timestamps.
+type BulkWriter interface {
+	// PutMany stores todos. When overwrite is false, todos whose ID already
+	// exists are left unchanged and their IDs returned as conflicts.
+	PutMany(ctx context.Context, todos []*Todo, overwrite bool) (conflicts []string, err error)
+}
+
+// BatchGetter is implemented by repositories that can fetch many todos in one
+// request. Repositories without it are queried once per ID.
+type BatchGetter interface {
+	// GetMany retrieves the todos with the given IDs. Missing IDs are left out
+	// of the result, which is in no particular order.
+	GetMany(ctx context.Context, ids []string) ([]*Todo, error)
+}
+
 // ListFilter defines filtering and pagination options for listing todos.
 type ListFilter struct {
 	// Status filters by todo status (empty = all)
 	Status Status
 
+	// Statuses filters todos that have any of the specified statuses
+	Statuses []Status
+
+	// ExcludeStatuses filters out todos that have any of the specified statuses
+	ExcludeStatuses []Status
+
 	// Labels filters todos that have all specified labels
 	Labels []string
 
+	// AnyLabels filters todos that have at least one of the specified labels
+	AnyLabels []string
+
+	// ExcludeLabels filters out todos that have any of the specified labels
+	ExcludeLabels []string
+
 	// SearchQuery performs full-text search on title and description
 	SearchQuery string
 
@@ -52,12 +95,23 @@ type ListFilter struct {
 	// ToDate filters todos created on or before this date
 	ToDate *time.Time
 
+	// Where filters todos on custom field values (all conditions must match)
+	Where []FieldCondition
+
+	// Query is a parsed query language expression combined with the other filters
+	Query *Query
+
 	// Limit is the maximum number of results to return
 	Limit int
 
-	// Offset is the number of results to skip (for pagination)
+	// Offset is the number of results to skip (for pagination). With cursor
+	// paging it skips results on the first page, and cursors continue from there.
 	Offset int
 
+	// Cursor continues a previous page (see Page.NextCursor). It cannot be combined
+	// with Offset and must be used with the same filter that produced it.
+	Cursor string
+
 	// SortBy specifies the field to sort by
 	SortBy SortField
 
@@ -82,6 +136,20 @@ func (f ListFilter) Validate() error {
 		return ErrInvalidInput
 	}
 
+	// Validate status sets
+	for _, status := range slices.Concat(f.Statuses, f.ExcludeStatuses) {
+		if !status.IsValid() {
+			return ErrInvalidInput
+		}
+	}
+
+	// Validate custom field conditions
+	for _, c := range f.Where {
+		if c.Field == "" || !c.Op.IsValid() {
+			return ErrInvalidInput
+		}
+	}
+
 	// Validate limit (must be positive if provided)
 	if f.Limit < 0 {
 		return ErrInvalidInput
@@ -92,6 +160,11 @@ func (f ListFilter) Validate() error {
 		return ErrInvalidInput
 	}
 
+	// Cursors replace offsets
+	if f.Cursor != "" && f.Offset > 0 {
+		return ErrInvalidInput
+	}
+
 	// Validate date range (FromDate must be before ToDate)
 	if f.FromDate != nil && f.ToDate != nil && f.FromDate.After(*f.ToDate) {
 		return ErrInvalidInput
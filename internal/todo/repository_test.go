@@ -42,6 +42,30 @@ func TestListFilter_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid status sets",
+			filter: ListFilter{
+				Statuses:        []Status{StatusPending, StatusInProgress},
+				ExcludeStatuses: TerminalStatuses(),
+				AnyLabels:       []string{"bug", "incident"},
+				ExcludeLabels:   []string{"wontfix"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid status in set",
+			filter: ListFilter{
+				Statuses: []Status{StatusPending, Status("done")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid excluded status",
+			filter: ListFilter{
+				ExcludeStatuses: []Status{Status("done")},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid sort field",
 			filter: ListFilter{
@@ -70,6 +94,21 @@ func TestListFilter_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "cursor without offset",
+			filter: ListFilter{
+				Cursor: "abc",
+			},
+			wantErr: false,
+		},
+		{
+			name: "cursor with offset",
+			filter: ListFilter{
+				Cursor: "abc",
+				Offset: 10,
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid date range (from after to)",
 			filter: ListFilter{
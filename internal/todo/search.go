@@ -0,0 +1,31 @@
+package todo
+
+import "context"
+
+const (
+	// HighlightPreTag marks the start of a highlighted fragment in SearchResult.Highlights.
+	HighlightPreTag = "<em>"
+
+	// HighlightPostTag marks the end of a highlighted fragment in SearchResult.Highlights.
+	HighlightPostTag = "</em>"
+)
+
+// SearchResult is a todo matched by a search together with why it matched.
+type SearchResult struct {
+	// Todo is the matched todo.
+	Todo *Todo `json:"todo"`
+
+	// Score is the relevance score reported by the backend (0 if not supported).
+	Score float64 `json:"score"`
+
+	// Highlights maps field names (title, description) to fragments in which
+	// matching terms are wrapped in HighlightPreTag and HighlightPostTag.
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// Searcher is implemented by repositories that can score and highlight search results.
+// Repositories without it fall back to List with zero scores and no highlights.
+type Searcher interface {
+	// Search retrieves todos matching the filter with relevance scores and highlights.
+	Search(ctx context.Context, filter ListFilter) ([]*SearchResult, error)
+}
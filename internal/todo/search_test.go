@@ -0,0 +1,73 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSearchRepository is a mock repository that also implements Searcher.
+type MockSearchRepository struct {
+	MockRepository
+}
+
+func (m *MockSearchRepository) Search(ctx context.Context, filter ListFilter) ([]*SearchResult, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*SearchResult), args.Error(1)
+}
+
+func TestService_SearchTodos(t *testing.T) {
+	ctx := context.Background()
+	testTodo := newValidTodo(t)
+
+	t.Run("uses searcher and sorts by relevance", func(t *testing.T) {
+		repo := new(MockSearchRepository)
+		want := []*SearchResult{{Todo: testTodo, Score: 1.5, Highlights: map[string][]string{"title": {"<em>Test</em> Todo"}}}}
+		repo.On("Search", ctx, mock.MatchedBy(func(f ListFilter) bool {
+			return f.SortBy == SortFieldRelevance && f.SortOrder == SortOrderDesc && f.Limit == 50
+		})).Return(want, nil)
+		service := NewService(repo)
+
+		results, err := service.SearchTodos(ctx, ListFilter{SearchQuery: "test"})
+		require.NoError(t, err)
+		require.Equal(t, want, results)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("query sort overrides relevance", func(t *testing.T) {
+		repo := new(MockSearchRepository)
+		repo.On("Search", ctx, mock.MatchedBy(func(f ListFilter) bool {
+			return f.SortBy == SortFieldTitle && f.SortOrder == SortOrderAsc
+		})).Return([]*SearchResult{}, nil)
+		service := NewService(repo)
+
+		query, err := ParseQuery("test sort:title")
+		require.NoError(t, err)
+		_, err = service.SearchTodos(ctx, ListFilter{Query: query})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("falls back to list without scores", func(t *testing.T) {
+		service, repo := newTestService(t)
+		repo.On("List", ctx, mock.AnythingOfType("todo.ListFilter")).Return([]*Todo{testTodo}, nil)
+
+		results, err := service.SearchTodos(ctx, ListFilter{SearchQuery: "test"})
+		require.NoError(t, err)
+		require.Equal(t, []*SearchResult{{Todo: testTodo}}, results)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		service, repo := newTestService(t)
+
+		_, err := service.SearchTodos(ctx, ListFilter{Limit: -1})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		repo.AssertExpectations(t)
+	})
+}
@@ -2,7 +2,10 @@ package todo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/MattDevy/es-todoify/internal/repository"
 	"github.com/google/uuid"
@@ -11,13 +14,43 @@ import (
 // Service provides business logic for Todo operations.
 // This is the application service layer in DDD.
 type Service struct {
-	repo Repository
+	repo       Repository
+	fields     FieldRepository
+	tokens     TokenRepository
+	publishers []Publisher
+	authorizer Authorizer
 }
 
 // NewService creates a new Todo service with the given repository.
-func NewService(repo Repository) *Service {
-	return &Service{
-		repo: repo,
+// If the repository also implements FieldRepository, custom fields are enabled,
+// and if it implements TokenRepository, personal access tokens are.
+func NewService(repo Repository, opts ...ServiceOption) *Service {
+	fields, _ := repo.(FieldRepository)
+	tokens, _ := repo.(TokenRepository)
+	s := &Service{
+		repo:   repo,
+		fields: fields,
+		tokens: tokens,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateOption configures optional behaviour of CreateTodo.
+type CreateOption func(*createOptions)
+
+type createOptions struct {
+	fields       map[string]any
+	onDuplicates func([]*DuplicateCandidate) error
+}
+
+// WithFields sets custom field values on the created todo.
+// Values may be raw strings (as parsed from --field) or native JSON values.
+func WithFields(fields map[string]any) CreateOption {
+	return func(o *createOptions) {
+		o.fields = fields
 	}
 }
 
@@ -28,23 +61,61 @@ func (s *Service) Health(ctx context.Context) (*repository.HealthInfo, error) {
 }
 
 // CreateTodo creates a new todo item.
-func (s *Service) CreateTodo(ctx context.Context, title, description string, labels []string) (*Todo, error) {
+func (s *Service) CreateTodo(ctx context.Context, title, description string, labels []string, opts ...CreateOption) (*Todo, error) {
+	if err := s.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+
+	var o createOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Validate and create domain object
 	todo, err := NewTodo(title, description, labels)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
 	}
 
+	// Validate custom fields against the registry
+	fields, err := s.validateFields(ctx, o.fields, nil)
+	if err != nil {
+		return nil, err
+	}
+	todo.Fields = fields
+
+	if !s.permits(ctx, ActionWrite, todo) {
+		return nil, fmt.Errorf("%w: todos must have the label of a project you may write", ErrForbidden)
+	}
+
+	// Look for likely duplicates before persisting
+	if o.onDuplicates != nil {
+		candidates, err := s.FindDuplicates(ctx, todo)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) > 0 {
+			if err := o.onDuplicates(candidates); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Persist via repository
 	if err := s.repo.Create(ctx, todo); err != nil {
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
 
+	s.publish(ctx, EventCreated, todo, "")
 	return todo, nil
 }
 
 // GetTodo retrieves a todo by ID.
 func (s *Service) GetTodo(ctx context.Context, id string) (*Todo, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
 	if id == "" {
 		return nil, fmt.Errorf("%w: id is required", ErrInvalidInput)
 	}
@@ -58,12 +129,67 @@ func (s *Service) GetTodo(ctx context.Context, id string) (*Todo, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkTodo(ctx, ActionRead, todo); err != nil {
+		return nil, err
+	}
 
 	return todo, nil
 }
 
+// GetTodos retrieves todos by ID in one batch where the repository supports
+// it. The result is aligned with ids; missing todos are nil.
+func (s *Service) GetTodos(ctx context.Context, ids []string) ([]*Todo, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("%w: invalid id format %q", ErrInvalidInput, id)
+		}
+	}
+
+	result := make([]*Todo, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	getter, ok := s.repo.(BatchGetter)
+	if !ok {
+		for i, id := range ids {
+			t, err := s.repo.Get(ctx, id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			if t != nil && s.permits(ctx, ActionRead, t) {
+				result[i] = t
+			}
+		}
+		return result, nil
+	}
+
+	todos, err := getter.GetMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Todo, len(todos))
+	for _, t := range todos {
+		if s.permits(ctx, ActionRead, t) {
+			byID[t.ID.String()] = t
+		}
+	}
+	for i, id := range ids {
+		result[i] = byID[id]
+	}
+	return result, nil
+}
+
 // UpdateTodo updates an existing todo.
 func (s *Service) UpdateTodo(ctx context.Context, id string, update UpdateTodo) (*Todo, error) {
+	if err := s.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+
 	if id == "" {
 		return nil, fmt.Errorf("%w: id is required", ErrInvalidInput)
 	}
@@ -78,10 +204,25 @@ func (s *Service) UpdateTodo(ctx context.Context, id string, update UpdateTodo)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkTodo(ctx, ActionWrite, todo); err != nil {
+		return nil, err
+	}
+
+	// Validate custom fields against the registry
+	if update.Fields != nil {
+		fields, err := s.validateFields(ctx, update.Fields, todo.Fields)
+		if err != nil {
+			return nil, err
+		}
+		update.Fields = fields
+	}
 
 	// Apply updates using domain logic
 	if err := todo.Update(update); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if !s.permits(ctx, ActionWrite, todo) {
+		return nil, fmt.Errorf("%w: todos must keep the label of a project you may write", ErrForbidden)
 	}
 
 	// Persist changes
@@ -89,11 +230,16 @@ func (s *Service) UpdateTodo(ctx context.Context, id string, update UpdateTodo)
 		return nil, fmt.Errorf("failed to update todo: %w", err)
 	}
 
+	s.publish(ctx, EventUpdated, todo, "")
 	return todo, nil
 }
 
 // ChangeStatus changes the status of a todo.
 func (s *Service) ChangeStatus(ctx context.Context, id string, newStatus Status) (*Todo, error) {
+	if err := s.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+
 	if id == "" {
 		return nil, fmt.Errorf("%w: id is required", ErrInvalidInput)
 	}
@@ -108,8 +254,12 @@ func (s *Service) ChangeStatus(ctx context.Context, id string, newStatus Status)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkTodo(ctx, ActionWrite, todo); err != nil {
+		return nil, err
+	}
 
 	// Apply status change using domain logic (validates business rules)
+	previous := todo.Status
 	if err := todo.ChangeStatus(newStatus); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidStatus, err)
 	}
@@ -119,11 +269,16 @@ func (s *Service) ChangeStatus(ctx context.Context, id string, newStatus Status)
 		return nil, fmt.Errorf("failed to update todo status: %w", err)
 	}
 
+	s.publish(ctx, EventStatusChanged, todo, previous)
 	return todo, nil
 }
 
 // DeleteTodo removes a todo by ID.
 func (s *Service) DeleteTodo(ctx context.Context, id string) error {
+	if err := s.Authorize(ctx, ActionWrite); err != nil {
+		return err
+	}
+
 	if id == "" {
 		return fmt.Errorf("%w: id is required", ErrInvalidInput)
 	}
@@ -133,14 +288,341 @@ func (s *Service) DeleteTodo(ctx context.Context, id string) error {
 		return fmt.Errorf("%w: invalid id format", ErrInvalidInput)
 	}
 
-	return s.repo.Delete(ctx, id)
+	_, limited := s.projects(ctx, ActionWrite)
+	if len(s.publishers) == 0 && !limited {
+		return s.repo.Delete(ctx, id)
+	}
+
+	// Read the todo first so subscribers can match the deletion against it,
+	// and to check its projects
+	todo, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkTodo(ctx, ActionWrite, todo); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publish(ctx, EventDeleted, todo, "")
+	return nil
 }
 
 // ListTodos retrieves todos with filtering and pagination.
 func (s *Service) ListTodos(ctx context.Context, filter ListFilter) ([]*Todo, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.prepareFilter(ctx, filter, SortFieldCreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.List(ctx, filter)
+}
+
+// ListTodosPage retrieves one page of todos matching the filter and a cursor for
+// the next page. Pass the cursor back in filter.Cursor to continue; the rest of
+// the filter must stay the same.
+func (s *Service) ListTodosPage(ctx context.Context, filter ListFilter) (*Page, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.prepareFilter(ctx, filter, SortFieldCreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listPage(ctx, filter)
+}
+
+// listPage retrieves a page of todos for an already prepared filter.
+func (s *Service) listPage(ctx context.Context, filter ListFilter) (*Page, error) {
+	if pager, ok := s.repo.(Pager); ok {
+		return pager.ListPage(ctx, filter)
+	}
+
+	return listOffsetPage(ctx, s.repo, filter)
+}
+
+// listAll retrieves every todo matching the filter by following cursors.
+func (s *Service) listAll(ctx context.Context, filter ListFilter) ([]*Todo, error) {
+	filter.Limit = 1000
+	filter.Offset = 0
+	filter.Cursor = ""
+
+	var todos []*Todo
+	for {
+		page, err := s.listPage(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, page.Todos...)
+		if page.NextCursor == "" {
+			return todos, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// ListTodosWithFacets retrieves todos like ListTodos together with status, label
+// and created-month counts over every todo matching the filter.
+func (s *Service) ListTodosWithFacets(ctx context.Context, filter ListFilter) ([]*Todo, *Facets, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, nil, err
+	}
+
+	filter, err := s.prepareFilter(ctx, filter, SortFieldCreateTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if faceter, ok := s.repo.(Faceter); ok {
+		return faceter.ListWithFacets(ctx, filter)
+	}
+
+	todos, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fall back to counting over every matching todo
+	matching, err := s.listAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return todos, facetsOf(matching), nil
+}
+
+// SearchTodos retrieves todos matching the filter together with relevance scores
+// and highlighted fragments. Results are sorted by relevance unless a sort is given.
+func (s *Service) SearchTodos(ctx context.Context, filter ListFilter) ([]*SearchResult, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.prepareFilter(ctx, filter, SortFieldRelevance)
+	if err != nil {
+		return nil, err
+	}
+
+	if searcher, ok := s.repo.(Searcher); ok {
+		return searcher.Search(ctx, filter)
+	}
+
+	// Fall back to a plain list for repositories that cannot score results
+	todos, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SearchResult, len(todos))
+	for i, t := range todos {
+		results[i] = &SearchResult{Todo: t}
+	}
+
+	return results, nil
+}
+
+// Suggest returns todo and label completions for a prefix.
+// It powers the suggest command and shell completion.
+func (s *Service) Suggest(ctx context.Context, prefix string, limit int) (*Suggestions, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	// Callers limited to projects get completions from their todos only
+	if suggester, ok := s.repo.(Suggester); ok {
+		if _, limited := s.projects(ctx, ActionRead); !limited {
+			return suggester.Suggest(ctx, prefix, limit)
+		}
+	}
+
+	// Fall back to matching against the most recently updated todos
+	filter, err := s.restrictFilter(ctx, ListFilter{
+		Limit:     1000,
+		SortBy:    SortFieldUpdateTime,
+		SortOrder: SortOrderDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestFromTodos(todos, prefix, limit), nil
+}
+
+// FindDuplicates returns open todos that look like duplicates of t, most similar first.
+func (s *Service) FindDuplicates(ctx context.Context, t *Todo) ([]*DuplicateCandidate, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	if finder, ok := s.repo.(DuplicateFinder); ok {
+		candidates, err := finder.FindSimilar(ctx, t, maxDuplicateCandidates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find duplicates: %w", err)
+		}
+		return slices.DeleteFunc(candidates, func(c *DuplicateCandidate) bool {
+			return !s.permits(ctx, ActionRead, c.Todo)
+		}), nil
+	}
+
+	// Fall back to comparing words against open todos
+	filter, err := s.restrictFilter(ctx, ListFilter{
+		ExcludeStatuses: TerminalStatuses(),
+		Limit:           1000,
+		SortBy:          SortFieldCreateTime,
+		SortOrder:       SortOrderDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	open, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicates: %w", err)
+	}
+
+	return findSimilarIn(t, open, maxDuplicateCandidates), nil
+}
+
+// FindDuplicateClusters scans open todos and groups likely duplicates together.
+// Each cluster's primary is its oldest todo, and every other member must be
+// a candidate of the primary whose words are as similar to it as the
+// fallback finder requires. Members are never joined through each other, so
+// todos that merely share words with a common neighbour stay apart.
+func (s *Service) FindDuplicateClusters(ctx context.Context) ([]*DuplicateCluster, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.restrictFilter(ctx, ListFilter{
+		ExcludeStatuses: TerminalStatuses(),
+		SortBy:          SortFieldCreateTime,
+		SortOrder:       SortOrderAsc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	open, err := s.listAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+
+	// Walk the todos oldest first: a todo joins the cluster of an older
+	// primary it duplicates, or else becomes a primary itself
+	byID := make(map[uuid.UUID]*Todo, len(open))
+	for _, t := range open {
+		byID[t.ID] = t
+	}
+	primaries := make(map[uuid.UUID]*DuplicateCluster)
+	assigned := make(map[uuid.UUID]bool)
+	var clusters []*DuplicateCluster
+
+	for _, t := range open {
+		if assigned[t.ID] {
+			continue
+		}
+		candidates, err := s.FindDuplicates(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		candidates = slices.DeleteFunc(candidates, func(c *DuplicateCandidate) bool {
+			_, ok := byID[c.Todo.ID]
+			return !ok || similarity(t, c.Todo) < fallbackSimilarity
+		})
+
+		assigned[t.ID] = true
+		if i := slices.IndexFunc(candidates, func(c *DuplicateCandidate) bool { return primaries[c.Todo.ID] != nil }); i >= 0 {
+			cluster := primaries[candidates[i].Todo.ID]
+			cluster.Duplicates = append(cluster.Duplicates, &DuplicateCandidate{Todo: t, Score: candidates[i].Score})
+			continue
+		}
+
+		cluster := &DuplicateCluster{Primary: t}
+		primaries[t.ID] = cluster
+		clusters = append(clusters, cluster)
+		for _, c := range candidates {
+			if !assigned[c.Todo.ID] {
+				assigned[c.Todo.ID] = true
+				cluster.Duplicates = append(cluster.Duplicates, &DuplicateCandidate{Todo: byID[c.Todo.ID], Score: c.Score})
+			}
+		}
+	}
+
+	return slices.DeleteFunc(clusters, func(c *DuplicateCluster) bool { return len(c.Duplicates) == 0 }), nil
+}
+
+// MergeTodos merges duplicates into the primary todo and deletes them.
+// Labels are combined, custom fields missing on the primary are copied over and
+// an empty description is filled from the duplicates.
+func (s *Service) MergeTodos(ctx context.Context, primaryID string, duplicateIDs []string) (*Todo, error) {
+	if err := s.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+
+	primary, err := s.GetTodo(ctx, primaryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTodo(ctx, ActionWrite, primary); err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]*Todo, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		if id == primaryID {
+			return nil, fmt.Errorf("%w: cannot merge a todo into itself", ErrInvalidInput)
+		}
+		d, err := s.GetTodo(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkTodo(ctx, ActionWrite, d); err != nil {
+			return nil, err
+		}
+		duplicates = append(duplicates, d)
+	}
+
+	if err := primary.Update(mergeInto(primary, duplicates)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	if err := s.repo.Update(ctx, primary); err != nil {
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+	s.publish(ctx, EventUpdated, primary, "")
+
+	for _, d := range duplicates {
+		if err := s.repo.Delete(ctx, d.ID.String()); err != nil {
+			return nil, fmt.Errorf("failed to delete merged todo %s: %w", d.ID, err)
+		}
+		s.publish(ctx, EventDeleted, d, "")
+	}
+
+	return primary, nil
+}
+
+// prepareFilter validates the filter and applies defaults for pagination and sorting.
+func (s *Service) prepareFilter(ctx context.Context, filter ListFilter, defaultSort SortField) (ListFilter, error) {
 	// Validate filter
 	if err := filter.Validate(); err != nil {
-		return nil, fmt.Errorf("%w: invalid filter", err)
+		return filter, fmt.Errorf("%w: invalid filter", err)
 	}
 
 	// Apply sensible defaults if not provided
@@ -151,23 +633,161 @@ func (s *Service) ListTodos(ctx context.Context, filter ListFilter) ([]*Todo, er
 		filter.Limit = 1000 // Max limit to prevent resource exhaustion
 	}
 
+	// Deep offsets are expensive and rejected by the backend; cursors reach any depth
+	if filter.Offset+filter.Limit > MaxResultWindow {
+		return filter, fmt.Errorf("%w: offset+limit must not exceed %d, use a cursor to page further", ErrInvalidInput, MaxResultWindow)
+	}
+
+	// A sort term in the query takes precedence over the filter's sort
+	if filter.Query != nil && filter.Query.SortBy != "" {
+		filter.SortBy = filter.Query.SortBy
+		filter.SortOrder = filter.Query.SortOrder
+	}
+
 	// Apply default sorting if not provided
 	if filter.SortBy == "" {
-		filter.SortBy = SortFieldCreateTime
+		filter.SortBy = defaultSort
 	}
 	if filter.SortOrder == "" {
 		filter.SortOrder = SortOrderDesc
 	}
 
-	return s.repo.List(ctx, filter)
+	// Resolve custom field conditions to typed values
+	return s.resolveFilter(ctx, filter)
 }
 
 // CountTodos returns the total count of todos matching the filter.
 func (s *Service) CountTodos(ctx context.Context, filter ListFilter) (int, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return 0, err
+	}
+
 	// Validate filter
 	if err := filter.Validate(); err != nil {
 		return 0, fmt.Errorf("%w: invalid filter", err)
 	}
 
+	// Resolve custom field conditions to typed values
+	filter, err := s.resolveFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
 	return s.repo.Count(ctx, filter)
 }
+
+// DefineField creates or replaces a custom field definition.
+func (s *Service) DefineField(ctx context.Context, def FieldDefinition) (*FieldDefinition, error) {
+	if err := s.Authorize(ctx, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	if s.fields == nil {
+		return nil, fmt.Errorf("%w: custom fields", ErrUnsupported)
+	}
+
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	if def.CreateTime.IsZero() {
+		def.CreateTime = time.Now()
+	}
+
+	if err := s.fields.PutField(ctx, &def); err != nil {
+		return nil, fmt.Errorf("failed to define field: %w", err)
+	}
+
+	return &def, nil
+}
+
+// ListFields returns all custom field definitions.
+func (s *Service) ListFields(ctx context.Context) ([]*FieldDefinition, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+
+	if s.fields == nil {
+		return nil, fmt.Errorf("%w: custom fields", ErrUnsupported)
+	}
+
+	return s.fields.ListFields(ctx)
+}
+
+// DeleteField removes a custom field definition.
+// Values already stored on todos are left untouched.
+func (s *Service) DeleteField(ctx context.Context, name string) error {
+	if err := s.Authorize(ctx, ActionAdmin); err != nil {
+		return err
+	}
+
+	if s.fields == nil {
+		return fmt.Errorf("%w: custom fields", ErrUnsupported)
+	}
+
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+
+	return s.fields.DeleteField(ctx, name)
+}
+
+// validateFields checks custom field values against the registry.
+// current holds the todo's existing values and is used for required checks.
+func (s *Service) validateFields(ctx context.Context, values, current map[string]any) (map[string]any, error) {
+	if s.fields == nil {
+		if len(values) > 0 {
+			return nil, fmt.Errorf("%w: custom fields", ErrUnsupported)
+		}
+		return nil, nil
+	}
+
+	defs, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field definitions: %w", err)
+	}
+
+	fields, err := validateFields(defs, values, current)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	return fields, nil
+}
+
+// ResolveFilter coerces the custom field conditions of a filter to their
+// declared types so the filter can be evaluated in memory with Matches. Like
+// every listing, the filter is limited to the projects the caller may read.
+func (s *Service) ResolveFilter(ctx context.Context, filter ListFilter) (ListFilter, error) {
+	if err := s.Authorize(ctx, ActionRead); err != nil {
+		return filter, err
+	}
+
+	return s.resolveFilter(ctx, filter)
+}
+
+// resolveFilter limits the filter to the projects the caller may read and
+// coerces its custom field conditions to their declared types.
+func (s *Service) resolveFilter(ctx context.Context, filter ListFilter) (ListFilter, error) {
+	filter, err := s.restrictFilter(ctx, filter)
+	if err != nil || len(filter.Where) == 0 {
+		return filter, err
+	}
+
+	if s.fields == nil {
+		return filter, fmt.Errorf("%w: custom fields", ErrUnsupported)
+	}
+
+	defs, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return filter, fmt.Errorf("failed to load field definitions: %w", err)
+	}
+
+	where, err := resolveConditions(defs, filter.Where)
+	if err != nil {
+		return filter, err
+	}
+	filter.Where = where
+
+	return filter, nil
+}
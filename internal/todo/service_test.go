@@ -239,6 +239,66 @@ func TestService_GetTodo(t *testing.T) {
 	}
 }
 
+// batchRepository adds BatchGetter to the mock repository.
+type batchRepository struct {
+	*MockRepository
+}
+
+func (m batchRepository) GetMany(ctx context.Context, ids []string) ([]*Todo, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Todo), args.Error(1)
+}
+
+func TestService_GetTodos(t *testing.T) {
+	ctx := context.Background()
+	first, second := newValidTodo(t), newValidTodo(t)
+	missing := validUUID()
+	ids := []string{second.ID.String(), missing, first.ID.String()}
+
+	t.Run("falls back to Get per id", func(t *testing.T) {
+		service, mockRepo := newTestService(t)
+		mockRepo.On("Get", ctx, first.ID.String()).Return(first, nil)
+		mockRepo.On("Get", ctx, second.ID.String()).Return(second, nil)
+		mockRepo.On("Get", ctx, missing).Return(nil, ErrNotFound)
+
+		todos, err := service.GetTodos(ctx, ids)
+		require.NoError(t, err)
+		require.Equal(t, []*Todo{second, nil, first}, todos)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("uses one batch request", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(batchRepository{mockRepo})
+		mockRepo.On("GetMany", ctx, ids).Return([]*Todo{first, second}, nil).Once()
+
+		todos, err := service.GetTodos(ctx, ids)
+		require.NoError(t, err)
+		require.Equal(t, []*Todo{second, nil, first}, todos)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects malformed ids", func(t *testing.T) {
+		service, mockRepo := newTestService(t)
+
+		_, err := service.GetTodos(ctx, []string{first.ID.String(), "invalid-uuid"})
+		require.ErrorIs(t, err, ErrInvalidInput)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("propagates repository errors", func(t *testing.T) {
+		service, mockRepo := newTestService(t)
+		mockRepo.On("Get", ctx, first.ID.String()).Return(nil, errors.New("connection refused"))
+
+		_, err := service.GetTodos(ctx, []string{first.ID.String()})
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrNotFound)
+	})
+}
+
 func TestService_UpdateTodo(t *testing.T) {
 	ctx := context.Background()
 	validID := validUUID()
@@ -32,12 +32,15 @@ const (
 	SortFieldUpdateTime SortField = "updateTime"
 	SortFieldTitle      SortField = "title"
 	SortFieldStatus     SortField = "status"
+
+	// SortFieldRelevance sorts by search relevance score (only meaningful with a search query).
+	SortFieldRelevance SortField = "_score"
 )
 
 // IsValid checks if the sort field is one of the defined values.
 func (s SortField) IsValid() bool {
 	switch s {
-	case SortFieldCreateTime, SortFieldUpdateTime, SortFieldTitle, SortFieldStatus:
+	case SortFieldCreateTime, SortFieldUpdateTime, SortFieldTitle, SortFieldStatus, SortFieldRelevance:
 		return true
 	}
 	return false
@@ -55,5 +58,6 @@ func AllSortFields() []SortField {
 		SortFieldUpdateTime,
 		SortFieldTitle,
 		SortFieldStatus,
+		SortFieldRelevance,
 	}
 }
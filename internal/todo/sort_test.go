@@ -33,6 +33,7 @@ func TestSortField_IsValid(t *testing.T) {
 		{"valid updateTime", SortFieldUpdateTime, true},
 		{"valid title", SortFieldTitle, true},
 		{"valid status", SortFieldStatus, true},
+		{"valid relevance", SortFieldRelevance, true},
 		{"invalid empty", SortField(""), false},
 		{"invalid random", SortField("invalidField"), false},
 	}
@@ -49,8 +50,8 @@ func TestSortField_IsValid(t *testing.T) {
 func TestAllSortFields(t *testing.T) {
 	fields := AllSortFields()
 
-	if len(fields) != 4 {
-		t.Errorf("AllSortFields() returned %d fields, want 4", len(fields))
+	if len(fields) != 5 {
+		t.Errorf("AllSortFields() returned %d fields, want 5", len(fields))
 	}
 
 	// Verify all returned fields are valid
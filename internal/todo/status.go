@@ -26,6 +26,19 @@ func (s Status) String() string {
 	return string(s)
 }
 
+// IsTerminal returns true for statuses that end a todo's lifecycle (completed, cancelled).
+func (s Status) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusCancelled
+}
+
+// TerminalStatuses returns the statuses that end a todo's lifecycle.
+func TerminalStatuses() []Status {
+	return []Status{
+		StatusCompleted,
+		StatusCancelled,
+	}
+}
+
 // AllStatuses returns all valid status values.
 func AllStatuses() []Status {
 	return []Status{
@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TodoSuggestion is a todo offered as a completion for a prefix.
+type TodoSuggestion struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+}
+
+// Suggestions holds completions for a prefix.
+type Suggestions struct {
+	// Todos are todos whose title (or ID) starts with the prefix.
+	Todos []TodoSuggestion `json:"todos"`
+
+	// Labels are existing labels starting with the prefix.
+	Labels []string `json:"labels"`
+}
+
+// Suggester is implemented by repositories that can efficiently complete prefixes.
+// Repositories without it fall back to filtering a page of recent todos.
+type Suggester interface {
+	// Suggest returns up to limit todo and label completions for prefix.
+	Suggest(ctx context.Context, prefix string, limit int) (*Suggestions, error)
+}
+
+// suggestFromTodos builds suggestions by matching the prefix against titles,
+// title words, IDs and labels of the given todos, ignoring case.
+func suggestFromTodos(todos []*Todo, prefix string, limit int) *Suggestions {
+	prefix = strings.ToLower(prefix)
+	suggestions := &Suggestions{Todos: []TodoSuggestion{}, Labels: []string{}}
+
+	for _, t := range todos {
+		if len(suggestions.Todos) < limit && matchesPrefix(t, prefix) {
+			suggestions.Todos = append(suggestions.Todos, TodoSuggestion{ID: t.ID, Title: t.Title})
+		}
+		for _, label := range t.Labels {
+			if len(suggestions.Labels) < limit && strings.HasPrefix(strings.ToLower(label), prefix) && !slices.Contains(suggestions.Labels, label) {
+				suggestions.Labels = append(suggestions.Labels, label)
+			}
+		}
+	}
+
+	slices.Sort(suggestions.Labels)
+	return suggestions
+}
+
+func matchesPrefix(t *Todo, prefix string) bool {
+	if strings.HasPrefix(t.ID.String(), prefix) {
+		return true
+	}
+	for _, word := range strings.Fields(strings.ToLower(t.Title)) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestFromTodos(t *testing.T) {
+	login := &Todo{ID: uuid.MustParse("a1b2c3d4-0000-0000-0000-000000000001"), Title: "Fix Login page", Labels: []string{"bug", "frontend"}}
+	logout := &Todo{ID: uuid.MustParse("ffffffff-0000-0000-0000-000000000002"), Title: "Logout button", Labels: []string{"backend", "bug"}}
+	docs := &Todo{ID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Title: "Write docs", Labels: []string{"docs"}}
+	todos := []*Todo{login, logout, docs}
+
+	tests := []struct {
+		name       string
+		prefix     string
+		limit      int
+		wantTodos  []TodoSuggestion
+		wantLabels []string
+	}{
+		{
+			name:       "title word prefix ignores case",
+			prefix:     "log",
+			limit:      10,
+			wantTodos:  []TodoSuggestion{{ID: login.ID, Title: login.Title}, {ID: logout.ID, Title: logout.Title}},
+			wantLabels: []string{},
+		},
+		{
+			name:       "id prefix",
+			prefix:     "a1b2",
+			limit:      10,
+			wantTodos:  []TodoSuggestion{{ID: login.ID, Title: login.Title}},
+			wantLabels: []string{},
+		},
+		{
+			name:       "labels are deduplicated and sorted",
+			prefix:     "b",
+			limit:      10,
+			wantTodos:  []TodoSuggestion{{ID: logout.ID, Title: logout.Title}},
+			wantLabels: []string{"backend", "bug"},
+		},
+		{
+			name:       "limit applies to each kind",
+			prefix:     "",
+			limit:      1,
+			wantTodos:  []TodoSuggestion{{ID: login.ID, Title: login.Title}},
+			wantLabels: []string{"bug"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestFromTodos(todos, tt.prefix, tt.limit)
+			require.Equal(t, tt.wantTodos, got.Todos)
+			require.Equal(t, tt.wantLabels, got.Labels)
+		})
+	}
+}
+
+func TestService_Suggest_FallsBackToList(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t)
+	testTodo := newValidTodo(t)
+	repo.On("List", ctx, mock.MatchedBy(func(f ListFilter) bool {
+		return f.SortBy == SortFieldUpdateTime
+	})).Return([]*Todo{testTodo}, nil)
+
+	suggestions, err := service.Suggest(ctx, "tes", 0)
+	require.NoError(t, err)
+	require.Equal(t, []TodoSuggestion{{ID: testTodo.ID, Title: testTodo.Title}}, suggestions.Todos)
+	require.Equal(t, []string{"test"}, suggestions.Labels)
+	repo.AssertExpectations(t)
+}
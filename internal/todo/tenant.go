@@ -0,0 +1,40 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// tenantPattern restricts tenant names so they are safe to use in index
+// names, routing values and URLs.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateTenant checks that a tenant name is well formed: up to 63
+// lowercase letters, digits, hyphens and underscores, starting with a letter
+// or digit.
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return fmt.Errorf("%w: invalid tenant %q (use lowercase letters, digits, '-' and '_')", ErrInvalidInput, tenant)
+	}
+	return nil
+}
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context acting for a tenant. Repositories that
+// isolate tenants only see and change the todos of the context's tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant a context acts for. The tenant of the
+// principal takes precedence over one set with WithTenant, so an
+// authenticated caller cannot act for another tenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if p, ok := PrincipalFromContext(ctx); ok && p.Tenant != "" {
+		return p.Tenant, true
+	}
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
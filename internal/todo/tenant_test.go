@@ -0,0 +1,54 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTenant(t *testing.T) {
+	tests := []struct {
+		tenant  string
+		wantErr bool
+	}{
+		{"acme", false},
+		{"team-1", false},
+		{"team_a", false},
+		{"7seas", false},
+		{"", true},
+		{"Acme", true},
+		{"-acme", true},
+		{"acme/payments", true},
+		{"a.b", true},
+		{"a234567890123456789012345678901234567890123456789012345678901234", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			err := ValidateTenant(tt.tenant)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidInput)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTenantFromContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := TenantFromContext(ctx)
+	require.False(t, ok)
+
+	tenant, ok := TenantFromContext(WithTenant(ctx, "acme"))
+	require.True(t, ok)
+	require.Equal(t, "acme", tenant)
+
+	// The principal's tenant cannot be overridden
+	ctx = WithPrincipal(ctx, &Principal{Subject: "bob", Tenant: "globex"})
+	tenant, ok = TenantFromContext(WithTenant(ctx, "acme"))
+	require.True(t, ok)
+	require.Equal(t, "globex", tenant)
+}
@@ -17,6 +17,10 @@ type Todo struct {
 	Status      Status    `json:"status"`
 	CreateTime  time.Time `json:"createTime"`
 	UpdateTime  time.Time `json:"updateTime"`
+
+	// Fields holds values for user-defined fields, keyed by field name.
+	// Values are coerced to their FieldDefinition type before being stored.
+	Fields map[string]any `json:"fields,omitempty"`
 }
 
 // NewTodo creates a new Todo with validation.
@@ -39,9 +43,15 @@ func NewTodo(title string, description string, labels []string) (*Todo, error) {
 }
 
 type UpdateTodo struct {
-	Title       *string  `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
-	Description *string  `json:"description,omitempty" validate:"omitempty,max=1024"`
-	Labels      []string `json:"labels,omitempty" validate:"omitempty,min=1,max=10"`
+	Title       *string `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1024"`
+
+	// Labels replaces the labels. Nil leaves them unchanged, while an empty
+	// list removes them all.
+	Labels []string `json:"labels,omitempty" validate:"max=10"`
+
+	// Fields sets custom field values. A nil value removes the field from the todo.
+	Fields map[string]any `json:"fields,omitempty"`
 }
 
 func (u UpdateTodo) Validate() error {
@@ -66,6 +76,17 @@ func (t *Todo) Update(update UpdateTodo) error {
 		t.Labels = update.Labels
 	}
 
+	for name, value := range update.Fields {
+		if value == nil {
+			delete(t.Fields, name)
+			continue
+		}
+		if t.Fields == nil {
+			t.Fields = make(map[string]any)
+		}
+		t.Fields[name] = value
+	}
+
 	t.UpdateTime = time.Now()
 
 	return nil
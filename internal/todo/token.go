@@ -0,0 +1,245 @@
+package todo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TokenPrefix starts every personal access token, so they are easy to tell
+// apart from JWTs and to spot in leaked secrets.
+const TokenPrefix = "todoify_"
+
+// Token is a personal access token. Only a hash of the secret is stored;
+// the token itself is shown once, when it is created.
+type Token struct {
+	// ID is the public part of the token, used to list and revoke it.
+	ID string `json:"id"`
+
+	// Name describes what the token is for.
+	Name string `json:"name"`
+
+	// Subject is the principal the token authenticates as.
+	Subject string `json:"subject"`
+
+	Scopes []Scope `json:"scopes"`
+
+	// Tenant is the tenant the token acts for, if any.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Hash is the hex SHA-256 of the full token.
+	Hash string `json:"hash"`
+
+	CreateTime time.Time `json:"createTime"`
+
+	// ExpireTime is when the token stops working; zero means never.
+	ExpireTime time.Time `json:"expireTime,omitzero"`
+}
+
+// Expired reports whether the token has expired at now.
+func (t *Token) Expired(now time.Time) bool {
+	return !t.ExpireTime.IsZero() && !now.Before(t.ExpireTime)
+}
+
+// TokenRepository defines persistence for personal access tokens.
+// Backends that can store tokens implement it alongside Repository.
+type TokenRepository interface {
+	// PutToken stores a token.
+	PutToken(ctx context.Context, token *Token) error
+
+	// GetToken retrieves a token by ID.
+	// Returns ErrNotFound if the token doesn't exist.
+	GetToken(ctx context.Context, id string) (*Token, error)
+
+	// ListTokens returns all tokens ordered by creation time.
+	ListTokens(ctx context.Context) ([]*Token, error)
+
+	// DeleteToken removes a token by ID.
+	// Returns ErrNotFound if the token doesn't exist.
+	DeleteToken(ctx context.Context, id string) error
+}
+
+// newTokenSecret generates a token and its public ID.
+func newTokenSecret() (id, token string, err error) {
+	idBytes := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	id = hex.EncodeToString(idBytes)
+	return id, TokenPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashToken returns the stored hash of a token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseToken returns the ID of a well-formed token.
+func parseToken(token string) (string, bool) {
+	rest, ok := strings.CutPrefix(token, TokenPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// CreateToken creates a personal access token for subject with the given
+// scopes. A positive ttl makes it expire. The token acts for the tenant of
+// ctx, if any. The returned string is the token itself, which cannot be
+// recovered later.
+func (s *Service) CreateToken(ctx context.Context, name, subject string, scopes []Scope, ttl time.Duration) (*Token, string, error) {
+	if err := s.Authorize(ctx, ActionAdmin); err != nil {
+		return nil, "", err
+	}
+
+	if s.tokens == nil {
+		return nil, "", fmt.Errorf("%w: access tokens", ErrUnsupported)
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if strings.TrimSpace(subject) == "" {
+		return nil, "", fmt.Errorf("%w: subject is required", ErrInvalidInput)
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidInput)
+	}
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return nil, "", fmt.Errorf("%w: invalid scope %q (valid: read, write)", ErrInvalidInput, scope)
+		}
+	}
+	if ttl < 0 {
+		return nil, "", fmt.Errorf("%w: expiry must not be negative", ErrInvalidInput)
+	}
+
+	id, raw, err := newTokenSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	t := &Token{
+		ID:         id,
+		Name:       name,
+		Subject:    subject,
+		Scopes:     scopes,
+		Hash:       hashToken(raw),
+		CreateTime: now,
+	}
+	t.Tenant, _ = TenantFromContext(ctx)
+	if ttl > 0 {
+		t.ExpireTime = now.Add(ttl)
+	}
+
+	if err := s.tokens.PutToken(ctx, t); err != nil {
+		return nil, "", fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return t, raw, nil
+}
+
+// ListTokens returns the personal access tokens of the context's tenant.
+func (s *Service) ListTokens(ctx context.Context) ([]*Token, error) {
+	if err := s.Authorize(ctx, ActionAdmin); err != nil {
+		return nil, err
+	}
+
+	if s.tokens == nil {
+		return nil, fmt.Errorf("%w: access tokens", ErrUnsupported)
+	}
+
+	tokens, err := s.tokens.ListTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tokens, func(t *Token) bool { return !ownToken(ctx, t) }), nil
+}
+
+// ownToken reports whether a token belongs to the context's tenant. Tokens
+// of other tenants are treated as if they did not exist.
+func ownToken(ctx context.Context, t *Token) bool {
+	tenant, _ := TenantFromContext(ctx)
+	return t.Tenant == tenant
+}
+
+// RevokeToken deletes a personal access token of the context's tenant,
+// which stops working at once.
+func (s *Service) RevokeToken(ctx context.Context, id string) error {
+	if err := s.Authorize(ctx, ActionAdmin); err != nil {
+		return err
+	}
+
+	if s.tokens == nil {
+		return fmt.Errorf("%w: access tokens", ErrUnsupported)
+	}
+
+	if id == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidInput)
+	}
+
+	t, err := s.tokens.GetToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ownToken(ctx, t) {
+		return fmt.Errorf("%w: token %s", ErrNotFound, id)
+	}
+
+	return s.tokens.DeleteToken(ctx, id)
+}
+
+// AuthenticateToken returns the principal of a personal access token.
+// Unknown, revoked, expired and malformed tokens are ErrUnauthenticated.
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (*Principal, error) {
+	if s.tokens == nil {
+		return nil, fmt.Errorf("%w: access tokens", ErrUnsupported)
+	}
+
+	id, ok := parseToken(token)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed access token", ErrUnauthenticated)
+	}
+
+	t, err := s.tokens.GetToken(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown access token", ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashToken(token))) != 1 {
+		return nil, fmt.Errorf("%w: unknown access token", ErrUnauthenticated)
+	}
+	if t.Expired(time.Now()) {
+		return nil, fmt.Errorf("%w: access token expired", ErrUnauthenticated)
+	}
+
+	return &Principal{
+		Subject: t.Subject,
+		Scopes:  t.Scopes,
+		Method:  "token",
+		TokenID: t.ID,
+		Tenant:  t.Tenant,
+	}, nil
+}
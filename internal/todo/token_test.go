@@ -0,0 +1,174 @@
+package todo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRepository is a repository that keeps tokens in memory.
+type tokenRepository struct {
+	MockRepository
+	tokens map[string]*Token
+}
+
+func (r *tokenRepository) PutToken(ctx context.Context, token *Token) error {
+	if _, ok := r.tokens[token.ID]; ok {
+		return ErrConflict
+	}
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *tokenRepository) GetToken(ctx context.Context, id string) (*Token, error) {
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func (r *tokenRepository) ListTokens(ctx context.Context) ([]*Token, error) {
+	var out []*Token
+	for _, t := range r.tokens {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (r *tokenRepository) DeleteToken(ctx context.Context, id string) error {
+	if _, ok := r.tokens[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.tokens, id)
+	return nil
+}
+
+func newTokenService() (*Service, *tokenRepository) {
+	repo := &tokenRepository{tokens: make(map[string]*Token)}
+	return NewService(repo), repo
+}
+
+func TestService_CreateToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		tname   string
+		subject string
+		scopes  []Scope
+		ttl     time.Duration
+		wantErr error
+	}{
+		{"read", "dashboard", "alice", []Scope{ScopeRead}, 0, nil},
+		{"expiring", "ci", "bob", []Scope{ScopeRead, ScopeWrite}, time.Hour, nil},
+		{"missing name", " ", "alice", []Scope{ScopeRead}, 0, ErrInvalidInput},
+		{"missing subject", "ci", "", []Scope{ScopeRead}, 0, ErrInvalidInput},
+		{"no scopes", "ci", "alice", nil, 0, ErrInvalidInput},
+		{"unknown scope", "ci", "alice", []Scope{"admin"}, 0, ErrInvalidInput},
+		{"negative ttl", "ci", "alice", []Scope{ScopeRead}, -time.Hour, ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newTokenService()
+
+			created, raw, err := service.CreateToken(context.Background(), tt.tname, tt.subject, tt.scopes, tt.ttl)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Empty(t, repo.tokens)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, raw, TokenPrefix+created.ID+"_")
+			require.NotContains(t, created.Hash, raw)
+			require.Equal(t, hashToken(raw), repo.tokens[created.ID].Hash)
+			require.Equal(t, tt.ttl > 0, !created.ExpireTime.IsZero())
+		})
+	}
+}
+
+func TestService_AuthenticateToken(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTokenService()
+
+	valid, validRaw, err := service.CreateToken(ctx, "ci", "bob", []Scope{ScopeWrite}, 0)
+	require.NoError(t, err)
+	expired, expiredRaw, err := service.CreateToken(ctx, "old", "bob", []Scope{ScopeRead}, time.Hour)
+	require.NoError(t, err)
+	repo.tokens[expired.ID].ExpireTime = time.Now().Add(-time.Minute)
+	revoked, revokedRaw, err := service.CreateToken(ctx, "gone", "bob", []Scope{ScopeRead}, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.RevokeToken(ctx, revoked.ID))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", validRaw, nil},
+		{"expired", expiredRaw, ErrUnauthenticated},
+		{"revoked", revokedRaw, ErrUnauthenticated},
+		{"wrong secret", TokenPrefix + valid.ID + "_wrong", ErrUnauthenticated},
+		{"malformed", "todoify_", ErrUnauthenticated},
+		{"not a token", "hunter2", ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := service.AuthenticateToken(ctx, tt.token)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "bob", p.Subject)
+			require.Equal(t, valid.ID, p.TokenID)
+			require.True(t, p.HasScope(ScopeRead))
+			require.True(t, p.HasScope(ScopeWrite))
+		})
+	}
+}
+
+func TestService_TokenTenant(t *testing.T) {
+	ctx := WithTenant(context.Background(), "acme")
+	service, _ := newTokenService()
+
+	created, raw, err := service.CreateToken(ctx, "ci", "bob", []Scope{ScopeRead}, 0)
+	require.NoError(t, err)
+	require.Equal(t, "acme", created.Tenant)
+
+	p, err := service.AuthenticateToken(context.Background(), raw)
+	require.NoError(t, err)
+	require.Equal(t, "acme", p.Tenant)
+
+	other := WithTenant(context.Background(), "globex")
+	_, _, err = service.CreateToken(other, "ci", "carol", []Scope{ScopeRead}, 0)
+	require.NoError(t, err)
+
+	// Each tenant only sees and revokes its own tokens
+	tokens, err := service.ListTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, created.ID, tokens[0].ID)
+
+	err = service.RevokeToken(other, created.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = service.AuthenticateToken(context.Background(), raw)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeToken(ctx, created.ID))
+	tokens, err = service.ListTokens(other)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, "carol", tokens[0].Subject)
+}
+
+func TestService_TokensUnsupported(t *testing.T) {
+	service, _ := newTestService(t)
+
+	_, _, err := service.CreateToken(context.Background(), "ci", "bob", []Scope{ScopeRead}, 0)
+	require.ErrorIs(t, err, ErrUnsupported)
+	_, err = service.AuthenticateToken(context.Background(), "todoify_a_b")
+	require.ErrorIs(t, err, ErrUnsupported)
+}
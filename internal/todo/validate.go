@@ -1,7 +1,11 @@
 package todo
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -15,6 +19,10 @@ import (
 // We want to reuse the validator to allow caching of struct tags and avoid reflection whenever possible.
 var validate = validator.New(validator.WithRequiredStructEnabled())
 
+// fieldNamePattern restricts custom field names to lowercase identifiers so they are safe
+// to use as backend field paths and in --field/--where expressions.
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
 // trans is the universal translator for English locale.
 // It provides human-readable error messages for validation failures.
 var trans ut.Translator
@@ -35,6 +43,56 @@ func init() {
 	if err := en_translations.RegisterDefaultTranslations(validate, trans); err != nil {
 		panic(fmt.Sprintf("failed to register translations: %v", err))
 	}
+
+	// Register custom validations and their translations
+	if err := validate.RegisterValidation("fieldname", func(fl validator.FieldLevel) bool {
+		return fieldNamePattern.MatchString(fl.Field().String())
+	}); err != nil {
+		panic(fmt.Sprintf("failed to register fieldname validation: %v", err))
+	}
+
+	if err := validate.RegisterTranslation("fieldname", trans,
+		func(ut ut.Translator) error {
+			return ut.Add("fieldname", "{0} must start with a lowercase letter and contain only lowercase letters, digits and underscores", true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T("fieldname", fe.Field())
+			return t
+		},
+	); err != nil {
+		panic(fmt.Sprintf("failed to register fieldname translation: %v", err))
+	}
+}
+
+// FieldErrors maps field names to human-readable validation messages.
+// It is returned when values that are not part of a struct (e.g. custom fields) fail validation.
+type FieldErrors map[string]string
+
+// Error implements the error interface.
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, msg := range e {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	return strings.Join(msgs, "; ")
+}
+
+// validateVar validates a single value against a validator tag and returns the
+// translated message for the first failure, or an empty string if it is valid.
+func validateVar(name string, value any, tag string) string {
+	err := validate.Var(value, tag)
+	if err == nil {
+		return ""
+	}
+
+	var validatorErrs validator.ValidationErrors
+	if !errors.As(err, &validatorErrs) || len(validatorErrs) == 0 {
+		return fmt.Sprintf("%s %v", name, err)
+	}
+
+	// Var validation has no field name, so the translation starts with the verb
+	return name + " " + strings.TrimSpace(validatorErrs[0].Translate(trans))
 }
 
 // TranslateError converts validator errors into human-readable messages.
@@ -44,8 +102,13 @@ func TranslateError(err error) map[string]string {
 		return nil
 	}
 
-	validatorErrs, ok := err.(validator.ValidationErrors)
-	if !ok {
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		return fieldErrs
+	}
+
+	var validatorErrs validator.ValidationErrors
+	if !errors.As(err, &validatorErrs) {
 		return map[string]string{"error": err.Error()}
 	}
 
@@ -56,3 +119,13 @@ func TranslateError(err error) map[string]string {
 
 	return errs
 }
+
+// FieldMessages returns the per-field validation messages of an error, or nil
+// when it does not carry any beyond its own message.
+func FieldMessages(err error) map[string]string {
+	errs := TranslateError(err)
+	if _, generic := errs["error"]; generic && len(errs) == 1 {
+		return nil
+	}
+	return errs
+}
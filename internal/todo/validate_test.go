@@ -1,9 +1,11 @@
 package todo
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTranslateError(t *testing.T) {
@@ -69,6 +71,18 @@ func TestTranslateError(t *testing.T) {
 	}
 }
 
+func TestFieldMessages(t *testing.T) {
+	// Validation errors map to their fields, also when wrapped
+	err := fmt.Errorf("%w: %w", ErrInvalidInput, UpdateTodo{Title: strPtr("")}.Validate())
+	require.Equal(t, map[string]string{"Title": "Title must be at least 1 character in length"}, FieldMessages(err))
+
+	require.Equal(t, map[string]string{"points": "points must be a number"}, FieldMessages(FieldErrors{"points": "points must be a number"}))
+
+	// Other errors carry nothing beyond their own message
+	require.Nil(t, FieldMessages(fmt.Errorf("%w: bad cursor", ErrInvalidInput)))
+	require.Nil(t, FieldMessages(nil))
+}
+
 func strPtr(s string) *string {
 	return &s
 }
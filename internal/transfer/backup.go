@@ -0,0 +1,219 @@
+// Package transfer moves todos between repositories: backups to and from
+// archives, and copies from one backend to another. It works on any
+// todo.Repository and uses optional capabilities (bulk writes, field
+// registries, index schemas) when the backend provides them.
+package transfer
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// ArchiveFormat identifies todoify backup archives.
+const ArchiveFormat = "todoify-backup"
+
+// ArchiveVersion is the version of the archive layout written by Backup.
+// Restore accepts archives up to this version.
+const ArchiveVersion = 1
+
+// Archive entries, in the order they are written.
+const (
+	manifestEntry = "manifest.json"
+	fieldsEntry   = "fields.json"
+	mappingsEntry = "mappings.json"
+	settingsEntry = "settings.json"
+	todosEntry    = "todos.ndjson"
+)
+
+// pageSize is the number of todos read or written per request.
+const pageSize = 500
+
+// Manifest describes the contents of a backup archive.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Todos is the number of todos in todos.ndjson.
+	Todos int `json:"todos"`
+
+	// Fields is the number of custom field definitions in fields.json.
+	Fields int `json:"fields"`
+
+	// Schema is true when the archive holds the source index mappings and settings.
+	Schema bool `json:"schema"`
+}
+
+// SchemaExporter is implemented by repositories backed by an index with a
+// mapping and settings worth keeping in a backup.
+type SchemaExporter interface {
+	ExportSchema(ctx context.Context) (mappings, settings json.RawMessage, err error)
+}
+
+// Progress reports how many of the total todos have been processed.
+type Progress func(done, total int)
+
+// Backup writes every todo, the custom field definitions and, when the backend
+// has one, the index schema to w as a gzip-compressed tar archive.
+func Backup(ctx context.Context, repo todo.Repository, w io.Writer, progress Progress) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:    ArchiveFormat,
+		Version:   ArchiveVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	total, err := repo.Count(ctx, todo.ListFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// The manifest comes first so restores can check it before reading the
+	// rest, but it needs the final count, so todos are spooled to disk first.
+	spool, err := os.CreateTemp("", "todoify-backup-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	buf := bufio.NewWriter(spool)
+	enc := json.NewEncoder(buf)
+	err = eachPage(ctx, repo, todo.ListFilter{}, func(todos []*todo.Todo) error {
+		for _, t := range todos {
+			if err := enc.Encode(t); err != nil {
+				return fmt.Errorf("failed to write temporary file: %w", err)
+			}
+		}
+		manifest.Todos += len(todos)
+		if progress != nil {
+			progress(manifest.Todos, total)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	fr, hasFields := repo.(todo.FieldRepository)
+	var fields []*todo.FieldDefinition
+	if hasFields {
+		fields, err = fr.ListFields(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list fields: %w", err)
+		}
+		manifest.Fields = len(fields)
+	}
+
+	var mappings, settings json.RawMessage
+	if exporter, ok := repo.(SchemaExporter); ok {
+		mappings, settings, err = exporter.ExportSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export schema: %w", err)
+		}
+		manifest.Schema = true
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeJSONEntry(tw, manifestEntry, manifest, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	if hasFields {
+		if fields == nil {
+			fields = []*todo.FieldDefinition{}
+		}
+		if err := writeJSONEntry(tw, fieldsEntry, fields, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if manifest.Schema {
+		if err := writeJSONEntry(tw, mappingsEntry, mappings, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := writeJSONEntry(tw, settingsEntry, settings, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	if err := tw.WriteHeader(entryHeader(todosEntry, size, manifest.CreatedAt)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", todosEntry, err)
+	}
+	if _, err := io.Copy(tw, spool); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", todosEntry, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// eachPage calls fn for every page of todos matching the filter, oldest first.
+func eachPage(ctx context.Context, repo todo.Repository, filter todo.ListFilter, fn func(todos []*todo.Todo) error) error {
+	service := todo.NewService(repo)
+	filter.Limit = pageSize
+	filter.SortBy = todo.SortFieldCreateTime
+	filter.SortOrder = todo.SortOrderAsc
+
+	for {
+		page, err := service.ListTodosPage(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list todos: %w", err)
+		}
+		if err := fn(page.Todos); err != nil {
+			return err
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func writeJSONEntry(tw *tar.Writer, name string, v any, modTime time.Time) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	if err := tw.WriteHeader(entryHeader(name, int64(len(data)), modTime)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func entryHeader(name string, size int64, modTime time.Time) *tar.Header {
+	return &tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    size,
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	}
+}
@@ -0,0 +1,138 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileRepository(t *testing.T) *filerepo.Repository {
+	t.Helper()
+	return filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json"))
+}
+
+// seed stores n todos with distinct, ordered creation times.
+func seed(t *testing.T, repo todo.Repository, n int) []*todo.Todo {
+	t.Helper()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var todos []*todo.Todo
+	for i := range n {
+		td, err := todo.NewTodo("todo", "", []string{"work"})
+		require.NoError(t, err)
+		td.CreateTime = base.Add(time.Duration(i) * time.Minute)
+		td.UpdateTime = td.CreateTime
+		require.NoError(t, repo.Create(context.Background(), td))
+		todos = append(todos, td)
+	}
+	return todos
+}
+
+// plainRepository hides the optional capabilities of the file repository so
+// the per-todo fallback is exercised.
+type plainRepository struct {
+	todo.Repository
+}
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newFileRepository(t)
+	todos := seed(t, source, pageSize+3)
+	require.NoError(t, source.PutField(ctx, &todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber}))
+
+	var archive bytes.Buffer
+	manifest, err := Backup(ctx, source, &archive, nil)
+	require.NoError(t, err)
+	require.Equal(t, pageSize+3, manifest.Todos)
+	require.Equal(t, 1, manifest.Fields)
+	require.False(t, manifest.Schema)
+
+	read, err := ReadManifest(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, manifest.Todos, read.Todos)
+
+	target := newFileRepository(t)
+	var done int
+	_, result, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), ConflictFail, func(d, total int) { done = d })
+	require.NoError(t, err)
+	require.Equal(t, &Result{Written: pageSize + 3, Fields: 1}, result)
+	require.Equal(t, pageSize+3, done)
+
+	got, err := target.Get(ctx, todos[10].ID.String())
+	require.NoError(t, err)
+	require.True(t, todos[10].CreateTime.Equal(got.CreateTime))
+	require.True(t, todos[10].UpdateTime.Equal(got.UpdateTime))
+}
+
+func TestRestore_ConflictPolicies(t *testing.T) {
+	ctx := context.Background()
+	source := newFileRepository(t)
+	todos := seed(t, source, 3)
+
+	var archive bytes.Buffer
+	_, err := Backup(ctx, source, &archive, nil)
+	require.NoError(t, err)
+
+	for _, wrap := range []struct {
+		name string
+		repo func(*filerepo.Repository) todo.Repository
+	}{
+		{"bulk", func(r *filerepo.Repository) todo.Repository { return r }},
+		{"per todo", func(r *filerepo.Repository) todo.Repository { return plainRepository{r} }},
+	} {
+		t.Run(wrap.name, func(t *testing.T) {
+			newTarget := func(t *testing.T) (*filerepo.Repository, todo.Repository) {
+				r := newFileRepository(t)
+				existing := *todos[0]
+				existing.Title = "changed"
+				require.NoError(t, r.Create(ctx, &existing))
+				return r, wrap.repo(r)
+			}
+
+			t.Run("skip", func(t *testing.T) {
+				r, target := newTarget(t)
+				_, result, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), ConflictSkip, nil)
+				require.NoError(t, err)
+				require.Equal(t, 2, result.Written)
+				require.Equal(t, 1, result.Skipped)
+				got, err := r.Get(ctx, todos[0].ID.String())
+				require.NoError(t, err)
+				require.Equal(t, "changed", got.Title)
+			})
+
+			t.Run("overwrite", func(t *testing.T) {
+				r, target := newTarget(t)
+				_, result, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), ConflictOverwrite, nil)
+				require.NoError(t, err)
+				require.Equal(t, 3, result.Written)
+				got, err := r.Get(ctx, todos[0].ID.String())
+				require.NoError(t, err)
+				require.Equal(t, "todo", got.Title)
+			})
+
+			t.Run("fail", func(t *testing.T) {
+				r, target := newTarget(t)
+				_, _, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), ConflictFail, nil)
+				require.ErrorIs(t, err, todo.ErrConflict)
+				count, err := r.Count(ctx, todo.ListFilter{})
+				require.NoError(t, err)
+				require.Equal(t, 1, count, "nothing from the conflicting batch is written")
+			})
+		})
+	}
+}
+
+func TestRestore_RejectsInvalidArchives(t *testing.T) {
+	ctx := context.Background()
+
+	_, _, err := Restore(ctx, newFileRepository(t), bytes.NewReader([]byte("not an archive")), ConflictSkip, nil)
+	require.ErrorContains(t, err, "not a backup archive")
+
+	_, _, err = Restore(ctx, newFileRepository(t), bytes.NewReader(nil), ConflictPolicy("merge"), nil)
+	require.ErrorIs(t, err, todo.ErrInvalidInput)
+}
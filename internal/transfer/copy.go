@@ -0,0 +1,304 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"golang.org/x/time/rate"
+)
+
+// CopyOptions configures Copy.
+type CopyOptions struct {
+	// Policy decides what happens to todos that already exist in the target.
+	Policy ConflictPolicy
+
+	// BatchSize is the number of todos written per request and checkpoint.
+	// Zero means 500.
+	BatchSize int
+
+	// Rate limits writes to the target in todos per second. Zero means unlimited.
+	Rate float64
+
+	// Checkpoint is a file recording progress after every batch. When it exists,
+	// the copy resumes after the last todo it records; it is removed once the
+	// copy completes. Empty disables checkpoints.
+	Checkpoint string
+
+	// From and To describe the source and target. They are stored in the
+	// checkpoint so it is not resumed against different backends.
+	From string
+	To   string
+
+	// Progress is called after every batch.
+	Progress Progress
+}
+
+// Checkpoint records how far a copy got. Todos are copied oldest first, so a
+// copy resumes with the todos created at or after After, skipping the IDs
+// already copied at exactly that time.
+type Checkpoint struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	After time.Time `json:"after"`
+	IDs   []string  `json:"ids"`
+	Done  int       `json:"done"`
+
+	Result Result `json:"result"`
+}
+
+// LoadCheckpoint reads a checkpoint file. It returns nil when the file does not exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// save writes the checkpoint atomically.
+func (cp *Checkpoint) save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// copied reports whether the checkpoint covers the todo.
+func (cp *Checkpoint) copied(t *todo.Todo) bool {
+	if cp.After.IsZero() {
+		return false
+	}
+	if t.CreateTime.Before(cp.After) {
+		return true
+	}
+	return t.CreateTime.Equal(cp.After) && slices.Contains(cp.IDs, t.ID.String())
+}
+
+// advance records the todos of a written batch.
+func (cp *Checkpoint) advance(batch []*todo.Todo) {
+	for _, t := range batch {
+		if t.CreateTime.After(cp.After) {
+			cp.After = t.CreateTime
+			cp.IDs = cp.IDs[:0]
+		}
+		if t.CreateTime.Equal(cp.After) {
+			cp.IDs = append(cp.IDs, t.ID.String())
+		}
+	}
+	cp.Done += len(batch)
+}
+
+// Copy streams every todo and field definition from src to dst, keeping IDs
+// and timestamps. The target's storage is created first when the backend
+// needs it. Writes are batched, rate limited and checkpointed as configured.
+func Copy(ctx context.Context, src, dst todo.Repository, opts CopyOptions) (*Result, error) {
+	if !opts.Policy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown conflict policy %q", todo.ErrInvalidInput, opts.Policy)
+	}
+	if opts.Rate < 0 {
+		return nil, fmt.Errorf("%w: rate must not be negative", todo.ErrInvalidInput)
+	}
+	if opts.BatchSize < 0 {
+		return nil, fmt.Errorf("%w: batch size must not be negative", todo.ErrInvalidInput)
+	}
+
+	cp := &Checkpoint{From: opts.From, To: opts.To}
+	if opts.Checkpoint != "" {
+		saved, err := LoadCheckpoint(opts.Checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			if saved.From != opts.From || saved.To != opts.To {
+				return nil, fmt.Errorf("checkpoint %s belongs to a copy from %s to %s, remove it to start over", opts.Checkpoint, saved.From, saved.To)
+			}
+			cp = saved
+		}
+	}
+
+	total, err := src.Count(ctx, todo.ListFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	if creator, ok := dst.(IndexCreator); ok {
+		if err := creator.CreateIndices(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create indices: %w", err)
+		}
+	}
+
+	result := &cp.Result
+	w := newWriter(dst, opts.Policy, result)
+
+	// Field definitions are small and idempotent to copy, so they are not checkpointed
+	if fr, ok := src.(todo.FieldRepository); ok && cp.Done == 0 {
+		defs, err := fr.ListFields(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list fields: %w", err)
+		}
+		if err := w.putFields(ctx, defs); err != nil {
+			return nil, err
+		}
+	}
+
+	chunkSize := pageSize
+	if opts.BatchSize > 0 {
+		chunkSize = opts.BatchSize
+	}
+
+	// Write at most one second's worth of todos at a time so the rate is kept
+	// from the start rather than after a full first batch
+	var limiter *rate.Limiter
+	if opts.Rate > 0 {
+		chunkSize = max(1, min(chunkSize, int(opts.Rate)))
+		limiter = rate.NewLimiter(rate.Limit(opts.Rate), chunkSize)
+	}
+
+	filter := todo.ListFilter{}
+	if !cp.After.IsZero() {
+		filter.FromDate = &cp.After
+	}
+
+	err = eachPage(ctx, src, filter, func(todos []*todo.Todo) error {
+		todos = slices.DeleteFunc(todos, cp.copied)
+		for chunk := range slices.Chunk(todos, chunkSize) {
+			if limiter != nil {
+				if err := limiter.WaitN(ctx, len(chunk)); err != nil {
+					return err
+				}
+			}
+			if err := w.put(ctx, chunk); err != nil {
+				return err
+			}
+
+			cp.advance(chunk)
+			if opts.Checkpoint != "" {
+				if err := cp.save(opts.Checkpoint); err != nil {
+					return err
+				}
+			}
+			if opts.Progress != nil {
+				opts.Progress(cp.Done, total)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if opts.Checkpoint != "" {
+		if err := os.Remove(opts.Checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return result, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Verification compares the todos of a source with a target.
+type Verification struct {
+	// SourceCount and TargetCount are the number of todos in each repository.
+	// The target may hold more todos than the source if it was not empty.
+	SourceCount int `json:"sourceCount"`
+	TargetCount int `json:"targetCount"`
+
+	// Checked is the number of source todos looked up in the target.
+	Checked int `json:"checked"`
+
+	// Missing are IDs of source todos absent from the target.
+	Missing []string `json:"missing,omitempty"`
+
+	// Mismatched are IDs of todos whose checksum differs between source and target.
+	Mismatched []string `json:"mismatched,omitempty"`
+}
+
+// OK returns true when every source todo exists unchanged in the target.
+func (v *Verification) OK() bool {
+	return len(v.Missing) == 0 && len(v.Mismatched) == 0 && v.TargetCount >= v.SourceCount
+}
+
+// Verify looks up every source todo in the target and compares their checksums.
+func Verify(ctx context.Context, src, dst todo.Repository, progress Progress) (*Verification, error) {
+	var v Verification
+	var err error
+	if v.SourceCount, err = src.Count(ctx, todo.ListFilter{}); err != nil {
+		return nil, fmt.Errorf("failed to count source todos: %w", err)
+	}
+	if v.TargetCount, err = dst.Count(ctx, todo.ListFilter{}); err != nil {
+		return nil, fmt.Errorf("failed to count target todos: %w", err)
+	}
+
+	err = eachPage(ctx, src, todo.ListFilter{}, func(todos []*todo.Todo) error {
+		for _, t := range todos {
+			got, err := dst.Get(ctx, t.ID.String())
+			switch {
+			case errors.Is(err, todo.ErrNotFound):
+				v.Missing = append(v.Missing, t.ID.String())
+			case err != nil:
+				return fmt.Errorf("failed to get todo %s: %w", t.ID, err)
+			case Checksum(got) != Checksum(t):
+				v.Mismatched = append(v.Mismatched, t.ID.String())
+			}
+			v.Checked++
+		}
+		if progress != nil {
+			progress(v.Checked, v.SourceCount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// Checksum returns a SHA-256 digest of the todo's content. Timestamps are
+// compared in UTC so backends that store them in another zone still match.
+func Checksum(t *todo.Todo) string {
+	normalized := *t
+	normalized.CreateTime = t.CreateTime.UTC()
+	normalized.UpdateTime = t.UpdateTime.UTC()
+
+	// Map keys are sorted by encoding/json, so equal todos encode equally
+	data, _ := json.Marshal(&normalized)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
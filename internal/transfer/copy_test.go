@@ -0,0 +1,129 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRepository fails every write after the first n todos.
+type failingRepository struct {
+	todo.Repository
+	n int
+}
+
+func (r *failingRepository) Create(ctx context.Context, t *todo.Todo) error {
+	if r.n == 0 {
+		return errors.New("connection reset")
+	}
+	r.n--
+	return r.Repository.Create(ctx, t)
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	src := newFileRepository(t)
+	seed(t, src, 7)
+	require.NoError(t, src.PutField(ctx, &todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber}))
+	dst := newFileRepository(t)
+
+	result, err := Copy(ctx, src, dst, CopyOptions{Policy: ConflictSkip})
+	require.NoError(t, err)
+	require.Equal(t, &Result{Written: 7, Fields: 1}, result)
+
+	v, err := Verify(ctx, src, dst, nil)
+	require.NoError(t, err)
+	require.True(t, v.OK(), "%+v", v)
+	require.Equal(t, 7, v.Checked)
+}
+
+func TestCopy_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	src := newFileRepository(t)
+	todos := seed(t, src, 5)
+	// Todos created at the same time are told apart by ID
+	todos[3].CreateTime = todos[2].CreateTime
+	require.NoError(t, src.Update(ctx, todos[3]))
+
+	dst := newFileRepository(t)
+	checkpoint := filepath.Join(t.TempDir(), "copy.checkpoint")
+	opts := CopyOptions{Policy: ConflictFail, BatchSize: 1, Checkpoint: checkpoint, From: "a", To: "b"}
+
+	_, err := Copy(ctx, src, &failingRepository{Repository: plainRepository{dst}, n: 3}, opts)
+	require.Error(t, err)
+
+	cp, err := LoadCheckpoint(checkpoint)
+	require.NoError(t, err)
+	require.Equal(t, 3, cp.Done)
+
+	_, err = Copy(ctx, src, dst, CopyOptions{Policy: ConflictFail, Checkpoint: checkpoint, From: "a", To: "other"})
+	require.ErrorContains(t, err, "belongs to a copy from a to b")
+
+	result, err := Copy(ctx, src, dst, opts)
+	require.NoError(t, err)
+	require.Equal(t, 5, result.Written)
+	require.NoFileExists(t, checkpoint)
+
+	v, err := Verify(ctx, src, dst, nil)
+	require.NoError(t, err)
+	require.True(t, v.OK(), "%+v", v)
+}
+
+func TestCopy_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	src := newFileRepository(t)
+	seed(t, src, 4)
+
+	start := time.Now()
+	_, err := Copy(ctx, src, newFileRepository(t), CopyOptions{Policy: ConflictSkip, Rate: 20})
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Second, "a full bucket allows the first 20 todos at once")
+
+	start = time.Now()
+	_, err = Copy(ctx, src, newFileRepository(t), CopyOptions{Policy: ConflictSkip, Rate: 10})
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), time.Second)
+
+	start = time.Now()
+	_, err = Copy(ctx, src, newFileRepository(t), CopyOptions{Policy: ConflictSkip, Rate: 2})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond, "4 todos at 2 per second after a burst of 2")
+}
+
+func TestVerify_ReportsDifferences(t *testing.T) {
+	ctx := context.Background()
+	src := newFileRepository(t)
+	todos := seed(t, src, 3)
+	dst := newFileRepository(t)
+	_, err := Copy(ctx, src, dst, CopyOptions{Policy: ConflictSkip})
+	require.NoError(t, err)
+
+	changed := *todos[0]
+	changed.Title = "changed"
+	require.NoError(t, dst.Update(ctx, &changed))
+	require.NoError(t, dst.Delete(ctx, todos[1].ID.String()))
+
+	v, err := Verify(ctx, src, dst, nil)
+	require.NoError(t, err)
+	require.False(t, v.OK())
+	require.Equal(t, []string{todos[1].ID.String()}, v.Missing)
+	require.Equal(t, []string{todos[0].ID.String()}, v.Mismatched)
+	require.Equal(t, 3, v.SourceCount)
+	require.Equal(t, 2, v.TargetCount)
+}
+
+func TestChecksum_IgnoresTimeZone(t *testing.T) {
+	td, err := todo.NewTodo("title", "", nil)
+	require.NoError(t, err)
+	moved := *td
+	moved.CreateTime = td.CreateTime.In(time.FixedZone("X", 3600))
+
+	require.Equal(t, Checksum(td), Checksum(&moved))
+	moved.Title = "other"
+	require.NotEqual(t, Checksum(td), Checksum(&moved))
+}
@@ -0,0 +1,285 @@
+package transfer
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// ConflictPolicy decides what happens when a restored or copied todo already
+// exists in the target.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing todo.
+	ConflictSkip ConflictPolicy = "skip"
+
+	// ConflictOverwrite replaces the existing todo.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+
+	// ConflictFail stops at the first existing todo.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ConflictPolicies lists the valid conflict policies.
+var ConflictPolicies = []ConflictPolicy{ConflictSkip, ConflictOverwrite, ConflictFail}
+
+// IsValid checks if the policy is one of the defined values.
+func (p ConflictPolicy) IsValid() bool {
+	return slices.Contains(ConflictPolicies, p)
+}
+
+// IndexCreator is implemented by repositories that need their storage created
+// or migrated before todos can be written.
+type IndexCreator interface {
+	CreateIndices(ctx context.Context) error
+}
+
+// Result summarizes a restore or copy.
+type Result struct {
+	// Written is the number of todos created or overwritten in the target.
+	Written int `json:"written"`
+
+	// Skipped is the number of todos that already existed and were kept.
+	Skipped int `json:"skipped"`
+
+	// Fields is the number of custom field definitions written.
+	Fields int `json:"fields"`
+}
+
+// Restore reads an archive written by Backup and loads it into repo. The
+// target's storage is created first when the backend needs it. The archive's
+// index schema is informational: indices are recreated from the mapping
+// embedded in this version of todoify and the configured settings.
+//
+// With ConflictFail, todos are checked a batch at a time before the batch is
+// written, so batches before the conflicting one are kept.
+func Restore(ctx context.Context, repo todo.Repository, r io.Reader, policy ConflictPolicy, progress Progress) (*Manifest, *Result, error) {
+	if !policy.IsValid() {
+		return nil, nil, fmt.Errorf("%w: unknown conflict policy %q", todo.ErrInvalidInput, policy)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if creator, ok := repo.(IndexCreator); ok {
+		if err := creator.CreateIndices(ctx); err != nil {
+			return manifest, nil, fmt.Errorf("failed to create indices: %w", err)
+		}
+	}
+
+	result := &Result{}
+	w := newWriter(repo, policy, result)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return manifest, result, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		switch hdr.Name {
+		case fieldsEntry:
+			var defs []*todo.FieldDefinition
+			if err := json.NewDecoder(tr).Decode(&defs); err != nil {
+				return manifest, result, fmt.Errorf("failed to decode %s: %w", fieldsEntry, err)
+			}
+			if err := w.putFields(ctx, defs); err != nil {
+				return manifest, result, err
+			}
+
+		case todosEntry:
+			done := 0
+			err := decodeTodos(tr, func(batch []*todo.Todo) error {
+				if err := w.put(ctx, batch); err != nil {
+					return err
+				}
+				done += len(batch)
+				if progress != nil {
+					progress(done, manifest.Todos)
+				}
+				return nil
+			})
+			if err != nil {
+				return manifest, result, err
+			}
+		}
+	}
+
+	return manifest, result, nil
+}
+
+// ReadManifest returns the manifest of an archive without restoring it.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	return readManifest(tar.NewReader(gz))
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestEntry {
+		return nil, fmt.Errorf("not a backup archive: %s must be the first entry", manifestEntry)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", manifestEntry, err)
+	}
+	if manifest.Format != ArchiveFormat {
+		return nil, fmt.Errorf("not a backup archive: unknown format %q", manifest.Format)
+	}
+	if manifest.Version > ArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than supported version %d, upgrade todoify", manifest.Version, ArchiveVersion)
+	}
+
+	return &manifest, nil
+}
+
+// decodeTodos reads newline-delimited todos and passes them on in batches.
+func decodeTodos(r io.Reader, fn func(batch []*todo.Todo) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	batch := make([]*todo.Todo, 0, pageSize)
+	for {
+		var t todo.Todo
+		err := dec.Decode(&t)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", todosEntry, err)
+		}
+
+		batch = append(batch, &t)
+		if len(batch) == pageSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]*todo.Todo, 0, pageSize)
+		}
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// writer writes todos and field definitions to a repository under a conflict policy.
+type writer struct {
+	repo   todo.Repository
+	policy ConflictPolicy
+	result *Result
+}
+
+func newWriter(repo todo.Repository, policy ConflictPolicy, result *Result) *writer {
+	return &writer{repo: repo, policy: policy, result: result}
+}
+
+// put writes a batch of todos, in bulk when the backend supports it.
+func (w *writer) put(ctx context.Context, batch []*todo.Todo) error {
+	if w.policy == ConflictFail {
+		if err := w.checkConflicts(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	if bulk, ok := w.repo.(todo.BulkWriter); ok {
+		conflicts, err := bulk.PutMany(ctx, batch, w.policy == ConflictOverwrite)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 && w.policy == ConflictFail {
+			return fmt.Errorf("%w: %s", todo.ErrConflict, conflicts[0])
+		}
+		w.result.Written += len(batch) - len(conflicts)
+		w.result.Skipped += len(conflicts)
+		return nil
+	}
+
+	for _, t := range batch {
+		_, err := w.repo.Get(ctx, t.ID.String())
+		switch {
+		case errors.Is(err, todo.ErrNotFound):
+			if err := w.repo.Create(ctx, t); err != nil {
+				return err
+			}
+			w.result.Written++
+		case err != nil:
+			return fmt.Errorf("failed to get todo %s: %w", t.ID, err)
+		case w.policy == ConflictOverwrite:
+			if err := w.repo.Update(ctx, t); err != nil {
+				return err
+			}
+			w.result.Written++
+		default:
+			w.result.Skipped++
+		}
+	}
+	return nil
+}
+
+// checkConflicts returns ErrConflict for the first todo in batch that already exists.
+func (w *writer) checkConflicts(ctx context.Context, batch []*todo.Todo) error {
+	for _, t := range batch {
+		_, err := w.repo.Get(ctx, t.ID.String())
+		if err == nil {
+			return fmt.Errorf("%w: %s", todo.ErrConflict, t.ID)
+		}
+		if !errors.Is(err, todo.ErrNotFound) {
+			return fmt.Errorf("failed to get todo %s: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// putFields writes field definitions under the conflict policy. Backends
+// without a field registry drop them, since their todos cannot use fields.
+func (w *writer) putFields(ctx context.Context, defs []*todo.FieldDefinition) error {
+	fr, ok := w.repo.(todo.FieldRepository)
+	if !ok || len(defs) == 0 {
+		return nil
+	}
+
+	existing, err := fr.ListFields(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list fields: %w", err)
+	}
+
+	for _, def := range defs {
+		exists := slices.ContainsFunc(existing, func(d *todo.FieldDefinition) bool { return d.Name == def.Name })
+		switch {
+		case exists && w.policy == ConflictSkip:
+			continue
+		case exists && w.policy == ConflictFail:
+			return fmt.Errorf("%w: field %s already exists", todo.ErrConflict, def.Name)
+		}
+		if err := fr.PutField(ctx, def); err != nil {
+			return fmt.Errorf("failed to put field %s: %w", def.Name, err)
+		}
+		w.result.Fields++
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/google/uuid"
+)
+
+// Defaults for a Dispatcher.
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBackoff      = 10 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultTimeout      = 10 * time.Second
+	DefaultPollInterval = 5 * time.Second
+)
+
+// userAgent identifies deliveries to receivers.
+const userAgent = "todoify-webhook"
+
+// Dispatcher queues the events of a todo.Service for the matching webhooks
+// and delivers them. It implements todo.Publisher.
+type Dispatcher struct {
+	store        *Store
+	client       *http.Client
+	logger       *slog.Logger
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	wake         chan struct{}
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithHTTPClient sets the client used for deliveries. The default times
+// requests out after DefaultTimeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithLogger sets the logger for delivery failures.
+func WithLogger(l *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = l
+	}
+}
+
+// WithMaxAttempts sets how many times a delivery is attempted before it is
+// dead-lettered.
+func WithMaxAttempts(n int) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = max(n, 1)
+	}
+}
+
+// WithBackoff sets the delay before the first retry, which doubles with
+// every failed attempt up to maxDelay.
+func WithBackoff(base, maxDelay time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.backoff = base
+		d.maxBackoff = max(maxDelay, base)
+	}
+}
+
+// WithPollInterval sets how often Run checks the store for deliveries queued
+// by other processes.
+func WithPollInterval(interval time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.pollInterval = interval
+	}
+}
+
+// NewDispatcher creates a Dispatcher for the webhooks in store.
+func NewDispatcher(store *Store, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:        store,
+		client:       &http.Client{Timeout: DefaultTimeout},
+		logger:       slog.New(slog.DiscardHandler),
+		maxAttempts:  DefaultMaxAttempts,
+		backoff:      DefaultBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		pollInterval: DefaultPollInterval,
+		wake:         make(chan struct{}, 1),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Publish queues a delivery of the event for every matching webhook. The
+// deliveries are stored before Publish returns, so they survive the process
+// exiting; they are sent by Run or DeliverDue.
+func (d *Dispatcher) Publish(ctx context.Context, event todo.Event) {
+	if err := d.Enqueue(ctx, event); err != nil {
+		d.logger.Error("failed to queue webhook deliveries", "event", event.Type, "error", err)
+	}
+}
+
+// Enqueue is Publish, reporting failures to store the deliveries.
+func (d *Dispatcher) Enqueue(ctx context.Context, event todo.Event) error {
+	// Matches leaves out the webhooks of other tenants
+	webhooks, err := d.store.webhooks()
+	if err != nil {
+		return err
+	}
+
+	now := d.now().UTC()
+	var deliveries []*Delivery
+	for _, w := range webhooks {
+		if !w.Matches(event) {
+			continue
+		}
+		deliveries = append(deliveries, &Delivery{
+			ID:          uuid.NewString(),
+			WebhookID:   w.ID,
+			Event:       event,
+			Status:      DeliveryPending,
+			NextAttempt: now,
+			QueueTime:   now,
+			CreateTime:  now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.store.Enqueue(ctx, deliveries...); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers queued events until the context is done. It wakes up when
+// Publish queues deliveries, when a retry is due and every poll interval,
+// to pick up deliveries queued by other processes sharing the store.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("failed to deliver webhooks", "error", err)
+		}
+
+		wait := d.pollInterval
+		if next, err := d.store.NextAttempt(ctx); err == nil && !next.IsZero() {
+			wait = min(wait, max(next.Sub(d.now()), 0))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery whose next attempt is due and returns
+// how many were attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	due, err := d.store.Due(ctx, d.now())
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range due {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := d.attempt(ctx, delivery); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+// Retry requeues a dead-lettered delivery for an immediate attempt, with a
+// fresh set of attempts.
+func (d *Dispatcher) Retry(ctx context.Context, id string) (*Delivery, error) {
+	delivery, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != DeliveryDead {
+		return nil, fmt.Errorf("%w: delivery %s is %s, only dead deliveries can be retried", todo.ErrConflict, id, delivery.Status)
+	}
+
+	delivery.Status = DeliveryPending
+	delivery.QueueTime = d.now().UTC()
+	delivery.NextAttempt = delivery.QueueTime
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return delivery, nil
+}
+
+// attempt sends a delivery once and records the outcome. Delivery failures
+// are recorded, not returned; errors are only returned when the outcome
+// cannot be stored.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) error {
+	w, err := d.store.webhook(delivery.WebhookID)
+	if errors.Is(err, todo.ErrNotFound) {
+		// Removed since the delivery was read
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	start := d.now()
+	statusCode, sendErr := d.send(ctx, w, delivery)
+	result := Attempt{
+		Time:       start.UTC(),
+		Duration:   d.now().Sub(start),
+		StatusCode: statusCode,
+	}
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, result)
+
+	// Count only the attempts since the delivery was last (re)queued
+	tries := 0
+	for _, a := range delivery.Attempts {
+		if !a.Time.Before(delivery.QueueTime) {
+			tries++
+		}
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = DeliverySucceeded
+		delivery.NextAttempt = time.Time{}
+	case tries >= d.maxAttempts:
+		delivery.Status = DeliveryDead
+		delivery.NextAttempt = time.Time{}
+		d.logger.Warn("webhook delivery dead-lettered", "webhook", w.ID, "delivery", delivery.ID, "attempts", tries, "error", sendErr)
+	default:
+		delivery.NextAttempt = d.now().UTC().Add(d.delay(tries))
+		d.logger.Debug("webhook delivery failed, retrying", "webhook", w.ID, "delivery", delivery.ID, "next", delivery.NextAttempt, "error", sendErr)
+	}
+
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil && !errors.Is(err, todo.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// delay returns the backoff after the given number of failed attempts.
+func (d *Dispatcher) delay(failures int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < failures && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
+
+// send posts the signed payload and returns the response status. Any
+// non-2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, w *Webhook, delivery *Delivery) (int, error) {
+	body, err := json.Marshal(Payload{ID: delivery.ID, Webhook: w.ID, Event: delivery.Event})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, string(delivery.Event.Type))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
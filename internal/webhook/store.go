@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/filelock"
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// formatVersion is the version of the on-disk document layout.
+const formatVersion = 1
+
+// maxHistory is the number of succeeded deliveries kept per webhook. Pending
+// deliveries and dead letters are always kept.
+const maxHistory = 100
+
+// document is the on-disk layout of the store.
+type document struct {
+	Version    int         `json:"version"`
+	Webhooks   []*Webhook  `json:"webhooks"`
+	Deliveries []*Delivery `json:"deliveries,omitempty"`
+}
+
+// Store keeps webhooks and their deliveries in a local JSON file. Every
+// change reads the file and writes it back atomically while holding an
+// exclusive lock on a sidecar .lock file, so several processes may share it:
+// CLI commands queue deliveries that a running server delivers.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a Store at path. The file and its directory are created
+// on the first write.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// load reads the store. A missing file is an empty store.
+func (s *Store) load() (*document, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &document{Version: formatVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", s.path, err)
+	}
+	if doc.Version > formatVersion {
+		return nil, fmt.Errorf("%s has format version %d, this version of todoify supports up to %d", s.path, doc.Version, formatVersion)
+	}
+	for _, w := range doc.Webhooks {
+		if err := w.parse(); err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", w.ID, err)
+		}
+	}
+
+	return &doc, nil
+}
+
+// save writes the store to a temporary file and renames it over the old one,
+// so readers never see a partial write. The file holds the webhook secrets,
+// so it is only readable by its owner.
+func (s *Store) save(doc *document) error {
+	doc.Version = formatVersion
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// modify loads the store, applies fn and saves the result unless fn fails.
+// The lock file is held throughout, so changes made by other processes in
+// the meantime are not overwritten.
+func (s *Store) modify(fn func(doc *document) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := filelock.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+	return s.save(doc)
+}
+
+// read loads the store for a read-only operation. Writes replace the file
+// atomically, so reads need no lock file.
+func (s *Store) read() (*document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// AddWebhook stores a new webhook for the tenant the context acts for.
+func (s *Store) AddWebhook(ctx context.Context, w *Webhook) error {
+	w.Tenant, _ = todo.TenantFromContext(ctx)
+	return s.modify(func(doc *document) error {
+		if slices.ContainsFunc(doc.Webhooks, func(x *Webhook) bool { return x.ID == w.ID }) {
+			return fmt.Errorf("%w: webhook %s", todo.ErrConflict, w.ID)
+		}
+		doc.Webhooks = append(doc.Webhooks, w)
+		return nil
+	})
+}
+
+// visible reports whether a caller acting with ctx may see a webhook: only
+// webhooks of its own tenant are visible.
+func visible(ctx context.Context, w *Webhook) bool {
+	tenant, _ := todo.TenantFromContext(ctx)
+	return w.Tenant == tenant
+}
+
+// indexWebhook returns the index of the webhook with id, or -1.
+func (doc *document) indexWebhook(id string) int {
+	return slices.IndexFunc(doc.Webhooks, func(w *Webhook) bool { return w.ID == id })
+}
+
+// visibleWebhook reports whether the webhook with id exists and is visible
+// to ctx.
+func (doc *document) visibleWebhook(ctx context.Context, id string) bool {
+	i := doc.indexWebhook(id)
+	return i >= 0 && visible(ctx, doc.Webhooks[i])
+}
+
+// GetWebhook returns a webhook of the context's tenant by ID.
+func (s *Store) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	w, err := s.webhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if !visible(ctx, w) {
+		return nil, fmt.Errorf("%w: webhook %s", todo.ErrNotFound, id)
+	}
+	return w, nil
+}
+
+// webhook returns a webhook of any tenant by ID, for delivering.
+func (s *Store) webhook(id string) (*Webhook, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	i := doc.indexWebhook(id)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: webhook %s", todo.ErrNotFound, id)
+	}
+	return doc.Webhooks[i], nil
+}
+
+// ListWebhooks returns the webhooks of the context's tenant, oldest first.
+func (s *Store) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	webhooks, err := s.webhooks()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(webhooks, func(w *Webhook) bool { return !visible(ctx, w) }), nil
+}
+
+// webhooks returns the webhooks of every tenant, for queueing deliveries.
+func (s *Store) webhooks() ([]*Webhook, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Webhooks, nil
+}
+
+// RemoveWebhook deletes a webhook of the context's tenant together with its
+// deliveries.
+func (s *Store) RemoveWebhook(ctx context.Context, id string) error {
+	return s.modify(func(doc *document) error {
+		i := doc.indexWebhook(id)
+		if i < 0 || !visible(ctx, doc.Webhooks[i]) {
+			return fmt.Errorf("%w: webhook %s", todo.ErrNotFound, id)
+		}
+		doc.Webhooks = slices.Delete(doc.Webhooks, i, i+1)
+		doc.Deliveries = slices.DeleteFunc(doc.Deliveries, func(d *Delivery) bool { return d.WebhookID == id })
+		return nil
+	})
+}
+
+// Enqueue stores new pending deliveries.
+func (s *Store) Enqueue(ctx context.Context, deliveries ...*Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return s.modify(func(doc *document) error {
+		doc.Deliveries = append(doc.Deliveries, deliveries...)
+		return nil
+	})
+}
+
+// GetDelivery returns a delivery to a webhook of the context's tenant by ID.
+func (s *Store) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(doc.Deliveries, func(d *Delivery) bool { return d.ID == id })
+	if i < 0 || !doc.visibleWebhook(ctx, doc.Deliveries[i].WebhookID) {
+		return nil, fmt.Errorf("%w: delivery %s", todo.ErrNotFound, id)
+	}
+	return doc.Deliveries[i], nil
+}
+
+// ListDeliveries returns the deliveries of a webhook of the context's
+// tenant, newest first.
+func (s *Store) ListDeliveries(ctx context.Context, webhookID string) ([]*Delivery, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if !doc.visibleWebhook(ctx, webhookID) {
+		return nil, fmt.Errorf("%w: webhook %s", todo.ErrNotFound, webhookID)
+	}
+
+	var deliveries []*Delivery
+	for _, d := range doc.Deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.Reverse(deliveries)
+	return deliveries, nil
+}
+
+// Due returns the pending deliveries whose next attempt is at or before now,
+// in the order they should be attempted.
+func (s *Store) Due(ctx context.Context, now time.Time) ([]*Delivery, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*Delivery
+	for _, d := range doc.Deliveries {
+		if d.Status == DeliveryPending && !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *Delivery) int { return a.NextAttempt.Compare(b.NextAttempt) })
+	return due, nil
+}
+
+// NextAttempt returns the earliest next attempt of a pending delivery, or
+// the zero time when none are pending.
+func (s *Store) NextAttempt(ctx context.Context) (time.Time, error) {
+	doc, err := s.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, d := range doc.Deliveries {
+		if d.Status == DeliveryPending && (next.IsZero() || d.NextAttempt.Before(next)) {
+			next = d.NextAttempt
+		}
+	}
+	return next, nil
+}
+
+// UpdateDelivery replaces a stored delivery and drops the oldest succeeded
+// deliveries of its webhook beyond maxHistory.
+func (s *Store) UpdateDelivery(ctx context.Context, d *Delivery) error {
+	return s.modify(func(doc *document) error {
+		i := slices.IndexFunc(doc.Deliveries, func(x *Delivery) bool { return x.ID == d.ID })
+		if i < 0 {
+			return fmt.Errorf("%w: delivery %s", todo.ErrNotFound, d.ID)
+		}
+		doc.Deliveries[i] = d
+
+		succeeded := 0
+		for j := len(doc.Deliveries) - 1; j >= 0; j-- {
+			x := doc.Deliveries[j]
+			if x.WebhookID != d.WebhookID || x.Status != DeliverySucceeded {
+				continue
+			}
+			if succeeded++; succeeded > maxHistory {
+				doc.Deliveries = slices.Delete(doc.Deliveries, j, j+1)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,189 @@
+// Package webhook delivers todo change events to HTTP endpoints. Webhooks
+// and their deliveries are kept in a local JSON store, which doubles as the
+// delivery queue: events are queued when todo.Service publishes them and a
+// Dispatcher posts them, retrying failures with exponential backoff until
+// they succeed or are dead-lettered.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/google/uuid"
+)
+
+// Headers sent with every delivery.
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+	// keyed with the webhook secret.
+	SignatureHeader = "X-Todoify-Signature-256"
+	EventHeader     = "X-Todoify-Event"
+	DeliveryHeader  = "X-Todoify-Delivery"
+)
+
+// Webhook is a subscription of an HTTP endpoint to todo events.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+
+	// Events limits the event types delivered; empty means all of them.
+	Events []todo.EventType `json:"events,omitempty"`
+
+	// Filter is a query language expression the todo must match; deletions
+	// match the todo as it was.
+	Filter string `json:"filter,omitempty"`
+
+	// Tenant is the tenant the webhook was added for. It only receives the
+	// events of that tenant, and only that tenant can see or remove it.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Secret keys the payload signatures.
+	Secret     string    `json:"secret"`
+	CreateTime time.Time `json:"createTime"`
+
+	query *todo.Query
+}
+
+// New creates a webhook for url, validating the event types and filter. An
+// empty secret is replaced by a random one.
+func New(rawURL string, events []todo.EventType, filter, secret string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", todo.ErrInvalidInput)
+	}
+
+	for _, e := range events {
+		if !e.IsValid() {
+			return nil, fmt.Errorf("%w: invalid event %q (valid: created, updated, status_changed, deleted)", todo.ErrInvalidInput, e)
+		}
+	}
+
+	if secret == "" {
+		secret, err = newSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w := &Webhook{
+		ID:         uuid.NewString(),
+		URL:        rawURL,
+		Events:     events,
+		Filter:     strings.TrimSpace(filter),
+		Secret:     secret,
+		CreateTime: time.Now().UTC(),
+	}
+	if err := w.parse(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// parse parses the filter.
+func (w *Webhook) parse() error {
+	if w.Filter == "" {
+		return nil
+	}
+	q, err := todo.ParseQuery(w.Filter)
+	if err != nil {
+		return err
+	}
+	w.query = q
+	return nil
+}
+
+// Matches reports whether the event should be delivered to the webhook.
+// Events of other tenants never match.
+func (w *Webhook) Matches(e todo.Event) bool {
+	if e.Tenant != w.Tenant {
+		return false
+	}
+	if len(w.Events) > 0 && !containsType(w.Events, e.Type) {
+		return false
+	}
+	return todo.ListFilter{Query: w.query}.Matches(e.Todo)
+}
+
+func containsType(types []todo.EventType, t todo.EventType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the SignatureHeader value for a body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the SignatureHeader value of the body.
+// Receivers written in Go can use it to authenticate deliveries.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// DeliveryStatus is the state of a delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are queued for their next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries were accepted with a 2xx response.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead deliveries failed every attempt and are kept as dead letters.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is an event queued for, or delivered to, a webhook.
+type Delivery struct {
+	ID          string         `json:"id"`
+	WebhookID   string         `json:"webhookId"`
+	Event       todo.Event     `json:"event"`
+	Status      DeliveryStatus `json:"status"`
+	Attempts    []Attempt      `json:"attempts,omitempty"`
+	NextAttempt time.Time      `json:"nextAttempt,omitzero"`
+
+	// QueueTime is when the delivery was queued, or last requeued after
+	// being dead-lettered; attempts before it do not count towards the limit.
+	QueueTime  time.Time `json:"queueTime"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+// Attempt records one try at delivering.
+type Attempt struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	// StatusCode is the response status, or 0 when no response was received.
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// Error explains a failed attempt.
+	Error string `json:"error,omitempty"`
+}
+
+// Payload is the JSON body posted to webhooks: the event together with the
+// delivery and webhook IDs. Retries of a delivery send the same payload.
+type Payload struct {
+	ID      string `json:"id"`
+	Webhook string `json:"webhook"`
+	todo.Event
+}
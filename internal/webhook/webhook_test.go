@@ -0,0 +1,514 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+	filerepo "github.com/MattDevy/es-todoify/internal/todo/repositories/file"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// received is a request captured by a receiver.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is an httptest server that records deliveries and answers with
+// the next queued status, or 204 once they run out.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []received
+	statuses []int
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, received{header: req.Header.Clone(), body: body})
+		status := http.StatusNoContent
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.requests...)
+}
+
+// clock is a settable time source.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newStore(t *testing.T) *Store {
+	return NewStore(filepath.Join(t.TempDir(), "webhooks.json"))
+}
+
+func newDispatcher(t *testing.T, store *Store, opts ...Option) (*Dispatcher, *clock) {
+	c := &clock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	d := NewDispatcher(store, opts...)
+	d.now = c.Now
+	return d, c
+}
+
+func addWebhook(t *testing.T, store *Store, url string, events []todo.EventType, filter string) *Webhook {
+	w, err := New(url, events, filter, "s3cret")
+	require.NoError(t, err)
+	require.NoError(t, store.AddWebhook(context.Background(), w))
+	return w
+}
+
+func event(typ todo.EventType, title string, labels ...string) todo.Event {
+	return todo.Event{
+		Type: typ,
+		Todo: &todo.Todo{ID: uuid.MustParse("8f14e45f-ceea-467f-a0e6-0c4b3b2f9d2a"), Title: title, Status: todo.StatusPending, Labels: labels},
+		Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// tenantEvent is an event made for a tenant.
+func tenantEvent(tenant string, typ todo.EventType, title string) todo.Event {
+	e := event(typ, title)
+	e.Tenant = tenant
+	return e
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		events  []todo.EventType
+		filter  string
+		wantErr error
+	}{
+		{name: "valid", url: "https://example.com/hook", events: []todo.EventType{todo.EventCreated}, filter: "label:ops"},
+		{name: "relative url", url: "/hook", wantErr: todo.ErrInvalidInput},
+		{name: "unsupported scheme", url: "ftp://example.com", wantErr: todo.ErrInvalidInput},
+		{name: "invalid event", url: "https://example.com", events: []todo.EventType{"exploded"}, wantErr: todo.ErrInvalidInput},
+		{name: "invalid filter", url: "https://example.com", filter: "status:nope", wantErr: todo.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(tt.url, tt.events, tt.filter, "")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, w.ID)
+			require.Len(t, w.Secret, 64, "a random secret is generated")
+		})
+	}
+}
+
+func TestWebhook_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []todo.EventType
+		filter string
+		event  todo.Event
+		want   bool
+	}{
+		{name: "everything", event: event(todo.EventDeleted, "a"), want: true},
+		{name: "event type", events: []todo.EventType{todo.EventCreated, todo.EventStatusChanged}, event: event(todo.EventStatusChanged, "a"), want: true},
+		{name: "other event type", events: []todo.EventType{todo.EventCreated}, event: event(todo.EventUpdated, "a")},
+		{name: "filter", filter: "label:ops", event: event(todo.EventCreated, "a", "ops", "db"), want: true},
+		{name: "filter mismatch", filter: "label:ops", event: event(todo.EventCreated, "a", "dev")},
+		{name: "both", events: []todo.EventType{todo.EventCreated}, filter: "-label:ops", event: event(todo.EventCreated, "a"), want: true},
+		{name: "other tenant", event: tenantEvent("payments", todo.EventCreated, "a")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New("https://example.com", tt.events, tt.filter, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, w.Matches(tt.event))
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	sig := Sign("s3cret", body)
+
+	require.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	require.True(t, Verify("s3cret", body, sig))
+	require.False(t, Verify("other", body, sig))
+	require.False(t, Verify("s3cret", []byte(`{"id":"2"}`), sig))
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	ctx := context.Background()
+	recv := newReceiver(t)
+	store := newStore(t)
+	w := addWebhook(t, store, recv.URL, []todo.EventType{todo.EventCreated, todo.EventStatusChanged}, "label:ops")
+	d, _ := newDispatcher(t, store)
+
+	d.Publish(ctx, event(todo.EventCreated, "page the oncall", "ops"))
+	d.Publish(ctx, event(todo.EventCreated, "write docs", "docs"))
+	d.Publish(ctx, event(todo.EventUpdated, "page the oncall", "ops"))
+
+	n, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n, "only the matching event is queued")
+
+	reqs := recv.received()
+	require.Len(t, reqs, 1)
+	req := reqs[0]
+
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	require.Equal(t, "created", req.header.Get(EventHeader))
+	require.True(t, Verify("s3cret", req.body, req.header.Get(SignatureHeader)))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	require.Equal(t, req.header.Get(DeliveryHeader), payload.ID)
+	require.Equal(t, w.ID, payload.Webhook)
+	require.Equal(t, todo.EventCreated, payload.Type)
+	require.Equal(t, "page the oncall", payload.Todo.Title)
+
+	deliveries, err := store.ListDeliveries(ctx, w.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, DeliverySucceeded, deliveries[0].Status)
+	require.Len(t, deliveries[0].Attempts, 1)
+	require.Equal(t, http.StatusNoContent, deliveries[0].Attempts[0].StatusCode)
+
+	n, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n, "delivered events are not sent again")
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("backs off exponentially until delivered", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+		store := newStore(t)
+		w := addWebhook(t, store, recv.URL, nil, "")
+		d, clock := newDispatcher(t, store, WithBackoff(time.Minute, time.Hour))
+
+		d.Publish(ctx, event(todo.EventCreated, "a"))
+
+		_, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+		deliveries, err := store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Equal(t, DeliveryPending, deliveries[0].Status)
+		require.Equal(t, clock.Now().Add(time.Minute), deliveries[0].NextAttempt)
+		require.Equal(t, "receiver responded 500 Internal Server Error", deliveries[0].Attempts[0].Error)
+
+		// Not due yet
+		clock.Advance(59 * time.Second)
+		n, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		clock.Advance(time.Second)
+		_, err = d.DeliverDue(ctx)
+		require.NoError(t, err)
+		deliveries, err = store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Equal(t, clock.Now().Add(2*time.Minute), deliveries[0].NextAttempt, "the delay doubles")
+
+		clock.Advance(2 * time.Minute)
+		_, err = d.DeliverDue(ctx)
+		require.NoError(t, err)
+		deliveries, err = store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Equal(t, DeliverySucceeded, deliveries[0].Status)
+		require.Len(t, deliveries[0].Attempts, 3)
+
+		reqs := recv.received()
+		require.Len(t, reqs, 3)
+		require.Equal(t, reqs[0].body, reqs[2].body, "retries send the same payload")
+	})
+
+	t.Run("dead-letters after the last attempt", func(t *testing.T) {
+		recv := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError)
+		store := newStore(t)
+		w := addWebhook(t, store, recv.URL, nil, "")
+		d, clock := newDispatcher(t, store, WithMaxAttempts(2), WithBackoff(time.Minute, time.Hour))
+
+		d.Publish(ctx, event(todo.EventDeleted, "a"))
+		_, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+		_, err = d.DeliverDue(ctx)
+		require.NoError(t, err)
+
+		deliveries, err := store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		dead := deliveries[0]
+		require.Equal(t, DeliveryDead, dead.Status)
+		require.Len(t, dead.Attempts, 2)
+
+		clock.Advance(time.Hour)
+		n, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n, "dead letters are not retried automatically")
+
+		// Requeued by hand, it gets a fresh set of attempts
+		_, err = d.Retry(ctx, dead.ID)
+		require.NoError(t, err)
+		_, err = d.Retry(ctx, dead.ID)
+		require.ErrorIs(t, err, todo.ErrConflict)
+
+		clock.Advance(time.Second)
+		n, err = d.DeliverDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		delivery, err := store.GetDelivery(ctx, dead.ID)
+		require.NoError(t, err)
+		require.Equal(t, DeliverySucceeded, delivery.Status)
+		require.Len(t, delivery.Attempts, 3)
+	})
+
+	t.Run("unreachable receiver", func(t *testing.T) {
+		recv := newReceiver(t)
+		recv.Close()
+		store := newStore(t)
+		w := addWebhook(t, store, recv.URL, nil, "")
+		d, _ := newDispatcher(t, store, WithMaxAttempts(1))
+
+		d.Publish(ctx, event(todo.EventCreated, "a"))
+		_, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+
+		deliveries, err := store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Equal(t, DeliveryDead, deliveries[0].Status)
+		require.Zero(t, deliveries[0].Attempts[0].StatusCode)
+		require.NotEmpty(t, deliveries[0].Attempts[0].Error)
+	})
+}
+
+func TestDispatcher_Delay(t *testing.T) {
+	d := NewDispatcher(nil, WithBackoff(10*time.Second, time.Minute))
+
+	var delays []time.Duration
+	for i := 1; i <= 5; i++ {
+		delays = append(delays, d.delay(i))
+	}
+	require.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}, delays)
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	recv := newReceiver(t)
+	store := newStore(t)
+	addWebhook(t, store, recv.URL, nil, "")
+	d := NewDispatcher(store, WithPollInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+
+	d.Publish(ctx, event(todo.EventCreated, "a"))
+	require.Eventually(t, func() bool { return len(recv.received()) == 1 }, 5*time.Second, 10*time.Millisecond, "publishing wakes the dispatcher")
+
+	cancel()
+	<-done
+}
+
+func TestDispatcher_Service(t *testing.T) {
+	ctx := context.Background()
+	recv := newReceiver(t)
+	store := newStore(t)
+	w := addWebhook(t, store, recv.URL, []todo.EventType{todo.EventStatusChanged}, "")
+	d, _ := newDispatcher(t, store)
+
+	repo := filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json"))
+	svc := todo.NewService(repo, todo.WithPublisher(d))
+
+	created, err := svc.CreateTodo(ctx, "ship it", "", nil)
+	require.NoError(t, err)
+	_, err = svc.ChangeStatus(ctx, created.ID.String(), todo.StatusInProgress)
+	require.NoError(t, err)
+
+	_, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+
+	reqs := recv.received()
+	require.Len(t, reqs, 1)
+	var payload Payload
+	require.NoError(t, json.Unmarshal(reqs[0].body, &payload))
+	require.Equal(t, w.ID, payload.Webhook)
+	require.Equal(t, todo.StatusPending, payload.PreviousStatus)
+	require.Equal(t, todo.StatusInProgress, payload.Todo.Status)
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("persists across instances", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dir", "webhooks.json")
+		store := NewStore(path)
+		w := addWebhook(t, store, "https://example.com/hook", []todo.EventType{todo.EventCreated}, "label:ops")
+		d, _ := newDispatcher(t, store)
+		d.Publish(ctx, event(todo.EventCreated, "a", "ops"))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "secrets are only readable by the owner")
+
+		reopened := NewStore(path)
+		got, err := reopened.GetWebhook(ctx, w.ID)
+		require.NoError(t, err)
+		require.Equal(t, w.Filter, got.Filter)
+		require.True(t, got.Matches(event(todo.EventCreated, "b", "ops")), "the filter is parsed on load")
+
+		due, err := reopened.Due(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+	})
+
+	t.Run("removing a webhook removes its deliveries", func(t *testing.T) {
+		store := newStore(t)
+		w := addWebhook(t, store, "https://example.com/hook", nil, "")
+		d, _ := newDispatcher(t, store)
+		d.Publish(ctx, event(todo.EventCreated, "a"))
+
+		require.NoError(t, store.RemoveWebhook(ctx, w.ID))
+		require.ErrorIs(t, store.RemoveWebhook(ctx, w.ID), todo.ErrNotFound)
+		_, err := store.ListDeliveries(ctx, w.ID)
+		require.ErrorIs(t, err, todo.ErrNotFound)
+		due, err := store.Due(ctx, time.Now())
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("processes sharing the store do not lose changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "webhooks.json")
+		// Separate stores only share the lock file, like separate processes
+		stores := []*Store{NewStore(path), NewStore(path)}
+		w := addWebhook(t, stores[0], "https://example.com/hook", nil, "")
+
+		const perStore = 20
+		var wg sync.WaitGroup
+		for _, store := range stores {
+			d, _ := newDispatcher(t, store)
+			wg.Go(func() {
+				for range perStore {
+					require.NoError(t, d.Enqueue(ctx, event(todo.EventCreated, "a")))
+				}
+			})
+		}
+		wg.Wait()
+
+		deliveries, err := NewStore(path).ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, len(stores)*perStore)
+	})
+
+	t.Run("keeps a bounded history", func(t *testing.T) {
+		recv := newReceiver(t)
+		store := newStore(t)
+		w := addWebhook(t, store, recv.URL, nil, "")
+		d, _ := newDispatcher(t, store)
+
+		for range maxHistory + 5 {
+			d.Publish(ctx, event(todo.EventCreated, "a"))
+		}
+		_, err := d.DeliverDue(ctx)
+		require.NoError(t, err)
+
+		deliveries, err := store.ListDeliveries(ctx, w.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, maxHistory)
+	})
+}
+
+func TestTenants(t *testing.T) {
+	recvA, recvB := newReceiver(t), newReceiver(t)
+	store := newStore(t)
+	ctxA := todo.WithTenant(context.Background(), "payments")
+	ctxB := todo.WithTenant(context.Background(), "search")
+
+	hookA, err := New(recvA.URL, nil, "", "")
+	require.NoError(t, err)
+	require.NoError(t, store.AddWebhook(ctxA, hookA))
+	require.Equal(t, "payments", hookA.Tenant)
+	hookB, err := New(recvB.URL, nil, "", "")
+	require.NoError(t, err)
+	require.NoError(t, store.AddWebhook(ctxB, hookB))
+
+	d, _ := newDispatcher(t, store)
+	repo := filerepo.NewRepository(filepath.Join(t.TempDir(), "todos.json"))
+	svc := todo.NewService(repo, todo.WithPublisher(d))
+	_, err = svc.CreateTodo(ctxA, "rotate keys", "", nil)
+	require.NoError(t, err)
+
+	n, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Len(t, recvA.received(), 1)
+	require.Empty(t, recvB.received(), "events of one tenant are not delivered to another")
+
+	t.Run("webhooks of other tenants are not found", func(t *testing.T) {
+		hooks, err := store.ListWebhooks(ctxB)
+		require.NoError(t, err)
+		require.Len(t, hooks, 1)
+		require.Equal(t, hookB.ID, hooks[0].ID)
+
+		_, err = store.GetWebhook(ctxB, hookA.ID)
+		require.ErrorIs(t, err, todo.ErrNotFound)
+		_, err = store.ListDeliveries(ctxB, hookA.ID)
+		require.ErrorIs(t, err, todo.ErrNotFound)
+		require.ErrorIs(t, store.RemoveWebhook(ctxB, hookA.ID), todo.ErrNotFound)
+
+		deliveries, err := store.ListDeliveries(ctxA, hookA.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		_, err = store.GetDelivery(ctxB, deliveries[0].ID)
+		require.ErrorIs(t, err, todo.ErrNotFound)
+		_, err = d.Retry(ctxB, deliveries[0].ID)
+		require.ErrorIs(t, err, todo.ErrNotFound)
+	})
+
+	t.Run("without a tenant only untenanted webhooks are visible", func(t *testing.T) {
+		hooks, err := store.ListWebhooks(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, hooks)
+	})
+}
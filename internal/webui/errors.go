@@ -0,0 +1,50 @@
+package webui
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// pageError is an error shown above the todos.
+type pageError struct {
+	Title  string
+	Detail string
+
+	// Fields holds per-field messages for invalid input.
+	Fields map[string]string
+}
+
+// toError maps an error from the todo service to the status code of the
+// page and the error shown on it. Unknown errors are logged and shown
+// without their details.
+func (s *Server) toError(err error) (int, *pageError) {
+	e := &pageError{Detail: err.Error()}
+
+	var status int
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		status, e.Title = http.StatusNotFound, "Todo not found"
+	case errors.Is(err, todo.ErrInvalidInput):
+		status, e.Title = http.StatusBadRequest, "Invalid input"
+		if e.Fields = todo.FieldMessages(err); e.Fields != nil {
+			e.Detail = ""
+		}
+	case errors.Is(err, todo.ErrConflict):
+		status, e.Title = http.StatusConflict, "Conflict"
+	case errors.Is(err, todo.ErrInvalidStatus):
+		status, e.Title = http.StatusUnprocessableEntity, "Invalid status transition"
+	case errors.Is(err, todo.ErrUnsupported):
+		status, e.Title = http.StatusNotImplemented, "Not supported by the backend"
+	case errors.Is(err, todo.ErrUnauthenticated):
+		status, e.Title = http.StatusUnauthorized, "Authentication required"
+	case errors.Is(err, todo.ErrForbidden):
+		status, e.Title = http.StatusForbidden, "Forbidden"
+	default:
+		s.logger.Error("web UI request failed", "error", err)
+		status, e.Title, e.Detail = http.StatusInternalServerError, "Internal error", ""
+	}
+
+	return status, e
+}
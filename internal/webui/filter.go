@@ -0,0 +1,202 @@
+package webui
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Layouts of the page.
+const (
+	layoutList   = "list"
+	layoutKanban = "kanban"
+)
+
+// defaultLimit is how many todos the page shows unless limit is given.
+const defaultLimit = 100
+
+// filterForm holds the parameters of the page. The filter parameters are
+// named after the flags of "todoify list" and, like it, completed and
+// cancelled todos are hidden unless statuses are chosen, in the form or the
+// query, or all is set:
+//
+//	status, not-status, labels, any-labels, not-labels  comma-separated or repeated
+//	all                                                 include completed and cancelled
+//	search                                              full-text search
+//	from-date, to-date                                  creation date range (YYYY-MM-DD or RFC3339)
+//	where                                               custom field condition key<op>value, repeatable
+//	q                                                   query language expression
+//	sort-by, sort-order, limit                          sorting and page size
+//	view                                                list or kanban
+type filterForm struct {
+	Status    []todo.Status
+	NotStatus []todo.Status
+	All       bool
+	Labels    []string
+	AnyLabels []string
+	NotLabels []string
+	Search    string
+	FromDate  string
+	ToDate    string
+	Where     []string
+	Q         string
+	SortBy    todo.SortField
+	SortOrder todo.SortOrder
+	Limit     int
+	View      string
+}
+
+// parseFilterForm reads the page parameters. Invalid values are kept, so
+// the form shows them, and reported by toFilter.
+func parseFilterForm(query url.Values) filterForm {
+	f := filterForm{
+		Status:    statuses(listValues(query, "status")),
+		NotStatus: statuses(listValues(query, "not-status")),
+		Labels:    listValues(query, "labels"),
+		AnyLabels: listValues(query, "any-labels"),
+		NotLabels: listValues(query, "not-labels"),
+		Search:    strings.TrimSpace(query.Get("search")),
+		FromDate:  strings.TrimSpace(query.Get("from-date")),
+		ToDate:    strings.TrimSpace(query.Get("to-date")),
+		Q:         strings.TrimSpace(query.Get("q")),
+		SortBy:    todo.SortField(query.Get("sort-by")),
+		SortOrder: todo.SortOrder(query.Get("sort-order")),
+		Limit:     defaultLimit,
+		View:      layoutList,
+	}
+	f.All, _ = strconv.ParseBool(query.Get("all"))
+	for _, expr := range query["where"] {
+		if expr = strings.TrimSpace(expr); expr != "" {
+			f.Where = append(f.Where, expr)
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		// Not a number is out of range
+		f.Limit, _ = strconv.Atoi(v)
+	}
+	if query.Get("view") == layoutKanban {
+		f.View = layoutKanban
+	}
+	return f
+}
+
+// toFilter converts the form to a list filter.
+func (f filterForm) toFilter() (todo.ListFilter, error) {
+	filter := todo.ListFilter{
+		Statuses:        f.Status,
+		ExcludeStatuses: f.NotStatus,
+		Labels:          f.Labels,
+		AnyLabels:       f.AnyLabels,
+		ExcludeLabels:   f.NotLabels,
+		SearchQuery:     f.Search,
+		Limit:           f.Limit,
+		SortBy:          f.SortBy,
+		SortOrder:       f.SortOrder,
+	}
+
+	for _, s := range slices.Concat(f.Status, f.NotStatus) {
+		if !s.IsValid() {
+			return filter, fmt.Errorf("%w: invalid status %q (valid: pending, in_progress, completed, cancelled, blocked)", todo.ErrInvalidInput, s)
+		}
+	}
+	var err error
+	if filter.FromDate, err = parseDate("from-date", f.FromDate); err != nil {
+		return filter, err
+	}
+	if filter.ToDate, err = parseDate("to-date", f.ToDate); err != nil {
+		return filter, err
+	}
+
+	for _, expr := range f.Where {
+		cond, err := todo.ParseFieldCondition(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = append(filter.Where, cond)
+	}
+
+	if f.Q != "" {
+		if filter.Query, err = todo.ParseQuery(f.Q); err != nil {
+			return filter, err
+		}
+	}
+	if !f.All {
+		filter.HideTerminal()
+	}
+
+	if f.Limit < 1 || f.Limit > 1000 {
+		return filter, fmt.Errorf("%w: limit must be between 1 and 1000", todo.ErrInvalidInput)
+	}
+	if f.SortBy != "" && !f.SortBy.IsValid() {
+		return filter, fmt.Errorf("%w: sort-by must be one of createTime, updateTime, title, status, _score", todo.ErrInvalidInput)
+	}
+	if f.SortOrder != "" && !f.SortOrder.IsValid() {
+		return filter, fmt.Errorf("%w: sort-order must be asc or desc", todo.ErrInvalidInput)
+	}
+
+	return filter, nil
+}
+
+// hidesTerminal reports whether completed and cancelled todos are hidden,
+// which a status term in the query also prevents.
+func (f filterForm) hidesTerminal() bool {
+	if len(f.Status) > 0 || len(f.NotStatus) > 0 || f.All {
+		return false
+	}
+	q, err := todo.ParseQuery(f.Q)
+	return err != nil || !q.HasField(todo.QueryFieldStatus)
+}
+
+// columns returns the statuses the filter can match, in lifecycle order.
+// The kanban board has a column for each, even when it is empty.
+func (f filterForm) columns() []todo.Status {
+	var columns []todo.Status
+	for _, s := range todo.AllStatuses() {
+		switch {
+		case len(f.Status) > 0 && !slices.Contains(f.Status, s):
+		case slices.Contains(f.NotStatus, s):
+		case f.hidesTerminal() && s.IsTerminal():
+		default:
+			columns = append(columns, s)
+		}
+	}
+	return columns
+}
+
+// listValues returns the comma-separated values of a repeatable parameter.
+func listValues(values url.Values, name string) []string {
+	var items []string
+	for _, v := range values[name] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func statuses(values []string) []todo.Status {
+	var s []todo.Status
+	for _, v := range values {
+		s = append(s, todo.Status(v))
+	}
+	return s
+}
+
+// parseDate parses an optional date parameter.
+func parseDate(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := todo.ParseDate(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a date (YYYY-MM-DD or RFC3339)", todo.ErrInvalidInput, name)
+	}
+	return &t, nil
+}
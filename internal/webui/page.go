@@ -0,0 +1,189 @@
+package webui
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// page is the data of the index template.
+type page struct {
+	Form   filterForm
+	Groups []group
+
+	// More is set when the limit hides some of the matching todos.
+	More bool
+
+	// Filtered is set when any filter parameter is given.
+	Filtered bool
+
+	// URL is the address of the page, which live updates reload, and Return
+	// its query, which posted forms return to.
+	URL    string
+	Return string
+
+	ListURL   string
+	KanbanURL string
+	ResetURL  string
+	EventsURL string
+
+	Statuses   []todo.Status
+	SortFields []todo.SortField
+
+	// Create holds the values of a new todo that could not be created.
+	Create todoForm
+	Error  *pageError
+}
+
+// group holds the todos with one status.
+type group struct {
+	Status todo.Status
+	Todos  []*todo.Todo
+}
+
+// todoForm holds the values of the new todo form.
+type todoForm struct {
+	Title       string
+	Description string
+	Labels      string
+	Fields      string
+}
+
+var sortFields = []todo.SortField{
+	todo.SortFieldCreateTime,
+	todo.SortFieldUpdateTime,
+	todo.SortFieldTitle,
+	todo.SortFieldStatus,
+	todo.SortFieldRelevance,
+}
+
+var funcs = template.FuncMap{
+	"label":      statusLabel,
+	"has":        slices.Contains[[]todo.Status],
+	"join":       func(s []string) string { return strings.Join(s, ", ") },
+	"moves":      moves,
+	"fieldLines": fieldLines,
+}
+
+// render renders the page for the parameters in query. A failure of the
+// form that was posted is shown above the todos, and sets the status code.
+func (s *Server) render(w http.ResponseWriter, r *http.Request, query url.Values, create todoForm, failure error) {
+	form := parseFilterForm(query)
+	p := &page{
+		Form:       form,
+		URL:        pageURL(query),
+		Return:     query.Encode(),
+		ListURL:    pageURL(withView(query, layoutList)),
+		KanbanURL:  pageURL(withView(query, layoutKanban)),
+		ResetURL:   pageURL(withView(nil, form.View)),
+		EventsURL:  s.eventsURL,
+		Statuses:   todo.AllStatuses(),
+		SortFields: sortFields,
+		Create:     create,
+	}
+	for name := range query {
+		if name != "view" {
+			p.Filtered = true
+		}
+	}
+
+	status := http.StatusOK
+	if failure != nil {
+		status, p.Error = s.toError(failure)
+	}
+
+	filter, err := form.toFilter()
+	if err == nil {
+		p.Groups, p.More, err = s.groups(r.Context(), form, filter)
+	}
+	if err != nil && failure == nil {
+		status, p.Error = s.toError(err)
+	}
+
+	s.execute(w, status, "index.html", p)
+}
+
+// groups lists the todos of the filter by status. The list leaves out
+// statuses without todos; the kanban board has a column for every status
+// the filter can match.
+func (s *Server) groups(ctx context.Context, form filterForm, filter todo.ListFilter) ([]group, bool, error) {
+	todos, err := s.service.ListTodos(ctx, filter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	byStatus := make(map[todo.Status][]*todo.Todo)
+	for _, t := range todos {
+		byStatus[t.Status] = append(byStatus[t.Status], t)
+	}
+
+	columns := form.columns()
+	var groups []group
+	for _, status := range todo.AllStatuses() {
+		column := form.View == layoutKanban && slices.Contains(columns, status)
+		if len(byStatus[status]) > 0 || column {
+			groups = append(groups, group{Status: status, Todos: byStatus[status]})
+		}
+	}
+	return groups, len(todos) == filter.Limit, nil
+}
+
+// pageURL returns the address of the page with the query.
+func pageURL(query url.Values) string {
+	if len(query) == 0 {
+		return "/"
+	}
+	return "/?" + query.Encode()
+}
+
+// withView returns a copy of query showing the layout.
+func withView(query url.Values, layout string) url.Values {
+	query = maps.Clone(query)
+	if query == nil {
+		query = url.Values{}
+	}
+	if layout == layoutList {
+		query.Del("view")
+	} else {
+		query.Set("view", layout)
+	}
+	return query
+}
+
+// statusLabel returns the status as shown on the page, e.g. "In progress".
+func statusLabel(s todo.Status) string {
+	label := strings.ReplaceAll(string(s), "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// moves returns the statuses a todo can change to.
+func moves(t *todo.Todo) []todo.Status {
+	var statuses []todo.Status
+	for _, s := range todo.AllStatuses() {
+		if s == t.Status || (t.Status == todo.StatusCompleted && s == todo.StatusBlocked) {
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// fieldLines returns custom field values as key=value lines, as the edit
+// form takes them.
+func fieldLines(fields map[string]any) string {
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		lines = append(lines, fmt.Sprintf("%s=%v", name, fields[name]))
+	}
+	return strings.Join(lines, "\n")
+}
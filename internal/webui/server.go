@@ -0,0 +1,96 @@
+// Package webui serves a small web interface for todo.Service: the todos of a
+// filter grouped by status, as a list or a kanban board, with inline
+// creating, editing and status changes. Pages are rendered on the server from
+// embedded templates and work without JavaScript; a short script only adds
+// live updates, so the UI builds with the rest of the binary.
+package webui
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// Prefix is the path of the UI's assets and form actions. The page itself is
+// served at "/".
+const Prefix = "/ui"
+
+// Patterns are the ServeMux patterns the handler of a Server serves.
+var Patterns = []string{"GET /{$}", Prefix + "/"}
+
+// maxFormBytes limits the size of submitted forms.
+const maxFormBytes = 1 << 20
+
+//go:embed templates static
+var content embed.FS
+
+// Server is the web interface for a todo service.
+type Server struct {
+	service   *todo.Service
+	logger    *slog.Logger
+	eventsURL string
+	templates *template.Template
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger used for internal errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithEventsURL refreshes open pages when the server-sent events stream at
+// url, such as the REST API's /api/v1/events, reports a change.
+func WithEventsURL(url string) Option {
+	return func(s *Server) {
+		s.eventsURL = url
+	}
+}
+
+// NewServer creates a Server for the service.
+func NewServer(service *todo.Service, opts ...Option) *Server {
+	s := &Server{
+		service: service,
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.templates = template.Must(template.New("").Funcs(funcs).ParseFS(content, "templates/*.html"))
+	return s
+}
+
+// Handler returns the handler of the page and of the paths under Prefix.
+// Forms posted from other origins are refused.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.Handle("GET "+Prefix+"/static/", http.StripPrefix(Prefix, http.FileServerFS(content)))
+	mux.HandleFunc("POST "+Prefix+"/todos", s.form(s.handleCreate))
+	mux.HandleFunc("POST "+Prefix+"/todos/{id}", s.form(s.handleUpdate))
+	mux.HandleFunc("POST "+Prefix+"/todos/{id}/status", s.form(s.handleStatus))
+	mux.HandleFunc("POST "+Prefix+"/todos/{id}/delete", s.form(s.handleDelete))
+	return http.NewCrossOriginProtection().Handler(mux)
+}
+
+// execute renders a template into a buffer first, so a failing template
+// does not leave a half-written page.
+func (s *Server) execute(w http.ResponseWriter, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		s.logger.Error("failed to render page", "template", name, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,275 @@
+package webui
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *todo.Service) {
+	t.Helper()
+	service := testutil.NewFileService(t)
+	srv := testutil.Serve(t, NewServer(service, WithLogger(testutil.Logger()), WithEventsURL("/api/v1/events")).Handler())
+	return srv, service
+}
+
+// get fetches a page and returns its status code and body.
+func get(t *testing.T, srv *httptest.Server, path string) (int, string) {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+// post submits a form without following the redirect, and returns the
+// response with its body read.
+func post(t *testing.T, srv *httptest.Server, path string, form url.Values) (*http.Response, string) {
+	t.Helper()
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.PostForm(srv.URL+path, form)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func createTodo(t *testing.T, service *todo.Service, title string, status todo.Status, labels ...string) *todo.Todo {
+	t.Helper()
+	ctx := context.Background()
+	created, err := service.CreateTodo(ctx, title, "", labels)
+	require.NoError(t, err)
+	if status != todo.StatusPending {
+		created, err = service.ChangeStatus(ctx, created.ID.String(), status)
+		require.NoError(t, err)
+	}
+	return created
+}
+
+func TestServer_Index(t *testing.T) {
+	srv, service := newTestServer(t)
+	createTodo(t, service, "Write docs", todo.StatusPending, "docs")
+	createTodo(t, service, "Fix login", todo.StatusInProgress, "bug")
+	createTodo(t, service, "Ship it", todo.StatusCompleted)
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "completed hidden by default",
+			path:     "/",
+			status:   http.StatusOK,
+			contains: []string{"Write docs", "Fix login", `class="list"`, `data-events="/api/v1/events"`},
+			excludes: []string{"Ship it", `<h2>Blocked`},
+		},
+		{
+			name:     "all",
+			path:     "/?all=true",
+			status:   http.StatusOK,
+			contains: []string{"Write docs", "Fix login", "Ship it"},
+		},
+		{
+			name:     "status",
+			path:     "/?status=completed",
+			status:   http.StatusOK,
+			contains: []string{"Ship it"},
+			excludes: []string{"Write docs", "Fix login"},
+		},
+		{
+			name:     "status in the query",
+			path:     "/?q=status:completed&view=kanban",
+			status:   http.StatusOK,
+			contains: []string{"Ship it", `<h2>Completed`},
+			excludes: []string{"Write docs", "Fix login"},
+		},
+		{
+			name:     "labels",
+			path:     "/?labels=bug",
+			status:   http.StatusOK,
+			contains: []string{"Fix login"},
+			excludes: []string{"Write docs"},
+		},
+		{
+			name:     "kanban has a column per status",
+			path:     "/?view=kanban",
+			status:   http.StatusOK,
+			contains: []string{`class="kanban"`, `<h2>Pending`, `<h2>In progress`, `<h2>Blocked`},
+			excludes: []string{`<h2>Completed`, `<h2>Cancelled`},
+		},
+		{
+			name:     "limit",
+			path:     "/?limit=1",
+			status:   http.StatusOK,
+			contains: []string{"Showing the first 1 todos"},
+		},
+		{
+			name:     "invalid status",
+			path:     "/?status=done",
+			status:   http.StatusBadRequest,
+			contains: []string{"Invalid input", "invalid status &#34;done&#34;"},
+		},
+		{
+			name:     "invalid query",
+			path:     "/?q=status:",
+			status:   http.StatusBadRequest,
+			contains: []string{"Invalid input"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(t, srv, tt.path)
+			require.Equal(t, tt.status, status)
+			for _, s := range tt.contains {
+				require.Contains(t, body, s)
+			}
+			for _, s := range tt.excludes {
+				require.NotContains(t, body, s)
+			}
+		})
+	}
+}
+
+func TestServer_Forms(t *testing.T) {
+	ctx := context.Background()
+	srv, service := newTestServer(t)
+	_, err := service.DefineField(ctx, todo.FieldDefinition{Name: "points", Type: todo.FieldTypeNumber})
+	require.NoError(t, err)
+
+	t.Run("create", func(t *testing.T) {
+		resp, _ := post(t, srv, "/ui/todos", url.Values{
+			"title":  {"Write docs"},
+			"labels": {"docs, ui"},
+			"fields": {"points=3\n"},
+			"return": {"view=kanban"},
+		})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		require.Equal(t, "/?view=kanban", resp.Header.Get("Location"))
+
+		todos, err := service.ListTodos(ctx, todo.ListFilter{})
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		require.Equal(t, "Write docs", todos[0].Title)
+		require.Equal(t, []string{"docs", "ui"}, todos[0].Labels)
+		require.Equal(t, map[string]any{"points": 3.0}, todos[0].Fields)
+	})
+
+	t.Run("create invalid", func(t *testing.T) {
+		resp, body := post(t, srv, "/ui/todos", url.Values{"title": {"Bad"}, "fields": {"points=many"}})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, body, "points must be a number")
+		// The form is shown again with its values
+		require.Contains(t, body, `value="Bad"`)
+	})
+
+	created := createTodo(t, service, "Fix login", todo.StatusPending, "bug")
+	path := "/ui/todos/" + created.ID.String()
+
+	t.Run("update", func(t *testing.T) {
+		resp, _ := post(t, srv, path, url.Values{
+			"title":       {"Fix the login"},
+			"description": {"Redirect loops"},
+			"labels":      {"bug,auth"},
+			"fields":      {"points=5"},
+		})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		require.Equal(t, "/", resp.Header.Get("Location"))
+
+		got, err := service.GetTodo(ctx, created.ID.String())
+		require.NoError(t, err)
+		require.Equal(t, "Fix the login", got.Title)
+		require.Equal(t, "Redirect loops", got.Description)
+		require.Equal(t, []string{"bug", "auth"}, got.Labels)
+		require.Equal(t, map[string]any{"points": 5.0}, got.Fields)
+
+		// Leaving a field out removes it, and labels left out are kept
+		resp, _ = post(t, srv, path, url.Values{"title": {"Fix the login"}})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		got, err = service.GetTodo(ctx, created.ID.String())
+		require.NoError(t, err)
+		require.Empty(t, got.Fields)
+		require.Equal(t, []string{"bug", "auth"}, got.Labels)
+
+		// An emptied labels input removes them all
+		resp, _ = post(t, srv, path, url.Values{"title": {"Fix the login"}, "labels": {" "}})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		got, err = service.GetTodo(ctx, created.ID.String())
+		require.NoError(t, err)
+		require.Empty(t, got.Labels)
+	})
+
+	t.Run("update without title", func(t *testing.T) {
+		resp, body := post(t, srv, path, url.Values{"title": {" "}})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, body, "title is a required field")
+	})
+
+	t.Run("status", func(t *testing.T) {
+		resp, _ := post(t, srv, path+"/status", url.Values{"status": {"completed"}})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		got, err := service.GetTodo(ctx, created.ID.String())
+		require.NoError(t, err)
+		require.Equal(t, todo.StatusCompleted, got.Status)
+
+		resp, body := post(t, srv, path+"/status", url.Values{"status": {"blocked"}})
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		require.Contains(t, body, "Invalid status transition")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp, _ := post(t, srv, path+"/delete", nil)
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		_, err := service.GetTodo(ctx, created.ID.String())
+		require.ErrorIs(t, err, todo.ErrNotFound)
+
+		resp, body := post(t, srv, path+"/delete", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Contains(t, body, "Todo not found")
+	})
+}
+
+func TestServer_CrossOrigin(t *testing.T) {
+	srv, service := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/ui/todos", strings.NewReader("title=Sneaky"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	todos, err := service.ListTodos(context.Background(), todo.ListFilter{})
+	require.NoError(t, err)
+	require.Empty(t, todos)
+}
+
+func TestServer_Static(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, path := range []string{"/ui/static/app.js", "/ui/static/style.css"} {
+		status, body := get(t, srv, path)
+		require.Equal(t, http.StatusOK, status, path)
+		require.NotEmpty(t, body, path)
+	}
+
+	status, _ := get(t, srv, "/ui/templates/index.html")
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,62 @@
+// Live updates: reload the todos of the page when the server reports a
+// change. While a form is being edited the reload waits until it is left,
+// so typing is not lost. Everything else works without this script.
+(() => {
+  "use strict";
+
+  document.addEventListener("submit", (event) => {
+    if (event.target.matches("form.delete") && !confirm("Delete this todo?")) {
+      event.preventDefault();
+    }
+  });
+
+  const { page, events } = document.body.dataset;
+  if (!events || !window.EventSource) {
+    return;
+  }
+
+  let stale = false;
+  let timer;
+
+  const editing = () =>
+    document.activeElement?.form || document.querySelector("#todos details[open]");
+
+  async function reload() {
+    if (editing()) {
+      stale = true;
+      return;
+    }
+    stale = false;
+    try {
+      const response = await fetch(page, { headers: { Accept: "text/html" } });
+      if (!response.ok) {
+        return;
+      }
+      const doc = new DOMParser().parseFromString(await response.text(), "text/html");
+      const todos = doc.getElementById("todos");
+      if (todos) {
+        document.getElementById("todos").replaceWith(todos);
+      }
+    } catch {
+      // The next change retries
+    }
+  }
+
+  // Changes often come in bursts, e.g. from imports
+  function schedule() {
+    clearTimeout(timer);
+    timer = setTimeout(reload, 250);
+  }
+
+  const live = document.getElementById("live");
+  const source = new EventSource(events);
+  source.addEventListener("open", () => { live.hidden = false; });
+  source.addEventListener("error", () => { live.hidden = true; });
+  for (const type of ["created", "updated", "status_changed", "deleted"]) {
+    source.addEventListener(type, schedule);
+  }
+
+  // Catch up once editing stops
+  document.addEventListener("focusout", () => { if (stale) schedule(); });
+  document.addEventListener("toggle", () => { if (stale) schedule(); }, true);
+})();
@@ -0,0 +1,136 @@
+package webui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MattDevy/es-todoify/internal/todo"
+)
+
+// handleIndex renders the page for the parameters in the query.
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.render(w, r, r.URL.Query(), todoForm{}, nil)
+}
+
+// form parses the posted form before h, limiting its size.
+func (s *Server) form(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxFormBytes)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// finish returns to the page a form was posted from or, when it failed,
+// renders that page with the error.
+func (s *Server) finish(w http.ResponseWriter, r *http.Request, create todoForm, err error) {
+	// The return query only selects the page, so it cannot redirect elsewhere
+	query, _ := url.ParseQuery(r.PostForm.Get("return"))
+	if err != nil {
+		s.render(w, r, query, create, err)
+		return
+	}
+	http.Redirect(w, r, pageURL(query), http.StatusSeeOther)
+}
+
+// handleCreate creates a todo from the new todo form.
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	form := todoForm{
+		Title:       r.PostForm.Get("title"),
+		Description: r.PostForm.Get("description"),
+		Labels:      r.PostForm.Get("labels"),
+		Fields:      r.PostForm.Get("fields"),
+	}
+
+	fields, err := parseFields(form.Fields)
+	if err == nil {
+		_, err = s.service.CreateTodo(r.Context(),
+			strings.TrimSpace(form.Title),
+			strings.TrimSpace(form.Description),
+			listValues(r.PostForm, "labels"),
+			todo.WithFields(fields),
+		)
+	}
+	s.finish(w, r, form, err)
+}
+
+// handleUpdate saves the edit form of a todo.
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	current, err := s.service.GetTodo(r.Context(), id)
+	if err == nil {
+		var update todo.UpdateTodo
+		if update, err = updateFrom(r.PostForm, current); err == nil {
+			_, err = s.service.UpdateTodo(r.Context(), id, update)
+		}
+	}
+	s.finish(w, r, todoForm{}, err)
+}
+
+// handleStatus changes the status of a todo.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	_, err := s.service.ChangeStatus(r.Context(), r.PathValue("id"), todo.Status(r.PostForm.Get("status")))
+	s.finish(w, r, todoForm{}, err)
+}
+
+// handleDelete deletes a todo.
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	err := s.service.DeleteTodo(r.Context(), r.PathValue("id"))
+	s.finish(w, r, todoForm{}, err)
+}
+
+// updateFrom builds the update of an edit form, which holds every editable
+// property: custom fields left out are removed from the todo.
+func updateFrom(form url.Values, current *todo.Todo) (todo.UpdateTodo, error) {
+	title := strings.TrimSpace(form.Get("title"))
+	if title == "" {
+		return todo.UpdateTodo{}, fmt.Errorf("%w: %w", todo.ErrInvalidInput, todo.FieldErrors{"title": "title is a required field"})
+	}
+	description := strings.TrimSpace(form.Get("description"))
+
+	update := todo.UpdateTodo{
+		Title:       &title,
+		Description: &description,
+		Labels:      listValues(form, "labels"),
+	}
+	// A nil list leaves the labels unchanged, so an emptied labels input
+	// needs an empty one to remove them all
+	if _, ok := form["labels"]; ok && update.Labels == nil {
+		update.Labels = []string{}
+	}
+
+	fields, err := parseFields(form.Get("fields"))
+	if err != nil {
+		return update, err
+	}
+	for name := range current.Fields {
+		if _, ok := fields[name]; !ok {
+			fields[name] = nil
+		}
+	}
+	if len(fields) > 0 {
+		update.Fields = fields
+	}
+	return update, nil
+}
+
+// parseFields parses custom field values given one key=value per line.
+func parseFields(text string) (map[string]any, error) {
+	fields := make(map[string]any)
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		key, value, err := todo.ParseFieldAssignment(line)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields, nil
+}
@@ -0,0 +1,339 @@
+// Package client is a Go client for the todoify REST API served by
+// `todoify serve`. Its types mirror the schemas in the API's OpenAPI
+// document (GET /api/v1/openapi.yaml), so other services can talk to
+// todoify without depending on its internal packages.
+//
+//	c, err := client.New("http://localhost:8080")
+//	if err != nil {
+//		return err
+//	}
+//	t, err := c.CreateTodo(ctx, client.CreateTodoRequest{Title: "Write docs"})
+//	if errors.Is(err, client.ErrInvalidInput) {
+//		// inspect err.(*client.Problem).Errors
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// basePath prefixes every todo endpoint.
+const basePath = "/api/v1"
+
+// Client calls the todoify API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userAgent  string
+	token      string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests (default http.DefaultClient).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithToken authenticates every request with a bearer credential: a
+// personal access token from "todoify token create" or a JWT.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// setHeaders sets the headers common to every request.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		userAgent:  "todoify-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// CreateTodo creates a todo.
+func (c *Client) CreateTodo(ctx context.Context, req CreateTodoRequest) (*Todo, error) {
+	var t Todo
+	if err := c.do(ctx, http.MethodPost, basePath+"/todos", nil, req, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTodo retrieves a todo by ID.
+func (c *Client) GetTodo(ctx context.Context, id string) (*Todo, error) {
+	var t Todo
+	if err := c.do(ctx, http.MethodGet, todoPath(id), nil, nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateTodo applies a partial update to a todo.
+func (c *Client) UpdateTodo(ctx context.Context, id string, update UpdateTodo) (*Todo, error) {
+	var t Todo
+	if err := c.do(ctx, http.MethodPatch, todoPath(id), nil, update, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ChangeStatus changes the status of a todo.
+func (c *Client) ChangeStatus(ctx context.Context, id string, status Status) (*Todo, error) {
+	var t Todo
+	if err := c.do(ctx, http.MethodPut, todoPath(id)+"/status", nil, ChangeStatusRequest{Status: status}, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteTodo deletes a todo.
+func (c *Client) DeleteTodo(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, todoPath(id), nil, nil, nil)
+}
+
+// ListTodos returns a page of todos selected by the filter's limit and offset.
+func (c *Client) ListTodos(ctx context.Context, filter ListFilter) (*ListResponse, error) {
+	var res ListResponse
+	if err := c.do(ctx, http.MethodGet, basePath+"/todos", filter.values(true), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListPage returns the page of todos after cursor, which is empty for the
+// first page. The filter's offset is ignored. The response's NextCursor is
+// empty on the last page.
+func (c *Client) ListPage(ctx context.Context, filter ListFilter, cursor string) (*ListResponse, error) {
+	query := filter.values(true)
+	query.Del("offset")
+	query.Set("cursor", cursor)
+
+	var res ListResponse
+	if err := c.do(ctx, http.MethodGet, basePath+"/todos", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// All iterates over every todo matching the filter, following cursors. The
+// filter's limit sets the page size. Iteration stops at the first error.
+func (c *Client) All(ctx context.Context, filter ListFilter) iter.Seq2[*Todo, error] {
+	return func(yield func(*Todo, error) bool) {
+		cursor := ""
+		for {
+			page, err := c.ListPage(ctx, filter, cursor)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, t := range page.Todos {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			cursor = page.NextCursor
+		}
+	}
+}
+
+// CountTodos counts the todos matching the filter.
+func (c *Client) CountTodos(ctx context.Context, filter ListFilter) (int, error) {
+	var res CountResponse
+	if err := c.do(ctx, http.MethodGet, basePath+"/todos/count", filter.values(false), nil, &res); err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// Health reports the health of the server's backend. An unhealthy backend is
+// not an error; check the returned status.
+func (c *Client) Health(ctx context.Context) (*HealthInfo, error) {
+	var info HealthInfo
+	err := c.do(ctx, http.MethodGet, "/healthz", nil, nil, &info)
+	if err != nil && info.Status == "" {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func todoPath(id string) string {
+	return basePath + "/todos/" + url.PathEscape(id)
+}
+
+// values encodes the filter as query parameters. Pagination, sorting and
+// facets are only included for listing.
+func (f ListFilter) values(listing bool) url.Values {
+	q := url.Values{}
+	setList := func(name string, values []string) {
+		if len(values) > 0 {
+			q.Set(name, strings.Join(values, ","))
+		}
+	}
+	setString := func(name, value string) {
+		if value != "" {
+			q.Set(name, value)
+		}
+	}
+
+	setList("status", statusStrings(f.Statuses))
+	setList("notStatus", statusStrings(f.ExcludeStatuses))
+	setList("labels", f.Labels)
+	setList("anyLabels", f.AnyLabels)
+	setList("notLabels", f.ExcludeLabels)
+	setString("search", f.Search)
+	if f.From != nil {
+		q.Set("from", f.From.Format(time.RFC3339))
+	}
+	if f.To != nil {
+		q.Set("to", f.To.Format(time.RFC3339))
+	}
+	for _, cond := range f.Where {
+		q.Add("where", cond)
+	}
+	setString("q", f.Query)
+
+	if !listing {
+		return q
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Offset > 0 {
+		q.Set("offset", strconv.Itoa(f.Offset))
+	}
+	setString("sortBy", f.SortBy)
+	setString("sortOrder", f.SortOrder)
+	if f.Facets {
+		q.Set("facets", "true")
+	}
+	return q
+}
+
+func statusStrings(statuses []Status) []string {
+	values := make([]string, len(statuses))
+	for i, s := range statuses {
+		values[i] = string(s)
+	}
+	return values
+}
+
+// do sends a request and decodes the JSON response into out. Error statuses
+// are returned as a *Problem; the body is still decoded into out when it is
+// JSON rather than a problem, as for an unhealthy /healthz.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL.JoinPath(path)
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	c.setHeaders(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: failed to read response: %w", method, path, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode >= 400 {
+		if mediaType == "application/json" && out != nil {
+			_ = json.Unmarshal(data, out)
+		}
+		return problemFrom(resp, mediaType, data)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// problemFrom builds the error for a failed response, falling back to the
+// status text when the body is not a problem document.
+func problemFrom(resp *http.Response, mediaType string, data []byte) *Problem {
+	p := &Problem{}
+	if mediaType == "application/problem+json" {
+		_ = json.Unmarshal(data, p)
+	}
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(resp.StatusCode)
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		p.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return p
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/api"
+	"github.com/MattDevy/es-todoify/internal/ratelimit"
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	srv := testutil.Serve(t, api.NewServer(testutil.NewFileService(t), api.WithLogger(testutil.Logger())).Handler())
+
+	c, err := New(srv.URL, WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr bool
+	}{
+		{"http", "http://localhost:8080", false},
+		{"trailing slash", "https://todo.example.com/", false},
+		{"no scheme", "localhost:8080", true},
+		{"invalid", "http://[::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.baseURL)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_CRUD(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	created, err := c.CreateTodo(ctx, CreateTodoRequest{Title: "Write docs", Labels: []string{"work"}})
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, created.Status)
+
+	got, err := c.GetTodo(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, got.ID)
+
+	title := "Write better docs"
+	updated, err := c.UpdateTodo(ctx, created.ID, UpdateTodo{Title: &title})
+	require.NoError(t, err)
+	require.Equal(t, title, updated.Title)
+
+	changed, err := c.ChangeStatus(ctx, created.ID, StatusCompleted)
+	require.NoError(t, err)
+	require.Equal(t, StatusCompleted, changed.Status)
+
+	require.NoError(t, c.DeleteTodo(ctx, created.ID))
+	_, err = c.GetTodo(ctx, created.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Errors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	created, err := c.CreateTodo(ctx, CreateTodoRequest{Title: "done"})
+	require.NoError(t, err)
+	_, err = c.ChangeStatus(ctx, created.ID, StatusCompleted)
+	require.NoError(t, err)
+
+	_, err = c.CreateTodo(ctx, CreateTodoRequest{})
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	empty := ""
+	_, err = c.UpdateTodo(ctx, created.ID, UpdateTodo{Title: &empty})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	var p *Problem
+	require.True(t, errors.As(err, &p))
+	require.Equal(t, http.StatusBadRequest, p.Status)
+	require.NotEmpty(t, p.Errors)
+
+	_, err = c.ChangeStatus(ctx, created.ID, StatusBlocked)
+	require.ErrorIs(t, err, ErrInvalidStatus)
+
+	err = c.DeleteTodo(ctx, "00000000-0000-0000-0000-000000000000")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	limiter, err := ratelimit.New(ratelimit.WithLimit(ratelimit.Standard, ratelimit.Limit{Rate: 0.1, Burst: 1}))
+	require.NoError(t, err)
+	srv := testutil.Serve(t, api.NewServer(testutil.NewFileService(t), api.WithLogger(testutil.Logger()), api.WithRateLimiter(limiter)).Handler())
+	c, err := New(srv.URL, WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	_, err = c.ListTodos(t.Context(), ListFilter{})
+	require.NoError(t, err)
+	_, err = c.ListTodos(t.Context(), ListFilter{})
+	require.ErrorIs(t, err, ErrRateLimited)
+	var p *Problem
+	require.True(t, errors.As(err, &p))
+	require.Equal(t, 10*time.Second, p.RetryAfter)
+}
+
+func TestClient_ListAndCount(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	for _, title := range []string{"alpha", "beta", "gamma", "delta", "epsilon"} {
+		_, err := c.CreateTodo(ctx, CreateTodoRequest{Title: title, Labels: []string{"work"}})
+		require.NoError(t, err)
+	}
+	_, err := c.CreateTodo(ctx, CreateTodoRequest{Title: "chores", Labels: []string{"home"}})
+	require.NoError(t, err)
+
+	res, err := c.ListTodos(ctx, ListFilter{Labels: []string{"work"}, SortBy: "title", SortOrder: "asc", Limit: 2, Offset: 1, Facets: true})
+	require.NoError(t, err)
+	require.Len(t, res.Todos, 2)
+	require.Equal(t, "beta", res.Todos[0].Title)
+	require.NotNil(t, res.Facets)
+
+	count, err := c.CountTodos(ctx, ListFilter{AnyLabels: []string{"home"}, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	var titles []string
+	for td, err := range c.All(ctx, ListFilter{Labels: []string{"work"}, Limit: 2}) {
+		require.NoError(t, err)
+		titles = append(titles, td.Title)
+	}
+	require.Len(t, titles, 5)
+
+	for _, err := range c.All(ctx, ListFilter{Statuses: []Status{"archived"}}) {
+		require.ErrorIs(t, err, ErrInvalidInput)
+	}
+}
+
+func TestClient_Health(t *testing.T) {
+	c := newTestClient(t)
+
+	info, err := c.Health(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, HealthStatusHealthy, info.Status)
+	require.NotEmpty(t, info.ResponseTime)
+}
+
+// TestTypesMatchOpenAPI checks that the client types have the properties of
+// the schemas they mirror.
+func TestTypesMatchOpenAPI(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `yaml:"properties"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	require.NoError(t, yaml.Unmarshal(api.OpenAPI, &doc))
+
+	tests := []struct {
+		schema string
+		typ    any
+	}{
+		{"Todo", Todo{}},
+		{"CreateTodoRequest", CreateTodoRequest{}},
+		{"UpdateTodo", UpdateTodo{}},
+		{"ChangeStatusRequest", ChangeStatusRequest{}},
+		{"ListResponse", ListResponse{}},
+		{"Facets", Facets{}},
+		{"FacetBucket", FacetBucket{}},
+		{"CountResponse", CountResponse{}},
+		{"HealthInfo", HealthInfo{}},
+		{"Event", Event{}},
+		{"Problem", Problem{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[tt.schema]
+			require.True(t, ok)
+
+			var documented []string
+			for name := range schema.Properties {
+				documented = append(documented, name)
+			}
+
+			var fields []string
+			typ := reflect.TypeOf(tt.typ)
+			for i := range typ.NumField() {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				if name != "" && name != "-" {
+					fields = append(fields, name)
+				}
+			}
+
+			sort.Strings(documented)
+			sort.Strings(fields)
+			require.Equal(t, documented, fields)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a todo does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidInput is returned when the server rejects a request as invalid.
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrConflict is returned when a request conflicts with the current state.
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidStatus is returned when a status transition is not allowed.
+	ErrInvalidStatus = errors.New("invalid status transition")
+
+	// ErrUnsupported is returned when the server's backend does not support an operation.
+	ErrUnsupported = errors.New("unsupported by the backend")
+
+	// ErrUnauthenticated is returned when the server requires a valid bearer
+	// credential, see WithToken.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is returned when the credential lacks the required scope.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrRateLimited is returned when the client is over the server's rate
+	// limit; Problem.RetryAfter says when to try again.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Problem is an RFC 9457 problem details error returned by the API. It
+// unwraps to the sentinel error matching its status, so callers can use
+// errors.Is(err, client.ErrNotFound).
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Errors maps field names to validation messages.
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// RetryAfter is how long to wait before retrying, from the Retry-After
+	// header of a rate limited response.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// Unwrap returns the sentinel error for the status, or nil.
+func (p *Problem) Unwrap() error {
+	switch p.Status {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrInvalidInput
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusUnprocessableEntity:
+		return ErrInvalidStatus
+	case http.StatusNotImplemented:
+		return ErrUnsupported
+	case http.StatusUnauthorized:
+		return ErrUnauthenticated
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Watch streams the changes made through the server to todos matching the
+// filter, optionally only those of the given types. Pagination and sorting
+// of the filter are ignored. The stream runs until ctx is done or the server
+// ends it; in the latter case the last value is a *Problem error, e.g. when
+// the client fell behind and should reload before watching again.
+func (c *Client) Watch(ctx context.Context, filter ListFilter, types ...EventType) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		query := filter.values(false)
+		if len(types) > 0 {
+			values := make([]string, len(types))
+			for i, t := range types {
+				values[i] = string(t)
+			}
+			query.Set("types", strings.Join(values, ","))
+		}
+
+		u := c.baseURL.JoinPath(basePath, "events")
+		u.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to create request: %w", err))
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		c.setHeaders(req)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			yield(nil, fmt.Errorf("GET %s/events: %w", basePath, err))
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			data, _ := io.ReadAll(resp.Body)
+			mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+			yield(nil, problemFrom(resp, mediaType, data))
+			return
+		}
+
+		for name, data := range readEvents(resp.Body) {
+			if name == "error" {
+				p := &Problem{}
+				if err := json.Unmarshal(data, p); err != nil {
+					yield(nil, fmt.Errorf("invalid error event: %w", err))
+				} else {
+					yield(nil, p)
+				}
+				return
+			}
+
+			var e Event
+			if err := json.Unmarshal(data, &e); err != nil {
+				yield(nil, fmt.Errorf("invalid %s event: %w", name, err))
+				return
+			}
+			if !yield(&e, nil) {
+				return
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		yield(nil, fmt.Errorf("GET %s/events: %w", basePath, io.ErrUnexpectedEOF))
+	}
+}
+
+// readEvents iterates over the names and data of server-sent events,
+// skipping comments. Multi-line data is joined with newlines.
+func readEvents(r io.Reader) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		var name string
+		var data []string
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				if len(data) > 0 && !yield(name, []byte(strings.Join(data, "\n"))) {
+					return
+				}
+				name, data = "", nil
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				name = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MattDevy/es-todoify/internal/api"
+	"github.com/MattDevy/es-todoify/internal/events"
+	"github.com/MattDevy/es-todoify/internal/testutil"
+	"github.com/MattDevy/es-todoify/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func newWatchClient(t *testing.T) (*Client, *events.Broker) {
+	t.Helper()
+	broker := events.NewBroker()
+	srv := testutil.Serve(t, api.NewServer(testutil.NewFileService(t, todo.WithPublisher(broker)), api.WithLogger(testutil.Logger()), api.WithEvents(broker)).Handler())
+	t.Cleanup(broker.Close)
+
+	c, err := New(srv.URL, WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	return c, broker
+}
+
+func TestClient_Watch(t *testing.T) {
+	c, broker := newWatchClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	go func() {
+		for broker.Subscribers() == 0 && ctx.Err() == nil {
+			time.Sleep(5 * time.Millisecond)
+		}
+		created, err := c.CreateTodo(ctx, CreateTodoRequest{Title: "Write docs", Labels: []string{"docs"}})
+		if err != nil {
+			return
+		}
+		_, _ = c.CreateTodo(ctx, CreateTodoRequest{Title: "unrelated"})
+		_, _ = c.ChangeStatus(ctx, created.ID, StatusCompleted)
+		broker.Close()
+	}()
+
+	var got []*Event
+	var end error
+	for e, err := range c.Watch(ctx, ListFilter{Labels: []string{"docs"}}) {
+		if err != nil {
+			end = err
+			break
+		}
+		got = append(got, e)
+	}
+
+	require.Len(t, got, 2)
+	require.Equal(t, EventCreated, got[0].Type)
+	require.Equal(t, "Write docs", got[0].Todo.Title)
+	require.Equal(t, EventStatusChanged, got[1].Type)
+	require.Equal(t, StatusPending, got[1].PreviousStatus)
+	require.Equal(t, StatusCompleted, got[1].Todo.Status)
+
+	var p *Problem
+	require.True(t, errors.As(end, &p), "got %v", end)
+	require.Equal(t, 503, p.Status)
+}
+
+func TestClient_WatchErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid filter", func(t *testing.T) {
+		c, _ := newWatchClient(t)
+		for _, err := range c.Watch(ctx, ListFilter{}, "nope") {
+			require.ErrorIs(t, err, ErrInvalidInput)
+		}
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		c := newTestClient(t)
+		for _, err := range c.Watch(ctx, ListFilter{}) {
+			require.ErrorIs(t, err, ErrUnsupported)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"time"
+)
+
+// Status is the lifecycle state of a todo.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusCancelled  Status = "cancelled"
+	StatusBlocked    Status = "blocked"
+)
+
+// Todo is a todo item as returned by the API.
+type Todo struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Labels      []string  `json:"labels,omitempty"`
+	Status      Status    `json:"status"`
+	CreateTime  time.Time `json:"createTime"`
+	UpdateTime  time.Time `json:"updateTime"`
+
+	// Fields holds custom field values keyed by field name.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// CreateTodoRequest describes a new todo.
+type CreateTodoRequest struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Labels      []string       `json:"labels,omitempty"`
+	Fields      map[string]any `json:"fields,omitempty"`
+}
+
+// UpdateTodo changes the given properties of a todo and leaves the rest as they are.
+type UpdateTodo struct {
+	Title       *string  `json:"title,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+
+	// Fields sets custom field values. A nil value removes the field from the todo.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// ChangeStatusRequest is the body of a status change.
+type ChangeStatusRequest struct {
+	Status Status `json:"status"`
+}
+
+// ListFilter selects todos for ListTodos, ListPage, All and CountTodos. Zero
+// values are left out of the request. Pagination and sorting are ignored by
+// CountTodos.
+type ListFilter struct {
+	Statuses        []Status
+	ExcludeStatuses []Status
+	Labels          []string
+	AnyLabels       []string
+	ExcludeLabels   []string
+
+	// Search is a full-text search over title and description.
+	Search string
+
+	// From and To bound the creation time.
+	From *time.Time
+	To   *time.Time
+
+	// Where holds custom field conditions such as "priority>=2".
+	Where []string
+
+	// Query is a query language expression such as "status:pending AND labels:work".
+	Query string
+
+	Limit  int
+	Offset int
+
+	// SortBy is one of createTime, updateTime, title, status or _score.
+	SortBy string
+
+	// SortOrder is asc or desc.
+	SortOrder string
+
+	// Facets requests status, label and created-month counts.
+	Facets bool
+}
+
+// ListResponse is a page of todos.
+type ListResponse struct {
+	Todos []*Todo `json:"todos"`
+
+	// NextCursor continues cursor pagination; it is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+
+	// Facets is set when the filter asked for facets.
+	Facets *Facets `json:"facets,omitempty"`
+}
+
+// Facets holds counts over every todo matching a filter.
+type Facets struct {
+	Statuses      []FacetBucket `json:"statuses"`
+	Labels        []FacetBucket `json:"labels"`
+	CreatedMonths []FacetBucket `json:"createdMonths"`
+}
+
+// FacetBucket is the number of todos with a facet value.
+type FacetBucket struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// CountResponse is the result of a count.
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+// HealthStatus is the overall health of the todoify backend.
+type HealthStatus string
+
+const (
+	HealthStatusHealthy   HealthStatus = "healthy"
+	HealthStatusDegraded  HealthStatus = "degraded"
+	HealthStatusUnhealthy HealthStatus = "unhealthy"
+)
+
+// HealthInfo describes the health of the todoify backend.
+type HealthInfo struct {
+	Status    HealthStatus `json:"status"`
+	Available bool         `json:"available"`
+
+	// ResponseTime is the duration of the health check, e.g. "1.5ms".
+	ResponseTime string `json:"responseTime"`
+
+	NodeCount         *int           `json:"nodeCount,omitempty"`
+	ActiveConnections *int           `json:"activeConnections,omitempty"`
+	Version           string         `json:"version,omitempty"`
+	Details           map[string]any `json:"details,omitempty"`
+}
+
+// EventType is the kind of change an Event reports.
+type EventType string
+
+const (
+	EventCreated       EventType = "created"
+	EventUpdated       EventType = "updated"
+	EventStatusChanged EventType = "status_changed"
+	EventDeleted       EventType = "deleted"
+)
+
+// Event is a change to a todo, as streamed by Watch.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Todo is the todo after the change, or as it was before a deletion.
+	Todo Todo `json:"todo"`
+
+	// PreviousStatus is set for status changes.
+	PreviousStatus Status `json:"previousStatus,omitempty"`
+
+	// Actor is the subject of the authenticated caller that made the change.
+	Actor string `json:"actor,omitempty"`
+
+	// Tenant is the tenant the change was made for, if any.
+	Tenant string    `json:"tenant,omitempty"`
+	Time   time.Time `json:"time"`
+}